@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func ApplyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "Apply pending migrations to the database",
+		Description: "Run the up sections of the pending SQL migrations against DATABASE_URL and record them " +
+			"in the goose version table like `goose up`; with --dry-run they run in a transaction that is " +
+			"rolled back, verifying they execute cleanly without changing the database",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Run the pending migrations in a transaction and roll it back"},
+			&cli.StringFlag{
+				Name:  "backup",
+				Usage: "Back up tables losing data before applying (pg_dump, csv or none); overrides the generator backup setting",
+			},
+		}, applyLimitFlags()...),
+		Action: runApply,
+	}
+}
+
+func runApply(c *cli.Context) error {
+	versionTable := c.String("table")
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	generator, err := schema.ReadGeneratorConfig("schema.prisma")
+	if err != nil {
+		return cli.Exit("Failed to read generator config: "+err.Error(), 1)
+	}
+	limits := applyLimitsFor(generator, c)
+
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return cli.Exit("Failed to connect to database: "+err.Error(), 1)
+	}
+	defer db.Close()
+
+	if c.Bool("dry-run") {
+		return runApplyDryRun(db, versionTable, limits)
+	}
+
+	backup, err := backupPolicyFor(generator, c.String("backup"), databaseURL)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	applied, err := applyPendingMigrations(db, migrationsDir(), versionTable, backup, limits)
+	if err != nil {
+		return cli.Exit("Failed to apply migrations: "+err.Error(), 1)
+	}
+	if len(applied) == 0 {
+		fmt.Println("No pending migrations.")
+	}
+	return nil
+}
+
+func runApplyDryRun(db *sql.DB, versionTable string, limits applyLimits) error {
+	checked, stopped, err := dryRunMigrations(db, migrationsDir(), versionTable, limits)
+	for _, f := range checked {
+		fmt.Println("Checked migration:", f)
+	}
+	if err != nil {
+		return cli.Exit("Dry run failed: "+err.Error(), 1)
+	}
+	if stopped != "" {
+		fmt.Printf("⚠️  %s is marked NO TRANSACTION and can't be rolled back - it and the migrations after it "+
+			"were not checked\n", stopped)
+	}
+	if len(checked) == 0 && stopped == "" {
+		fmt.Println("No pending migrations.")
+		return nil
+	}
+	fmt.Printf("✅ %d migration(s) run cleanly; the transaction was rolled back and the database is unchanged\n",
+		len(checked))
+	return nil
+}
+
+// dryRunMigrations applies the pending migrations of a directory, version rows included, inside one
+// transaction and rolls it back, returning the migrations that ran. A migration marked NO TRANSACTION
+// can't run inside it; as later migrations may depend on it, the dry run stops there and returns its name.
+// The timeouts and the deadline of the limits apply as they would to the real run.
+func dryRunMigrations(db *sql.DB, dir, versionTable string, limits applyLimits) (checked []string, stopped string, err error) {
+	ctx, cancel := limits.context()
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback()
+	if err := limits.set(ctx, tx, true); err != nil {
+		return nil, "", err
+	}
+
+	done, pending, err := migrationStates(tx, dir, versionTable)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := checkMigrationOrder(done, pending); err != nil {
+		return nil, "", err
+	}
+
+	record := "INSERT INTO " + versionTable + " (version_id, is_applied) VALUES ($1, true)"
+	for _, f := range pending {
+		content, err := os.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return checked, "", err
+		}
+		version, err := strconv.ParseInt(migrationVersion(f), 10, 64)
+		if err != nil {
+			return checked, "", fmt.Errorf("%s has no numeric version", f)
+		}
+		migration := schema.ParseGooseMigration(string(content))
+		if migration.NoTransaction {
+			return checked, f, nil
+		}
+		for _, stmt := range migration.Up {
+			if err := execStatement(ctx, tx, stmt); err != nil {
+				return checked, "", fmt.Errorf("%s: %w", f, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, record, version); err != nil {
+			return checked, "", err
+		}
+		checked = append(checked, f)
+	}
+	return checked, "", nil
+}
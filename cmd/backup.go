@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/lib/pq"
+	"github.com/phathdt/schema-manager/pkg/schema"
+)
+
+// backupPolicy says how the tables a migration drops data from are saved before it is applied
+type backupPolicy struct {
+	Mode        string // schema.BackupPgDump, schema.BackupCSV or "" for none
+	Dir         string
+	DatabaseURL string // Connection string handed to pg_dump
+}
+
+// backupPolicyFor returns the backup policy of the generator block, with mode overriding it when set
+func backupPolicyFor(generator schema.GeneratorConfig, mode, databaseURL string) (backupPolicy, error) {
+	policy := backupPolicy{Mode: generator.Backup, Dir: generator.BackupDir, DatabaseURL: databaseURL}
+	if mode != "" {
+		policy.Mode = mode
+	}
+	if policy.Mode == "none" {
+		policy.Mode = ""
+	}
+	if policy.Dir == "" {
+		policy.Dir = "backups"
+	}
+	if policy.Mode != "" && policy.Mode != schema.BackupPgDump && policy.Mode != schema.BackupCSV {
+		return policy, fmt.Errorf("unsupported backup %q - use %s, %s or none", policy.Mode, schema.BackupPgDump, schema.BackupCSV)
+	}
+	return policy, nil
+}
+
+// backupBeforeMigration saves the tables whose data the up section of a migration drops. Tables the
+// migration drops that do not exist yet are skipped.
+func backupBeforeMigration(db *sql.DB, policy backupPolicy, migration *schema.GooseMigration, file string) error {
+	if policy.Mode == "" {
+		return nil
+	}
+	var tables []string
+	for _, table := range schema.IrreversibleTables(migration.UpSQL) {
+		var exists bool
+		if err := db.QueryRow("SELECT to_regclass($1) IS NOT NULL", pq.QuoteIdentifier(table)).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(policy.Dir, 0o755); err != nil {
+		return err
+	}
+
+	version := migrationVersion(file)
+	if policy.Mode == schema.BackupPgDump {
+		output := filepath.Join(policy.Dir, version+"_backup.sql")
+		args := []string{"--data-only", "--file", output}
+		for _, table := range tables {
+			args = append(args, "--table", pq.QuoteIdentifier(table))
+		}
+		cmd := exec.Command("pg_dump", append(args, policy.DatabaseURL)...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("pg_dump failed: %w", err)
+		}
+		fmt.Printf("💾 Backed up %v to %s\n", tables, output)
+		return nil
+	}
+
+	for _, table := range tables {
+		output := filepath.Join(policy.Dir, version+"_"+table+".csv")
+		if err := copyTableToCSV(db, table, output); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", table, err)
+		}
+		fmt.Printf("💾 Backed up %s to %s\n", table, output)
+	}
+	return nil
+}
+
+// copyTableToCSV writes the rows of a table to a CSV file with a header line, in the format
+// `\copy table FROM 'file' CSV HEADER NULL '\N'` reads back
+func copyTableToCSV(db *sql.DB, table, output string) error {
+	rows, err := db.Query("SELECT * FROM " + pq.QuoteIdentifier(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			if v == nil {
+				record[i] = `\N`
+			} else {
+				record[i] = string(v)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
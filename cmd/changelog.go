@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func ChangelogCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "changelog",
+		Usage: "Write a human-readable changelog of the schema changes of each migration or tag",
+		Description: "Replay the migrations and describe what each one changed - tables added, columns changed, " +
+			"indexes created - as Markdown for release artifacts. With --by tag the changes are grouped by " +
+			"the tags of schema-tags.json, with the untagged migrations under Unreleased",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "by", Usage: "Group changes by migration or tag", Value: "migration"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Write to this file instead of stdout"},
+		},
+		Action: func(c *cli.Context) error {
+			files, err := listMigrationFiles(migrationsDir())
+			if err != nil {
+				return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+			}
+
+			var sections []*changelogSection
+			switch c.String("by") {
+			case "migration":
+				sections, err = migrationChangelog(files)
+			case "tag":
+				var tags []*schemaTag
+				if tags, err = readTags(); err == nil {
+					sections, err = tagChangelog(files, tags)
+				}
+			default:
+				return cli.Exit("--by must be migration or tag", 1)
+			}
+			if err != nil {
+				return cli.Exit("Failed to build changelog: "+err.Error(), 1)
+			}
+
+			content := renderChangelog(sections)
+			if output := c.String("output"); output != "" {
+				if err := os.WriteFile(output, []byte(content), 0o644); err != nil {
+					return cli.Exit("Failed to write "+output+": "+err.Error(), 1)
+				}
+				fmt.Println("Wrote changelog:", output)
+				return nil
+			}
+			fmt.Print(content)
+			return nil
+		},
+	}
+}
+
+// changelogSection is the list of schema changes under one heading of the changelog
+type changelogSection struct {
+	Title   string
+	Changes []string
+}
+
+// migrationChangelog describes every migration, newest first
+func migrationChangelog(files []string) ([]*changelogSection, error) {
+	var sections []*changelogSection
+	before := &schema.Schema{}
+	for _, f := range files {
+		after, err := schemaAtVersion(files, migrationVersion(f))
+		if err != nil {
+			return nil, err
+		}
+		section := &changelogSection{Title: strings.TrimSuffix(f, ".sql"), Changes: describeSchemaChanges(before, after)}
+		if content, err := os.ReadFile(filepath.Join(migrationsDir(), f)); err == nil {
+			if ticket := schema.ParseMigrationHeader(string(content)).Ticket; ticket != "" {
+				section.Title += " (" + ticket + ")"
+			}
+		}
+		sections = append([]*changelogSection{section}, sections...)
+		before = after
+	}
+	return sections, nil
+}
+
+// tagChangelog describes the changes between consecutive tags, newest first, starting with the
+// migrations after the latest tag
+func tagChangelog(files []string, tags []*schemaTag) ([]*changelogSection, error) {
+	var sections []*changelogSection
+	before := &schema.Schema{}
+	previous := ""
+	for _, t := range tags {
+		after, err := schemaAtVersion(files, t.Version)
+		if err != nil {
+			return nil, err
+		}
+		title := fmt.Sprintf("%s (%s)", t.Name, t.Created.Format("2006-01-02"))
+		sections = append([]*changelogSection{{Title: title, Changes: describeSchemaChanges(before, after)}}, sections...)
+		before, previous = after, t.Version
+	}
+
+	if len(files) > 0 && migrationVersion(files[len(files)-1]) > previous {
+		after, err := schemaAtVersion(files, migrationVersion(files[len(files)-1]))
+		if err != nil {
+			return nil, err
+		}
+		sections = append([]*changelogSection{{Title: "Unreleased", Changes: describeSchemaChanges(before, after)}},
+			sections...)
+	}
+	return sections, nil
+}
+
+// describeSchemaChanges lists the changes between two schemas, including the indexes created and dropped
+// on tables that exist afterwards
+func describeSchemaChanges(before, after *schema.Schema) []string {
+	changes := describeSchemaDiff(schema.DiffSchemas(before, after))
+	for _, m := range after.Models {
+		existing := map[string]bool{}
+		if old := modelByTableName(before, m.TableName); old != nil {
+			for _, idx := range old.Indexes {
+				existing[idx.Name] = true
+			}
+			current := map[string]bool{}
+			for _, idx := range m.Indexes {
+				current[idx.Name] = true
+			}
+			for _, idx := range old.Indexes {
+				if !current[idx.Name] {
+					changes = append(changes, "Index "+idx.Name+" dropped from "+m.TableName)
+				}
+			}
+		}
+		for _, idx := range m.Indexes {
+			if existing[idx.Name] {
+				continue
+			}
+			kind := "Index"
+			if idx.Unique {
+				kind = "Unique index"
+			}
+			changes = append(changes, fmt.Sprintf("%s %s created on %s (%s)", kind, idx.Name, m.TableName,
+				strings.Join(idx.Columns, ", ")))
+		}
+	}
+	return changes
+}
+
+func modelByTableName(s *schema.Schema, table string) *schema.Model {
+	for _, m := range s.Models {
+		if m.TableName == table {
+			return m
+		}
+	}
+	return nil
+}
+
+func renderChangelog(sections []*changelogSection) string {
+	var sb strings.Builder
+	sb.WriteString("# Schema Changelog\n")
+	for _, s := range sections {
+		sb.WriteString("\n## " + s.Title + "\n\n")
+		if len(s.Changes) == 0 {
+			sb.WriteString("- No schema changes detected (custom SQL or data only)\n")
+		}
+		for _, change := range s.Changes {
+			sb.WriteString("- " + change + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func CheckCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "Fail if schema.prisma has changes that are not covered by a migration",
+		Description: "Compare schema.prisma with the schema built from migrations and exit with status 1 " +
+			"when they differ, so CI can reject schema edits made without running generate. The schema " +
+			"rules of the generator block are checked as well and fail the command on error severity. " +
+			"With --shadow the migrations are also applied to the reset shadowDatabaseUrl database, " +
+			"which must then match schema.prisma",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "shadow", Usage: "Verify the migrations on the shadowDatabaseUrl database of the datasource block"},
+		},
+		Action: func(c *cli.Context) error {
+			ruleErrors, err := checkSchemaRules("schema.prisma")
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			diff, err := pendingSchemaDiff("schema.prisma", migrationsDir())
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			if !hasSchemaChanges(diff) {
+				if ruleErrors > 0 {
+					return cli.Exit(fmt.Sprintf("schema.prisma violates %d schema rule(s)", ruleErrors), 1)
+				}
+				if c.Bool("shadow") {
+					if err := verifyShadowDatabase(migrationsDir()); err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+				}
+				fmt.Println("✅ Migrations are up to date with schema.prisma")
+				return nil
+			}
+
+			fmt.Println("❌ schema.prisma has changes that are not represented by any migration:")
+			for _, change := range describeSchemaDiff(diff) {
+				fmt.Printf("  • %s\n", change)
+			}
+			return cli.Exit("Run 'schema-manager generate --name <name>' and commit the migration", 1)
+		},
+	}
+}
+
+// verifyShadowDatabase empties the public schema of the shadow database, applies every migration to it
+// and compares the resulting tables with schema.prisma, catching migrations that don't build the schema
+// they claim to
+func verifyShadowDatabase(migrationsDir string) error {
+	datasource, err := schema.ReadDatasource("schema.prisma")
+	if err != nil {
+		return err
+	}
+	shadowURL, err := datasource.ResolveShadowDatabaseURL()
+	if err != nil {
+		return err
+	}
+	db, err := connectWithSSLFallback(shadowURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the shadow database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("DROP SCHEMA IF EXISTS public CASCADE; CREATE SCHEMA public"); err != nil {
+		return fmt.Errorf("failed to reset the shadow database: %w", err)
+	}
+	applied, err := applyPendingMigrations(db, migrationsDir, "goose_db_version", backupPolicy{}, applyLimits{})
+	if err != nil {
+		return fmt.Errorf("migrations fail on the shadow database: %w", err)
+	}
+	diff, err := compareDatabaseSchema(db)
+	if err != nil {
+		return fmt.Errorf("failed to verify the shadow database: %w", err)
+	}
+	if !isDiffEmpty(diff) {
+		printDifferences(diff)
+		return fmt.Errorf("the shadow database differs from schema.prisma after applying %d migration(s) - see the differences above",
+			len(applied))
+	}
+	fmt.Printf("✅ %d migration(s) build schema.prisma on the shadow database\n", len(applied))
+	return nil
+}
+
+// checkSchemaRules prints the schema rule violations of a schema file and returns the number of
+// violations with error severity
+func checkSchemaRules(prismaPath string) (int, error) {
+	s, err := (&schema.PrismaFileSource{Path: prismaPath}).LoadSchema(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", prismaPath, err)
+	}
+	failed := 0
+	for _, e := range schema.CheckSchemaRules(s, prismaPath) {
+		fmt.Println(e.Error())
+		if !e.Warning {
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// pendingSchemaDiff returns the changes of a schema file that the migrations of a directory don't contain
+func pendingSchemaDiff(prismaPath, migrationsDir string) (*schema.SchemaDiff, error) {
+	ctx := context.Background()
+	targetSchema, err := (&schema.PrismaFileSource{Path: prismaPath}).LoadSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", prismaPath, err)
+	}
+
+	currentSchema := &schema.Schema{}
+	if entries, err := os.ReadDir(migrationsDir); err == nil && len(entries) > 0 {
+		currentSchema, err = (&schema.MigrationsFolderSource{Dir: migrationsDir}).LoadSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse current schema from migrations: %w", err)
+		}
+	}
+	return schema.DiffSchemas(currentSchema, targetSchema), nil
+}
+
+// describeSchemaDiff lists the changes of a diff in a human-readable form
+func describeSchemaDiff(diff *schema.SchemaDiff) []string {
+	var changes []string
+	for _, m := range diff.ModelsAdded {
+		changes = append(changes, "Table "+m.TableName+" added")
+	}
+	for _, m := range diff.ModelsRemoved {
+		changes = append(changes, "Table "+m.TableName+" removed")
+	}
+	for _, tr := range diff.TablesRenamed {
+		changes = append(changes, "Table "+tr.CurrentName+" renamed to "+tr.Model.TableName)
+	}
+	for _, e := range diff.EnumsAdded {
+		changes = append(changes, "Enum "+e.Name+" added")
+	}
+	for _, e := range diff.EnumsRemoved {
+		changes = append(changes, "Enum "+e.Name+" removed")
+	}
+	for _, ec := range diff.EnumsModified {
+		changes = append(changes, "Enum "+ec.Enum.Name+" values changed: "+ec.Description())
+	}
+	for _, ct := range diff.CompositeTypesAdded {
+		changes = append(changes, "Type "+ct.Name+" added")
+	}
+	for _, ct := range diff.CompositeTypesRemoved {
+		changes = append(changes, "Type "+ct.Name+" removed")
+	}
+	for _, ctc := range diff.CompositeTypesModified {
+		changes = append(changes, "Type "+ctc.Type.Name+" modified")
+	}
+	for _, fc := range diff.FieldsAdded {
+		changes = append(changes, fmt.Sprintf("Column %s.%s added", fc.ModelName, fc.Field.ColumnName))
+	}
+	for _, fc := range diff.FieldsRemoved {
+		changes = append(changes, fmt.Sprintf("Column %s.%s removed", fc.ModelName, fc.Field.ColumnName))
+	}
+	for _, fc := range diff.FieldsModified {
+		changes = append(changes, fmt.Sprintf("Column %s.%s modified", fc.ModelName, fc.Field.ColumnName))
+	}
+	for _, fc := range diff.ColumnsRenamed {
+		changes = append(changes, fmt.Sprintf("Column %s.%s renamed to %s", fc.ModelName, fc.CurrentField.ColumnName, fc.Field.ColumnName))
+	}
+	for _, cr := range diff.ConstraintsRenamed {
+		kind := "Constraint"
+		if cr.Index {
+			kind = "Index"
+		}
+		changes = append(changes, fmt.Sprintf("%s %s on %s renamed to %s", kind, cr.Name, cr.TableName, cr.NewName))
+	}
+	for _, ic := range diff.IndexesAdded {
+		changes = append(changes, fmt.Sprintf("Index %s on %s added", ic.Index.Name, ic.TableName))
+	}
+	for _, ic := range diff.IndexesRemoved {
+		changes = append(changes, fmt.Sprintf("Index %s on %s removed", ic.Index.Name, ic.TableName))
+	}
+	for _, fc := range diff.ForeignKeysAdded {
+		changes = append(changes, fmt.Sprintf("Foreign key %s on %s added", fc.ForeignKey.Name, fc.TableName))
+	}
+	for _, fc := range diff.ForeignKeysRemoved {
+		changes = append(changes, fmt.Sprintf("Foreign key %s on %s removed", fc.ForeignKey.Name, fc.TableName))
+	}
+	for _, pk := range diff.PrimaryKeys {
+		changes = append(changes, "Primary key of "+pk.TableName+" changed")
+	}
+	for _, cc := range diff.Comments {
+		if cc.Column != "" {
+			changes = append(changes, fmt.Sprintf("Comment on column %s.%s changed", cc.TableName, cc.Column))
+		} else {
+			changes = append(changes, "Comment on table "+cc.TableName+" changed")
+		}
+	}
+	for _, ext := range diff.ExtensionsAdded {
+		changes = append(changes, "Extension "+ext.Name+" added")
+	}
+	for _, ext := range diff.ExtensionsRemoved {
+		changes = append(changes, "Extension "+ext.Name+" removed")
+	}
+	for _, t := range diff.TriggersAdded {
+		changes = append(changes, "Trigger "+t.Name+" added or changed")
+	}
+	for _, t := range diff.TriggersRemoved {
+		changes = append(changes, "Trigger "+t.Name+" removed or changed")
+	}
+	for _, fn := range diff.FunctionsAdded {
+		changes = append(changes, "Function "+fn.Name+" added")
+	}
+	for _, fn := range diff.FunctionsRemoved {
+		changes = append(changes, "Function "+fn.Name+" removed")
+	}
+	for _, fnChange := range diff.FunctionsModified {
+		changes = append(changes, "Function "+fnChange.Function.Name+" modified")
+	}
+	for _, p := range diff.PoliciesAdded {
+		changes = append(changes, "Policy "+p.Name+" added or changed")
+	}
+	for _, p := range diff.PoliciesRemoved {
+		changes = append(changes, "Policy "+p.Name+" removed or changed")
+	}
+	for _, m := range diff.RowLevelSecurityEnabled {
+		changes = append(changes, "Row-level security enabled on "+m.TableName)
+	}
+	for _, m := range diff.RowLevelSecurityDisabled {
+		changes = append(changes, "Row-level security disabled on "+m.TableName)
+	}
+	for _, g := range diff.GrantsAdded {
+		changes = append(changes, "Grant on "+g.TableName+" to "+g.Role+" added")
+	}
+	for _, g := range diff.GrantsRevoked {
+		changes = append(changes, "Grant on "+g.TableName+" to "+g.Role+" revoked")
+	}
+	for _, v := range diff.ViewsAdded {
+		changes = append(changes, "View "+v.ViewName+" added")
+	}
+	for _, v := range diff.ViewsRemoved {
+		changes = append(changes, "View "+v.ViewName+" removed")
+	}
+	for _, vChange := range diff.ViewsModified {
+		changes = append(changes, "View "+vChange.View.ViewName+" modified")
+	}
+	for _, seq := range diff.SequencesAdded {
+		changes = append(changes, "Sequence "+seq.Name+" added")
+	}
+	for _, seq := range diff.SequencesRemoved {
+		changes = append(changes, "Sequence "+seq.Name+" removed")
+	}
+	for _, seqChange := range diff.SequencesModified {
+		changes = append(changes, "Sequence "+seqChange.Sequence.Name+" modified")
+	}
+	return changes
+}
@@ -8,10 +8,38 @@ import (
 func GetAllCommands() []*cli.Command {
 	return []*cli.Command{
 		GenerateCommand(),
+		PlanCommand(),
+		DevCommand(),
+		ApplyCommand(),
+		PushCommand(),
+		RollbackCommand(),
+		RedoCommand(),
 		EmptyCommand(),
+		PartitionsCommand(),
 		ValidateCommand(),
+		RelationCommand(),
 		IntrospectCommand(),
+		DbCommand(),
+		ConvertCommand(),
 		SyncCommand(),
+		SquashCommand(),
+		CheckCommand(),
+		LintCommand(),
+		ShowCommand(),
+		HistoryCommand(),
+		TagCommand(),
+		DiffCommand(),
+		ChangelogCommand(),
+		RenumberCommand(),
+		MergeCommand(),
+		WorkspaceCommand(),
+		TenantsCommand(),
+		ExportCommand(),
+		SeedCommand(),
+		FixturesCommand(),
+		HooksCommand(),
+		DoctorCommand(),
+		UpgradeCommand(),
 		VersionCommand(),
 	}
 }
@@ -19,6 +47,7 @@ func GetAllCommands() []*cli.Command {
 func SetupGlobalFlags(c *cli.Context) error {
 	if c.Bool("verbose") {
 		logger.SetVerbose(true)
+		hintNewVersion()
 	}
 	return nil
 }
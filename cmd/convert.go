@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+var varcharLengthRegex = regexp.MustCompile(`^(varchar|character varying)\((\d+)\)$`)
+
+func ConvertCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "convert",
+		Usage:     "Convert a SQL dump into schema.prisma",
+		ArgsUsage: "<schema.sql>",
+		Description: "Parse a pg_dump --schema-only style DDL file offline, without a database connection, and " +
+			"write the tables, enums and sequences it creates to schema.prisma",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output schema file path",
+				Value:   "schema.prisma",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return cli.Exit("Usage: schema-manager convert <schema.sql>", 1)
+			}
+			return runConvert(c.Args().First(), c.String("output"))
+		},
+	}
+}
+
+func runConvert(inputFile, outputFile string) error {
+	content, err := os.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", inputFile, err)
+	}
+
+	dump, err := schema.ParseSQLToSchema(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", inputFile, err)
+	}
+	if len(dump.Models) == 0 {
+		fmt.Printf("⚠️  No tables found in %s\n", inputFile)
+		return nil
+	}
+
+	fmt.Printf("📊 Found %d tables in %s\n", len(dump.Models), inputFile)
+
+	// Keep the datasource url and naming rules of a schema being re-converted
+	datasource, err := schema.ReadDatasource(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read datasource: %w", err)
+	}
+	generator, err := schema.ReadGeneratorConfig(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read generator config: %w", err)
+	}
+
+	tables, serialSequences := dumpTables(dump)
+	schemaContent := generatePrismaSchema(tables, datasource, generator)
+	for _, e := range dump.Enums {
+		schemaContent += fmt.Sprintf("enum %s {\n  %s\n}\n\n", e.Name, strings.Join(e.Values, "\n  "))
+	}
+	for _, seq := range dump.Sequences {
+		if serialSequences[strings.ToLower(seq.Name)] {
+			continue
+		}
+		schemaContent += generateSequenceBlock(seq)
+	}
+
+	if err := writeSchemaFile(outputFile, schemaContent); err != nil {
+		return fmt.Errorf("failed to write schema file: %w", err)
+	}
+
+	fmt.Printf("✅ Generated schema.prisma at %s\n", outputFile)
+	for _, skipped := range []struct {
+		count int
+		kind  string
+	}{
+		{len(dump.Views), "views"},
+		{len(dump.Functions), "functions"},
+		{len(dump.Triggers), "triggers"},
+		{len(dump.Policies), "policies"},
+	} {
+		if skipped.count > 0 {
+			fmt.Printf("⚠️  Skipped %d %s - add them to schema.prisma by hand\n", skipped.count, skipped.kind)
+		}
+	}
+	return nil
+}
+
+// dumpTables converts the models of a parsed dump into the table descriptions introspect writes
+// schema.prisma from. Sequences that only back an integer column become autoincrement() and are
+// returned so that no separate sequence block is written for them.
+func dumpTables(dump *schema.Schema) ([]TableInfo, map[string]bool) {
+	serialSequences := map[string]bool{}
+	var tables []TableInfo
+
+	for _, m := range dump.Models {
+		table := TableInfo{TableName: m.TableName}
+
+		compositePK := map[string]bool{}
+		for _, attr := range m.Attributes {
+			if attr.Name == "id" && len(attr.Args) > 0 {
+				for _, col := range attr.Args {
+					compositePK[strings.Trim(strings.TrimSpace(col), "[]")] = true
+				}
+			}
+		}
+		unique := map[string]bool{}
+		for _, idx := range m.Indexes {
+			if idx.Unique && len(idx.Columns) == 1 {
+				unique[idx.Columns[0]] = true
+			}
+		}
+
+		for _, f := range m.Fields {
+			col := ColumnInfo{
+				ColumnName:    f.ColumnName,
+				DataType:      f.Type,
+				IsNullable:    f.IsOptional,
+				IsUnique:      unique[f.ColumnName],
+				IsPrimaryKey:  hasAttribute(f, "id") || compositePK[f.ColumnName],
+				IsCompositePK: len(compositePK) > 1 && compositePK[f.ColumnName],
+			}
+			if matches := varcharLengthRegex.FindStringSubmatch(f.Type); matches != nil {
+				length, _ := strconv.ParseInt(matches[2], 10, 64)
+				col.DataType = matches[1]
+				col.MaxLength = sql.NullInt64{Int64: length, Valid: true}
+			}
+			switch f.Type {
+			case "serial", "serial4":
+				col.DataType, col.IsAutoIncrement = "integer", true
+			case "bigserial", "serial8":
+				col.DataType, col.IsAutoIncrement = "bigint", true
+			}
+			if def := defaultExpression(f); def != "" {
+				col.DefaultValue = sql.NullString{String: def, Valid: true}
+			}
+			if seq := ownedNextvalSequence(dump, m, f, col.DefaultValue.String); seq != "" {
+				col.IsAutoIncrement = true
+				serialSequences[seq] = true
+			}
+			for _, e := range dump.Enums {
+				if strings.EqualFold(e.Name, f.Type) {
+					col.EnumName = e.Name
+				}
+			}
+			table.Columns = append(table.Columns, col)
+		}
+		tables = append(tables, table)
+	}
+	return tables, serialSequences
+}
+
+// defaultExpression returns the SQL expression of a column default kept by the migration parser
+func defaultExpression(f *schema.Field) string {
+	for _, attr := range f.Attributes {
+		if attr.Name != "default" || len(attr.Args) == 0 {
+			continue
+		}
+		arg := attr.Args[0]
+		if strings.HasPrefix(arg, "dbgenerated(") && strings.HasSuffix(arg, ")") {
+			if expr, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(arg, "dbgenerated("), ")")); err == nil {
+				return expr
+			}
+		}
+		return arg
+	}
+	return ""
+}
+
+// ownedNextvalSequence returns the lower-cased name of the sequence a nextval() default draws from when
+// the sequence is owned by that column, the way pg_dump writes serial columns
+func ownedNextvalSequence(dump *schema.Schema, m *schema.Model, f *schema.Field, def string) string {
+	matches := nextvalDefaultRegex.FindStringSubmatch(def)
+	if matches == nil {
+		return ""
+	}
+	name := strings.ToLower(strings.TrimPrefix(strings.ToLower(matches[1]), "public."))
+	for _, seq := range dump.Sequences {
+		if strings.EqualFold(seq.Name, name) && strings.EqualFold(seq.OwnerTable, m.TableName) &&
+			strings.EqualFold(seq.OwnerColumn, f.ColumnName) {
+			return name
+		}
+	}
+	return ""
+}
+
+var nextvalDefaultRegex = regexp.MustCompile(`(?i)^nextval\('([^']+)'(?:::regclass)?\)$`)
+
+// generateSequenceBlock renders a sequence of the dump as a schema.prisma sequence block
+func generateSequenceBlock(seq *schema.Sequence) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("sequence %s {\n", seq.Name))
+	sb.WriteString(fmt.Sprintf("  start     = %d\n", seq.Start))
+	sb.WriteString(fmt.Sprintf("  increment = %d\n", seq.Increment))
+	if seq.OwnerTable != "" {
+		sb.WriteString(fmt.Sprintf("  ownedBy   = \"%s.%s\"\n", seq.OwnerTable, seq.OwnerColumn))
+	}
+	sb.WriteString("}\n\n")
+	return sb.String()
+}
+
+func hasAttribute(f *schema.Field, name string) bool {
+	for _, attr := range f.Attributes {
+		if attr.Name == name {
+			return true
+		}
+	}
+	return false
+}
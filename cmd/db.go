@@ -0,0 +1,651 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func DbCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "db",
+		Usage: "Work with the database schema directly",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "pull",
+				Usage: "Rewrite schema.prisma to match the database",
+				Description: "Introspect DATABASE_URL and update schema.prisma to match it like `prisma db pull`: models, " +
+					"fields and enums of dropped tables, columns and types are removed, new ones are added and " +
+					"fields whose column changed are rewritten. Model and field names, @map names, relations and " +
+					"comments of the parts that still exist are kept",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "print", Usage: "Write the pulled schema to stdout instead of overwriting schema.prisma"},
+				},
+				Action: func(c *cli.Context) error {
+					return runDbPull("schema.prisma", c.Bool("print"))
+				},
+			},
+		},
+	}
+}
+
+// pulledDatabase is what db pull reads from the database
+type pulledDatabase struct {
+	Tables   []TableInfo
+	Enums    []*schema.Enum
+	HasEnums bool // False for SQLite, which has no enum types, so enum blocks are left alone
+}
+
+func runDbPull(path string, print bool) error {
+	pulled, err := pullDatabase()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	generator, err := schema.ReadGeneratorConfig(path)
+	if err != nil {
+		return cli.Exit("Failed to read generator config: "+err.Error(), 1)
+	}
+	existing, err := os.ReadFile(path)
+	content := string(existing)
+	if os.IsNotExist(err) {
+		datasource, err := schema.ReadDatasource(path)
+		if err != nil {
+			return cli.Exit("Failed to read datasource: "+err.Error(), 1)
+		}
+		content = generateSchemaHeader(datasource, generator)
+	} else if err != nil {
+		return cli.Exit("Failed to read "+path+": "+err.Error(), 1)
+	}
+
+	result, changes, err := pullSchema(content, path, pulled, generator)
+	if err != nil {
+		return cli.Exit("Failed to pull the database schema: "+err.Error(), 1)
+	}
+	if print {
+		fmt.Print(result)
+		return nil
+	}
+	if result == string(existing) {
+		fmt.Printf("✅ %s already matches the database\n", path)
+		return nil
+	}
+	if err := writeSchemaFile(path, result); err != nil {
+		return cli.Exit("Failed to write "+path+": "+err.Error(), 1)
+	}
+	fmt.Printf("✅ Pulled the database schema into %s\n", path)
+	for _, change := range changes {
+		fmt.Println("  " + change)
+	}
+	fmt.Println("Run 'schema-manager generate --idempotent --name <name>' to record the pulled changes in a migration " +
+		"that is safe to run against this database")
+	return nil
+}
+
+// pullDatabase reads the tables and, for PostgreSQL, the enum types of the database schema.prisma
+// connects to
+func pullDatabase() (pulledDatabase, error) {
+	var pulled pulledDatabase
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return pulled, err
+	}
+	db, introspect, err := openIntrospectionDatabase(databaseURL)
+	if err != nil {
+		return pulled, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if pulled.Tables, err = introspect(db); err != nil {
+		return pulled, fmt.Errorf("failed to introspect database: %w", err)
+	}
+	if _, ok := sqliteDatabasePath(databaseURL); !ok {
+		pulled.HasEnums = true
+		if pulled.Enums, err = introspectEnums(db, pulled.Tables); err != nil {
+			return pulled, fmt.Errorf("failed to introspect enums: %w", err)
+		}
+	}
+	return pulled, nil
+}
+
+// introspectEnums reads the enum types of the public schema with their values in declaration order,
+// and sets the enum type of the columns using one
+func introspectEnums(db *sql.DB, tables []TableInfo) ([]*schema.Enum, error) {
+	rows, err := db.Query(`
+		SELECT t.typname, e.enumlabel
+		FROM pg_type t
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = 'public'
+		ORDER BY t.typname, e.enumsortorder
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enums []*schema.Enum
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		if len(enums) == 0 || enums[len(enums)-1].Name != name {
+			enums = append(enums, &schema.Enum{Name: name})
+		}
+		e := enums[len(enums)-1]
+		e.Values = append(e.Values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	columns, err := db.Query(`
+		SELECT table_name, column_name, udt_name
+		FROM information_schema.columns
+		WHERE table_schema = 'public'
+		AND data_type = 'USER-DEFINED'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer columns.Close()
+	for columns.Next() {
+		var table, column, typ string
+		if err := columns.Scan(&table, &column, &typ); err != nil {
+			return nil, err
+		}
+		if findEnum(enums, typ) == nil {
+			continue
+		}
+		for i := range tables {
+			for j := range tables[i].Columns {
+				if tables[i].TableName == table && tables[i].Columns[j].ColumnName == column {
+					tables[i].Columns[j].EnumName = typ
+				}
+			}
+		}
+	}
+	return enums, columns.Err()
+}
+
+// findEnum returns the enum with a name, matched case-insensitively since generated migrations don't
+// quote type names
+func findEnum(enums []*schema.Enum, name string) *schema.Enum {
+	for _, e := range enums {
+		if strings.EqualFold(e.Name, name) {
+			return e
+		}
+	}
+	return nil
+}
+
+var (
+	nativeTypeAttributeRegex = regexp.MustCompile(`\s*@db\.\w+(\([^)]*\))?`)
+	idAttributeRegex         = regexp.MustCompile(`(^|\s)@id(\([^)]*\))?`)
+	enumValueNameRegex       = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// schemaPull collects the line edits turning a schema file into one matching the database
+type schemaPull struct {
+	lines   []string
+	remove  map[int]bool     // 0-based lines to drop
+	replace map[int]string   // 0-based lines to rewrite
+	insert  map[int][]string // Lines to insert before a 0-based line
+	removed map[*schema.Field]bool
+	changes []string
+}
+
+// removeLines drops lines start to end (1-based, inclusive) along with the /// doc comment above them
+func (p *schemaPull) removeLines(start, end int) {
+	for start > 1 && strings.HasPrefix(strings.TrimSpace(p.lines[start-2]), "///") {
+		start--
+	}
+	for i := start - 1; i < end && i < len(p.lines); i++ {
+		p.remove[i] = true
+	}
+}
+
+// removeBlock drops a model or enum block and the blank line after it
+func (p *schemaPull) removeBlock(start, end int) {
+	p.removeLines(start, end)
+	if end < len(p.lines) && strings.TrimSpace(p.lines[end]) == "" {
+		p.remove[end] = true
+	}
+}
+
+// pullSchema rewrites schema.prisma content to match the database. Blocks of tables, columns and enums
+// that still exist are kept as written; models of included files are left alone.
+func pullSchema(content, path string, pulled pulledDatabase, generator schema.GeneratorConfig) (string, []string, error) {
+	ctx := context.Background()
+	s, err := schema.ParsePrismaToSchema(ctx, content, path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	p := &schemaPull{
+		lines:   strings.Split(content, "\n"),
+		remove:  map[int]bool{},
+		replace: map[int]string{},
+		insert:  map[int][]string{},
+		removed: map[*schema.Field]bool{},
+	}
+	declared := func(line int, keyword, name string) bool {
+		return line > 0 && line <= len(p.lines) && strings.Contains(p.lines[line-1], keyword+" "+name)
+	}
+
+	var added []string
+	if pulled.HasEnums {
+		added = append(added, p.pullEnums(s, pulled, declared)...)
+	}
+
+	tables := map[string]TableInfo{}
+	for _, table := range pulled.Tables {
+		tables[table.TableName] = table
+	}
+	kept := map[string]bool{}
+	modeled := map[string]bool{}
+	for _, m := range s.Models {
+		modeled[m.TableName] = true
+		if _, ok := tables[m.TableName]; ok || !declared(m.Line, "model", m.Name) {
+			kept[m.Name] = true
+		}
+	}
+
+	var models []*schema.Model
+	modelChanges := map[*schema.Model][]string{}
+	for _, m := range s.Models {
+		if !declared(m.Line, "model", m.Name) {
+			continue
+		}
+		if !kept[m.Name] {
+			p.removeBlock(m.Line, m.EndLine)
+			p.changes = append(p.changes, fmt.Sprintf("- model %s (%s)", m.Name, m.TableName))
+			continue
+		}
+		models = append(models, m)
+		modelChanges[m] = p.pullColumns(s, m, tables[m.TableName], generator)
+	}
+	// Relations are settled once every model lost its dropped columns
+	for _, m := range models {
+		modelChanges[m] = append(modelChanges[m], p.pullRelations(s, m, kept, declared)...)
+	}
+	var touched []string
+	for _, m := range models {
+		modelChanges[m] = append(modelChanges[m], p.pullModelAttributes(m)...)
+		if len(modelChanges[m]) > 0 {
+			touched = append(touched, m.Name)
+			p.changes = append(p.changes, fmt.Sprintf("~ model %s: %s", m.Name, strings.Join(modelChanges[m], ", ")))
+		}
+	}
+
+	var missing []TableInfo
+	for _, table := range pulled.Tables {
+		if !modeled[table.TableName] {
+			missing = append(missing, table)
+			touched = append(touched, toPascalCase(table.TableName, generator))
+			p.changes = append(p.changes, fmt.Sprintf("+ model %s (%s)", toPascalCase(table.TableName, generator), table.TableName))
+		}
+	}
+
+	var out []string
+	for i, line := range p.lines {
+		out = append(out, p.insert[i]...)
+		if p.remove[i] {
+			continue
+		}
+		if rewritten, ok := p.replace[i]; ok {
+			line = rewritten
+		}
+		out = append(out, line)
+	}
+	result := strings.TrimRight(strings.Join(out, "\n"), "\n") + "\n"
+	if models := generatePrismaModels(missing, generator); models != "" || len(added) > 0 {
+		result += "\n" + models + strings.Join(added, "")
+		result = strings.TrimRight(result, "\n") + "\n"
+	}
+
+	result, err = schema.AlignModelFields(ctx, result, path, touched)
+	if err != nil {
+		return "", nil, fmt.Errorf("the pulled schema doesn't parse: %w", err)
+	}
+	return result, p.changes, nil
+}
+
+// pullEnums updates the values of enum blocks, removes the ones the database doesn't have and returns
+// the blocks of new enum types. Columns of known enums get the name the schema gives the enum.
+func (p *schemaPull) pullEnums(s *schema.Schema, pulled pulledDatabase, declared func(int, string, string) bool) []string {
+	for i := range pulled.Tables {
+		for j, col := range pulled.Tables[i].Columns {
+			if e := findEnum(s.Enums, col.EnumName); e != nil {
+				pulled.Tables[i].Columns[j].EnumName = e.Name
+			}
+		}
+	}
+
+	for _, e := range s.Enums {
+		if !declared(e.Line, "enum", e.Name) {
+			continue
+		}
+		end := e.Line
+		for end < len(p.lines) && !strings.HasPrefix(strings.TrimSpace(p.lines[end-1]), "}") {
+			end++
+		}
+		dbEnum := findEnum(pulled.Enums, e.Name)
+		if dbEnum == nil {
+			p.removeBlock(e.Line, end)
+			p.changes = append(p.changes, "- enum "+e.Name)
+			continue
+		}
+
+		var changes []string
+		labels := map[string]bool{}
+		indent := "  "
+		for i := e.Line; i < end-1; i++ {
+			trimmed := strings.TrimSpace(p.lines[i])
+			if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "@@") {
+				continue
+			}
+			indent = p.lines[i][:len(p.lines[i])-len(strings.TrimLeftFunc(p.lines[i], unicode.IsSpace))]
+			value, _, _ := strings.Cut(trimmed, "//")
+			label := schema.EnumValueLabel(strings.TrimSpace(value))
+			labels[label] = true
+			if !slices.Contains(dbEnum.Values, label) {
+				p.removeLines(i+1, i+1)
+				changes = append(changes, "- "+strings.Fields(trimmed)[0])
+			}
+		}
+		for _, value := range dbEnum.Values {
+			if !labels[value] {
+				p.insert[end-1] = append(p.insert[end-1], indent+enumValueLine(value))
+				changes = append(changes, "+ "+value)
+			}
+		}
+		if len(changes) > 0 {
+			p.changes = append(p.changes, fmt.Sprintf("~ enum %s: %s", e.Name, strings.Join(changes, ", ")))
+		}
+	}
+
+	var blocks []string
+	for _, e := range pulled.Enums {
+		if findEnum(s.Enums, e.Name) != nil {
+			continue
+		}
+		values := make([]string, len(e.Values))
+		for i, value := range e.Values {
+			values[i] = enumValueLine(value)
+		}
+		blocks = append(blocks, fmt.Sprintf("enum %s {\n  %s\n}\n\n", e.Name, strings.Join(values, "\n  ")))
+		p.changes = append(p.changes, "+ enum "+e.Name)
+	}
+	return blocks
+}
+
+// enumValueLine declares an enum value, mapping labels that aren't valid Prisma names
+func enumValueLine(label string) string {
+	if enumValueNameRegex.MatchString(label) {
+		return label
+	}
+	name := strings.Join(identifierParts(label), "_")
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		name = "value_" + name
+	}
+	return fmt.Sprintf("%s @map(%s)", name, strconv.Quote(label))
+}
+
+// pullColumns removes the fields of dropped columns, rewrites the ones whose column changed and adds
+// fields for new columns after the last field of the model
+func (p *schemaPull) pullColumns(s *schema.Schema, m *schema.Model, table TableInfo, generator schema.GeneratorConfig) []string {
+	var changes []string
+	matched := map[string]bool{}
+	names := map[string]bool{}
+	for _, f := range m.Fields {
+		names[f.Name] = true
+		// Relation fields and scalar lists have no column of their own
+		if f.IsRelation || f.IsArray {
+			continue
+		}
+		col, ok := findColumn(table, f.ColumnName)
+		if !ok {
+			p.removeLines(f.Line, f.Line)
+			p.removed[f] = true
+			changes = append(changes, "- "+f.Name)
+			continue
+		}
+		matched[col.ColumnName] = true
+		if !fieldMatchesColumn(s, f, col) {
+			p.replace[f.Line-1] = pulledFieldLine(p.lines[f.Line-1], f, col, generator)
+			changes = append(changes, "~ "+f.Name)
+		}
+	}
+
+	at, indent := m.EndLine-1, "  "
+	for _, f := range m.Fields {
+		if f.Line > 0 && f.Line < m.EndLine {
+			at = f.Line
+			line := p.lines[f.Line-1]
+			indent = line[:len(line)-len(strings.TrimLeftFunc(line, unicode.IsSpace))]
+		}
+	}
+	for _, col := range table.Columns {
+		if matched[col.ColumnName] {
+			continue
+		}
+		name := toCamelCase(col.ColumnName)
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s%d", toCamelCase(col.ColumnName), i)
+		}
+		names[name] = true
+		p.insert[at] = append(p.insert[at], indent+introspectedFieldLine(name, col, generator))
+		changes = append(changes, "+ "+name)
+	}
+	return changes
+}
+
+func findColumn(table TableInfo, name string) (ColumnInfo, bool) {
+	for _, col := range table.Columns {
+		if col.ColumnName == name {
+			return col, true
+		}
+	}
+	return ColumnInfo{}, false
+}
+
+// fieldMatchesColumn reports whether a field still describes its column: same type, nullability and
+// single-column primary key. Types introspection can't name, such as composite types, always match.
+func fieldMatchesColumn(s *schema.Schema, f *schema.Field, col ColumnInfo) bool {
+	if f.IsOptional != (col.IsNullable && !col.IsPrimaryKey) {
+		return false
+	}
+	if hasAttribute(f, "id") != (col.IsPrimaryKey && !col.IsCompositePK) {
+		return false
+	}
+	if col.EnumName != "" {
+		return strings.EqualFold(f.Type, col.EnumName)
+	}
+	known := mapDataTypeToSQL(col.DataType, col.MaxLength) != "TEXT" || strings.EqualFold(col.DataType, "text")
+	if !known || findEnum(s.Enums, f.Type) != nil || strings.HasPrefix(f.Type, "Unsupported(") {
+		return true
+	}
+	for _, ct := range s.CompositeTypes {
+		if ct.Name == f.Type {
+			return true
+		}
+	}
+	return comparableSQLType(schema.GetSQLTypeForField(f)) == comparableSQLType(mapDataTypeToSQL(col.DataType, col.MaxLength))
+}
+
+// comparableSQLType folds the spellings of a SQL type introspection can't tell apart, e.g. the
+// precision of numeric columns
+func comparableSQLType(sqlType string) string {
+	sqlType = strings.ToUpper(sqlType)
+	switch {
+	case strings.HasPrefix(sqlType, "NUMERIC"), strings.HasPrefix(sqlType, "DECIMAL"):
+		return "DECIMAL"
+	case sqlType == "JSON":
+		return "JSONB"
+	}
+	return sqlType
+}
+
+// pulledFieldLine rewrites the type of a field line to the one of its column, keeping its name and
+// the attributes the column doesn't determine such as @map, @unique and comments. A default is only
+// added when the field has none, since Prisma-level defaults don't show in the database.
+func pulledFieldLine(line string, f *schema.Field, col ColumnInfo, generator schema.GeneratorConfig) string {
+	trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+	indent := line[:len(line)-len(trimmed)]
+	_, rest, _ := strings.Cut(strings.TrimSpace(trimmed), " ")
+	_, rest, _ = strings.Cut(strings.TrimSpace(rest), " ")
+	rest = idAttributeRegex.ReplaceAllString(nativeTypeAttributeRegex.ReplaceAllString(rest, ""), "")
+
+	var attributes []string
+	if attr := nativeTypeAttribute(col, generator); attr != "" && col.EnumName == "" {
+		attributes = append(attributes, attr)
+	}
+	if col.IsPrimaryKey && !col.IsCompositePK {
+		attributes = append(attributes, "@id")
+	}
+	if def := introspectedDefault(col); def != "" && !hasAttribute(f, "default") {
+		attributes = append(attributes, def)
+	}
+	rest = strings.TrimSpace(strings.Join(attributes, " ") + " " + strings.TrimSpace(rest))
+	return indent + strings.TrimSpace(f.Name+" "+introspectedFieldType(col)+" "+rest)
+}
+
+// pullRelations removes relation fields whose related model is gone or whose key fields were removed,
+// and back-relation fields whose relation was removed
+func (p *schemaPull) pullRelations(s *schema.Schema, m *schema.Model, kept map[string]bool, declared func(int, string, string) bool) []string {
+	var changes []string
+	for _, f := range m.Fields {
+		if !f.IsRelation || p.relationKept(s, m, f, kept, declared) {
+			continue
+		}
+		p.removeLines(f.Line, f.Line)
+		p.removed[f] = true
+		changes = append(changes, "- "+f.Name)
+	}
+	return changes
+}
+
+func (p *schemaPull) relationKept(s *schema.Schema, m *schema.Model, f *schema.Field, kept map[string]bool, declared func(int, string, string) bool) bool {
+	related := findModel(s, f.Type)
+	if related == nil || !kept[related.Name] {
+		return false
+	}
+	name, fields := relationArgs(f)
+	if len(fields) > 0 {
+		for _, field := range fields {
+			for _, mf := range m.Fields {
+				if mf.Name == field && p.removed[mf] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	// Models of included files aren't pulled, so their side of the relation stays
+	if !declared(related.Line, "model", related.Name) {
+		return true
+	}
+	// A back-relation field lives as long as the relation field holding the key on the other side;
+	// implicit many-to-many relations have no key on either side
+	for _, other := range related.Fields {
+		if other == f || other.Type != m.Name {
+			continue
+		}
+		if otherName, otherFields := relationArgs(other); otherName == name && len(otherFields) > 0 {
+			return p.relationKept(s, related, other, kept, declared)
+		}
+	}
+	return true
+}
+
+func findModel(s *schema.Schema, name string) *schema.Model {
+	for _, m := range s.Models {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// relationArgs returns the relation name and the key fields of the @relation attribute of a field
+func relationArgs(f *schema.Field) (string, []string) {
+	var name string
+	var fields []string
+	for _, attr := range f.Attributes {
+		if attr.Name != "relation" {
+			continue
+		}
+		for _, arg := range attr.Args {
+			arg = strings.TrimSpace(arg)
+			key, value, found := strings.Cut(arg, ":")
+			switch {
+			case !found && strings.HasPrefix(arg, `"`):
+				name = strings.Trim(arg, `"`)
+			case strings.TrimSpace(key) == "name":
+				name = strings.Trim(strings.TrimSpace(value), `"`)
+			case strings.TrimSpace(key) == "fields":
+				fields = fieldListNames(value)
+			}
+		}
+	}
+	return name, fields
+}
+
+// fieldListNames returns the field names of a list like [a, b(sort: Desc)]
+func fieldListNames(list string) []string {
+	list = strings.TrimSpace(list)
+	if start := strings.Index(list, "["); start >= 0 {
+		list = list[start+1:]
+	}
+	if end := strings.Index(list, "]"); end >= 0 {
+		list = list[:end]
+	}
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name, _, _ = strings.Cut(strings.TrimSpace(name), "(")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// pullModelAttributes removes @@id, @@unique and @@index attributes listing a removed field
+func (p *schemaPull) pullModelAttributes(m *schema.Model) []string {
+	var changes []string
+	for _, attr := range m.Attributes {
+		if attr.Name != "id" && attr.Name != "unique" && attr.Name != "index" {
+			continue
+		}
+		if attr.Line <= m.Line || attr.Line >= m.EndLine {
+			continue
+		}
+		for _, name := range fieldListNames(strings.Join(attr.Args, ",")) {
+			if f := findField(m, name); f != nil && p.removed[f] {
+				p.removeLines(attr.Line, attr.Line)
+				changes = append(changes, "- @@"+attr.Name+"("+strings.Join(attr.Args, ", ")+")")
+				break
+			}
+		}
+	}
+	return changes
+}
+
+func findField(m *schema.Model, name string) *schema.Field {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
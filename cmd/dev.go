@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func DevCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "dev",
+		Usage: "Generate and apply migrations against the development database",
+		Description: "Generate a migration for schema.prisma changes, apply all pending migrations to " +
+			"DATABASE_URL and verify that the database matches schema.prisma - the local development loop",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "name", Usage: "Name of the migration generated for schema changes", Value: "dev"},
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.StringFlag{
+				Name:  "backup",
+				Usage: "Back up tables losing data before applying (pg_dump, csv or none); overrides the generator backup setting",
+			},
+		}, applyLimitFlags()...),
+		Action: func(c *cli.Context) error {
+			return runDev(c.String("name"), c.String("table"), c.String("backup"), c)
+		},
+	}
+}
+
+func runDev(name, versionTable, backupMode string, c *cli.Context) error {
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	generator, err := schema.ReadGeneratorConfig("schema.prisma")
+	if err != nil {
+		return cli.Exit("Failed to read generator config: "+err.Error(), 1)
+	}
+	backup, err := backupPolicyFor(generator, backupMode, databaseURL)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	if _, err := runGenerate(generateOptions{Name: name}); err != nil {
+		if errors.Is(err, errGenerateCancelled) {
+			fmt.Println("Dev cancelled; no migrations were applied.")
+			return nil
+		}
+		return err
+	}
+
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return cli.Exit("Failed to connect to database: "+err.Error(), 1)
+	}
+	defer db.Close()
+
+	applied, err := applyPendingMigrations(db, migrationsDir(), versionTable, backup, applyLimitsFor(generator, c))
+	if err != nil {
+		return cli.Exit("Failed to apply migrations: "+err.Error(), 1)
+	}
+	if len(applied) == 0 {
+		fmt.Println("No pending migrations.")
+	}
+
+	diff, err := compareSchemas()
+	if err != nil {
+		return cli.Exit("Failed to verify database: "+err.Error(), 1)
+	}
+	if !isDiffEmpty(diff) {
+		printDifferences(diff)
+		return cli.Exit("Database differs from schema.prisma after migrating - see the differences above", 1)
+	}
+	fmt.Println("✅ Database and schema.prisma are in sync!")
+	return nil
+}
@@ -3,6 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/urfave/cli/v2"
@@ -14,15 +17,31 @@ func EmptyCommand() *cli.Command {
 		Usage: "Create an empty migration file for manual SQL writing",
 		Flags: []cli.Flag{
 			&cli.StringFlag{Name: "name", Usage: "Migration name", Required: true},
+			&cli.BoolFlag{
+				Name:  "go",
+				Usage: "Create a goose Go migration for data transformations that can't be written in SQL",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			name := c.String("name")
 			ts := time.Now().Format("20060102150405")
 
 			// Create migrations directory if it doesn't exist
-			os.MkdirAll("migrations", 0o755)
+			dir := migrationsDir()
+			os.MkdirAll(dir, 0o755)
 
-			filename := "migrations/" + ts + "_" + name + ".sql"
+			if c.Bool("go") {
+				filename := filepath.Join(dir, ts+"_"+name+".go")
+				if err := os.WriteFile(filename, []byte(goMigrationTemplate(name)), 0o644); err != nil {
+					return cli.Exit("Failed to create migration file: "+err.Error(), 1)
+				}
+				fmt.Println("Created empty Go migration:", filename)
+				fmt.Println("Go migrations are compiled into your own goose binary and are not read when " +
+					"rebuilding the schema from migrations, so keep schema changes in SQL migrations.")
+				return nil
+			}
+
+			filename := filepath.Join(dir, ts+"_"+name+".sql")
 			f, err := os.Create(filename)
 			if err != nil {
 				return cli.Exit("Failed to create migration file: "+err.Error(), 1)
@@ -49,3 +68,38 @@ func EmptyCommand() *cli.Command {
 		},
 	}
 }
+
+var nonIdentCharRegex = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// goMigrationTemplate returns a goose Go migration whose Up and Down functions run in the
+// migration's transaction, e.g. upBackfillEmails and downBackfillEmails for backfill_emails
+func goMigrationTemplate(name string) string {
+	funcName := strings.TrimLeft(nonIdentCharRegex.ReplaceAllString(name, "_"), "_0123456789")
+	funcName = toCamelCase(strings.ToLower(funcName))
+	if funcName != "" {
+		funcName = strings.ToUpper(funcName[:1]) + funcName[1:]
+	}
+	return fmt.Sprintf(`package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(up%[1]s, down%[1]s)
+}
+
+func up%[1]s(ctx context.Context, tx *sql.Tx) error {
+	// Transform data here, e.g. tx.ExecContext(ctx, "UPDATE ...")
+	return nil
+}
+
+func down%[1]s(ctx context.Context, tx *sql.Tx) error {
+	// Revert the transformation here
+	return nil
+}
+`, funcName)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func ExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Compile schema.prisma into a standalone DDL script",
+		Description: "Render schema.prisma as one idempotent SQL script without migration history, e.g. to " +
+			"bootstrap test databases or to diff against other tools",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "Output format (sql)", Value: "sql"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Write to a file instead of stdout"},
+		},
+		Action: func(c *cli.Context) error {
+			if format := c.String("format"); format != "sql" {
+				return cli.Exit(fmt.Sprintf("Unsupported format %q - supported formats: sql", format), 1)
+			}
+
+			targetSchema, err := (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(context.Background())
+			if err != nil {
+				return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
+			}
+			script := "-- Generated by schema-manager export from schema.prisma\n\n" +
+				schema.GenerateSchemaSQL(targetSchema, true)
+
+			output := c.String("output")
+			if output == "" {
+				fmt.Print(script)
+				return nil
+			}
+			if err := os.WriteFile(output, []byte(script), 0o644); err != nil {
+				return cli.Exit("Failed to write "+output+": "+err.Error(), 1)
+			}
+			fmt.Println("Exported schema.prisma to", output)
+			return nil
+		},
+	}
+}
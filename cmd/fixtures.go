@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/urfave/cli/v2"
+)
+
+// fixtureFile is the JSON document fixtures are stored in. Tables keep the order they were dumped in,
+// which is the order they are restored in.
+type fixtureFile struct {
+	Tables []fixtureTable `json:"tables"`
+}
+
+type fixtureTable struct {
+	Name string                       `json:"name"`
+	Rows []map[string]json.RawMessage `json:"rows"`
+}
+
+func FixturesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "fixtures",
+		Usage: "Snapshot table data and restore it after the schema is rebuilt",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "dump",
+				Usage: "Dump the rows of tables to a fixtures file",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:     "table",
+						Aliases:  []string{"t"},
+						Usage:    "Table to dump, repeatable; list referenced tables before the tables referencing them",
+						Required: true,
+					},
+					&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Fixtures file", Value: "fixtures.json"},
+				},
+				Action: func(c *cli.Context) error {
+					return runFixturesDump(c.StringSlice("table"), c.String("output"))
+				},
+			},
+			{
+				Name:  "restore",
+				Usage: "Replace the rows of the tables in a fixtures file with the dumped rows",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "input", Aliases: []string{"i"}, Usage: "Fixtures file", Value: "fixtures.json"},
+				},
+				Action: func(c *cli.Context) error {
+					return runFixturesRestore(c.String("input"))
+				},
+			},
+		},
+	}
+}
+
+func runFixturesDump(tables []string, output string) error {
+	db, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var fixtures fixtureFile
+	for _, table := range tables {
+		var data []byte
+		query := "SELECT COALESCE(json_agg(t), '[]') FROM " + pq.QuoteIdentifier(table) + " t"
+		if err := db.QueryRow(query).Scan(&data); err != nil {
+			return cli.Exit("Failed to dump "+table+": "+err.Error(), 1)
+		}
+		ft := fixtureTable{Name: table}
+		if err := json.Unmarshal(data, &ft.Rows); err != nil {
+			return cli.Exit("Failed to decode rows of "+table+": "+err.Error(), 1)
+		}
+		fixtures.Tables = append(fixtures.Tables, ft)
+		fmt.Printf("Dumped %d rows from %s\n", len(ft.Rows), table)
+	}
+
+	content, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return cli.Exit("Failed to encode fixtures: "+err.Error(), 1)
+	}
+	if err := os.WriteFile(output, append(content, '\n'), 0o644); err != nil {
+		return cli.Exit("Failed to write "+output+": "+err.Error(), 1)
+	}
+	fmt.Println("✅ Wrote fixtures to", output)
+	return nil
+}
+
+func runFixturesRestore(input string) error {
+	content, err := os.ReadFile(input)
+	if err != nil {
+		return cli.Exit("Failed to read "+input+": "+err.Error(), 1)
+	}
+	var fixtures fixtureFile
+	if err := json.Unmarshal(content, &fixtures); err != nil {
+		return cli.Exit("Failed to parse "+input+": "+err.Error(), 1)
+	}
+	if len(fixtures.Tables) == 0 {
+		fmt.Println("No tables in", input)
+		return nil
+	}
+
+	db, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// The fixtures replace the table contents as a whole or not at all
+	tx, err := db.Begin()
+	if err != nil {
+		return cli.Exit("Failed to start transaction: "+err.Error(), 1)
+	}
+	defer tx.Rollback()
+
+	names := make([]string, len(fixtures.Tables))
+	for i, ft := range fixtures.Tables {
+		names[i] = pq.QuoteIdentifier(ft.Name)
+	}
+	if _, err := tx.Exec("TRUNCATE " + strings.Join(names, ", ")); err != nil {
+		return cli.Exit("Failed to empty fixture tables: "+err.Error(), 1)
+	}
+	for _, ft := range fixtures.Tables {
+		if err := restoreFixtureTable(tx, ft); err != nil {
+			return cli.Exit("Failed to restore "+ft.Name+": "+err.Error(), 1)
+		}
+		fmt.Printf("Restored %d rows into %s\n", len(ft.Rows), ft.Name)
+	}
+	if err := tx.Commit(); err != nil {
+		return cli.Exit("Failed to commit fixtures: "+err.Error(), 1)
+	}
+	fmt.Println("✅ Restored fixtures from", input)
+	return nil
+}
+
+// restoreFixtureTable inserts the dumped rows of a table. Only columns that still exist are written,
+// so new columns get their defaults and dropped ones are ignored; serial and identity sequences are
+// moved past the restored values.
+func restoreFixtureTable(tx *sql.Tx, ft fixtureTable) error {
+	if len(ft.Rows) == 0 {
+		return nil
+	}
+	rows, err := tx.Query(`
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND is_generated <> 'ALWAYS'
+		ORDER BY ordinal_position`, ft.Name)
+	if err != nil {
+		return err
+	}
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := ft.Rows[0][column]; ok {
+			columns = append(columns, column)
+		}
+	}
+	rows.Close()
+	if len(columns) == 0 {
+		return fmt.Errorf("none of the dumped columns exist")
+	}
+
+	data, err := json.Marshal(ft.Rows)
+	if err != nil {
+		return err
+	}
+	table := pq.QuoteIdentifier(ft.Name)
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+	}
+	list := strings.Join(quoted, ", ")
+	insert := fmt.Sprintf("INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE SELECT %s FROM json_populate_recordset(NULL::%s, $1)",
+		table, list, list, table)
+	if _, err := tx.Exec(insert, string(data)); err != nil {
+		return err
+	}
+
+	for i, column := range columns {
+		var seq sql.NullString
+		if err := tx.QueryRow("SELECT pg_get_serial_sequence($1, $2)", table, column).Scan(&seq); err != nil {
+			return err
+		}
+		if !seq.Valid {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("SELECT setval($1, COALESCE((SELECT max(%s) FROM %s), 0) + 1, false)",
+			quoted[i], table), seq.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connectDatabase connects to the database of the datasource block
+func connectDatabase() (*sql.DB, error) {
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return nil, cli.Exit(err.Error(), 1)
+	}
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return nil, cli.Exit("Failed to connect to database: "+err.Error(), 1)
+	}
+	return db, nil
+}
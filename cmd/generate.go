@@ -3,12 +3,16 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/phathdt/schema-manager/internal/schema"
+	"github.com/lib/pq"
+	"github.com/phathdt/schema-manager/pkg/schema"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,123 +21,504 @@ func GenerateCommand() *cli.Command {
 		Name:  "generate",
 		Usage: "Generate migration from Prisma schema changes",
 		Flags: []cli.Flag{
-			&cli.StringFlag{Name: "name", Usage: "Migration name", Required: true},
+			&cli.StringFlag{Name: "name", Usage: "Migration name (with --amend, defaults to the amended migration's)"},
+			&cli.BoolFlag{
+				Name:  "no-down",
+				Usage: "Emit a down section that fails instead of rolling back (forward-only migrations)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the migration file name and its up/down SQL without writing anything",
+			},
+			&cli.StringFlag{Name: "ticket", Usage: "Ticket recorded in the migration header, e.g. JIRA-123"},
+			&cli.BoolFlag{
+				Name:  "amend",
+				Usage: "Regenerate the most recent migration instead of adding one, unless it has been applied",
+			},
+			&cli.BoolFlag{
+				Name:  "guarded",
+				Usage: "Run ALTER TABLE actions only when the column or constraint is (still) there; overrides guardedDdl",
+			},
+			&cli.BoolFlag{
+				Name:  "idempotent",
+				Usage: "Add IF NOT EXISTS / IF EXISTS to CREATE, ADD COLUMN and DROP statements; overrides idempotent",
+			},
+			&cli.BoolFlag{
+				Name: "zero-downtime",
+				Usage: "Change column types through a shadow column, backfill and swap across several migrations " +
+					"instead of a blocking ALTER COLUMN TYPE",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			ctx := context.Background()
-			prismaSource := &schema.PrismaFileSource{Path: "schema.prisma"}
-			migrationsSource := &schema.MigrationsFolderSource{Dir: "migrations"}
-			targetSchema, err := prismaSource.LoadSchema(ctx)
-			if err != nil {
-				return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
-			}
-			entries, err := os.ReadDir("migrations")
-			if err != nil || len(entries) == 0 {
-				// Initial migration
-				diff := &schema.SchemaDiff{}
-				for _, m := range targetSchema.Models {
-					diff.ModelsAdded = append(diff.ModelsAdded, m)
-				}
-				for _, e := range targetSchema.Enums {
-					diff.EnumsAdded = append(diff.EnumsAdded, e)
-				}
-				up := schema.GenerateMigrationSQL(diff)
-				down := schema.GenerateDownMigrationSQL(diff)
-				ts := time.Now().Format("20060102150405")
-				name := c.String("name")
-				os.MkdirAll("migrations", 0o755)
-				filename := "migrations/" + ts + "_" + name + ".sql"
-				f, err := os.Create(filename)
-				if err != nil {
-					return cli.Exit("Failed to create migration file: "+err.Error(), 1)
-				}
-				defer f.Close()
-				f.WriteString("-- +goose Up\n" + up + "\n\n-- +goose Down\n" + down)
-				fmt.Println("Created migration:", filename)
+			_, err := runGenerate(generateOptions{
+				Name:       c.String("name"),
+				NoDown:     c.Bool("no-down"),
+				DryRun:     c.Bool("dry-run"),
+				Amend:      c.Bool("amend"),
+				Ticket:     c.String("ticket"),
+				Guarded:    c.Bool("guarded"),
+				Idempotent: c.Bool("idempotent"),
+
+				ZeroDowntime: c.Bool("zero-downtime"),
+			})
+			if errors.Is(err, errGenerateCancelled) {
 				return nil
 			}
-			currentSchema, err := migrationsSource.LoadSchema(ctx)
-			if err != nil {
-				return cli.Exit("Failed to parse current schema from migrations: "+err.Error(), 1)
-			}
+			return err
+		},
+	}
+}
 
-			// Debug: Print current schema
-			fmt.Printf("Current schema has %d models, %d enums\n", len(currentSchema.Models), len(currentSchema.Enums))
-			for _, m := range currentSchema.Models {
-				fmt.Printf("  - Model: %s (table: %s)\n", m.Name, m.TableName)
-			}
-			for _, e := range currentSchema.Enums {
-				fmt.Printf("  - Enum: %s\n", e.Name)
-			}
+// errGenerateCancelled is returned by runGenerate when the user declines the risky operations prompt,
+// so callers that go on to apply migrations stop instead of treating it as "no changes"
+var errGenerateCancelled = errors.New("migration generation cancelled")
+
+type generateOptions struct {
+	Name       string
+	NoDown     bool // Emit a failing down section
+	DryRun     bool // Print the migration instead of writing it
+	Amend      bool // Regenerate the latest migration
+	Ticket     string
+	Guarded    bool // Wrap ALTER TABLE actions in state-checking DO blocks
+	Idempotent bool // Add IF [NOT] EXISTS clauses
+
+	ZeroDowntime bool // Roll out type changes through shadow columns
+}
+
+// runGenerate writes the migration for the changes between migrations and schema.prisma and returns
+// its file name, or "" when there are no changes. With DryRun the file is only printed. It returns
+// errGenerateCancelled when the user declines the risky operations prompt.
+func runGenerate(opts generateOptions) (string, error) {
+	ctx := context.Background()
+	dir := migrationsDir()
+	prismaSource := &schema.PrismaFileSource{Path: "schema.prisma"}
+	migrationsSource := &schema.MigrationsFolderSource{Dir: dir}
+	targetSchema, err := prismaSource.LoadSchema(ctx)
+	if err != nil {
+		return "", cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
+	}
+	if errs := schema.CheckFieldTypes(targetSchema, prismaSource.Path); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Println(e.Error())
+		}
+		return "", cli.Exit("schema.prisma has fields of unknown type - fix them before generating a migration", 1)
+	}
+	if errs := schema.CheckDefaults(targetSchema, prismaSource.Path); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Println(e.Error())
+		}
+		return "", cli.Exit("schema.prisma has defaults that don't fit their field types - fix them before generating a migration", 1)
+	}
+	if opts.NoDown {
+		targetSchema.Generator.ForwardOnly = true
+	}
+	if opts.Guarded {
+		targetSchema.Generator.GuardedDDL = true
+	}
+	if opts.Idempotent {
+		targetSchema.Generator.Idempotent = true
+	}
+
+	existing, _ := listMigrationFiles(dir)
+	ts := nextMigrationVersion(existing, time.Now())
+	name := opts.Name
+	var amended string
+	if opts.Amend && opts.ZeroDowntime {
+		return "", cli.Exit("--zero-downtime writes several migrations and can't be combined with --amend", 1)
+	}
+	if opts.Amend {
+		amended, err = amendableMigration(dir, "goose_db_version")
+		if err != nil {
+			return "", cli.Exit("Cannot amend: "+err.Error(), 1)
+		}
+		// The amended migration keeps its version so it stays last in the history
+		ts = migrationVersion(amended)
+		if name == "" {
+			name = strings.TrimSuffix(strings.TrimPrefix(amended, ts+"_"), ".sql")
+		}
+		migrationsSource.Skip = []string{amended}
+		fmt.Println("Amending migration:", amended)
+	} else if name == "" {
+		return "", cli.Exit("Required flag \"name\" not set", 1)
+	} else {
+		warnMigrationOrder(dir, "goose_db_version")
+	}
+	filename := filepath.Join(dir, ts+"_"+name+".sql")
 
-			fmt.Printf("Target schema has %d models, %d enums\n", len(targetSchema.Models), len(targetSchema.Enums))
-			for _, m := range targetSchema.Models {
-				fmt.Printf("  - Model: %s (table: %s)\n", m.Name, m.TableName)
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) == len(migrationsSource.Skip) {
+		// Initial migration
+		diff := initialSchemaDiff(targetSchema)
+		printChangeSummary(diff)
+		up := schema.GenerateMigrationSQL(diff)
+		down := schema.GenerateDownMigrationSQL(diff)
+		if err := writeMigration(filename, migrationHeader(opts.Ticket), up, down, opts.DryRun); err != nil {
+			return "", cli.Exit("Failed to create migration file: "+err.Error(), 1)
+		}
+		removeAmendedMigration(amended, filename, opts.DryRun)
+		printNoTransactionNote(up, down)
+		return filename, nil
+	}
+	currentSchema, err := migrationsSource.LoadSchema(ctx)
+	if err != nil {
+		return "", cli.Exit("Failed to parse current schema from migrations: "+err.Error(), 1)
+	}
+
+	// Debug: Print current schema
+	fmt.Printf("Current schema has %d models, %d enums\n", len(currentSchema.Models), len(currentSchema.Enums))
+	for _, m := range currentSchema.Models {
+		fmt.Printf("  - Model: %s (table: %s)\n", m.Name, m.TableName)
+	}
+	for _, e := range currentSchema.Enums {
+		fmt.Printf("  - Enum: %s\n", e.Name)
+	}
+
+	fmt.Printf("Target schema has %d models, %d enums\n", len(targetSchema.Models), len(targetSchema.Enums))
+	for _, m := range targetSchema.Models {
+		fmt.Printf("  - Model: %s (table: %s)\n", m.Name, m.TableName)
+	}
+	for _, e := range targetSchema.Enums {
+		fmt.Printf("  - Enum: %s\n", e.Name)
+	}
+
+	// One reader serves every question, so answers piped in together aren't lost between them
+	reader := bufio.NewReader(os.Stdin)
+	diff := schema.DiffSchemas(currentSchema, targetSchema)
+	if opts.DryRun {
+		for _, rc := range diff.RenameCandidates {
+			fmt.Printf("ℹ️  Possible rename: %s (generate asks whether to rename it instead of dropping and adding it)\n", rc)
+		}
+	} else if diff, err = confirmRenames(reader, currentSchema, targetSchema, diff); err != nil {
+		return "", err
+	}
+	fmt.Printf(
+		"Diff: %d models added, %d models removed, %d enums added, %d enums removed, %d fields added, %d fields removed, %d fields modified\n",
+		len(
+			diff.ModelsAdded,
+		),
+		len(diff.ModelsRemoved),
+		len(diff.EnumsAdded),
+		len(diff.EnumsRemoved),
+		len(diff.FieldsAdded),
+		len(diff.FieldsRemoved),
+		len(diff.FieldsModified),
+	)
+
+	if !hasSchemaChanges(diff) {
+		fmt.Println("No changes detected.")
+		if amended != "" {
+			fmt.Println("schema.prisma matches the migrations before", amended, "- delete it if it is no longer needed")
+		}
+		return "", nil
+	}
+
+	var shadowed []*schema.FieldChange
+	if opts.ZeroDowntime {
+		shadowed = extractShadowColumnChanges(diff)
+	}
+
+	// Row counts of affected tables make the warnings below concrete
+	estimateAffectedRows(diff)
+	printChangeSummary(diff)
+	printPreflightChecks(diff)
+
+	// Check for risky operations before generating
+	risks := analyzeRiskyOperations(diff)
+	if len(risks) > 0 && opts.DryRun {
+		fmt.Println("\n⚠️  WARNING: The following operations cannot be automatically rolled back:")
+		for _, risk := range risks {
+			fmt.Printf("  • %s\n", risk)
+		}
+	} else if len(risks) > 0 {
+		fmt.Println("\n⚠️  WARNING: The following operations cannot be automatically rolled back:")
+		for _, risk := range risks {
+			fmt.Printf("  • %s\n", risk)
+		}
+		fmt.Print("\nDo you want to continue? This will generate the migration with warnings. (y/N): ")
+
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return "", cli.Exit("Failed to read user input: "+err.Error(), 1)
+		}
+
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Migration generation cancelled.")
+			return "", errGenerateCancelled
+		}
+
+		fmt.Println("Proceeding with risky migration...")
+	}
+	up := schema.GenerateMigrationSQL(diff)
+	down := schema.GenerateDownMigrationSQL(diff)
+	if !hasSchemaChanges(diff) {
+		// Every change is rolled out by the shadow column migrations
+		filename, up, down = "", "", ""
+	} else if err := writeMigration(filename, migrationHeader(opts.Ticket), up, down, opts.DryRun); err != nil {
+		return "", cli.Exit("Failed to create migration file: "+err.Error(), 1)
+	}
+	removeAmendedMigration(amended, filename, opts.DryRun)
+	if len(shadowed) > 0 {
+		first, err := writeShadowColumnMigrations(shadowed, diff.Generator, name, filename, opts)
+		if err != nil {
+			return "", err
+		}
+		if filename == "" {
+			filename = first
+		}
+	}
+	printNoTransactionNote(up, down)
+	printLongLocks(diff, up)
+	printLargeTableIndexes(diff, up)
+	return filename, nil
+}
+
+// extractShadowColumnChanges removes the type changes that can go through a shadow column from the
+// modified fields of a diff and returns them
+func extractShadowColumnChanges(diff *schema.SchemaDiff) []*schema.FieldChange {
+	var shadowed, kept []*schema.FieldChange
+	for _, fc := range diff.FieldsModified {
+		if fc.CanUseShadowColumn(diff.Generator) {
+			shadowed = append(shadowed, fc)
+		} else {
+			kept = append(kept, fc)
+		}
+	}
+	diff.FieldsModified = kept
+	return shadowed
+}
+
+// writeShadowColumnMigrations writes the migrations of the zero-downtime recipe of every shadowed
+// type change after main, the main migration's file name ("" without one), and returns the name of
+// the first one
+func writeShadowColumnMigrations(changes []*schema.FieldChange, generator schema.GeneratorConfig, name, main string,
+	opts generateOptions) (string, error) {
+	existing, _ := listMigrationFiles(migrationsDir())
+	// Dry runs don't write the main migration, whose version the steps come after all the same
+	if main != "" && !slices.Contains(existing, filepath.Base(main)) {
+		existing = append(existing, filepath.Base(main))
+	}
+	var first string
+	for _, fc := range changes {
+		fmt.Printf("\n🐢 Zero-downtime type change of %s.%s:\n", fc.ModelName, fc.Field.ColumnName)
+		for _, step := range schema.ShadowColumnRecipe(fc, generator) {
+			version := nextMigrationVersion(existing, time.Now())
+			filename := filepath.Join(migrationsDir(), version+"_"+name+"_"+step.Name+".sql")
+			if err := writeMigration(filename, migrationHeader(opts.Ticket), step.Up, step.Down, opts.DryRun); err != nil {
+				return "", cli.Exit("Failed to create migration file: "+err.Error(), 1)
 			}
-			for _, e := range targetSchema.Enums {
-				fmt.Printf("  - Enum: %s\n", e.Name)
+			// Dry runs write nothing, so the planned versions are tracked here
+			existing = append(existing, filepath.Base(filename))
+			if first == "" {
+				first = filename
 			}
+		}
+	}
+	fmt.Println("ℹ️  Deploy the migrations one at a time: the application must stop reading the old column " +
+		"before the swap is applied, and the last migration drops it")
+	return first, nil
+}
 
-			diff := schema.DiffSchemas(currentSchema, targetSchema)
-			fmt.Printf(
-				"Diff: %d models added, %d models removed, %d enums added, %d enums removed, %d fields added, %d fields removed, %d fields modified\n",
-				len(
-					diff.ModelsAdded,
-				),
-				len(diff.ModelsRemoved),
-				len(diff.EnumsAdded),
-				len(diff.EnumsRemoved),
-				len(diff.FieldsAdded),
-				len(diff.FieldsRemoved),
-				len(diff.FieldsModified),
-			)
-
-			if diff == nil ||
-				(len(diff.ModelsAdded) == 0 && len(diff.EnumsAdded) == 0 && len(diff.FieldsAdded) == 0 && len(diff.FieldsRemoved) == 0 && len(diff.FieldsModified) == 0) {
-				fmt.Println("No changes detected.")
-				return nil
-			}
+// writeMigration creates a migration file, or with dryRun prints the file name and content it would
+// create without touching disk
+func writeMigration(filename string, header *schema.MigrationHeader, up, down string, dryRun bool) error {
+	content := header.String() + schema.FormatMigration(up, down)
+	if dryRun {
+		fmt.Println("Would create migration:", filename)
+		fmt.Print("\n" + content)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		return err
+	}
+	fmt.Println("Created migration:", filename)
+	return nil
+}
 
-			// Check for risky operations before generating
-			risks := analyzeRiskyOperations(diff)
-			if len(risks) > 0 {
-				fmt.Println("\n⚠️  WARNING: The following operations cannot be automatically rolled back:")
-				for _, risk := range risks {
-					fmt.Printf("  • %s\n", risk)
-				}
-				fmt.Print("\nDo you want to continue? This will generate the migration with warnings. (y/N): ")
-
-				reader := bufio.NewReader(os.Stdin)
-				response, err := reader.ReadString('\n')
-				if err != nil {
-					return cli.Exit("Failed to read user input: "+err.Error(), 1)
-				}
-
-				response = strings.ToLower(strings.TrimSpace(response))
-				if response != "y" && response != "yes" {
-					fmt.Println("Migration generation cancelled.")
-					return nil
-				}
-
-				fmt.Println("Proceeding with risky migration...")
+// migrationHeader collects the metadata written at the top of a generated migration: the tool version,
+// the git author, the ticket and the hash of schema.prisma
+func migrationHeader(ticket string) *schema.MigrationHeader {
+	header := &schema.MigrationHeader{Tool: "schema-manager " + Version, Ticket: ticket}
+	name, _ := gitOutput("config", "user.name")
+	email, _ := gitOutput("config", "user.email")
+	switch {
+	case name != "" && email != "":
+		header.Author = name + " <" + email + ">"
+	default:
+		header.Author = name + email
+	}
+	if content, err := os.ReadFile("schema.prisma"); err == nil {
+		header.SchemaHash = schema.SchemaHash(content)
+	}
+	return header
+}
+
+// amendableMigration returns the most recent migration of a directory, refusing when the goose version
+// table of DATABASE_URL records it as applied. Without DATABASE_URL the check is skipped with a warning.
+func amendableMigration(dir, versionTable string) (string, error) {
+	files, err := listMigrationFiles(dir)
+	if err != nil || len(files) == 0 {
+		return "", fmt.Errorf("no migration to amend in %s", dir)
+	}
+	last := files[len(files)-1]
+
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		fmt.Printf("⚠️  %v - cannot check whether %s has been applied\n", err, last)
+		return last, nil
+	}
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	var applied bool
+	err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM "+versionTable+" WHERE version_id = $1 AND is_applied)",
+		migrationVersion(last)).Scan(&applied)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "42P01" {
+		// No version table: nothing has been migrated yet
+		return last, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", versionTable, err)
+	}
+	if applied {
+		return "", fmt.Errorf("%s has already been applied - generate a new migration instead", last)
+	}
+	return last, nil
+}
+
+// removeAmendedMigration deletes the amended migration when it was regenerated under a new name
+func removeAmendedMigration(amended, filename string, dryRun bool) {
+	if amended == "" || dryRun || filepath.Join(migrationsDir(), amended) == filepath.Clean(filename) {
+		return
+	}
+	if err := os.Remove(filepath.Join(migrationsDir(), amended)); err != nil {
+		fmt.Printf("⚠️  Failed to remove %s: %v\n", amended, err)
+		return
+	}
+	fmt.Println("Removed migration:", amended)
+}
+
+// printNoTransactionNote explains why a migration was marked NO TRANSACTION
+func printNoTransactionNote(up, down string) {
+	if schema.RequiresNoTransaction(up) || schema.RequiresNoTransaction(down) {
+		fmt.Println("ℹ️  The migration contains statements that can't run in a transaction and is marked " +
+			"-- +goose NO TRANSACTION; a failure part-way leaves earlier statements applied")
+	}
+}
+
+// printLongLocks lists the statements of a migration that hold a table lock for more than a moment
+func printLongLocks(diff *schema.SchemaDiff, sql string) {
+	var long []*schema.StatementLock
+	for _, lock := range schema.AnalyzeLocks(sql) {
+		if lock.Long {
+			long = append(long, lock)
+		}
+	}
+	if len(long) == 0 {
+		return
+	}
+	fmt.Println("\n🔒 Long-held locks:")
+	for _, lock := range long {
+		fmt.Printf("  • %s on %s%s: %s\n", lock.Lock, lock.Table, diff.RowImpact(lock.Table), lock.Reason)
+	}
+}
+
+// printLargeTableIndexes warns about indexes built without CONCURRENTLY on tables above the
+// largeTableRows threshold
+func printLargeTableIndexes(diff *schema.SchemaDiff, sql string) {
+	locks := diff.LargeTableIndexes(sql)
+	if len(locks) == 0 {
+		return
+	}
+	fmt.Println("\n⚠️  Indexes on large tables:")
+	for _, lock := range locks {
+		fmt.Printf("  • %s%s\n", largeTableIndexWarning(lock), diff.RowImpact(lock.Table))
+	}
+}
+
+// largeTableIndexWarning describes an index build that blocks writes to a large table
+func largeTableIndexWarning(lock *schema.StatementLock) string {
+	return fmt.Sprintf("%s blocks writes to %s until the index is built; use CREATE INDEX CONCURRENTLY in a "+
+		"migration of its own", strings.Join(strings.Fields(lock.Statement), " "), lock.Table)
+}
+
+// initialSchemaDiff returns a diff that creates every object of the target schema from scratch
+func initialSchemaDiff(targetSchema *schema.Schema) *schema.SchemaDiff {
+	diff := &schema.SchemaDiff{Generator: targetSchema.Generator}
+	for _, m := range targetSchema.Models {
+		diff.ModelsAdded = append(diff.ModelsAdded, m)
+	}
+	for _, e := range targetSchema.Enums {
+		diff.EnumsAdded = append(diff.EnumsAdded, e)
+	}
+	if targetSchema.Generator.CompositeTypes == schema.CompositeTypesNative {
+		diff.CompositeTypesAdded = append(diff.CompositeTypesAdded, targetSchema.CompositeTypes...)
+	}
+	diff.ExtensionsAdded = append(diff.ExtensionsAdded, targetSchema.Extensions...)
+	diff.TriggersAdded = append(diff.TriggersAdded, targetSchema.Triggers...)
+	diff.FunctionsAdded = append(diff.FunctionsAdded, targetSchema.Functions...)
+	diff.PoliciesAdded = append(diff.PoliciesAdded, targetSchema.Policies...)
+	diff.ViewsAdded = append(diff.ViewsAdded, targetSchema.Views...)
+	diff.SequencesAdded = append(diff.SequencesAdded, targetSchema.Sequences...)
+	return diff
+}
+
+// confirmRenames asks whether the dropped tables and columns that look renamed to added ones were
+// renamed, and diffs again with the renames confirmed. A renamed table can bring up candidates among
+// its columns, which are asked about in turn.
+func confirmRenames(reader *bufio.Reader, current, target *schema.Schema, diff *schema.SchemaDiff) (*schema.SchemaDiff, error) {
+	var confirmed []*schema.RenameCandidate
+	asked := map[string]bool{}
+	for {
+		var pending []*schema.RenameCandidate
+		for _, rc := range diff.RenameCandidates {
+			if !asked[rc.String()] {
+				pending = append(pending, rc)
 			}
-			up := schema.GenerateMigrationSQL(diff)
-			down := schema.GenerateDownMigrationSQL(diff)
-			ts := time.Now().Format("20060102150405")
-			name := c.String("name")
-			filename := "migrations/" + ts + "_" + name + ".sql"
-			f, err := os.Create(filename)
+		}
+		if len(pending) == 0 {
+			return diff, nil
+		}
+		for _, rc := range pending {
+			asked[rc.String()] = true
+			fmt.Printf("Rename %s instead of dropping and adding it? (y/N): ", rc)
+			response, err := reader.ReadString('\n')
 			if err != nil {
-				return cli.Exit("Failed to create migration file: "+err.Error(), 1)
+				return nil, cli.Exit("Failed to read user input: "+err.Error(), 1)
 			}
-			defer f.Close()
-			f.WriteString("-- +goose Up\n" + up + "\n\n-- +goose Down\n" + down)
-			fmt.Println("Created migration:", filename)
-			return nil
-		},
+			if response = strings.ToLower(strings.TrimSpace(response)); response == "y" || response == "yes" {
+				confirmed = append(confirmed, rc)
+			}
+		}
+		diff = schema.DiffSchemasWithRenames(current, target, confirmed)
 	}
 }
 
+// hasSchemaChanges reports whether a diff contains anything worth a migration
+func hasSchemaChanges(diff *schema.SchemaDiff) bool {
+	return diff != nil &&
+		(len(diff.ModelsAdded) > 0 || len(diff.ModelsRemoved) > 0 || len(diff.EnumsAdded) > 0 ||
+			len(diff.EnumsRemoved) > 0 || len(diff.EnumsModified) > 0 || len(diff.CompositeTypesAdded) > 0 || len(diff.CompositeTypesRemoved) > 0 ||
+			len(diff.CompositeTypesModified) > 0 || len(diff.ExtensionsAdded) > 0 ||
+			len(diff.ExtensionsRemoved) > 0 || len(diff.TriggersAdded) > 0 || len(diff.TriggersRemoved) > 0 ||
+			len(diff.FunctionsAdded) > 0 || len(diff.FunctionsRemoved) > 0 ||
+			len(diff.FunctionsModified) > 0 || len(diff.PoliciesAdded) > 0 || len(diff.PoliciesRemoved) > 0 ||
+			len(diff.RowLevelSecurityEnabled) > 0 || len(diff.RowLevelSecurityDisabled) > 0 ||
+			len(diff.GrantsAdded) > 0 || len(diff.GrantsRevoked) > 0 ||
+			len(diff.ViewsAdded) > 0 || len(diff.ViewsRemoved) > 0 || len(diff.ViewsModified) > 0 ||
+			len(diff.SequencesAdded) > 0 || len(diff.SequencesRemoved) > 0 || len(diff.SequencesModified) > 0 ||
+			len(diff.FieldsAdded) > 0 || len(diff.FieldsRemoved) > 0 || len(diff.FieldsModified) > 0 ||
+			len(diff.IndexesAdded) > 0 || len(diff.IndexesRemoved) > 0 ||
+			len(diff.ForeignKeysAdded) > 0 || len(diff.ForeignKeysRemoved) > 0 || len(diff.PrimaryKeys) > 0 ||
+			len(diff.Comments) > 0 || len(diff.TablesRenamed) > 0 || len(diff.ColumnsRenamed) > 0 ||
+			len(diff.ConstraintsRenamed) > 0)
+}
+
 // analyzeRiskyOperations checks for operations that cannot be safely rolled back
 func analyzeRiskyOperations(diff *schema.SchemaDiff) []string {
 	var risks []string
@@ -143,24 +528,31 @@ func analyzeRiskyOperations(diff *schema.SchemaDiff) []string {
 		currentField := fieldChange.CurrentField
 		targetField := fieldChange.Field
 
-		currentNormalizedType := schema.NormalizeTypeForComparison(currentField.Type, currentField.Attributes)
-		targetNormalizedType := schema.NormalizeTypeForComparison(targetField.Type, targetField.Attributes)
+		currentNormalizedType, targetNormalizedType := fieldChange.NormalizedTypes()
 
 		if currentNormalizedType != targetNormalizedType {
 			// Check forward conversion (UP migration)
-			forwardCastResult := schema.CanCastType(currentNormalizedType, targetNormalizedType)
+			forwardCastResult := fieldChange.CastType(diff.Generator, false)
 			// Check reverse conversion (DOWN migration rollback)
-			reverseCastResult := schema.CanCastType(targetNormalizedType, currentNormalizedType)
+			reverseCastResult := fieldChange.CastType(diff.Generator, true)
+			// A @using expression written for the column replaces the generic cast
+			if using := schema.UsingOverride(targetField, false); using != "" {
+				forwardCastResult = schema.TypeCastResult{CanCast: true, CastExpression: using}
+			}
+			if using := schema.UsingOverride(targetField, true); using != "" {
+				reverseCastResult = schema.TypeCastResult{CanCast: true, CastExpression: using}
+			}
 
 			if forwardCastResult.IsRisky {
-				risk := fmt.Sprintf("Field %s.%s: %s → %s (%s)",
+				risk := fmt.Sprintf("Field %s.%s: %s → %s (%s)%s",
 					fieldChange.ModelName, targetField.ColumnName,
-					currentNormalizedType, targetNormalizedType, forwardCastResult.WarningMessage)
+					currentNormalizedType, targetNormalizedType, forwardCastResult.WarningMessage,
+					diff.RowImpact(fieldChange.ModelName))
 				risks = append(risks, risk)
 			} else if !forwardCastResult.CanCast {
-				risk := fmt.Sprintf("Field %s.%s: %s → %s (Cannot be automatically cast - manual intervention required)",
+				risk := fmt.Sprintf("Field %s.%s: %s → %s (Cannot be automatically cast - manual intervention required)%s",
 					fieldChange.ModelName, targetField.ColumnName,
-					currentNormalizedType, targetNormalizedType)
+					currentNormalizedType, targetNormalizedType, diff.RowImpact(fieldChange.ModelName))
 				risks = append(risks, risk)
 			}
 
@@ -181,24 +573,30 @@ func analyzeRiskyOperations(diff *schema.SchemaDiff) []string {
 		// Check for nullability changes that could be problematic
 		if !currentField.IsOptional && targetField.IsOptional {
 			// Making a field nullable is generally safe
-		} else if currentField.IsOptional && !targetField.IsOptional {
+		} else if currentField.IsOptional && !targetField.IsOptional && !fieldChange.BackfillsNulls(diff.Generator) {
 			// Making a field NOT NULL is risky if there are existing NULL values
-			risk := fmt.Sprintf("Field %s.%s: Making nullable field NOT NULL (may fail if NULL values exist)",
-				fieldChange.ModelName, targetField.ColumnName)
+			risk := fmt.Sprintf("Field %s.%s: Making nullable field NOT NULL (may fail if NULL values exist)%s",
+				fieldChange.ModelName, targetField.ColumnName, diff.RowImpact(fieldChange.ModelName))
 			risks = append(risks, risk)
 		}
 	}
 
 	// Check for model/table drops - these can't be easily rolled back with data
 	for _, model := range diff.ModelsRemoved {
-		risk := fmt.Sprintf("Table %s: Being dropped (all data will be lost)", model.TableName)
+		risk := fmt.Sprintf("Table %s: Being dropped (all data will be lost)%s", model.TableName, diff.RowImpact(model.TableName))
 		risks = append(risks, risk)
 	}
 
 	// Check for field removals - data will be lost
 	for _, fieldChange := range diff.FieldsRemoved {
-		risk := fmt.Sprintf("Field %s.%s: Being removed (column data will be lost)",
-			fieldChange.ModelName, fieldChange.Field.ColumnName)
+		risk := fmt.Sprintf("Field %s.%s: Being removed (column data will be lost)%s",
+			fieldChange.ModelName, fieldChange.Field.ColumnName, diff.RowImpact(fieldChange.ModelName))
+		risks = append(risks, risk)
+	}
+
+	// Check for extension removals
+	for _, ext := range diff.ExtensionsRemoved {
+		risk := fmt.Sprintf("Extension %s: Being dropped (objects depending on it will break)", ext.Name)
 		risks = append(risks, risk)
 	}
 
@@ -208,5 +606,81 @@ func analyzeRiskyOperations(diff *schema.SchemaDiff) []string {
 		risks = append(risks, risk)
 	}
 
+	// Check for enum values being removed or renamed, which recreates the type
+	for _, ec := range diff.EnumsModified {
+		if removed := ec.RemovedLabels(); len(removed) > 0 {
+			risk := fmt.Sprintf("Enum %s: Removing values %s (rows holding them get the column default or fail the migration)",
+				ec.Enum.Name, strings.Join(removed, ", "))
+			risks = append(risks, risk)
+		}
+		if len(ec.Renamed) > 0 {
+			var renames []string
+			for from, to := range ec.Renamed {
+				renames = append(renames, from+" -> "+to)
+			}
+			slices.Sort(renames)
+			risk := fmt.Sprintf("Enum %s: Values replaced in place are migrated as renames (%s); check the USING mappings",
+				ec.Enum.Name, strings.Join(renames, ", "))
+			risks = append(risks, risk)
+		}
+	}
+
+	// Check for composite types and attributes being dropped
+	for _, ct := range diff.CompositeTypesRemoved {
+		risk := fmt.Sprintf("Type %s: Being dropped (may affect dependent fields)", ct.Name)
+		risks = append(risks, risk)
+	}
+	for _, ctc := range diff.CompositeTypesModified {
+		for _, f := range ctc.CurrentType.Fields {
+			if !slices.ContainsFunc(ctc.Type.Fields, func(t *schema.Field) bool { return t.ColumnName == f.ColumnName }) {
+				risk := fmt.Sprintf("Type %s: Dropping attribute %s (data stored in it will be lost)", ctc.Type.Name, f.ColumnName)
+				risks = append(risks, risk)
+			}
+		}
+	}
+
 	return risks
 }
+
+// estimateAffectedRows fills in approximate row counts (pg_class.reltuples) of the tables a diff
+// rewrites or drops data from, when DATABASE_URL points to a reachable database
+func estimateAffectedRows(diff *schema.SchemaDiff) {
+	databaseURL, err := resolveDatabaseURL()
+	tables := diff.AffectedTables()
+	if err != nil || len(tables) == 0 {
+		return
+	}
+
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping row estimates: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT c.relname, c.reltuples::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'r' AND n.nspname = current_schema() AND c.relname = ANY($1)`,
+		pq.Array(tables))
+	if err != nil {
+		fmt.Printf("⚠️  Skipping row estimates: %v\n", err)
+		return
+	}
+	defer rows.Close()
+
+	diff.RowEstimates = map[string]int64{}
+	for rows.Next() {
+		var table string
+		var estimate int64
+		if err := rows.Scan(&table, &estimate); err != nil {
+			fmt.Printf("⚠️  Skipping row estimates: %v\n", err)
+			return
+		}
+		// reltuples is -1 for tables that were never vacuumed or analyzed
+		if estimate >= 0 {
+			diff.RowEstimates[table] = estimate
+		}
+	}
+}
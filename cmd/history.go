@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func HistoryCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "List migrations with the metadata of their headers",
+		Description: "Show every migration in version order with the author, ticket, schema hash and tool " +
+			"version recorded in its header when it was generated",
+		Action: func(c *cli.Context) error {
+			files, err := listMigrationFiles(migrationsDir())
+			if err != nil {
+				return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+			}
+			if len(files) == 0 {
+				fmt.Println("No migrations.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tAUTHOR\tTICKET\tSCHEMA HASH\tGENERATED BY")
+			for _, f := range files {
+				content, err := os.ReadFile(filepath.Join(migrationsDir(), f))
+				if err != nil {
+					return cli.Exit("Failed to read "+f+": "+err.Error(), 1)
+				}
+				h := schema.ParseMigrationHeader(string(content))
+				version := migrationVersion(f)
+				name := strings.TrimSuffix(strings.TrimPrefix(f, version+"_"), ".sql")
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", version, name,
+					orDash(h.Author), orDash(h.Ticket), orDash(shortHash(h.SchemaHash)), orDash(h.Tool))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// shortHash abbreviates a schema hash for display
+func shortHash(hash string) string {
+	if len(hash) > len("sha256:")+12 {
+		return hash[:len("sha256:")+12]
+	}
+	return hash
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// hookMarker identifies git hooks written by schema-manager so they can be safely overwritten
+const hookMarker = "# Installed by schema-manager hooks install"
+
+func HooksCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "hooks",
+		Usage: "Manage git hooks that guard schema/migration consistency",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "install",
+				Usage: "Install a git hook running validate and check",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "hook",
+						Usage: "Hook to install (pre-commit or pre-push)",
+						Value: "pre-commit",
+					},
+					&cli.StringFlag{
+						Name:  "bin",
+						Usage: "schema-manager executable the hook invokes",
+						Value: "schema-manager",
+					},
+					&cli.BoolFlag{Name: "force", Usage: "Overwrite an existing hook not created by schema-manager"},
+				},
+				Action: func(c *cli.Context) error {
+					return runHooksInstall(c.String("hook"), c.String("bin"), c.Bool("force"))
+				},
+			},
+		},
+	}
+}
+
+func runHooksInstall(hook, bin string, force bool) error {
+	if hook != "pre-commit" && hook != "pre-push" {
+		return cli.Exit("Unsupported hook "+hook+": use pre-commit or pre-push", 1)
+	}
+
+	hooksDir, err := gitOutput("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return cli.Exit("Not inside a git repository: "+err.Error(), 1)
+	}
+	// The hook runs from the repository root, so remember where schema.prisma lives relative to it
+	prefix, err := gitOutput("rev-parse", "--show-prefix")
+	if err != nil {
+		return cli.Exit("Failed to resolve repository path: "+err.Error(), 1)
+	}
+
+	hookPath := filepath.Join(hooksDir, hook)
+	if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), hookMarker) && !force {
+		return cli.Exit(hookPath+" already exists - use --force to overwrite it", 1)
+	}
+
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		return cli.Exit("Failed to create hooks directory: "+err.Error(), 1)
+	}
+	if err := os.WriteFile(hookPath, []byte(generateHookScript(bin, prefix)), 0o755); err != nil {
+		return cli.Exit("Failed to write hook: "+err.Error(), 1)
+	}
+
+	fmt.Printf("✅ Installed %s hook at %s\n", hook, hookPath)
+	return nil
+}
+
+// generateHookScript returns a shell hook that validates the schema and checks migrations are up to date
+func generateHookScript(bin, prefix string) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString(hookMarker + "\n")
+	sb.WriteString("cd \"$(git rev-parse --show-toplevel)/" + prefix + "\" || exit 1\n\n")
+	sb.WriteString(bin + " validate || exit 1\n")
+	sb.WriteString(bin + " check || exit 1\n")
+	return sb.String()
+}
+
+// gitOutput runs a git command and returns its trimmed output
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
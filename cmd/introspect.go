@@ -1,17 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	_ "github.com/lib/pq"
+	"github.com/phathdt/schema-manager/pkg/schema"
 	"github.com/urfave/cli/v2"
 )
 
+var (
+	numericDefaultRegex = regexp.MustCompile(`^\(?-?[0-9]+(\.[0-9]+)?\)?$`)
+	stringDefaultRegex  = regexp.MustCompile(`^'((?:[^']|'')*)'(?:::[a-zA-Z_ ]+)?$`)
+)
+
 type TableInfo struct {
 	TableName   string
 	Columns     []ColumnInfo
@@ -22,12 +32,14 @@ type TableInfo struct {
 type ColumnInfo struct {
 	ColumnName      string
 	DataType        string
+	MaxLength       sql.NullInt64 // Length limit of character varying(n) columns
 	IsNullable      bool
 	DefaultValue    sql.NullString
 	IsAutoIncrement bool
 	IsPrimaryKey    bool
 	IsUnique        bool
 	IsCompositePK   bool
+	EnumName        string // Enum type of the column, empty for other types
 }
 
 type IndexInfo struct {
@@ -54,21 +66,43 @@ func IntrospectCommand() *cli.Command {
 				Usage:   "Output schema file path",
 				Value:   "schema.prisma",
 			},
+			&cli.BoolFlag{
+				Name:  "append",
+				Usage: "Only add models for tables the schema file doesn't have yet, keeping the rest of the file as it is",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the schema and baseline migration instead of writing them",
+			},
+			&cli.BoolFlag{
+				Name:  "diff",
+				Usage: "With --dry-run, print the schema as a diff against the existing file",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
-			outputFile := ctx.String("output")
-			return runIntrospect(outputFile)
+			return runIntrospect(ctx.String("output"), introspectOptions{
+				Append: ctx.Bool("append"),
+				DryRun: ctx.Bool("dry-run") || ctx.Bool("diff"),
+				Diff:   ctx.Bool("diff"),
+			})
 		},
 	}
 }
 
-func runIntrospect(outputFile string) error {
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		return fmt.Errorf("DATABASE_URL environment variable is required")
+// introspectOptions are the flags of introspect
+type introspectOptions struct {
+	Append bool // Only add models for tables without one
+	DryRun bool // Print the files instead of writing them
+	Diff   bool // Print the schema as a diff against the existing file
+}
+
+func runIntrospect(outputFile string, opts introspectOptions) error {
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return err
 	}
 
-	db, err := connectWithSSLFallback(databaseURL)
+	db, introspect, err := openIntrospectionDatabase(databaseURL)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -76,7 +110,7 @@ func runIntrospect(outputFile string) error {
 
 	fmt.Println("✅ Connected to database successfully")
 
-	tables, err := introspectDatabase(db)
+	tables, err := introspect(db)
 	if err != nil {
 		return fmt.Errorf("failed to introspect database: %w", err)
 	}
@@ -88,18 +122,64 @@ func runIntrospect(outputFile string) error {
 
 	fmt.Printf("📊 Found %d tables in database\n", len(tables))
 
-	schemaContent := generatePrismaSchema(tables)
-	if err := writeSchemaFile(outputFile, schemaContent); err != nil {
-		return fmt.Errorf("failed to write schema file: %w", err)
+	// Keep the datasource url and naming rules of a schema being re-introspected
+	datasource, err := schema.ReadDatasource(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read datasource: %w", err)
+	}
+	generator, err := schema.ReadGeneratorConfig(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read generator config: %w", err)
 	}
 
-	fmt.Printf("✅ Generated schema.prisma at %s\n", outputFile)
+	existing, err := os.ReadFile(outputFile)
+	var schemaContent string
+	appended := opts.Append && err == nil
+	if appended {
+		tables, err = tablesMissingFromSchema(outputFile, tables)
+		if err != nil {
+			return err
+		}
+		if len(tables) == 0 {
+			fmt.Printf("✅ Every table already has a model in %s\n", outputFile)
+			return nil
+		}
+		content := strings.TrimRight(string(existing), "\n") + "\n\n" + generatePrismaModels(tables, generator)
+		schemaContent = strings.TrimRight(content, "\n") + "\n"
+		for _, table := range tables {
+			fmt.Printf("  + model %s (%s)\n", toPascalCase(table.TableName, generator), table.TableName)
+		}
+	} else {
+		schemaContent = generatePrismaSchema(tables, datasource, generator)
+	}
 
 	migrationContent := generateBaselineMigration(tables)
 	timestamp := time.Now().Format("20060102150405")
-	migrationFile := fmt.Sprintf("migrations/%s_baseline_from_database.sql", timestamp)
+	dir := migrationsDirFor(outputFile)
+	migrationFile := filepath.Join(dir, timestamp+"_baseline_from_database.sql")
+
+	if opts.DryRun {
+		if opts.Diff {
+			fmt.Print("\n" + lineDiff(outputFile, outputFile+" (introspected)", string(existing), schemaContent))
+		} else {
+			fmt.Println("\nWould write", outputFile)
+			fmt.Print("\n" + schemaContent)
+		}
+		fmt.Println("\nWould create migration:", migrationFile)
+		fmt.Print("\n" + migrationContent)
+		return nil
+	}
+
+	if err := writeSchemaFile(outputFile, schemaContent); err != nil {
+		return fmt.Errorf("failed to write schema file: %w", err)
+	}
+	if appended {
+		fmt.Printf("✅ Added %d models to %s\n", len(tables), outputFile)
+	} else {
+		fmt.Printf("✅ Generated schema.prisma at %s\n", outputFile)
+	}
 
-	if err := createMigrationsDir(); err != nil {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return fmt.Errorf("failed to create migrations directory: %w", err)
 	}
 
@@ -113,6 +193,27 @@ func runIntrospect(outputFile string) error {
 	return nil
 }
 
+// openIntrospectionDatabase connects to the database of a connection URL and returns the function
+// reading its tables: SQLite projects are read from their database file, others are PostgreSQL
+func openIntrospectionDatabase(databaseURL string) (*sql.DB, func(*sql.DB) ([]TableInfo, error), error) {
+	if path, ok := sqliteDatabasePath(databaseURL); ok {
+		db, err := openSQLite(path)
+		return db, introspectSQLite, err
+	}
+	db, err := connectWithSSLFallback(databaseURL)
+	return db, introspectDatabase, err
+}
+
+// resolveDatabaseURL returns the connection URL the datasource block in schema.prisma names: directUrl,
+// which bypasses connection poolers, or url, DATABASE_URL when there is no schema or url field
+func resolveDatabaseURL() (string, error) {
+	datasource, err := schema.ReadDatasource("schema.prisma")
+	if err != nil {
+		return "", err
+	}
+	return datasource.ResolveDirectURL()
+}
+
 func connectWithSSLFallback(databaseURL string) (*sql.DB, error) {
 	// First, try to connect with the original URL
 	db, err := sql.Open("postgres", databaseURL)
@@ -227,6 +328,7 @@ func getTableColumns(db *sql.DB, tableName string) ([]ColumnInfo, error) {
 		SELECT
 			column_name,
 			data_type,
+			character_maximum_length,
 			is_nullable,
 			column_default,
 			CASE
@@ -250,7 +352,7 @@ func getTableColumns(db *sql.DB, tableName string) ([]ColumnInfo, error) {
 		var col ColumnInfo
 		var isNullable string
 
-		if err := rows.Scan(&col.ColumnName, &col.DataType, &isNullable, &col.DefaultValue, &col.IsAutoIncrement); err != nil {
+		if err := rows.Scan(&col.ColumnName, &col.DataType, &col.MaxLength, &isNullable, &col.DefaultValue, &col.IsAutoIncrement); err != nil {
 			return nil, err
 		}
 
@@ -407,56 +509,41 @@ func getTablePrimaryKeys(db *sql.DB, tableName string) ([]string, error) {
 	return primaryKeys, nil
 }
 
-func generatePrismaSchema(tables []TableInfo) string {
-	var schema strings.Builder
-
-	schema.WriteString(`datasource db {
-  provider = "postgresql"
-  url      = env("DATABASE_URL")
+func generatePrismaSchema(tables []TableInfo, datasource schema.Datasource, generator schema.GeneratorConfig) string {
+	return generateSchemaHeader(datasource, generator) + generatePrismaModels(tables, generator)
 }
 
-generator client {
-  provider = "schema-manager"
-  output   = "./migrations"
+// tablesMissingFromSchema returns the tables no model of a schema file is mapped to
+func tablesMissingFromSchema(path string, tables []TableInfo) ([]TableInfo, error) {
+	s, err := (&schema.PrismaFileSource{Path: path}).LoadSchema(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	modeled := map[string]bool{}
+	for _, m := range s.Models {
+		modeled[m.TableName] = true
+	}
+	var missing []TableInfo
+	for _, table := range tables {
+		if !modeled[table.TableName] {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
 }
 
-`)
+// generatePrismaModels returns the model blocks of introspected tables
+func generatePrismaModels(tables []TableInfo, generator schema.GeneratorConfig) string {
+	var schema strings.Builder
 
 	for _, table := range tables {
-		schema.WriteString(fmt.Sprintf("model %s {\n", toPascalCase(table.TableName)))
+		schema.WriteString(fmt.Sprintf("model %s {\n", toPascalCase(table.TableName, generator)))
 
 		// Collect primary key fields for composite primary key
 		var primaryKeyFields []string
 
 		for _, col := range table.Columns {
-			schema.WriteString(fmt.Sprintf("  %s", toCamelCase(col.ColumnName)))
-
-			prismaType := mapDataTypeToPrisma(col.DataType)
-			if col.IsNullable && !col.IsPrimaryKey {
-				prismaType += "?"
-			}
-			schema.WriteString(fmt.Sprintf(" %s", prismaType))
-
-			var attributes []string
-			// Only add @id for single primary keys, not composite ones
-			if col.IsPrimaryKey && !col.IsCompositePK {
-				attributes = append(attributes, "@id")
-			}
-			if col.IsAutoIncrement {
-				attributes = append(attributes, "@default(autoincrement())")
-			}
-			if col.IsUnique && !col.IsPrimaryKey {
-				attributes = append(attributes, "@unique")
-			}
-			if col.ColumnName != toCamelCase(col.ColumnName) {
-				attributes = append(attributes, fmt.Sprintf("@map(\"%s\")", col.ColumnName))
-			}
-
-			if len(attributes) > 0 {
-				schema.WriteString(" " + strings.Join(attributes, " "))
-			}
-
-			schema.WriteString("\n")
+			schema.WriteString("  " + introspectedFieldLine(toCamelCase(col.ColumnName), col, generator) + "\n")
 
 			// Collect primary key fields for composite key
 			if col.IsPrimaryKey {
@@ -471,13 +558,68 @@ generator client {
 			schema.WriteString(fmt.Sprintf("  @@id([%s])\n", strings.Join(primaryKeyFields, ", ")))
 		}
 
-		schema.WriteString(fmt.Sprintf("  @@map(\"%s\")\n", table.TableName))
+		schema.WriteString(fmt.Sprintf("  @@map(%s)\n", strconv.Quote(table.TableName)))
 		schema.WriteString("}\n\n")
 	}
 
 	return schema.String()
 }
 
+// introspectedFieldLine returns the field declaration of an introspected column, e.g.
+// createdAt DateTime @default(now()) @map("created_at")
+func introspectedFieldLine(name string, col ColumnInfo, generator schema.GeneratorConfig) string {
+	prismaType := introspectedFieldType(col)
+	var attributes []string
+	if attr := nativeTypeAttribute(col, generator); attr != "" && col.EnumName == "" {
+		attributes = append(attributes, attr)
+	}
+	// Only add @id for single primary keys, not composite ones
+	if col.IsPrimaryKey && !col.IsCompositePK {
+		attributes = append(attributes, "@id")
+	}
+	if def := introspectedDefault(col); def != "" {
+		attributes = append(attributes, def)
+	}
+	if col.IsUnique && !col.IsPrimaryKey {
+		attributes = append(attributes, "@unique")
+	}
+	if attr := fieldMapAttribute(name, col.ColumnName, generator); attr != "" {
+		attributes = append(attributes, attr)
+	}
+
+	if len(attributes) == 0 {
+		return name + " " + prismaType
+	}
+	return name + " " + prismaType + " " + strings.Join(attributes, " ")
+}
+
+// introspectedFieldType returns the Prisma type of an introspected column, e.g. String?
+func introspectedFieldType(col ColumnInfo) string {
+	prismaType := mapDataTypeToPrisma(col.DataType)
+	if col.EnumName != "" {
+		prismaType = col.EnumName
+	}
+	if col.IsNullable && !col.IsPrimaryKey {
+		prismaType += "?"
+	}
+	return prismaType
+}
+
+// introspectedDefault returns the @default attribute of an introspected column, or "" when it has none
+func introspectedDefault(col ColumnInfo) string {
+	if col.IsAutoIncrement {
+		return "@default(autoincrement())"
+	}
+	if matches := stringDefaultRegex.FindStringSubmatch(col.DefaultValue.String); col.EnumName != "" && matches != nil {
+		// Enum defaults are written as the bare value
+		return "@default(" + matches[1] + ")"
+	}
+	if col.DefaultValue.Valid {
+		return mapDefaultToPrisma(col.DefaultValue.String)
+	}
+	return ""
+}
+
 func generateBaselineMigration(tables []TableInfo) string {
 	var migration strings.Builder
 
@@ -487,43 +629,7 @@ func generateBaselineMigration(tables []TableInfo) string {
 	migration.WriteString("-- All tables use conditional creation (IF NOT EXISTS)\n\n")
 
 	for _, table := range tables {
-		migration.WriteString("DO $$\n")
-		migration.WriteString("BEGIN\n")
-		migration.WriteString(
-			fmt.Sprintf(
-				"    IF NOT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = '%s') THEN\n",
-				table.TableName,
-			),
-		)
-		migration.WriteString(fmt.Sprintf("        CREATE TABLE %s (\n", table.TableName))
-
-		var columnDefs []string
-		for _, col := range table.Columns {
-			colDef := fmt.Sprintf("            %s %s", col.ColumnName, mapDataTypeToSQL(col.DataType))
-
-			if col.IsPrimaryKey {
-				colDef += " PRIMARY KEY"
-			}
-			if col.IsAutoIncrement {
-				colDef = strings.Replace(colDef, mapDataTypeToSQL(col.DataType), "SERIAL", 1)
-			}
-			if !col.IsNullable && !col.IsPrimaryKey {
-				colDef += " NOT NULL"
-			}
-			if col.IsUnique && !col.IsPrimaryKey {
-				colDef += " UNIQUE"
-			}
-			if col.DefaultValue.Valid && !col.IsAutoIncrement {
-				colDef += fmt.Sprintf(" DEFAULT %s", col.DefaultValue.String)
-			}
-
-			columnDefs = append(columnDefs, colDef)
-		}
-
-		migration.WriteString(strings.Join(columnDefs, ",\n"))
-		migration.WriteString("\n        );\n")
-		migration.WriteString("    END IF;\n")
-		migration.WriteString("END $$;\n\n")
+		migration.WriteString(conditionalCreateTableSQL(table))
 	}
 
 	migration.WriteString("-- +goose StatementEnd\n\n")
@@ -531,7 +637,7 @@ func generateBaselineMigration(tables []TableInfo) string {
 	migration.WriteString("-- +goose StatementBegin\n")
 
 	for i := len(tables) - 1; i >= 0; i-- {
-		migration.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", tables[i].TableName))
+		migration.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", schema.QuoteIdent(tables[i].TableName)))
 	}
 
 	migration.WriteString("-- +goose StatementEnd\n")
@@ -539,6 +645,53 @@ func generateBaselineMigration(tables []TableInfo) string {
 	return migration.String()
 }
 
+// conditionalCreateTableSQL returns a DO block creating an introspected table unless it exists. Names
+// are quoted where PostgreSQL would otherwise fold them to lower case or read them as key words.
+func conditionalCreateTableSQL(table TableInfo) string {
+	var sb strings.Builder
+	sb.WriteString("DO $$\n")
+	sb.WriteString("BEGIN\n")
+	sb.WriteString(fmt.Sprintf("    IF NOT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = '%s') THEN\n",
+		strings.ReplaceAll(table.TableName, "'", "''")))
+	sb.WriteString(fmt.Sprintf("        CREATE TABLE %s (\n", schema.QuoteIdent(table.TableName)))
+
+	var columnDefs, primaryKey []string
+	for _, col := range table.Columns {
+		sqlType := mapDataTypeToSQL(col.DataType, col.MaxLength)
+		if col.IsAutoIncrement {
+			sqlType = serialTypeFor(col.DataType)
+		}
+		colDef := fmt.Sprintf("            %s %s", schema.QuoteIdent(col.ColumnName), sqlType)
+
+		if col.IsCompositePK {
+			// Composite keys are declared after the columns
+			primaryKey = append(primaryKey, schema.QuoteIdent(col.ColumnName))
+		} else if col.IsPrimaryKey {
+			colDef += " PRIMARY KEY"
+		}
+		if !col.IsNullable && !col.IsPrimaryKey {
+			colDef += " NOT NULL"
+		}
+		if col.IsUnique && !col.IsPrimaryKey {
+			colDef += " UNIQUE"
+		}
+		if col.DefaultValue.Valid && !col.IsAutoIncrement {
+			colDef += fmt.Sprintf(" DEFAULT %s", col.DefaultValue.String)
+		}
+
+		columnDefs = append(columnDefs, colDef)
+	}
+	if len(primaryKey) > 0 {
+		columnDefs = append(columnDefs, "            PRIMARY KEY ("+strings.Join(primaryKey, ", ")+")")
+	}
+
+	sb.WriteString(strings.Join(columnDefs, ",\n"))
+	sb.WriteString("\n        );\n")
+	sb.WriteString("    END IF;\n")
+	sb.WriteString("END $$;\n\n")
+	return sb.String()
+}
+
 func mapDataTypeToPrisma(sqlType string) string {
 	switch strings.ToLower(sqlType) {
 	case "integer", "int4", "serial":
@@ -568,14 +721,129 @@ func mapDataTypeToPrisma(sqlType string) string {
 	}
 }
 
-func mapDataTypeToSQL(sqlType string) string {
+// nativeTypeAttribute returns the @db attribute of a column whose type differs from the one its
+// Prisma type maps to by default, e.g. @db.Real for a real column, or "" when none is needed
+func nativeTypeAttribute(col ColumnInfo, generator schema.GeneratorConfig) string {
+	switch strings.ToLower(col.DataType) {
+	case "uuid":
+		return "@db.Uuid"
+	case "date":
+		return "@db.Date"
+	}
+	if attr := dateTimeNativeAttribute(col.DataType, generator); attr != "" {
+		return attr
+	}
+	if attr := floatNativeAttribute(col.DataType, generator); attr != "" {
+		return attr
+	}
+	return stringNativeAttribute(col, generator)
+}
+
+// floatNativeAttribute returns @db.Real or @db.DoublePrecision for a floating-point column whose
+// type differs from the one Float fields get by default
+func floatNativeAttribute(sqlType string, generator schema.GeneratorConfig) string {
+	var attr string
+	switch strings.ToLower(sqlType) {
+	case "real", "float4":
+		attr = "@db.Real"
+	case "double precision", "float8":
+		attr = "@db.DoublePrecision"
+	default:
+		return ""
+	}
+	if (attr == "@db.Real") == (generator.FloatType == schema.FloatReal) {
+		return ""
+	}
+	return attr
+}
+
+// dateTimeNativeAttribute returns @db.Timestamp or @db.Timestamptz for a timestamp column whose
+// type differs from the one DateTime fields get by default
+func dateTimeNativeAttribute(sqlType string, generator schema.GeneratorConfig) string {
+	withTimeZone := false
+	switch strings.ToLower(sqlType) {
+	case "timestamp", "timestamp without time zone":
+	case "timestamptz", "timestamp with time zone":
+		withTimeZone = true
+	default:
+		return ""
+	}
+	defaultWithTimeZone := generator.DateTimeType != schema.DateTimeTimestamp
+	switch {
+	case withTimeZone == defaultWithTimeZone:
+		return ""
+	case withTimeZone:
+		return "@db.Timestamptz"
+	default:
+		return "@db.Timestamp"
+	}
+}
+
+// stringNativeAttribute returns @db.Text or @db.VarChar(n) for a text column whose type differs from
+// the one String fields get by default
+func stringNativeAttribute(col ColumnInfo, generator schema.GeneratorConfig) string {
+	var sqlType, attr string
+	switch strings.ToLower(col.DataType) {
+	case "text":
+		sqlType, attr = "TEXT", "@db.Text"
+	case "varchar", "character varying":
+		sqlType, attr = "VARCHAR", "@db.VarChar"
+		if col.MaxLength.Valid {
+			sqlType = fmt.Sprintf("VARCHAR(%d)", col.MaxLength.Int64)
+			attr = fmt.Sprintf("@db.VarChar(%d)", col.MaxLength.Int64)
+		}
+	default:
+		return ""
+	}
+	if sqlType == generator.StringSQLType() {
+		return ""
+	}
+	return attr
+}
+
+// mapDefaultToPrisma converts a column_default expression into a Prisma @default attribute.
+// Expressions without a native Prisma equivalent are preserved verbatim with dbgenerated().
+func mapDefaultToPrisma(defaultValue string) string {
+	v := strings.TrimSpace(defaultValue)
+	lower := strings.ToLower(v)
+
+	switch {
+	case lower == "now()" || strings.HasPrefix(lower, "current_timestamp"):
+		return "@default(now())"
+	case lower == "true" || lower == "false":
+		return "@default(" + lower + ")"
+	case numericDefaultRegex.MatchString(v):
+		return "@default(" + strings.Trim(v, "()") + ")"
+	}
+
+	// String literals come back as 'value'::type
+	if matches := stringDefaultRegex.FindStringSubmatch(v); len(matches) == 2 {
+		return "@default(" + strconv.Quote(strings.ReplaceAll(matches[1], "''", "'")) + ")"
+	}
+
+	return "@default(dbgenerated(" + strconv.Quote(v) + "))"
+}
+
+// serialTypeFor returns the auto-increment column type matching an introspected integer type
+func serialTypeFor(sqlType string) string {
+	switch strings.ToLower(sqlType) {
+	case "bigint", "int8":
+		return "BIGSERIAL"
+	}
+	return "SERIAL"
+}
+
+func mapDataTypeToSQL(sqlType string, maxLength sql.NullInt64) string {
 	switch strings.ToLower(sqlType) {
 	case "integer", "int4":
 		return "INTEGER"
 	case "bigint", "int8":
 		return "BIGINT"
 	case "varchar", "character varying":
-		return "VARCHAR(255)"
+		if maxLength.Valid {
+			return fmt.Sprintf("VARCHAR(%d)", maxLength.Int64)
+		}
+		return "VARCHAR"
 	case "text":
 		return "TEXT"
 	case "boolean", "bool":
@@ -583,7 +851,7 @@ func mapDataTypeToSQL(sqlType string) string {
 	case "timestamp", "timestamp without time zone":
 		return "TIMESTAMP"
 	case "timestamptz", "timestamp with time zone":
-		return "TIMESTAMP WITH TIME ZONE"
+		return "TIMESTAMPTZ"
 	case "date":
 		return "DATE"
 	case "decimal", "numeric":
@@ -603,45 +871,135 @@ func mapDataTypeToSQL(sqlType string) string {
 	}
 }
 
-func toPascalCase(s string) string {
-	parts := strings.Split(s, "_")
+// identifierParts splits a table or column name into words at underscores and at any other character
+// a Prisma name can't contain, such as spaces and dashes
+func identifierParts(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r > unicode.MaxASCII || (!unicode.IsLetter(r) && !unicode.IsDigit(r))
+	})
+}
+
+func toPascalCase(s string, generator schema.GeneratorConfig) string {
+	parts := identifierParts(s)
 	for i, part := range parts {
 		parts[i] = strings.Title(part)
 	}
 	result := strings.Join(parts, "")
-	return singularize(result)
+	// Prisma names start with a letter
+	if result == "" || !unicode.IsLetter(rune(result[0])) {
+		result = "Table" + result
+	}
+	return generator.Singularize(result)
 }
 
-func singularize(s string) string {
-	if len(s) == 0 {
-		return s
+// datasourceURLValue writes a datasource URL field value, quoting literal URLs
+func datasourceURLValue(value string) string {
+	if name := schema.DatasourceEnv(value); name != "" {
+		return fmt.Sprintf("env(\"%s\")", name)
 	}
+	return fmt.Sprintf("\"%s\"", value)
+}
 
-	// Handle common plural patterns
-	switch {
-	case strings.HasSuffix(s, "ies"):
-		// categories -> category, companies -> company
-		return s[:len(s)-3] + "y"
-	case strings.HasSuffix(s, "ses"):
-		// addresses -> address, processes -> process
-		return s[:len(s)-2]
-	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
-		// users -> user, wallets -> wallet (but not address -> addres)
-		return s[:len(s)-1]
-	default:
-		return s
+// generateSchemaHeader returns the datasource and generator blocks of a generated schema.prisma,
+// including the datasource url and the configured naming rules and constraint name templates
+func generateSchemaHeader(datasource schema.Datasource, generator schema.GeneratorConfig) string {
+	if datasource.URL == "" {
+		datasource.URL = fmt.Sprintf("env(\"%s\")", schema.DefaultDatabaseURLEnv)
 	}
+	fields := [][2]string{{"provider", `"postgresql"`}, {"url", datasourceURLValue(datasource.URL)}}
+	if datasource.DirectURL != "" {
+		fields = append(fields, [2]string{"directUrl", datasourceURLValue(datasource.DirectURL)})
+	}
+	if datasource.ShadowDatabaseURL != "" {
+		fields = append(fields, [2]string{"shadowDatabaseUrl", datasourceURLValue(datasource.ShadowDatabaseURL)})
+	}
+	width := 0
+	for _, f := range fields {
+		width = max(width, len(f[0]))
+	}
+	var sb strings.Builder
+	sb.WriteString("datasource db {\n")
+	for _, f := range fields {
+		sb.WriteString(fmt.Sprintf("  %-*s = %s\n", width, f[0], f[1]))
+	}
+	sb.WriteString(`}
+
+generator client {
+  provider = "schema-manager"
+`)
+	output := generator.Output
+	if output == "" {
+		output = "./" + schema.DefaultMigrationsDir
+	}
+	sb.WriteString(fmt.Sprintf("  output   = \"%s\"\n", output))
+	if generator.Naming != "" {
+		sb.WriteString(fmt.Sprintf("  naming   = \"%s\"\n", generator.Naming))
+	}
+	if generator.DisablePluralization {
+		sb.WriteString("  pluralize = \"false\"\n")
+	}
+	if len(generator.Irregulars) > 0 {
+		var words []string
+		for _, w := range generator.Irregulars {
+			words = append(words, fmt.Sprintf("\"%s:%s\"", w.Singular, w.Plural))
+		}
+		sb.WriteString("  irregulars = [" + strings.Join(words, ", ") + "]\n")
+	}
+	if len(generator.CastRules) > 0 {
+		var rules []string
+		for _, rule := range generator.CastRules {
+			rules = append(rules, strconv.Quote(rule.Definition))
+		}
+		sb.WriteString("  castRules = [" + strings.Join(rules, ", ") + "]\n")
+	}
+	if generator.IndexNameTemplate != "" {
+		sb.WriteString(fmt.Sprintf("  indexName = \"%s\"\n", generator.IndexNameTemplate))
+	}
+	if generator.UniqueIndexNameTemplate != "" {
+		sb.WriteString(fmt.Sprintf("  uniqueIndexName = \"%s\"\n", generator.UniqueIndexNameTemplate))
+	}
+	if generator.ForeignKeyNameTemplate != "" {
+		sb.WriteString(fmt.Sprintf("  foreignKeyName = \"%s\"\n", generator.ForeignKeyNameTemplate))
+	}
+	if generator.DateTimeType != "" {
+		sb.WriteString(fmt.Sprintf("  dateTimeType = \"%s\"\n", generator.DateTimeType))
+	}
+	if generator.FloatType != "" {
+		sb.WriteString(fmt.Sprintf("  floatType = \"%s\"\n", generator.FloatType))
+	}
+	if generator.StringType != "" {
+		sb.WriteString(fmt.Sprintf("  stringType = \"%s\"\n", generator.StringType))
+	}
+	sb.WriteString("}\n\n")
+	return sb.String()
 }
 
 func toCamelCase(s string) string {
-	parts := strings.Split(s, "_")
-	result := parts[0]
-	for i := 1; i < len(parts); i++ {
-		result += strings.Title(parts[i])
+	parts := identifierParts(s)
+	result := ""
+	for i, part := range parts {
+		if i > 0 {
+			part = strings.Title(part)
+		}
+		result += part
+	}
+	// Prisma names start with a letter
+	if result == "" || !unicode.IsLetter(rune(result[0])) {
+		result = "column" + strings.Title(result)
 	}
 	return result
 }
 
+// fieldMapAttribute returns the @map attribute keeping the column of an introspected field, or "" when
+// the field name already maps to it; unmapped fields are stored in lower case, or in snake_case with
+// naming = "snake_case", so mixed-case and renamed columns need one
+func fieldMapAttribute(fieldName, column string, generator schema.GeneratorConfig) string {
+	if generator.FieldColumnName(fieldName) == column {
+		return ""
+	}
+	return fmt.Sprintf("@map(%s)", strconv.Quote(column))
+}
+
 func writeSchemaFile(filename, content string) error {
 	return os.WriteFile(filename, []byte(content), 0o644)
 }
@@ -651,6 +1009,5 @@ func writeMigrationFile(filename, content string) error {
 }
 
 func createMigrationsDir() error {
-	dir := filepath.Dir("migrations/")
-	return os.MkdirAll(dir, 0o755)
+	return os.MkdirAll(migrationsDir(), 0o755)
 }
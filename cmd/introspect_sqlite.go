@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteTypeRegex splits a declared SQLite column type such as VARCHAR(255) into its name and length
+var sqliteTypeRegex = regexp.MustCompile(`^([A-Za-z ]+?)\s*(?:\(\s*(\d+)\s*(?:,\s*\d+\s*)?\))?$`)
+
+// sqliteDatabasePath returns the database file of a SQLite connection URL, as Prisma writes it
+// (file:./dev.db), or false for other databases
+func sqliteDatabasePath(databaseURL string) (string, bool) {
+	path, ok := strings.CutPrefix(databaseURL, "file:")
+	if !ok {
+		return "", false
+	}
+	path, _, _ = strings.Cut(path, "?")
+	return path, path != ""
+}
+
+// openSQLite opens a SQLite database file read-only; a missing file is an error rather than a new
+// empty database
+func openSQLite(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// introspectSQLite reads the tables of a SQLite database with PRAGMA table_info, index_list and
+// foreign_key_list, mapping their column types to the PostgreSQL types the baseline migration creates
+func introspectSQLite(db *sql.DB) ([]TableInfo, error) {
+	rows, err := db.Query(`
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'table'
+		AND name NOT LIKE 'sqlite_%'
+		AND name NOT IN ('goose_db_version', '_prisma_migrations')
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var tables []TableInfo
+	for _, name := range names {
+		table, err := getSQLiteTable(db, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to introspect table %s: %w", name, err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+func getSQLiteTable(db *sql.DB, tableName string) (TableInfo, error) {
+	table := TableInfo{TableName: tableName}
+
+	// PRAGMA arguments can't be bound as parameters, so the table name is quoted as a string literal
+	quoted := "'" + strings.ReplaceAll(tableName, "'", "''") + "'"
+	rows, err := db.Query("SELECT cid, name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(" + quoted + ")")
+	if err != nil {
+		return table, err
+	}
+	var primaryKeys []string
+	for rows.Next() {
+		var cid, notNull, pk int
+		var col ColumnInfo
+		var declaredType string
+		if err := rows.Scan(&cid, &col.ColumnName, &declaredType, &notNull, &col.DefaultValue, &pk); err != nil {
+			rows.Close()
+			return table, err
+		}
+		col.DataType, col.MaxLength = mapSQLiteType(declaredType)
+		col.IsNullable = notNull == 0
+		col.IsPrimaryKey = pk > 0
+		if col.IsPrimaryKey {
+			primaryKeys = append(primaryKeys, col.ColumnName)
+			table.Constraints = append(table.Constraints, ConstraintInfo{
+				ConstraintName: tableName + "_pkey", ConstraintType: "PRIMARY KEY", ColumnName: col.ColumnName,
+			})
+		}
+		// An INTEGER PRIMARY KEY column is an alias of the rowid, which SQLite fills in on insert
+		col.IsAutoIncrement = pk == 1 && strings.EqualFold(strings.TrimSpace(declaredType), "INTEGER")
+		if col.DefaultValue.Valid && col.DataType == "boolean" {
+			switch col.DefaultValue.String {
+			case "0":
+				col.DefaultValue.String = "false"
+			case "1":
+				col.DefaultValue.String = "true"
+			}
+		}
+		table.Columns = append(table.Columns, col)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return table, err
+	}
+	for i := range table.Columns {
+		if table.Columns[i].IsPrimaryKey && len(primaryKeys) > 1 {
+			table.Columns[i].IsCompositePK = true
+			table.Columns[i].IsAutoIncrement = false
+		}
+	}
+
+	indexes, err := getSQLiteIndexes(db, quoted)
+	if err != nil {
+		return table, err
+	}
+	table.Indexes = indexes
+	columnIndexes := map[string]int{}
+	for _, idx := range indexes {
+		columnIndexes[idx.IndexName]++
+	}
+	for _, idx := range indexes {
+		if !idx.IsUnique || columnIndexes[idx.IndexName] != 1 {
+			continue
+		}
+		table.Constraints = append(table.Constraints, ConstraintInfo{
+			ConstraintName: idx.IndexName, ConstraintType: "UNIQUE", ColumnName: idx.ColumnName,
+		})
+		for i := range table.Columns {
+			if table.Columns[i].ColumnName == idx.ColumnName {
+				table.Columns[i].IsUnique = true
+			}
+		}
+	}
+
+	foreignKeys, err := getSQLiteForeignKeys(db, tableName, quoted)
+	if err != nil {
+		return table, err
+	}
+	table.Constraints = append(table.Constraints, foreignKeys...)
+	return table, nil
+}
+
+// getSQLiteIndexes returns the columns of the indexes of a table, leaving out the primary key
+func getSQLiteIndexes(db *sql.DB, quotedTable string) ([]IndexInfo, error) {
+	rows, err := db.Query("SELECT name, \"unique\", origin FROM pragma_index_list(" + quotedTable + ") ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	type sqliteIndex struct {
+		name   string
+		unique bool
+	}
+	var list []sqliteIndex
+	for rows.Next() {
+		var name, origin string
+		var unique int
+		if err := rows.Scan(&name, &unique, &origin); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if origin != "pk" {
+			list = append(list, sqliteIndex{name, unique == 1})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var indexes []IndexInfo
+	for _, idx := range list {
+		quotedIndex := "'" + strings.ReplaceAll(idx.name, "'", "''") + "'"
+		rows, err := db.Query("SELECT name FROM pragma_index_info(" + quotedIndex + ") ORDER BY seqno")
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var column sql.NullString
+			if err := rows.Scan(&column); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			// Expression indexes have no column name
+			if column.Valid {
+				indexes = append(indexes, IndexInfo{IndexName: idx.name, ColumnName: column.String, IsUnique: idx.unique})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+// getSQLiteForeignKeys returns the foreign key constraints of a table, named the way PostgreSQL
+// names them since SQLite keeps no constraint names
+func getSQLiteForeignKeys(db *sql.DB, tableName, quotedTable string) ([]ConstraintInfo, error) {
+	rows, err := db.Query("SELECT \"from\" FROM pragma_foreign_key_list(" + quotedTable + ") ORDER BY id, seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []ConstraintInfo
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, ConstraintInfo{
+			ConstraintName: tableName + "_" + column + "_fkey", ConstraintType: "FOREIGN KEY", ColumnName: column,
+		})
+	}
+	return constraints, rows.Err()
+}
+
+// mapSQLiteType maps a declared SQLite column type to the PostgreSQL type name introspect writes
+// schema.prisma from, following SQLite's type affinity rules for names it doesn't know
+func mapSQLiteType(declared string) (string, sql.NullInt64) {
+	var length sql.NullInt64
+	name := strings.ToUpper(strings.TrimSpace(declared))
+	if matches := sqliteTypeRegex.FindStringSubmatch(name); matches != nil {
+		name = matches[1]
+		if n, err := strconv.ParseInt(matches[2], 10, 64); err == nil {
+			length = sql.NullInt64{Int64: n, Valid: true}
+		}
+	}
+
+	switch {
+	case name == "BIGINT" || name == "INT8" || name == "UNSIGNED BIG INT":
+		return "bigint", length
+	case strings.Contains(name, "INT"):
+		return "integer", length
+	case strings.Contains(name, "CHAR") && length.Valid:
+		return "character varying", length
+	case strings.Contains(name, "CHAR"), strings.Contains(name, "CLOB"), strings.Contains(name, "TEXT"), name == "":
+		return "text", sql.NullInt64{}
+	case strings.Contains(name, "BOOL"):
+		return "boolean", length
+	case name == "DATE":
+		return "date", length
+	case strings.Contains(name, "DATETIME"), strings.Contains(name, "TIMESTAMP"):
+		return "timestamp", length
+	case strings.Contains(name, "REAL"), strings.Contains(name, "FLOA"), strings.Contains(name, "DOUB"):
+		return "double precision", length
+	case strings.Contains(name, "JSON"):
+		return "jsonb", length
+	case strings.Contains(name, "UUID"):
+		return "uuid", length
+	case strings.Contains(name, "BLOB"):
+		return "bytea", sql.NullInt64{}
+	}
+	// DECIMAL, NUMERIC and other names have numeric affinity
+	return "numeric", sql.NullInt64{}
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+// transientErrorCodes are the SQLSTATEs after which a migration is retried: serialization failures,
+// deadlocks and lock waits that ran into lock_timeout
+var transientErrorCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+}
+
+// maxRetryWait caps the exponential wait between two attempts of a migration
+const maxRetryWait = 30 * time.Second
+
+// applyLimits bounds how long applying migrations may take, so a DDL statement blocked behind a
+// long-running transaction fails (and is retried) instead of hanging the deploy
+type applyLimits struct {
+	StatementTimeout time.Duration // Server-side statement_timeout of every statement
+	LockTimeout      time.Duration // Server-side lock_timeout; exceeding it is a transient error
+	Timeout          time.Duration // Deadline of the whole run
+	Retries          int           // Attempts after the first one when a migration fails with a transient error
+}
+
+// applyLimitFlags are the flags of the commands that apply migrations; they override the generator block
+func applyLimitFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.DurationFlag{Name: "statement-timeout", Usage: "Cancel a statement running longer than this, e.g. 5m"},
+		&cli.DurationFlag{Name: "lock-timeout", Usage: "Fail a statement waiting longer than this for a lock, e.g. 10s"},
+		&cli.DurationFlag{Name: "timeout", Usage: "Give up applying migrations after this long, e.g. 30m"},
+		&cli.IntFlag{Name: "retries", Usage: "Retry a migration this many times after a lock timeout, deadlock or serialization failure"},
+	}
+}
+
+// generatorApplyLimits returns the limits set in the generator block
+func generatorApplyLimits(generator schema.GeneratorConfig) applyLimits {
+	return applyLimits{
+		StatementTimeout: generator.StatementTimeout,
+		LockTimeout:      generator.LockTimeout,
+		Timeout:          generator.ApplyTimeout,
+		Retries:          generator.ApplyRetries,
+	}
+}
+
+// applyLimitsFor returns the limits of the generator block, overridden by the flags set on the command line
+func applyLimitsFor(generator schema.GeneratorConfig, c *cli.Context) applyLimits {
+	limits := generatorApplyLimits(generator)
+	if c.IsSet("statement-timeout") {
+		limits.StatementTimeout = c.Duration("statement-timeout")
+	}
+	if c.IsSet("lock-timeout") {
+		limits.LockTimeout = c.Duration("lock-timeout")
+	}
+	if c.IsSet("timeout") {
+		limits.Timeout = c.Duration("timeout")
+	}
+	if c.IsSet("retries") {
+		limits.Retries = c.Int("retries")
+	}
+	return limits
+}
+
+// context returns a context carrying the deadline of the run
+func (l applyLimits) context() (context.Context, context.CancelFunc) {
+	if l.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), l.Timeout)
+}
+
+// contextExecer is a transaction or a single connection statements are run on
+type contextExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// set applies the statement and lock timeouts to a transaction with SET LOCAL, or with local unset to
+// the session of a connection, which resetSession restores
+func (l applyLimits) set(ctx context.Context, db contextExecer, local bool) error {
+	scope := "SET "
+	if local {
+		scope = "SET LOCAL "
+	}
+	if l.StatementTimeout > 0 {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("%sstatement_timeout = %d", scope, l.StatementTimeout.Milliseconds())); err != nil {
+			return err
+		}
+	}
+	if l.LockTimeout > 0 {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("%slock_timeout = %d", scope, l.LockTimeout.Milliseconds())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetSession undoes set on a connection before it goes back to the pool
+func (l applyLimits) resetSession(db contextExecer) {
+	if l.StatementTimeout > 0 {
+		db.ExecContext(context.Background(), "RESET statement_timeout")
+	}
+	if l.LockTimeout > 0 {
+		db.ExecContext(context.Background(), "RESET lock_timeout")
+	}
+}
+
+// retry runs attempt until it succeeds, fails with an error that isn't transient or the retries are used
+// up, waiting 1s, 2s, 4s... between attempts while the deadline of ctx allows
+func (l applyLimits) retry(ctx context.Context, what string, attempt func() error) error {
+	wait := time.Second
+	for i := 0; ; i++ {
+		err := attempt()
+		if err == nil || i >= l.Retries || !isTransientError(err) {
+			return err
+		}
+		fmt.Printf("⚠️  %s: %v - retrying in %s (%d/%d)\n", what, err, wait, i+1, l.Retries)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		// Doubling the clamped wait rather than shifting by the attempt can't overflow with many retries
+		wait = min(2*wait, maxRetryWait)
+	}
+}
+
+// isTransientError reports whether err is a PostgreSQL error that may not happen again on a retry
+func isTransientError(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && transientErrorCodes[pqErr.Code]
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+// Severity levels of lint findings, named as in SARIF
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+	lintNote    = "note"
+)
+
+// lintRule is a check run on migration files
+type lintRule struct {
+	ID          string
+	Level       string
+	Description string
+}
+
+var (
+	ruleDataLoss         = &lintRule{"data-loss", lintWarning, "The up section drops tables or columns or deletes rows"}
+	ruleNotNullNoDefault = &lintRule{"not-null-without-default", lintError,
+		"A NOT NULL column without a default is added to an existing table; this fails when the table has rows"}
+	ruleManualIntervention = &lintRule{"manual-intervention", lintError, "The migration contains a conversion that has to be written by hand"}
+	ruleRiskyConversion    = &lintRule{"risky-conversion", lintWarning, "A column type change may fail or lose data for some values"}
+	ruleLongLock           = &lintRule{"long-lock", lintWarning, "A statement holds a table lock while the table is rewritten, scanned or indexed"}
+	ruleMissingDown        = &lintRule{"missing-down", lintNote, "The migration has no down section and cannot be rolled back"}
+)
+
+// lintRules lists every rule, in the order they are documented and reported to SARIF consumers
+var lintRules = []*lintRule{
+	ruleDataLoss, ruleNotNullNoDefault, ruleManualIntervention, ruleRiskyConversion, ruleLongLock, ruleMissingDown,
+}
+
+// lintFinding is a rule violation at a line of a migration file
+type lintFinding struct {
+	Rule    *lintRule
+	File    string // Path relative to the project root
+	Line    int
+	Message string
+}
+
+var (
+	alterTableRegex = regexp.MustCompile(`(?is)^ALTER TABLE\s+(\S+)\s+(.*)$`)
+	addColumnRegex  = regexp.MustCompile(`(?is)^ADD COLUMN\s+(?:IF NOT EXISTS\s+)?(\S+)\s+(.*)$`)
+)
+
+func LintCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "lint",
+		Usage:     "Check migration files for operations that lose data, fail or lock tables",
+		ArgsUsage: "[migration files or schema files]",
+		Description: "Lint the given migration files, or every file in migrations, and exit with status 1 when " +
+			"an error is found. .prisma files, and schema.prisma by default, are checked against the schema " +
+			"rules of their generator block. With --format sarif the findings are written as SARIF for GitHub " +
+			"code scanning and other platforms to annotate the files in pull requests",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "Output format: text or sarif", Value: "text"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Write the report to this file instead of stdout"},
+		},
+		Action: func(c *cli.Context) error {
+			files := c.Args().Slice()
+			if len(files) == 0 {
+				names, err := listMigrationFiles(migrationsDir())
+				if err != nil {
+					return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+				}
+				for _, name := range names {
+					files = append(files, filepath.Join(migrationsDir(), name))
+				}
+				if _, err := os.Stat("schema.prisma"); err == nil {
+					files = append(files, "schema.prisma")
+				}
+			}
+
+			var findings []*lintFinding
+			for _, f := range files {
+				if filepath.Ext(f) == ".prisma" {
+					schemaFindings, err := lintSchemaRules(f)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					findings = append(findings, schemaFindings...)
+					continue
+				}
+				content, err := os.ReadFile(f)
+				if err != nil {
+					return cli.Exit("Failed to read "+f+": "+err.Error(), 1)
+				}
+				findings = append(findings, lintMigration(filepath.ToSlash(f), string(content))...)
+			}
+
+			var report string
+			switch c.String("format") {
+			case "text":
+				report = formatLintText(findings, len(files))
+			case "sarif":
+				sarif, err := formatSARIF(findings)
+				if err != nil {
+					return cli.Exit("Failed to write SARIF: "+err.Error(), 1)
+				}
+				report = sarif
+			default:
+				return cli.Exit("--format must be text or sarif", 1)
+			}
+			if output := c.String("output"); output != "" {
+				if err := os.WriteFile(output, []byte(report), 0o644); err != nil {
+					return cli.Exit("Failed to write "+output+": "+err.Error(), 1)
+				}
+			} else {
+				fmt.Print(report)
+			}
+
+			for _, f := range findings {
+				if f.Rule.Level == lintError {
+					return cli.Exit("Lint found errors", 1)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// lintMigration checks the content of one migration file
+func lintMigration(file, content string) []*lintFinding {
+	var findings []*lintFinding
+	add := func(rule *lintRule, line int, message string) {
+		findings = append(findings, &lintFinding{Rule: rule, File: file, Line: line, Message: message})
+	}
+
+	migration := schema.ParseGooseMigration(content)
+	upStart := strings.Index(content, "-- +goose Up")
+	if upStart < 0 {
+		upStart = 0
+	}
+	lineOf := lineFinder(content, upStart)
+
+	// Comments written by generate
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "-- ERROR: "):
+			add(ruleManualIntervention, i+1, strings.TrimPrefix(trimmed, "-- ERROR: "))
+		case strings.HasPrefix(trimmed, "-- WARNING: RISKY CONVERSION: "):
+			message := strings.TrimPrefix(trimmed, "-- WARNING: ")
+			message, _, _ = strings.Cut(message, " | ")
+			add(ruleRiskyConversion, i+1, message)
+		}
+	}
+
+	created := map[string]bool{}
+	for _, stmt := range migration.Up {
+		if parsed, _ := schema.ParseSQLStatement(stmt); parsed != nil {
+			if ct, ok := parsed.(*schema.CreateTableStatement); ok {
+				created[ct.TableName] = true
+			}
+		}
+	}
+
+	for _, stmt := range migration.Up {
+		line := lineOf(stmt)
+		flat := strings.Join(strings.Fields(stmt), " ")
+		if dataLossRegex.MatchString(stmt) {
+			add(ruleDataLoss, line, "Deletes data: "+flat)
+		}
+		if alter := alterTableRegex.FindStringSubmatch(stmt); alter != nil {
+			// generate coalesces the column changes of a table into the actions of one statement
+			for _, action := range schema.SplitAlterActions(alter[2]) {
+				matches := addColumnRegex.FindStringSubmatch(action)
+				if matches == nil {
+					continue
+				}
+				definition := strings.ToUpper(matches[2])
+				if strings.Contains(definition, "NOT NULL") && !strings.Contains(definition, "DEFAULT") &&
+					!strings.Contains(definition, "GENERATED") {
+					add(ruleNotNullNoDefault, line, fmt.Sprintf("Column %s.%s is NOT NULL without a default",
+						strings.Trim(alter[1], `"`), strings.Trim(matches[1], `"`)))
+				}
+			}
+		}
+		for _, lock := range schema.AnalyzeLocks(stmt) {
+			if lock.Long && !created[lock.Table] {
+				add(ruleLongLock, line, fmt.Sprintf("%s lock on %s: %s", lock.Lock, lock.Table, lock.Reason))
+			}
+		}
+	}
+
+	if len(migration.Up) > 0 && len(migration.Down) == 0 {
+		add(ruleMissingDown, 1, "No down migration")
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return findings
+}
+
+// lintSchemaRules reports the schema rule violations of a schema file, each rule as its own lint rule
+func lintSchemaRules(path string) ([]*lintFinding, error) {
+	s, err := (&schema.PrismaFileSource{Path: path}).LoadSchema(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	var findings []*lintFinding
+	rules := map[string]*lintRule{}
+	for _, e := range schema.CheckSchemaRules(s, filepath.ToSlash(path)) {
+		level := lintError
+		if e.Warning {
+			level = lintWarning
+		}
+		rule, ok := rules[e.Rule+":"+level]
+		if !ok {
+			rule = &lintRule{ID: e.Rule, Level: level, Description: schema.SchemaRuleDescription(e.Rule)}
+			rules[e.Rule+":"+level] = rule
+		}
+		line := e.Line
+		if line == 0 {
+			line = 1
+		}
+		findings = append(findings, &lintFinding{Rule: rule, File: e.File, Line: line, Message: e.Message})
+	}
+	return findings, nil
+}
+
+// lineFinder returns a function locating statements in content, searching forward from offset so
+// repeated statements map to successive lines
+func lineFinder(content string, offset int) func(stmt string) int {
+	return func(stmt string) int {
+		first, _, _ := strings.Cut(strings.TrimSpace(stmt), "\n")
+		idx := strings.Index(content[offset:], first)
+		if idx < 0 {
+			return 1
+		}
+		offset += idx
+		line := strings.Count(content[:offset], "\n") + 1
+		offset += len(first)
+		return line
+	}
+}
+
+func formatLintText(findings []*lintFinding, files int) string {
+	var sb strings.Builder
+	icons := map[string]string{lintError: "❌", lintWarning: "⚠️ ", lintNote: "ℹ️ "}
+	counts := map[string]int{}
+	for _, f := range findings {
+		fmt.Fprintf(&sb, "%s %s:%d [%s] %s\n", icons[f.Rule.Level], f.File, f.Line, f.Rule.ID, f.Message)
+		counts[f.Rule.Level]++
+	}
+	fmt.Fprintf(&sb, "\n%d file(s) linted: %d error(s), %d warning(s), %d note(s)\n",
+		files, counts[lintError], counts[lintWarning], counts[lintNote])
+	return sb.String()
+}
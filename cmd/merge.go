@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func MergeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "merge",
+		Usage: "Reconcile local migrations after a git merge brought in migrations from another branch",
+		Description: "Re-stamp the migrations of this branch after the merged ones, replay the combined history " +
+			"to find conflicting statements, and regenerate the local migration when the schema it was " +
+			"generated against changed. Run it once schema.prisma merges cleanly",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "The branch or commit that was merged in (default: MERGE_HEAD, or HEAD^2 after the merge commit)",
+			},
+			&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "Don't ask before rewriting the local migration"},
+		},
+		Action: func(c *cli.Context) error {
+			return runMerge(c.String("from"), c.Bool("yes"))
+		},
+	}
+}
+
+func runMerge(from string, yes bool) error {
+	content, err := os.ReadFile("schema.prisma")
+	if err != nil {
+		return cli.Exit("Failed to read schema.prisma: "+err.Error(), 1)
+	}
+	if strings.Contains(string(content), "\n<<<<<<< ") {
+		return cli.Exit("schema.prisma still has merge conflict markers - resolve them first", 1)
+	}
+
+	local, incoming, err := localMigrations(from)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if len(local) == 0 {
+		fmt.Println("No migrations of this branch to reconcile.")
+	} else if err := checkLocalUnapplied(local); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	// Local migrations must run after the merged ones
+	renames := renumberMigrations(incoming, local, time.Now())
+	dir := migrationsDir()
+	for i, f := range local {
+		newName, ok := renames[f]
+		if !ok {
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, f), filepath.Join(dir, newName)); err != nil {
+			return cli.Exit("Failed to rename "+f+": "+err.Error(), 1)
+		}
+		fmt.Printf("Renamed %s → %s\n", f, newName)
+		local[i] = newName
+	}
+
+	switch len(local) {
+	case 0:
+	case 1:
+		if err := regenerateLocalMigration(local[0], yes); err != nil {
+			return err
+		}
+	default:
+		fmt.Printf("ℹ️  This branch has %d migrations; only a single one is regenerated. Review them against "+
+			"the merged migrations, or squash them and run merge again\n", len(local))
+	}
+
+	conflicts, err := schema.ValidateMigrationHistory(migrationsDir())
+	if err != nil {
+		return cli.Exit("Failed to replay migrations: "+err.Error(), 1)
+	}
+	if len(conflicts) > 0 {
+		fmt.Println("\n❌ The combined migration history has conflicts:")
+		for _, c := range conflicts {
+			fmt.Printf("  • %s\n", c)
+		}
+		return cli.Exit("Fix the conflicting migrations and run 'schema-manager merge' again", 1)
+	}
+	fmt.Println("✅ The combined migration history replays cleanly.")
+	return nil
+}
+
+// localMigrations splits the migrations on disk into those added on this branch and those that exist on
+// the merged branch
+func localMigrations(from string) (local, incoming []string, err error) {
+	if from == "" {
+		for _, ref := range []string{"MERGE_HEAD", "HEAD^2"} {
+			if _, err := gitOutput("rev-parse", "--verify", "--quiet", ref); err == nil {
+				from = ref
+				break
+			}
+		}
+		if from == "" {
+			return nil, nil, fmt.Errorf("no merge in progress and HEAD is not a merge commit - pass --from <branch>")
+		}
+	}
+	tree, err := gitOutput("ls-tree", "--name-only", from, migrationsDir()+"/")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list migrations of %s: %w", from, err)
+	}
+	merged := map[string]bool{}
+	for _, path := range strings.Split(tree, "\n") {
+		merged[filepath.Base(path)] = true
+	}
+
+	files, err := listMigrationFiles(migrationsDir())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for _, f := range files {
+		if merged[f] {
+			incoming = append(incoming, f)
+		} else {
+			local = append(local, f)
+		}
+	}
+	return local, incoming, nil
+}
+
+// checkLocalUnapplied refuses to touch local migrations the database at DATABASE_URL has applied, since
+// renaming or rewriting them would make it re-run or skip changes
+func checkLocalUnapplied(local []string) error {
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return nil
+	}
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+	versions, err := appliedVersions(db, "goose_db_version")
+	if err != nil {
+		// No version table: nothing has been migrated yet
+		return nil
+	}
+	for _, f := range local {
+		if versions[migrationVersion(f)] {
+			return fmt.Errorf("%s is applied on the database - roll it back with 'schema-manager rollback' first", f)
+		}
+	}
+	return nil
+}
+
+// regenerateLocalMigration regenerates the migration of this branch from the merged history and
+// schema.prisma, and rewrites it when it changed. The header keeps the original ticket.
+func regenerateLocalMigration(file string, yes bool) error {
+	path := filepath.Join(migrationsDir(), file)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cli.Exit("Failed to read "+file+": "+err.Error(), 1)
+	}
+
+	ctx := context.Background()
+	target, err := (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(ctx)
+	if err != nil {
+		return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
+	}
+	baseline, err := (&schema.MigrationsFolderSource{Dir: migrationsDir(), Skip: []string{file}}).LoadSchema(ctx)
+	if err != nil {
+		return cli.Exit("Failed to parse merged migrations: "+err.Error(), 1)
+	}
+	diff := schema.DiffSchemas(baseline, target)
+	if !hasSchemaChanges(diff) {
+		fmt.Printf("⚠️  The merged migrations already contain every change of %s - delete it\n", file)
+		return nil
+	}
+	up := schema.GenerateMigrationSQL(diff)
+	down := schema.GenerateDownMigrationSQL(diff)
+
+	existing := schema.ParseGooseMigration(string(content))
+	regenerated := schema.ParseGooseMigration(schema.FormatMigration(up, down))
+	if strings.TrimSpace(existing.UpSQL) == strings.TrimSpace(regenerated.UpSQL) {
+		fmt.Printf("The baseline of %s is unchanged.\n", file)
+		return nil
+	}
+
+	fmt.Printf("The merged migrations changed the baseline of %s; regenerated up section:\n\n%s\n\n", file, up)
+	if !yes {
+		fmt.Print("Rewrite the migration? Manual edits to it are lost. (y/N): ")
+		response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return cli.Exit("Failed to read user input: "+err.Error(), 1)
+		}
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "y" && response != "yes" {
+			fmt.Println("Left", file, "unchanged.")
+			return nil
+		}
+	}
+	header := migrationHeader(schema.ParseMigrationHeader(string(content)).Ticket)
+	if err := writeMigration(path, header, up, down, false); err != nil {
+		return cli.Exit("Failed to write "+file+": "+err.Error(), 1)
+	}
+	return nil
+}
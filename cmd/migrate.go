@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phathdt/schema-manager/internal/logger"
+	"github.com/phathdt/schema-manager/pkg/schema"
+)
+
+// execer is what the migration helpers need from a connection; *sql.DB and *sql.Tx both provide it
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// ensureVersionTable creates the goose version table the way goose does on its first run
+func ensureVersionTable(db execer, versionTable string) error {
+	var exists bool
+	if err := db.QueryRow("SELECT to_regclass($1) IS NOT NULL", versionTable).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	if _, err := db.Exec(fmt.Sprintf(`CREATE TABLE %s (
+  id serial NOT NULL PRIMARY KEY,
+  version_id bigint NOT NULL,
+  is_applied boolean NOT NULL,
+  tstamp timestamp NULL DEFAULT now()
+)`, versionTable)); err != nil {
+		return err
+	}
+	_, err := db.Exec("INSERT INTO " + versionTable + " (version_id, is_applied) VALUES (0, true)")
+	return err
+}
+
+// appliedVersions returns the migration versions the goose version table records as applied. As in
+// goose, the latest row of a version decides.
+func appliedVersions(db execer, versionTable string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT version_id, is_applied FROM " + versionTable + " ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	applied := map[string]bool{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		if err := rows.Scan(&version, &isApplied); err != nil {
+			return nil, err
+		}
+		key := strconv.FormatInt(version, 10)
+		if !seen[key] {
+			seen[key] = true
+			applied[key] = isApplied
+		}
+	}
+	return applied, rows.Err()
+}
+
+// migrationStates splits the SQL migrations of a directory into those the goose version table records
+// as applied and those still pending, each in version order
+func migrationStates(db execer, dir, versionTable string) (applied, pending []string, err error) {
+	if err := ensureVersionTable(db, versionTable); err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s: %w", versionTable, err)
+	}
+	versions, err := appliedVersions(db, versionTable)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", versionTable, err)
+	}
+	files, err := listMigrationFiles(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, f := range files {
+		if versions[migrationVersion(f)] {
+			applied = append(applied, f)
+		} else {
+			pending = append(pending, f)
+		}
+	}
+	return applied, pending, nil
+}
+
+// applyMigration runs the up section of a migration and records its version like `goose up`. The
+// statements and the version row share one transaction unless the file is marked NO TRANSACTION.
+// Tables the migration drops data from are backed up first according to the backup policy. Within
+// the limits, a transaction failing with a transient error is retried as a whole, and a statement of
+// a NO TRANSACTION migration on its own.
+func applyMigration(ctx context.Context, db *sql.DB, dir, file, versionTable string, backup backupPolicy, limits applyLimits) error {
+	content, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return err
+	}
+	version, err := strconv.ParseInt(migrationVersion(file), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s has no numeric version", file)
+	}
+	migration := schema.ParseGooseMigration(string(content))
+	if err := backupBeforeMigration(db, backup, migration, file); err != nil {
+		return fmt.Errorf("backup before %s: %w", file, err)
+	}
+	record := "INSERT INTO " + versionTable + " (version_id, is_applied) VALUES ($1, true)"
+
+	if migration.NoTransaction {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		defer limits.resetSession(conn)
+		if err := limits.set(ctx, conn, false); err != nil {
+			return err
+		}
+		for _, stmt := range migration.Up {
+			err := limits.retry(ctx, file, func() error {
+				return execStatement(ctx, conn, stmt)
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		_, err = conn.ExecContext(ctx, record, version)
+		return err
+	}
+
+	return limits.retry(ctx, file, func() error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := limits.set(ctx, tx, true); err != nil {
+			return err
+		}
+		for _, stmt := range migration.Up {
+			if err := execStatement(ctx, tx, stmt); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, record, version); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// applyPendingMigrations applies every pending migration of a directory in order and returns the
+// files it applied, giving up when the deadline of the limits passes. Goose Go migrations are not
+// run; they are left for goose.
+func applyPendingMigrations(db *sql.DB, dir, versionTable string, backup backupPolicy, limits applyLimits) ([]string, error) {
+	done, pending, err := migrationStates(db, dir, versionTable)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMigrationOrder(done, pending); err != nil {
+		return nil, err
+	}
+	if goFiles, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(goFiles) > 0 {
+		fmt.Println("⚠️  Go migrations are not applied here - run 'goose up' for them")
+	}
+
+	ctx, cancel := limits.context()
+	defer cancel()
+	var applied []string
+	var timings []time.Duration
+	defer func() { printMigrationTimings(applied, timings) }()
+	for _, f := range pending {
+		start := time.Now()
+		if err := applyMigration(ctx, db, dir, f, versionTable, backup, limits); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return applied, fmt.Errorf("%s: deadline of %s exceeded: %w", f, limits.Timeout, err)
+			}
+			return applied, err
+		}
+		elapsed := time.Since(start)
+		fmt.Printf("Applied migration: %s (%s)\n", f, formatElapsed(elapsed))
+		applied = append(applied, f)
+		timings = append(timings, elapsed)
+	}
+	return applied, nil
+}
+
+// execStatement runs a migration statement and logs it with its duration and affected rows: a one-line
+// summary at info level, the complete SQL at debug level (--verbose)
+func execStatement(ctx context.Context, db contextExecer, stmt string) error {
+	start := time.Now()
+	result, err := db.ExecContext(ctx, stmt)
+	elapsed := formatElapsed(time.Since(start))
+	logger.Debug("SQL:\n%s", stmt)
+	if err != nil {
+		logger.Info("%s - failed after %s", statementSummary(stmt), elapsed)
+		return err
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows > 0 {
+		logger.Info("%s - %s, %d row(s)", statementSummary(stmt), elapsed, rows)
+	} else {
+		logger.Info("%s - %s", statementSummary(stmt), elapsed)
+	}
+	return nil
+}
+
+// statementSummary returns the start of a statement on one line, short enough for a log line
+func statementSummary(stmt string) string {
+	const maxLen = 80
+	summary := strings.Join(strings.Fields(stmt), " ")
+	if runes := []rune(summary); len(runes) > maxLen {
+		summary = string(runes[:maxLen-3]) + "..."
+	}
+	return summary
+}
+
+// printMigrationTimings prints how long each applied migration took, slowest first, when more than
+// one was applied
+func printMigrationTimings(applied []string, timings []time.Duration) {
+	if len(applied) < 2 {
+		return
+	}
+	order := make([]int, len(applied))
+	var total time.Duration
+	for i := range order {
+		order[i] = i
+		total += timings[i]
+	}
+	sort.SliceStable(order, func(a, b int) bool { return timings[order[a]] > timings[order[b]] })
+
+	fmt.Println("\nMigration timings:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, i := range order {
+		fmt.Fprintf(w, "  %s\t%s\n", applied[i], formatElapsed(timings[i]))
+	}
+	fmt.Fprintf(w, "  total\t%s\n", formatElapsed(total))
+	w.Flush()
+}
+
+// formatElapsed rounds a duration for display: milliseconds below a minute, seconds above
+func formatElapsed(d time.Duration) string {
+	if d >= time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// checkMigrationOrder reports migrations sharing a version, and pending migrations that predate the
+// latest applied one and so would never run after it
+func checkMigrationOrder(done, pending []string) error {
+	if duplicates := duplicateVersions(append(append([]string{}, done...), pending...)); len(duplicates) > 0 {
+		return fmt.Errorf("several migrations share version %s - run 'schema-manager renumber'",
+			strings.Join(duplicates, ", "))
+	}
+	if outOfOrder := outOfOrderMigrations(done, pending); len(outOfOrder) > 0 {
+		return fmt.Errorf("%s predate the latest applied migration %s, probably from a merged branch - "+
+			"run 'schema-manager renumber' to re-stamp them", strings.Join(outOfOrder, ", "), done[len(done)-1])
+	}
+	return nil
+}
+
+// rollbackMigration runs the down section of a migration and removes its version row like
+// `goose down`, in one transaction unless the file is marked NO TRANSACTION
+func rollbackMigration(db *sql.DB, dir, file, versionTable string) error {
+	content, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return err
+	}
+	version, err := strconv.ParseInt(migrationVersion(file), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s has no numeric version", file)
+	}
+	migration := schema.ParseGooseMigration(string(content))
+	record := "DELETE FROM " + versionTable + " WHERE version_id = $1"
+
+	if migration.NoTransaction {
+		for _, stmt := range migration.Down {
+			if _, err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+		_, err := db.Exec(record, version)
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, stmt := range migration.Down {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	if _, err := tx.Exec(record, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func PartitionsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "partitions",
+		Usage: "Generate a migration creating upcoming partitions of @@partition models",
+		Description: "For every model declared with @@partition, create the partitions of the current period " +
+			"and the premake upcoming ones that migrations don't create yet. Partitions that ended before the " +
+			"retain period are detached with --prune detach or dropped with --prune drop. Run it on a " +
+			"schedule, e.g. monthly in CI, and apply the migration like any other",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Usage: "Migration name", Value: "partitions"},
+			&cli.StringFlag{Name: "prune", Usage: "Remove partitions older than retain: detach or drop"},
+			&cli.StringFlag{Name: "date", Usage: "Plan partitions as of this date (YYYY-MM-DD) instead of today"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Print the migration instead of writing it"},
+		},
+		Action: func(c *cli.Context) error {
+			prune := c.String("prune")
+			if prune != "" && prune != "detach" && prune != "drop" {
+				return cli.Exit("--prune must be detach or drop", 1)
+			}
+			now := time.Now()
+			if date := c.String("date"); date != "" {
+				t, err := time.Parse("2006-01-02", date)
+				if err != nil {
+					return cli.Exit("--date must look like 2026-10-01", 1)
+				}
+				now = t
+			}
+			return runPartitions(c.String("name"), prune, now, c.Bool("dry-run"))
+		},
+	}
+}
+
+func runPartitions(name, prune string, now time.Time, dryRun bool) error {
+	ctx := context.Background()
+	target, err := (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(ctx)
+	if err != nil {
+		return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
+	}
+	current := &schema.Schema{}
+	if files, _ := listMigrationFiles(migrationsDir()); len(files) > 0 {
+		if current, err = (&schema.MigrationsFolderSource{Dir: migrationsDir()}).LoadSchema(ctx); err != nil {
+			return cli.Exit("Failed to parse current schema from migrations: "+err.Error(), 1)
+		}
+	}
+
+	var plans []*schema.PartitionPlan
+	for _, m := range target.Models {
+		if m.Partitioning == nil {
+			continue
+		}
+		migrated := modelByTableName(current, m.TableName)
+		if migrated == nil || migrated.Partitioning == nil {
+			fmt.Printf("⚠️  %s is not created as a partitioned table by the migrations yet - run generate first\n",
+				m.TableName)
+			continue
+		}
+		plan := schema.PlanPartitions(m, current.Partitions, now)
+		if prune == "" {
+			plan.Prune = nil
+		}
+		for _, part := range plan.Create {
+			fmt.Printf("  + %s [%s, %s)\n", part.Name, part.From, part.To)
+		}
+		for _, part := range plan.Prune {
+			fmt.Printf("  - %s [%s, %s) (%s)\n", part.Name, part.From, part.To, prune)
+		}
+		if len(plan.Create) > 0 || len(plan.Prune) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	if len(plans) == 0 {
+		fmt.Println("No partitions to create or prune.")
+		return nil
+	}
+
+	up, down := schema.GeneratePartitionMigrationSQL(plans, prune == "drop")
+	existing, _ := listMigrationFiles(migrationsDir())
+	filename := filepath.Join(migrationsDir(), nextMigrationVersion(existing, time.Now())+"_"+name+".sql")
+	if err := writeMigration(filename, migrationHeader(""), up, down, dryRun); err != nil {
+		return cli.Exit("Failed to write migration: "+err.Error(), 1)
+	}
+	if prune == "drop" && !dryRun {
+		fmt.Fprintln(os.Stderr, "⚠️  The migration drops partitions with their rows; rolling it back recreates them empty")
+	}
+	return nil
+}
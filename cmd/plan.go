@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func PlanCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "plan",
+		Usage: "Show the migration generate would create, as text or a Markdown report for PR comments",
+		Description: "Diff schema.prisma against the migrations and print the change summary, risks and SQL " +
+			"without writing a file. --format markdown produces a collapsible, table-formatted report for CI " +
+			"bots to post as a pull request comment",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "format", Usage: "Output format: text or markdown", Value: "text"},
+			&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "Write the plan to this file instead of stdout"},
+		},
+		Action: func(c *cli.Context) error {
+			diff, err := pendingSchemaDiff("schema.prisma", migrationsDir())
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			// Row counts flag index builds on large tables
+			estimateAffectedRows(diff)
+
+			var report string
+			switch c.String("format") {
+			case "text":
+				report = formatPlanText(diff)
+			case "markdown":
+				report = formatPlanMarkdown(diff)
+			default:
+				return cli.Exit("--format must be text or markdown", 1)
+			}
+			if output := c.String("output"); output != "" {
+				if err := os.WriteFile(output, []byte(report), 0o644); err != nil {
+					return cli.Exit("Failed to write "+output+": "+err.Error(), 1)
+				}
+				fmt.Println("Wrote plan:", output)
+				return nil
+			}
+			fmt.Print(report)
+			return nil
+		},
+	}
+}
+
+func formatPlanText(diff *schema.SchemaDiff) string {
+	if !hasSchemaChanges(diff) {
+		return "No changes detected.\n"
+	}
+	var sb strings.Builder
+	sb.WriteString("Summary:\n")
+	for _, line := range summarizeSchemaDiff(diff) {
+		sb.WriteString("  " + line.String() + "\n")
+	}
+	if risks := analyzeRiskyOperations(diff); len(risks) > 0 {
+		sb.WriteString("\nCannot be automatically rolled back:\n")
+		for _, risk := range risks {
+			sb.WriteString("  • " + risk + "\n")
+		}
+	}
+	up := schema.GenerateMigrationSQL(diff)
+	if locks := diff.LargeTableIndexes(up); len(locks) > 0 {
+		sb.WriteString("\nIndexes on large tables:\n")
+		for _, lock := range locks {
+			sb.WriteString("  • " + largeTableIndexWarning(lock) + diff.RowImpact(lock.Table) + "\n")
+		}
+	}
+	sb.WriteString("\n" + up + "\n")
+	return sb.String()
+}
+
+// Icons of the Markdown plan
+var (
+	planKindIcons = map[string]string{"+": "➕", "-": "➖", "~": "✏️"}
+	planRiskIcons = map[string]string{riskRisky: "⚠️ risky", riskDataLoss: "🔥 data loss"}
+)
+
+func formatPlanMarkdown(diff *schema.SchemaDiff) string {
+	var sb strings.Builder
+	sb.WriteString("## 🗄️ Schema migration plan\n\n")
+	if !hasSchemaChanges(diff) {
+		sb.WriteString("✅ schema.prisma has no changes without a migration.\n")
+		return sb.String()
+	}
+
+	changes := summarizeSchemaDiff(diff)
+	risky := 0
+	for _, change := range changes {
+		if change.Risk != "" {
+			risky++
+		}
+	}
+	fmt.Fprintf(&sb, "**%d change(s)**", len(changes))
+	if risky > 0 {
+		fmt.Fprintf(&sb, ", ⚠️ **%d need attention**", risky)
+	}
+	sb.WriteString("\n\n| | Change | Risk |\n|---|---|---|\n")
+	for _, change := range changes {
+		fmt.Fprintf(&sb, "| %s | `%s` | %s |\n", planKindIcons[change.Kind], change.Text, planRiskIcons[change.Risk])
+	}
+
+	if risks := analyzeRiskyOperations(diff); len(risks) > 0 {
+		sb.WriteString("\n<details>\n<summary>⚠️ Cannot be automatically rolled back</summary>\n\n")
+		for _, risk := range risks {
+			sb.WriteString("- " + risk + "\n")
+		}
+		sb.WriteString("\n</details>\n")
+	}
+
+	up := schema.GenerateMigrationSQL(diff)
+	var long []string
+	for _, lock := range schema.AnalyzeLocks(up) {
+		if lock.Long {
+			long = append(long, fmt.Sprintf("- %s on `%s`: %s", lock.Lock, lock.Table, lock.Reason))
+		}
+	}
+	if len(long) > 0 {
+		sb.WriteString("\n<details>\n<summary>🔒 Long-held locks</summary>\n\n" + strings.Join(long, "\n") + "\n\n</details>\n")
+	}
+	if locks := diff.LargeTableIndexes(up); len(locks) > 0 {
+		sb.WriteString("\n⚠️ **Indexes on large tables**\n\n")
+		for _, lock := range locks {
+			sb.WriteString("- " + largeTableIndexWarning(lock) + diff.RowImpact(lock.Table) + "\n")
+		}
+	}
+
+	sb.WriteString("\n<details>\n<summary>Up SQL</summary>\n\n```sql\n" + strings.TrimSpace(up) + "\n```\n\n</details>\n")
+	down := schema.GenerateDownMigrationSQL(diff)
+	sb.WriteString("\n<details>\n<summary>Down SQL</summary>\n\n```sql\n" + strings.TrimSpace(down) + "\n```\n\n</details>\n")
+	return sb.String()
+}
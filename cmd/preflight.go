@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+)
+
+// preflightTimeout bounds each pre-flight count, which scans the whole table
+const preflightTimeout = 30 * time.Second
+
+// printPreflightChecks counts the rows the risky changes of a diff would fail on in the database of
+// DATABASE_URL and prints the results; without a reachable database nothing is checked
+func printPreflightChecks(diff *schema.SchemaDiff) {
+	probes := diff.PreflightProbes()
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil || len(probes) == 0 {
+		return
+	}
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping pre-flight checks: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	fmt.Println("\n🔎 Pre-flight checks against the database:")
+	for _, probe := range probes {
+		ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+		var violations int64
+		err := db.QueryRowContext(ctx, probe.SQL).Scan(&violations)
+		cancel()
+		switch {
+		case err != nil:
+			fmt.Printf("  ⚠️  %s.%s: not checked (%v)\n", probe.Table, probe.Change, err)
+		case violations > 0:
+			fmt.Printf("  ❌ %s.%s: %d %s - the migration will fail until they are fixed\n",
+				probe.Table, probe.Change, violations, probe.Description)
+		default:
+			fmt.Printf("  ✅ %s.%s: no %s\n", probe.Table, probe.Change, probe.Description)
+		}
+	}
+}
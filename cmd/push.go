@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func PushCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "push",
+		Usage: "Generate a migration for schema.prisma changes and apply it to the database",
+		Description: "Write the migration generate would create and apply it, along with any other pending " +
+			"migrations, to DATABASE_URL like `goose up`. With --dry-run the SQL of the pending migrations " +
+			"and of the migration for schema.prisma changes is printed instead; neither the migrations " +
+			"folder nor the database is changed",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "name", Usage: "Name of the migration generated for schema changes", Value: "push"},
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Print the SQL that would run instead of writing and executing it"},
+			&cli.StringFlag{
+				Name:  "backup",
+				Usage: "Back up tables losing data before applying (pg_dump, csv or none); overrides the generator backup setting",
+			},
+		}, applyLimitFlags()...),
+		Action: runPush,
+	}
+}
+
+func runPush(c *cli.Context) error {
+	versionTable := c.String("table")
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	generator, err := schema.ReadGeneratorConfig("schema.prisma")
+	if err != nil {
+		return cli.Exit("Failed to read generator config: "+err.Error(), 1)
+	}
+
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return cli.Exit("Failed to connect to database: "+err.Error(), 1)
+	}
+	defer db.Close()
+
+	if c.Bool("dry-run") {
+		return runPushDryRun(db, c.String("name"), versionTable)
+	}
+
+	backup, err := backupPolicyFor(generator, c.String("backup"), databaseURL)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if _, err := runGenerate(generateOptions{Name: c.String("name")}); err != nil {
+		if errors.Is(err, errGenerateCancelled) {
+			fmt.Println("Push cancelled; no migrations were applied.")
+			return nil
+		}
+		return err
+	}
+	applied, err := applyPendingMigrations(db, migrationsDir(), versionTable, backup, applyLimitsFor(generator, c))
+	if err != nil {
+		return cli.Exit("Failed to apply migrations: "+err.Error(), 1)
+	}
+	if len(applied) == 0 {
+		fmt.Println("No pending migrations.")
+		return nil
+	}
+	fmt.Printf("✅ Pushed %d migration(s) to the database\n", len(applied))
+	return nil
+}
+
+// runPushDryRun prints the up sections of the migrations the database hasn't applied yet, followed by
+// the migration schema.prisma changes would add
+func runPushDryRun(db *sql.DB, name, versionTable string) error {
+	pending, err := pendingMigrations(db, versionTable)
+	if err != nil {
+		return cli.Exit("Failed to read migration state: "+err.Error(), 1)
+	}
+	for _, f := range pending {
+		content, err := os.ReadFile(filepath.Join(migrationsDir(), f))
+		if err != nil {
+			return cli.Exit("Failed to read "+f+": "+err.Error(), 1)
+		}
+		fmt.Println("Would apply pending migration:", f)
+		fmt.Print("\n" + strings.TrimSpace(schema.ParseGooseMigration(string(content)).UpSQL) + "\n\n")
+	}
+
+	filename, err := runGenerate(generateOptions{Name: name, DryRun: true})
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 && filename == "" {
+		fmt.Println("Nothing to push.")
+		return nil
+	}
+	fmt.Println("\nDry run: no migration was written and the database is unchanged")
+	return nil
+}
+
+// pendingMigrations lists the migrations the goose version table doesn't record as applied, inside a
+// transaction that is rolled back so not even the version table is created
+func pendingMigrations(db *sql.DB, versionTable string) ([]string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	done, pending, err := migrationStates(tx, migrationsDir(), versionTable)
+	if err != nil {
+		return nil, err
+	}
+	return pending, checkMigrationOrder(done, pending)
+}
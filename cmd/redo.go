@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func RedoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "redo",
+		Usage: "Roll back and re-apply the latest migration",
+		Description: "Run the down section of the latest applied migration and then its up section again, " +
+			"to try out a migration that was just edited",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "Don't ask for confirmation"},
+		},
+		Action: func(c *cli.Context) error {
+			return runRedo(c.String("table"), c.Bool("yes"))
+		},
+	}
+}
+
+func runRedo(versionTable string, yes bool) error {
+	generator, err := schema.ReadGeneratorConfig("schema.prisma")
+	if err != nil {
+		return cli.Exit("Failed to read generator config: "+err.Error(), 1)
+	}
+	databaseURL, _ := resolveDatabaseURL()
+	backup, err := backupPolicyFor(generator, "", databaseURL)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	db, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, _, err := migrationStates(db, migrationsDir(), versionTable)
+	if err != nil {
+		return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+	}
+	if len(applied) == 0 {
+		fmt.Println("No applied migration to redo.")
+		return nil
+	}
+	latest := applied[len(applied)-1]
+
+	if ok, err := confirmRollback([]string{latest}, yes); err != nil || !ok {
+		return err
+	}
+	if err := rollbackMigration(db, migrationsDir(), latest, versionTable); err != nil {
+		return cli.Exit("Failed to roll back: "+err.Error(), 1)
+	}
+	fmt.Println("Rolled back migration:", latest)
+
+	limits := generatorApplyLimits(generator)
+	ctx, cancel := limits.context()
+	defer cancel()
+	if err := applyMigration(ctx, db, migrationsDir(), latest, versionTable, backup, limits); err != nil {
+		return cli.Exit("Failed to re-apply "+latest+" (it is rolled back now): "+err.Error(), 1)
+	}
+	fmt.Println("Applied migration:", latest)
+	return nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func RelationCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "relation",
+		Usage: "Scaffold relations in schema.prisma",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Add a relation field, its foreign key field and the back-relation field",
+				ArgsUsage: "'Post.author -> User.id'",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "field", Usage: "Scalar field holding the key (default: <relation>Id, e.g. authorId)"},
+					&cli.StringFlag{Name: "back-relation", Usage: "Name of the field added to the referenced model (default: e.g. posts)"},
+					&cli.BoolFlag{Name: "optional", Usage: "Allow records without a related record"},
+					&cli.BoolFlag{Name: "one-to-one", Usage: "Make the key unique, with a single back-relation field"},
+					&cli.StringFlag{Name: "on-delete", Usage: "Referential action: Cascade, Restrict, NoAction, SetNull or SetDefault"},
+				},
+				Action: runRelationAdd,
+			},
+		},
+	}
+}
+
+func runRelationAdd(c *cli.Context) error {
+	spec := &schema.RelationSpec{
+		ForeignKey:   c.String("field"),
+		BackRelation: c.String("back-relation"),
+		Optional:     c.Bool("optional"),
+		OneToOne:     c.Bool("one-to-one"),
+		OnDelete:     c.String("on-delete"),
+	}
+	switch spec.OnDelete {
+	case "", "Cascade", "Restrict", "NoAction", "SetNull", "SetDefault":
+	default:
+		return cli.Exit("Unsupported --on-delete "+spec.OnDelete+": use Cascade, Restrict, NoAction, SetNull or SetDefault", 1)
+	}
+	if spec.OnDelete == "SetNull" && !spec.Optional {
+		return cli.Exit("--on-delete SetNull needs --optional, the key can't be set to NULL otherwise", 1)
+	}
+
+	from, to, err := relationEnds(c.Args().Slice())
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	var found bool
+	if spec.Model, spec.Field, found = strings.Cut(from, "."); !found || spec.Model == "" || spec.Field == "" {
+		return cli.Exit("The relation field must be written as Model.field, e.g. Post.author, not "+from, 1)
+	}
+	spec.RelatedModel, spec.References, _ = strings.Cut(to, ".")
+
+	path := "schema.prisma"
+	added, err := schema.AddRelation(context.Background(), path, spec)
+	if err != nil {
+		return cli.Exit("Failed to add relation: "+err.Error(), 1)
+	}
+	fmt.Printf("✅ Added relation %s.%s -> %s to %s\n", spec.Model, spec.Field, spec.RelatedModel, path)
+	for _, line := range added.ModelLines {
+		fmt.Printf("  %s: %s\n", spec.Model, line)
+	}
+	for _, line := range added.RelatedLines {
+		fmt.Printf("  %s: %s\n", spec.RelatedModel, line)
+	}
+	fmt.Println("Run 'schema-manager generate --name <name>' to create the migration")
+	return nil
+}
+
+// relationEnds reads the two ends of a relation from the arguments, given as "Post.author -> User.id"
+// in one argument or several, or asks for them when there are none
+func relationEnds(args []string) (string, string, error) {
+	if len(args) == 0 {
+		return promptRelationEnds()
+	}
+	ends := strings.Fields(strings.ReplaceAll(strings.Join(args, " "), "->", " "))
+	if len(ends) != 2 {
+		return "", "", fmt.Errorf("expected a relation like 'Post.author -> User.id', got %q", strings.Join(args, " "))
+	}
+	return ends[0], ends[1], nil
+}
+
+func promptRelationEnds() (string, string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	ask := func(question string) (string, error) {
+		fmt.Print(question)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to read user input: %w", err)
+		}
+		return strings.TrimSpace(answer), nil
+	}
+	from, err := ask("Relation field to add (Model.field, e.g. Post.author): ")
+	if err != nil {
+		return "", "", err
+	}
+	to, err := ask("Referenced model and field (Model or Model.field, e.g. User.id): ")
+	if err != nil {
+		return "", "", err
+	}
+	if from == "" || to == "" {
+		return "", "", fmt.Errorf("both ends of the relation are required")
+	}
+	return from, to, nil
+}
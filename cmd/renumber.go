@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// migrationVersionLayout is the timestamp format of migration versions
+const migrationVersionLayout = "20060102150405"
+
+func RenumberCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "renumber",
+		Usage: "Re-stamp unapplied migrations so they sort after every applied one",
+		Description: "Give unapplied migrations new versions after the latest applied migration, keeping their " +
+			"order, e.g. after merging a branch whose migrations interleave with yours. Applied migrations are " +
+			"read from the goose version table of DATABASE_URL, or given with --since",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "since", Usage: "Treat migrations from this version on as unapplied (without DATABASE_URL)"},
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.BoolFlag{Name: "dry-run", Usage: "Show the new file names without renaming"},
+		},
+		Action: func(c *cli.Context) error {
+			return runRenumber(c.String("since"), c.String("table"), c.Bool("dry-run"))
+		},
+	}
+}
+
+func runRenumber(since, versionTable string, dryRun bool) error {
+	var applied, pending []string
+	_, urlErr := resolveDatabaseURL()
+	switch {
+	case since != "":
+		files, err := listMigrationFiles(migrationsDir())
+		if err != nil {
+			return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+		}
+		for _, f := range files {
+			if migrationVersion(f) >= since {
+				pending = append(pending, f)
+			} else {
+				applied = append(applied, f)
+			}
+		}
+	case urlErr == nil:
+		db, err := connectDatabase()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if applied, pending, err = migrationStates(db, migrationsDir(), versionTable); err != nil {
+			return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+		}
+	default:
+		return cli.Exit(urlErr.Error()+" - set it or pass --since <version> to tell which migrations are unapplied", 1)
+	}
+	if len(pending) == 0 {
+		fmt.Println("No unapplied migrations to renumber.")
+		return nil
+	}
+
+	renames := renumberMigrations(applied, pending, time.Now())
+	if len(renames) == 0 {
+		fmt.Println("Unapplied migrations already sort after the applied ones.")
+		return nil
+	}
+	dir := migrationsDir()
+	for _, f := range pending {
+		newName, ok := renames[f]
+		if !ok {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("Would rename %s → %s\n", f, newName)
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, f), filepath.Join(dir, newName)); err != nil {
+			return cli.Exit("Failed to rename "+f+": "+err.Error(), 1)
+		}
+		fmt.Printf("Renamed %s → %s\n", f, newName)
+	}
+	return nil
+}
+
+// renumberMigrations returns new file names for the pending migrations, in their current order, with
+// versions after every applied one and no two alike. Nothing is returned when the pending migrations
+// already come after the applied ones with unique versions.
+func renumberMigrations(applied, pending []string, now time.Time) map[string]string {
+	if len(outOfOrderMigrations(applied, pending)) == 0 && len(duplicateVersions(append(applied, pending...))) == 0 {
+		return nil
+	}
+
+	next := now.Truncate(time.Second)
+	for _, f := range applied {
+		if t, err := time.Parse(migrationVersionLayout, migrationVersion(f)); err == nil && !t.Before(next) {
+			next = t.Add(time.Second)
+		}
+	}
+	renames := map[string]string{}
+	for _, f := range pending {
+		version := migrationVersion(f)
+		renames[f] = next.Format(migrationVersionLayout) + strings.TrimPrefix(f, version)
+		next = next.Add(time.Second)
+	}
+	return renames
+}
+
+// outOfOrderMigrations returns the pending migrations whose version is older than the latest applied
+// migration; goose refuses to apply them
+func outOfOrderMigrations(applied, pending []string) []string {
+	if len(applied) == 0 {
+		return nil
+	}
+	latest := migrationVersion(applied[len(applied)-1])
+	var result []string
+	for _, f := range pending {
+		if migrationVersion(f) < latest {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// duplicateVersions returns the migration versions used by more than one file
+func duplicateVersions(files []string) []string {
+	count := map[string]int{}
+	for _, f := range files {
+		count[migrationVersion(f)]++
+	}
+	var result []string
+	for version, n := range count {
+		if n > 1 {
+			result = append(result, version)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// nextMigrationVersion returns the version for a new migration: the current time, moved past the
+// latest existing version so two migrations generated within a second don't collide
+func nextMigrationVersion(files []string, now time.Time) string {
+	next := now
+	for _, f := range files {
+		if t, err := time.Parse(migrationVersionLayout, migrationVersion(f)); err == nil && !t.Before(next.Truncate(time.Second)) {
+			next = t.Add(time.Second)
+		}
+	}
+	return next.Format(migrationVersionLayout)
+}
+
+// warnMigrationOrder warns about migrations sharing a version and, when DATABASE_URL is set, about
+// unapplied migrations older than the latest applied one. Both happen when branches are merged.
+func warnMigrationOrder(dir, versionTable string) {
+	files, err := listMigrationFiles(dir)
+	if err != nil {
+		return
+	}
+	if duplicates := duplicateVersions(files); len(duplicates) > 0 {
+		fmt.Printf("⚠️  Several migrations share version %s - run 'schema-manager renumber'\n",
+			strings.Join(duplicates, ", "))
+	}
+
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return
+	}
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+	// The version table is only read here; generate must not create it
+	versions, err := appliedVersions(db, versionTable)
+	if err != nil {
+		return
+	}
+	var applied, pending []string
+	for _, f := range files {
+		if versions[migrationVersion(f)] {
+			applied = append(applied, f)
+		} else {
+			pending = append(pending, f)
+		}
+	}
+	if outOfOrder := outOfOrderMigrations(applied, pending); len(outOfOrder) > 0 {
+		fmt.Printf("⚠️  Unapplied migrations predate the latest applied one (%s): %s - run 'schema-manager renumber'\n",
+			applied[len(applied)-1], strings.Join(outOfOrder, ", "))
+	}
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+// dataLossRegex matches statements that delete stored rows or columns
+var dataLossRegex = regexp.MustCompile(`(?is)^(?:DROP TABLE|TRUNCATE|DELETE FROM|ALTER TABLE\s.*\sDROP COLUMN\s)`)
+
+func RollbackCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "rollback",
+		Usage: "Roll back applied migrations on the database",
+		Description: "Run the down sections of the latest applied migration, with --steps of the latest N, or " +
+			"with --to of every migration applied after a version, against DATABASE_URL; asks for confirmation " +
+			"when data would be lost. The rolled-back migration files are moved to --archive-dir so generate " +
+			"and check no longer count their changes",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "Roll back every migration newer than this version (0 rolls back all)",
+			},
+			&cli.IntFlag{Name: "steps", Usage: "Number of applied migrations to roll back, newest first", Value: 1},
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.StringFlag{
+				Name:  "archive-dir",
+				Usage: "Directory the rolled-back migrations are moved to (default: migrations_rolled_back next to the migrations directory)",
+			},
+			&cli.BoolFlag{
+				Name:  "keep",
+				Usage: "Leave the rolled-back migration files in the migrations directory, to re-apply them",
+			},
+			&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "Don't ask for confirmation"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.IsSet("steps") && c.IsSet("to") {
+				return cli.Exit("--steps and --to can't be combined", 1)
+			}
+			if c.Int("steps") < 1 {
+				return cli.Exit("--steps must be at least 1", 1)
+			}
+			archiveDir := c.String("archive-dir")
+			if archiveDir == "" {
+				archiveDir = filepath.Join(filepath.Dir(migrationsDir()), "migrations_rolled_back")
+			}
+			if c.Bool("keep") {
+				archiveDir = ""
+			}
+			return runRollback(c.String("to"), c.Int("steps"), c.String("table"), archiveDir, c.Bool("yes"))
+		},
+	}
+}
+
+// runRollback rolls back the target migrations and moves their files to archiveDir, so the schema built
+// from the migrations folder matches the database again; with an empty archiveDir the files are kept
+func runRollback(to string, steps int, versionTable, archiveDir string, yes bool) error {
+	db, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, _, err := migrationStates(db, migrationsDir(), versionTable)
+	if err != nil {
+		return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+	}
+	targets, err := rollbackTargets(applied, to, steps)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if len(targets) == 0 {
+		fmt.Println("Nothing to roll back.")
+		return nil
+	}
+
+	if ok, err := confirmRollback(targets, yes); err != nil || !ok {
+		return err
+	}
+
+	if archiveDir != "" {
+		if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+			return cli.Exit("Failed to create archive directory: "+err.Error(), 1)
+		}
+	}
+	for _, f := range targets {
+		if err := rollbackMigration(db, migrationsDir(), f, versionTable); err != nil {
+			return cli.Exit("Failed to roll back: "+err.Error(), 1)
+		}
+		fmt.Println("Rolled back migration:", f)
+		// Moving each file right after its rollback keeps the folder in step with the database when a
+		// later down section fails
+		if archiveDir == "" {
+			continue
+		}
+		if err := os.Rename(filepath.Join(migrationsDir(), f), filepath.Join(archiveDir, f)); err != nil {
+			return cli.Exit("Failed to archive migration "+f+": "+err.Error(), 1)
+		}
+	}
+	if archiveDir == "" {
+		// generate and check read the schema from the files, which still hold the rolled-back changes
+		fmt.Printf("The %d rolled-back migration file(s) are kept: 'apply' re-applies them\n", len(targets))
+		return nil
+	}
+	fmt.Printf("Moved the %d rolled-back migration file(s) to %s/; generate and check no longer count "+
+		"their changes\n", len(targets), archiveDir)
+	return nil
+}
+
+// confirmRollback lists the migrations about to be rolled back with their data-loss warnings and,
+// when there are any, asks the user to confirm unless yes is set
+func confirmRollback(targets []string, yes bool) (bool, error) {
+	fmt.Printf("Rolling back %d migration(s):\n", len(targets))
+	dataLoss := false
+	for _, f := range targets {
+		content, err := os.ReadFile(filepath.Join(migrationsDir(), f))
+		if err != nil {
+			return false, cli.Exit("Failed to read "+f+": "+err.Error(), 1)
+		}
+		fmt.Printf("  • %s\n", f)
+		for _, warning := range rollbackWarnings(schema.ParseGooseMigration(string(content))) {
+			fmt.Printf("      ⚠️  %s\n", warning)
+			dataLoss = true
+		}
+	}
+	if !dataLoss || yes {
+		return true, nil
+	}
+
+	fmt.Print("\nThe down migrations above carry data-loss warnings. Continue? (y/N): ")
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, cli.Exit("Failed to read user input: "+err.Error(), 1)
+	}
+	response = strings.ToLower(strings.TrimSpace(response))
+	if response != "y" && response != "yes" {
+		fmt.Println("Rollback cancelled.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// rollbackTargets returns the applied migrations to roll back, newest first: the latest steps ones, or
+// with to every migration newer than that version
+func rollbackTargets(applied []string, to string, steps int) ([]string, error) {
+	if len(applied) == 0 {
+		return nil, nil
+	}
+	if to == "" {
+		if steps > len(applied) {
+			return nil, fmt.Errorf("can't roll back %d migrations, only %d are applied", steps, len(applied))
+		}
+		targets := make([]string, 0, steps)
+		for i := len(applied) - 1; i >= len(applied)-steps; i-- {
+			targets = append(targets, applied[i])
+		}
+		return targets, nil
+	}
+
+	target, err := strconv.ParseInt(to, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q", to)
+	}
+	known := target == 0
+	var targets []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		version, _ := strconv.ParseInt(migrationVersion(applied[i]), 10, 64)
+		if version == target {
+			known = true
+		}
+		if version > target {
+			targets = append(targets, applied[i])
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("version %s is not an applied migration", to)
+	}
+	return targets, nil
+}
+
+// rollbackWarnings lists the warnings written into the down section of a migration and its
+// statements that delete data
+func rollbackWarnings(migration *schema.GooseMigration) []string {
+	var warnings []string
+	for _, line := range strings.Split(migration.DownSQL, "\n") {
+		if warning, ok := strings.CutPrefix(strings.TrimSpace(line), "-- WARNING: "); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+	for _, stmt := range migration.Down {
+		if dataLossRegex.MatchString(stmt) {
+			warnings = append(warnings, "Deletes data: "+strings.Join(strings.Fields(stmt), " "))
+		}
+	}
+	return warnings
+}
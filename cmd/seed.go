@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+)
+
+func SeedCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "seed",
+		Usage: "Load seed data into the database",
+		Description: "Run the SQL files of the seeds directory followed by those of seeds/<env>, each set in " +
+			"file name order, in a single transaction against DATABASE_URL",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "env", Usage: "Seed set to run after the shared seeds (seeds/<env>/*.sql)", Value: "dev"},
+			&cli.StringFlag{Name: "dir", Usage: "Seeds directory", Value: "seeds"},
+		},
+		Action: func(c *cli.Context) error {
+			return runSeed(c.String("dir"), c.String("env"))
+		},
+	}
+}
+
+func runSeed(dir, env string) error {
+	files, err := seedFiles(dir, env)
+	if err != nil {
+		return cli.Exit("Failed to read seeds: "+err.Error(), 1)
+	}
+	if len(files) == 0 {
+		fmt.Printf("No seed files in %s or %s\n", dir, filepath.Join(dir, env))
+		return nil
+	}
+
+	db, err := connectDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// All seeds commit together, so a failing file leaves no partial data behind
+	tx, err := db.Begin()
+	if err != nil {
+		return cli.Exit("Failed to start transaction: "+err.Error(), 1)
+	}
+	defer tx.Rollback()
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return cli.Exit("Failed to read "+f+": "+err.Error(), 1)
+		}
+		if _, err := tx.Exec(string(content)); err != nil {
+			return cli.Exit(fmt.Sprintf("Seed %s failed, no seeds were applied: %v", f, err), 1)
+		}
+		fmt.Println("Seeded:", f)
+	}
+	if err := tx.Commit(); err != nil {
+		return cli.Exit("Failed to commit seeds: "+err.Error(), 1)
+	}
+	fmt.Printf("✅ Applied %d seed files for %s\n", len(files), env)
+	return nil
+}
+
+// seedFiles returns the shared seed files of a directory followed by the files of its env
+// subdirectory, each sorted by name so a numeric prefix (01_users.sql) sets the order
+func seedFiles(dir, env string) ([]string, error) {
+	dirs := []string{dir}
+	if env != "" {
+		if _, err := os.Stat(filepath.Join(dir, env)); err != nil {
+			fmt.Printf("⚠️  No %s seed set in %s - running the shared seeds only\n", env, dir)
+		} else {
+			dirs = append(dirs, filepath.Join(dir, env))
+		}
+	}
+
+	var files []string
+	for _, d := range dirs {
+		matches, err := filepath.Glob(filepath.Join(d, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
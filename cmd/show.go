@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func ShowCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "show",
+		Usage: "Show the models, fields and enums of the schema",
+		Description: "List the models with their fields, types and attributes and the enums of the schema the " +
+			"migrations folder builds, of schema.prisma or of the database, or with --sql print the " +
+			"complete CREATE script of that schema for code review or other tools",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "Schema to show: migrations, prisma (schema.prisma) or db (DATABASE_URL)",
+				Value: "migrations",
+			},
+			&cli.StringFlag{Name: "model", Usage: "Only show the model with this name or table name"},
+			&cli.BoolFlag{Name: "json", Usage: "Print the schema as JSON"},
+			&cli.BoolFlag{
+				Name:  "sql",
+				Usage: "Print the full DDL (extensions, enums, tables, indexes, foreign keys, views, policies, triggers)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.Bool("json") && c.Bool("sql") {
+				return cli.Exit("--json and --sql can't be combined", 1)
+			}
+			source := c.String("source")
+			s, err := loadShownSchema(source)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			if name := c.String("model"); name != "" {
+				if s, err = filterShownModel(s, name); err != nil {
+					return cli.Exit(err.Error(), 1)
+				}
+			}
+
+			switch {
+			case c.Bool("sql"):
+				fmt.Print(schema.GenerateSchemaSQL(s, false))
+				return nil
+			case c.Bool("json"):
+				content, err := json.MarshalIndent(newShownSchema(source, s), "", "  ")
+				if err != nil {
+					return cli.Exit("Failed to encode schema: "+err.Error(), 1)
+				}
+				fmt.Println(string(content))
+				return nil
+			}
+
+			if len(s.Models) == 0 && len(s.Enums) == 0 {
+				fmt.Printf("No models or enums in %s.\n", shownSourceName(source))
+				return nil
+			}
+			printShownSchema(s)
+			return nil
+		},
+	}
+}
+
+// loadShownSchema parses the schema show lists
+func loadShownSchema(source string) (*schema.Schema, error) {
+	ctx := context.Background()
+	switch source {
+	case "migrations":
+		entries, err := os.ReadDir(migrationsDir())
+		if err != nil || len(entries) == 0 {
+			return &schema.Schema{}, nil
+		}
+		s, err := (&schema.MigrationsFolderSource{Dir: migrationsDir()}).LoadSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse current schema from migrations: %w", err)
+		}
+		return s, nil
+	case "prisma":
+		s, err := (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema.prisma: %w", err)
+		}
+		return s, nil
+	case "db":
+		pulled, err := pullDatabase()
+		if err != nil {
+			return nil, err
+		}
+		generator, err := schema.ReadGeneratorConfig("schema.prisma")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read generator config: %w", err)
+		}
+		// The database is shown as the schema db pull would write from scratch
+		content, _, err := pullSchema(generateSchemaHeader(schema.Datasource{}, generator), "schema.prisma", pulled, generator)
+		if err != nil {
+			return nil, err
+		}
+		return schema.ParsePrismaToSchema(ctx, content, "schema.prisma")
+	}
+	return nil, fmt.Errorf("unsupported --source %s: use migrations, prisma or db", source)
+}
+
+func shownSourceName(source string) string {
+	switch source {
+	case "prisma":
+		return "schema.prisma"
+	case "db":
+		return "the database"
+	}
+	return "migrations"
+}
+
+// filterShownModel keeps the model with a name or table name and the enums its fields use
+func filterShownModel(s *schema.Schema, name string) (*schema.Schema, error) {
+	for _, m := range s.Models {
+		if !strings.EqualFold(m.Name, name) && !strings.EqualFold(m.TableName, name) {
+			continue
+		}
+		filtered := &schema.Schema{Models: []*schema.Model{m}, Generator: s.Generator}
+		for _, e := range s.Enums {
+			for _, f := range m.Fields {
+				if strings.EqualFold(f.Type, e.Name) {
+					filtered.Enums = append(filtered.Enums, e)
+					break
+				}
+			}
+		}
+		return filtered, nil
+	}
+	return nil, fmt.Errorf("model %s not found", name)
+}
+
+// printShownSchema lists every model as a table of its fields, followed by the enums
+func printShownSchema(s *schema.Schema) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, m := range s.Models {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Model %s (table %s)\n", m.Name, m.TableName)
+		fmt.Fprintln(w, "  FIELD\tTYPE\tCOLUMN\tATTRIBUTES")
+		for _, f := range m.Fields {
+			column := f.ColumnName
+			if f.IsRelation {
+				column = "-"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", f.Name, shownFieldType(f), column,
+				orDash(strings.Join(shownAttributes(f.Attributes), " ")))
+		}
+		for _, attr := range m.Attributes {
+			fmt.Fprintf(w, "  @@%s\n", attributeText(attr.Name, attr.Args))
+		}
+	}
+	if len(s.Models) > 0 && len(s.Enums) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, e := range s.Enums {
+		fmt.Fprintf(w, "Enum %s: %s\n", e.Name, strings.Join(e.Values, ", "))
+	}
+	w.Flush()
+}
+
+// shownFieldType returns the type of a field as schema.prisma writes it, e.g. String? or Tag[]
+func shownFieldType(f *schema.Field) string {
+	switch {
+	case f.IsArray:
+		return f.Type + "[]"
+	case f.IsOptional:
+		return f.Type + "?"
+	}
+	return f.Type
+}
+
+func shownAttributes(attrs []*schema.FieldAttribute) []string {
+	shown := make([]string, len(attrs))
+	for i, attr := range attrs {
+		shown[i] = "@" + attributeText(attr.Name, attr.Args)
+	}
+	return shown
+}
+
+// attributeText writes an attribute without its @, e.g. default(now())
+func attributeText(name string, args []string) string {
+	if len(args) == 0 {
+		return name
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// shownSchema is the JSON document show --json prints
+type shownSchema struct {
+	Source string       `json:"source"`
+	Models []shownModel `json:"models"`
+	Enums  []shownEnum  `json:"enums"`
+}
+
+type shownModel struct {
+	Name       string       `json:"name"`
+	Table      string       `json:"table"`
+	Fields     []shownField `json:"fields"`
+	Attributes []string     `json:"attributes"`
+}
+
+type shownField struct {
+	Name       string   `json:"name"`
+	Column     string   `json:"column,omitempty"`
+	Type       string   `json:"type"`
+	Optional   bool     `json:"optional"`
+	List       bool     `json:"list"`
+	Relation   bool     `json:"relation"`
+	Attributes []string `json:"attributes"`
+}
+
+type shownEnum struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+func newShownSchema(source string, s *schema.Schema) shownSchema {
+	shown := shownSchema{Source: source, Models: []shownModel{}, Enums: []shownEnum{}}
+	for _, m := range s.Models {
+		model := shownModel{Name: m.Name, Table: m.TableName, Fields: []shownField{}, Attributes: []string{}}
+		for _, f := range m.Fields {
+			field := shownField{
+				Name:       f.Name,
+				Column:     f.ColumnName,
+				Type:       f.Type,
+				Optional:   f.IsOptional,
+				List:       f.IsArray,
+				Relation:   f.IsRelation,
+				Attributes: shownAttributes(f.Attributes),
+			}
+			if f.IsRelation {
+				field.Column = ""
+			}
+			model.Fields = append(model.Fields, field)
+		}
+		for _, attr := range m.Attributes {
+			model.Attributes = append(model.Attributes, "@@"+attributeText(attr.Name, attr.Args))
+		}
+		shown.Models = append(shown.Models, model)
+	}
+	for _, e := range s.Enums {
+		shown.Enums = append(shown.Enums, shownEnum{Name: e.Name, Values: e.Values})
+	}
+	return shown
+}
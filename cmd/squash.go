@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	_ "github.com/lib/pq"
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+func SquashCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "squash",
+		Usage: "Squash all migrations into a single baseline migration",
+		Description: "Replace the migration history with one baseline migration and generate the SQL that " +
+			"reconciles goose_db_version on databases that already applied the squashed migrations",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "name", Usage: "Baseline migration name", Value: "squashed_baseline"},
+			&cli.StringFlag{
+				Name:  "archive-dir",
+				Usage: "Directory the squashed migrations are moved to",
+				Value: "migrations_squashed",
+			},
+			&cli.StringFlag{Name: "table", Usage: "Goose version table", Value: "goose_db_version"},
+			&cli.BoolFlag{
+				Name:  "apply",
+				Usage: "Run the version-table reconciliation against DATABASE_URL",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			return runSquash(c.String("name"), c.String("archive-dir"), c.String("table"), c.Bool("apply"))
+		},
+	}
+}
+
+func runSquash(name, archiveDir, versionTable string, apply bool) error {
+	ctx := context.Background()
+	files, err := listMigrationFiles(migrationsDir())
+	if err != nil {
+		return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+	}
+	if len(files) < 2 {
+		fmt.Println("Nothing to squash.")
+		return nil
+	}
+	if goFiles, _ := filepath.Glob(filepath.Join(migrationsDir(), "*.go")); len(goFiles) > 0 {
+		// A Go migration left next to the baseline would run before the tables it works on exist
+		return cli.Exit("Cannot squash migrations that include Go migrations ("+filepath.Base(goFiles[0])+
+			") - fold their data changes into SQL or remove them first", 1)
+	}
+
+	databaseURL, err := resolveDatabaseURL()
+	if apply && err != nil {
+		return cli.Exit(err.Error()+" with --apply", 1)
+	}
+
+	// The baseline is generated from schema.prisma, so it must describe exactly what the history builds
+	targetSchema, err := (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(ctx)
+	if err != nil {
+		return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
+	}
+	currentSchema, err := (&schema.MigrationsFolderSource{Dir: migrationsDir()}).LoadSchema(ctx)
+	if err != nil {
+		return cli.Exit("Failed to parse current schema from migrations: "+err.Error(), 1)
+	}
+	if diff := schema.DiffSchemas(currentSchema, targetSchema); hasSchemaChanges(diff) || len(diff.ModelsRemoved) > 0 {
+		return cli.Exit("schema.prisma has changes that are not in migrations yet - run 'generate' first", 1)
+	}
+
+	// The baseline reuses the latest version so fully migrated databases already have it applied
+	versions := make([]string, 0, len(files))
+	for _, f := range files {
+		versions = append(versions, migrationVersion(f))
+	}
+	baselineVersion := versions[len(versions)-1]
+
+	diff := initialSchemaDiff(targetSchema)
+	up := schema.GenerateMigrationSQL(diff)
+	down := schema.GenerateDownMigrationSQL(diff)
+
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		return cli.Exit("Failed to create archive directory: "+err.Error(), 1)
+	}
+	for _, f := range files {
+		if err := os.Rename(filepath.Join(migrationsDir(), f), filepath.Join(archiveDir, f)); err != nil {
+			return cli.Exit("Failed to archive migration "+f+": "+err.Error(), 1)
+		}
+	}
+
+	filename := filepath.Join(migrationsDir(), baselineVersion+"_"+name+".sql")
+	if err := os.WriteFile(filename, []byte(schema.FormatMigration(up, down)), 0o644); err != nil {
+		return cli.Exit("Failed to create migration file: "+err.Error(), 1)
+	}
+	fmt.Printf("Squashed %d migrations into %s\n", len(files), filename)
+	fmt.Printf("Archived previous migrations to %s/\n", archiveDir)
+
+	reconcileSQL := generateVersionReconcileSQL(versionTable, baselineVersion, versions[:len(versions)-1])
+	reconcileFile := filepath.Join(archiveDir, baselineVersion+"_reconcile_"+versionTable+".sql")
+	if err := os.WriteFile(reconcileFile, []byte(reconcileSQL), 0o644); err != nil {
+		return cli.Exit("Failed to write reconciliation SQL: "+err.Error(), 1)
+	}
+	fmt.Println("Created version-table reconciliation:", reconcileFile)
+
+	if !apply {
+		fmt.Println("Run it once on every database that already applied the squashed migrations, e.g.:")
+		fmt.Printf("  psql \"$DATABASE_URL\" -f %s\n", reconcileFile)
+		return nil
+	}
+
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return cli.Exit("Failed to connect to database: "+err.Error(), 1)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(reconcileSQL); err != nil {
+		return cli.Exit("Failed to reconcile "+versionTable+": "+err.Error(), 1)
+	}
+	fmt.Printf("✅ Reconciled %s with baseline version %s\n", versionTable, baselineVersion)
+	return nil
+}
+
+// migrationsDir returns the migrations directory configured by the generator output of schema.prisma
+func migrationsDir() string {
+	return migrationsDirFor("schema.prisma")
+}
+
+// migrationsDirFor returns the migrations directory of a schema file, the default one when the schema
+// can't be read
+func migrationsDirFor(prismaPath string) string {
+	generator, _ := schema.ReadGeneratorConfig(prismaPath)
+	return generator.MigrationsDir(prismaPath)
+}
+
+// listMigrationFiles returns the migration file names of a directory in version order
+func listMigrationFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// migrationVersion returns the goose version prefix of a migration file name
+func migrationVersion(filename string) string {
+	if idx := strings.Index(filename, "_"); idx > 0 {
+		return filename[:idx]
+	}
+	return strings.TrimSuffix(filename, ".sql")
+}
+
+// generateVersionReconcileSQL rewrites the goose version table of a fully migrated database so
+// the baseline counts as applied and the squashed versions are forgotten. It refuses to run on a
+// database that has not applied the baseline version yet, since that database still needs the DDL.
+func generateVersionReconcileSQL(versionTable, baselineVersion string, squashedVersions []string) string {
+	var sb strings.Builder
+	sb.WriteString("-- Reconcile " + versionTable + " after squashing migrations into version " + baselineVersion + "\n")
+	sb.WriteString("BEGIN;\n\n")
+	sb.WriteString("DO $$\nBEGIN\n")
+	sb.WriteString(fmt.Sprintf(
+		"    IF NOT EXISTS (SELECT 1 FROM %s WHERE version_id = %s AND is_applied) THEN\n",
+		versionTable, baselineVersion,
+	))
+	sb.WriteString(fmt.Sprintf(
+		"        RAISE EXCEPTION 'version %s is not applied - migrate this database before reconciling';\n",
+		baselineVersion,
+	))
+	sb.WriteString("    END IF;\nEND $$;\n\n")
+	if len(squashedVersions) > 0 {
+		sb.WriteString(fmt.Sprintf(
+			"DELETE FROM %s WHERE version_id IN (%s);\n\n",
+			versionTable, strings.Join(squashedVersions, ", "),
+		))
+	}
+	sb.WriteString("COMMIT;\n")
+	return sb.String()
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+)
+
+// printChangeSummary prints the one-line summary of every change of a diff
+func printChangeSummary(diff *schema.SchemaDiff) {
+	fmt.Println("\nSummary:")
+	for _, line := range summarizeSchemaDiff(diff) {
+		fmt.Println("  " + line.String())
+	}
+}
+
+// changeSummary is one change of a diff as shown to reviewers
+type changeSummary struct {
+	Kind string // + added, - removed, ~ changed
+	Text string
+	Risk string // "risky" when the migration may fail, "data loss" when it deletes data
+}
+
+func (c *changeSummary) String() string {
+	if c.Risk != "" {
+		return c.Kind + " " + c.Text + " [" + c.Risk + "]"
+	}
+	return c.Kind + " " + c.Text
+}
+
+const (
+	riskRisky    = "risky"
+	riskDataLoss = "data loss"
+)
+
+// summarizeSchemaDiff describes a diff in one line per change for reviewers, e.g.
+// "~ users.email TEXT→VARCHAR(255) [risky]"
+func summarizeSchemaDiff(diff *schema.SchemaDiff) []*changeSummary {
+	var lines []*changeSummary
+	add := func(kind, text, risk string) {
+		lines = append(lines, &changeSummary{Kind: kind, Text: text, Risk: risk})
+	}
+	for _, m := range diff.ModelsAdded {
+		add("+", fmt.Sprintf("table %s (%d columns)", m.TableName, columnCount(m)), "")
+	}
+	for _, m := range diff.ModelsRemoved {
+		add("-", "table "+m.TableName, riskDataLoss)
+	}
+	for _, tr := range diff.TablesRenamed {
+		add("~", fmt.Sprintf("table %s→%s", tr.CurrentName, tr.Model.TableName), "")
+	}
+	for _, fc := range diff.FieldsAdded {
+		risk := ""
+		if !fc.Field.IsOptional && !hasAttribute(fc.Field, "default") && !hasAttribute(fc.Field, "updatedAt") {
+			risk = riskRisky
+		}
+		add("+", fmt.Sprintf("column %s.%s %s", fc.ModelName, fc.Field.ColumnName, schema.GetSQLTypeForField(fc.Field)), risk)
+	}
+	for _, fc := range diff.FieldsRemoved {
+		add("-", fmt.Sprintf("column %s.%s", fc.ModelName, fc.Field.ColumnName), riskDataLoss)
+	}
+	for _, fc := range diff.FieldsModified {
+		lines = append(lines, summarizeFieldChange(diff, fc))
+	}
+	for _, fc := range diff.ColumnsRenamed {
+		add("~", fmt.Sprintf("column %s.%s→%s", fc.ModelName, fc.CurrentField.ColumnName, fc.Field.ColumnName), "")
+	}
+	for _, ic := range diff.IndexesAdded {
+		risk := ""
+		if ic.Index.Unique {
+			// Existing duplicates make the unique index fail
+			risk = riskRisky
+		}
+		add("+", fmt.Sprintf("index %s on %s(%s)", ic.Index.Name, ic.TableName, strings.Join(ic.Index.Columns, ", ")), risk)
+	}
+	for _, ic := range diff.IndexesRemoved {
+		add("-", fmt.Sprintf("index %s on %s(%s)", ic.Index.Name, ic.TableName, strings.Join(ic.Index.Columns, ", ")), "")
+	}
+	for _, fc := range diff.ForeignKeysAdded {
+		// Rows without a matching referenced row make the constraint fail
+		add("+", fmt.Sprintf("foreign key %s on %s(%s) → %s", fc.ForeignKey.Name, fc.TableName,
+			strings.Join(fc.ForeignKey.Columns, ", "), fc.ForeignKey.RefTable), riskRisky)
+	}
+	for _, fc := range diff.ForeignKeysRemoved {
+		add("-", fmt.Sprintf("foreign key %s on %s(%s)", fc.ForeignKey.Name, fc.TableName, strings.Join(fc.ForeignKey.Columns, ", ")), "")
+	}
+	for _, pk := range diff.PrimaryKeys {
+		add("~", fmt.Sprintf("primary key of %s (%s)→(%s)", pk.TableName, strings.Join(pk.CurrentColumns, ", "),
+			strings.Join(pk.Columns, ", ")), riskRisky)
+	}
+	for _, cc := range diff.Comments {
+		if cc.Column != "" {
+			add("~", fmt.Sprintf("comment on %s.%s", cc.TableName, cc.Column), "")
+		} else {
+			add("~", "comment on "+cc.TableName, "")
+		}
+	}
+	for _, e := range diff.EnumsAdded {
+		add("+", fmt.Sprintf("enum %s (%d values)", e.Name, len(e.Values)), "")
+	}
+	for _, e := range diff.EnumsRemoved {
+		add("-", "enum "+e.Name, "")
+	}
+	for _, ec := range diff.EnumsModified {
+		add("~", fmt.Sprintf("enum %s (%s)", ec.Enum.Name, ec.Description()), "")
+	}
+	for _, ct := range diff.CompositeTypesAdded {
+		add("+", fmt.Sprintf("type %s (%d fields)", ct.Name, len(ct.Fields)), "")
+	}
+	for _, ct := range diff.CompositeTypesRemoved {
+		add("-", "type "+ct.Name, "")
+	}
+	for _, ctc := range diff.CompositeTypesModified {
+		add("~", "type "+ctc.Type.Name, "")
+	}
+	for _, ext := range diff.ExtensionsAdded {
+		add("+", "extension "+ext.Name, "")
+	}
+	for _, ext := range diff.ExtensionsRemoved {
+		add("-", "extension "+ext.Name, "")
+	}
+	for _, v := range diff.ViewsAdded {
+		add("+", "view "+v.ViewName, "")
+	}
+	for _, v := range diff.ViewsRemoved {
+		add("-", "view "+v.ViewName, "")
+	}
+	for _, vc := range diff.ViewsModified {
+		add("~", "view "+vc.View.ViewName, "")
+	}
+	for _, fn := range diff.FunctionsAdded {
+		add("+", "function "+fn.Name, "")
+	}
+	for _, fn := range diff.FunctionsRemoved {
+		add("-", "function "+fn.Name, "")
+	}
+	for _, fc := range diff.FunctionsModified {
+		add("~", "function "+fc.Function.Name, "")
+	}
+	for _, t := range diff.TriggersAdded {
+		add("+", "trigger "+t.Name, "")
+	}
+	for _, t := range diff.TriggersRemoved {
+		add("-", "trigger "+t.Name, "")
+	}
+	for _, p := range diff.PoliciesAdded {
+		add("+", "policy "+p.Name, "")
+	}
+	for _, p := range diff.PoliciesRemoved {
+		add("-", "policy "+p.Name, "")
+	}
+	for _, m := range diff.RowLevelSecurityEnabled {
+		add("~", "row-level security on "+m.TableName, "")
+	}
+	for _, m := range diff.RowLevelSecurityDisabled {
+		add("~", "row-level security off "+m.TableName, "")
+	}
+	for _, g := range diff.GrantsAdded {
+		add("+", "grant on "+g.TableName+" to "+g.Role, "")
+	}
+	for _, g := range diff.GrantsRevoked {
+		add("-", "grant on "+g.TableName+" to "+g.Role, "")
+	}
+	for _, seq := range diff.SequencesAdded {
+		add("+", "sequence "+seq.Name, "")
+	}
+	for _, seq := range diff.SequencesRemoved {
+		add("-", "sequence "+seq.Name, "")
+	}
+	for _, sc := range diff.SequencesModified {
+		add("~", "sequence "+sc.Sequence.Name, "")
+	}
+	return lines
+}
+
+// summarizeFieldChange describes a modified column by its type and nullability changes
+func summarizeFieldChange(diff *schema.SchemaDiff, fc *schema.FieldChange) *changeSummary {
+	summary := &changeSummary{Kind: "~", Text: fc.ModelName + "." + fc.Field.ColumnName}
+	if _, _, cast, changed := fc.TypeChange(diff.Generator); changed {
+		current, target := schema.GetSQLTypeForField(fc.CurrentField), schema.GetSQLTypeForField(fc.Field)
+		if fc.CurrentEnum != nil {
+			current = fc.CurrentEnum.Name
+		}
+		if fc.TargetEnum != nil {
+			target = fc.TargetEnum.Name
+		}
+		summary.Text += " " + current + "→" + target
+		if cast.IsRisky || !cast.CanCast {
+			summary.Risk = riskRisky
+		}
+	}
+	if value, changed := fc.DefaultChange(); changed && value == "" {
+		summary.Text += " DROP DEFAULT"
+	} else if changed {
+		summary.Text += " DEFAULT " + value
+	}
+	switch {
+	case fc.CurrentField.IsOptional && !fc.Field.IsOptional:
+		summary.Text += " NOT NULL"
+		summary.Risk = riskRisky
+	case !fc.CurrentField.IsOptional && fc.Field.IsOptional:
+		summary.Text += " NULL"
+	}
+	return summary
+}
+
+// columnCount counts the fields of a model that are stored as columns
+func columnCount(m *schema.Model) int {
+	count := 0
+	for _, f := range m.Fields {
+		if !f.IsArray && !f.IsRelation && !hasAttribute(f, "relation") {
+			count++
+		}
+	}
+	return count
+}
@@ -2,13 +2,16 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
-	"github.com/phathdt/schema-manager/internal/schema"
+	"github.com/phathdt/schema-manager/pkg/schema"
 	"github.com/urfave/cli/v2"
 )
 
@@ -135,7 +138,7 @@ func runSyncGenerateMigration() error {
 		return fmt.Errorf("failed to generate migration: %w", err)
 	}
 
-	fmt.Printf("✅ Migration created: migrations/%s.sql\n", migrationName)
+	fmt.Printf("✅ Migration created: %s\n", filepath.Join(migrationsDir(), migrationName+".sql"))
 	fmt.Println("🚀 Run 'goose up' to apply the migration")
 
 	return nil
@@ -181,9 +184,9 @@ func runSyncInteractive() error {
 }
 
 func compareSchemas() (*SchemaDiff, error) {
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL environment variable is required")
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return nil, err
 	}
 
 	db, err := connectWithSSLFallback(databaseURL)
@@ -191,7 +194,11 @@ func compareSchemas() (*SchemaDiff, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer db.Close()
+	return compareDatabaseSchema(db)
+}
 
+// compareDatabaseSchema compares the tables of a database with the models of schema.prisma
+func compareDatabaseSchema(db *sql.DB) (*SchemaDiff, error) {
 	dbTables, err := introspectDatabase(db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to introspect database: %w", err)
@@ -284,6 +291,11 @@ func updateSchemaFromDB(diff *SchemaDiff) error {
 		return nil
 	}
 
+	generator, err := schema.ReadGeneratorConfig("schema.prisma")
+	if err != nil {
+		return fmt.Errorf("failed to read existing schema: %w", err)
+	}
+
 	var existingSchema string
 	if fileExists("schema.prisma") {
 		content, err := os.ReadFile("schema.prisma")
@@ -292,21 +304,11 @@ func updateSchemaFromDB(diff *SchemaDiff) error {
 		}
 		existingSchema = string(content)
 	} else {
-		existingSchema = `datasource db {
-  provider = "postgresql"
-  url      = env("DATABASE_URL")
-}
-
-generator client {
-  provider = "schema-manager"
-  output   = "./migrations"
-}
-
-`
+		existingSchema = generateSchemaHeader(schema.Datasource{}, generator)
 	}
 
 	for _, table := range diff.MissingInSchema {
-		modelString := generateModelString(table)
+		modelString := generateModelString(table, generator)
 		existingSchema += modelString
 	}
 
@@ -317,10 +319,10 @@ generator client {
 	return nil
 }
 
-func generateModelString(table TableInfo) string {
+func generateModelString(table TableInfo, generator schema.GeneratorConfig) string {
 	var model strings.Builder
 
-	model.WriteString(fmt.Sprintf("model %s {\n", toPascalCase(table.TableName)))
+	model.WriteString(fmt.Sprintf("model %s {\n", toPascalCase(table.TableName, generator)))
 
 	for _, col := range table.Columns {
 		model.WriteString(fmt.Sprintf("  %s", toCamelCase(col.ColumnName)))
@@ -332,17 +334,22 @@ func generateModelString(table TableInfo) string {
 		model.WriteString(fmt.Sprintf(" %s", prismaType))
 
 		var attributes []string
+		if attr := nativeTypeAttribute(col, generator); attr != "" {
+			attributes = append(attributes, attr)
+		}
 		if col.IsPrimaryKey {
 			attributes = append(attributes, "@id")
 		}
 		if col.IsAutoIncrement {
 			attributes = append(attributes, "@default(autoincrement())")
+		} else if col.DefaultValue.Valid {
+			attributes = append(attributes, mapDefaultToPrisma(col.DefaultValue.String))
 		}
 		if col.IsUnique && !col.IsPrimaryKey {
 			attributes = append(attributes, "@unique")
 		}
-		if col.ColumnName != toCamelCase(col.ColumnName) {
-			attributes = append(attributes, fmt.Sprintf("@map(\"%s\")", col.ColumnName))
+		if attr := fieldMapAttribute(toCamelCase(col.ColumnName), col.ColumnName, generator); attr != "" {
+			attributes = append(attributes, attr)
 		}
 
 		if len(attributes) > 0 {
@@ -352,7 +359,7 @@ func generateModelString(table TableInfo) string {
 		model.WriteString("\n")
 	}
 
-	model.WriteString(fmt.Sprintf("\n  @@map(\"%s\")\n", table.TableName))
+	model.WriteString(fmt.Sprintf("\n  @@map(%s)\n", strconv.Quote(table.TableName)))
 	model.WriteString("}\n\n")
 
 	return model.String()
@@ -365,7 +372,7 @@ func createConditionalMigration(tables []TableInfo) error {
 
 	migrationContent := generateConditionalMigration(tables)
 	timestamp := time.Now().Format("20060102150405")
-	migrationFile := fmt.Sprintf("migrations/%s_sync_from_database.sql", timestamp)
+	migrationFile := filepath.Join(migrationsDir(), timestamp+"_sync_from_database.sql")
 
 	if err := createMigrationsDir(); err != nil {
 		return fmt.Errorf("failed to create migrations directory: %w", err)
@@ -388,43 +395,7 @@ func generateConditionalMigration(tables []TableInfo) string {
 	migration.WriteString("-- Tables already exist in database\n\n")
 
 	for _, table := range tables {
-		migration.WriteString("DO $$\n")
-		migration.WriteString("BEGIN\n")
-		migration.WriteString(
-			fmt.Sprintf(
-				"    IF NOT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = '%s') THEN\n",
-				table.TableName,
-			),
-		)
-		migration.WriteString(fmt.Sprintf("        CREATE TABLE %s (\n", table.TableName))
-
-		var columnDefs []string
-		for _, col := range table.Columns {
-			colDef := fmt.Sprintf("            %s %s", col.ColumnName, mapDataTypeToSQL(col.DataType))
-
-			if col.IsPrimaryKey {
-				colDef += " PRIMARY KEY"
-			}
-			if col.IsAutoIncrement {
-				colDef = strings.Replace(colDef, mapDataTypeToSQL(col.DataType), "SERIAL", 1)
-			}
-			if !col.IsNullable && !col.IsPrimaryKey {
-				colDef += " NOT NULL"
-			}
-			if col.IsUnique && !col.IsPrimaryKey {
-				colDef += " UNIQUE"
-			}
-			if col.DefaultValue.Valid && !col.IsAutoIncrement {
-				colDef += fmt.Sprintf(" DEFAULT %s", col.DefaultValue.String)
-			}
-
-			columnDefs = append(columnDefs, colDef)
-		}
-
-		migration.WriteString(strings.Join(columnDefs, ",\n"))
-		migration.WriteString("\n        );\n")
-		migration.WriteString("    END IF;\n")
-		migration.WriteString("END $$;\n\n")
+		migration.WriteString(conditionalCreateTableSQL(table))
 	}
 
 	migration.WriteString("-- +goose StatementEnd\n\n")
@@ -434,7 +405,7 @@ func generateConditionalMigration(tables []TableInfo) string {
 	migration.WriteString("-- Dropping them might cause data loss\n")
 
 	for i := len(tables) - 1; i >= 0; i-- {
-		migration.WriteString(fmt.Sprintf("-- DROP TABLE IF EXISTS %s;\n", tables[i].TableName))
+		migration.WriteString(fmt.Sprintf("-- DROP TABLE IF EXISTS %s;\n", schema.QuoteIdent(tables[i].TableName)))
 	}
 
 	migration.WriteString("-- +goose StatementEnd\n")
@@ -474,7 +445,7 @@ func generateMigrationFromDiff(diff *SchemaDiff, migrationName string) error {
 
 	migration.WriteString("-- +goose StatementEnd\n")
 
-	migrationFile := fmt.Sprintf("migrations/%s.sql", migrationName)
+	migrationFile := filepath.Join(migrationsDir(), migrationName+".sql")
 
 	if err := createMigrationsDir(); err != nil {
 		return fmt.Errorf("failed to create migrations directory: %w", err)
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+// tagsFile records the schema version tags next to schema.prisma
+const tagsFile = "schema-tags.json"
+
+// schemaTag labels the migration state at the time of tagging
+type schemaTag struct {
+	Name       string    `json:"name"`
+	Version    string    `json:"version"`    // Latest migration included in the tag
+	SchemaHash string    `json:"schemaHash"` // Hash of schema.prisma when tagged
+	Created    time.Time `json:"created"`
+}
+
+func TagCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "tag",
+		Usage:     "Label the current migration state with a version such as v2.3.0",
+		ArgsUsage: "[name]",
+		Description: "Record the latest migration under a name in " + tagsFile + ", so 'diff --between' can " +
+			"produce the SQL between two releases. Without a name the tags are listed",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "Move an existing tag to the current state"},
+			&cli.BoolFlag{Name: "delete", Aliases: []string{"d"}, Usage: "Delete the tag"},
+		},
+		Action: func(c *cli.Context) error {
+			tags, err := readTags()
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			name := c.Args().First()
+			switch {
+			case name == "":
+				return listTags(tags)
+			case c.Bool("delete"):
+				if findTag(tags, name) == nil {
+					return cli.Exit("Unknown tag "+name, 1)
+				}
+				var kept []*schemaTag
+				for _, t := range tags {
+					if t.Name != name {
+						kept = append(kept, t)
+					}
+				}
+				if err := writeTags(kept); err != nil {
+					return cli.Exit(err.Error(), 1)
+				}
+				fmt.Println("Deleted tag", name)
+				return nil
+			}
+			return addTag(tags, name, c.Bool("force"))
+		},
+	}
+}
+
+func DiffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Print the SQL delta between two tagged schema versions, or compare two branches",
+		ArgsUsage: "--between <from> [to] | --theirs <branch>",
+		Description: "Build the schema of both tags from their migrations and print the SQL that turns the " +
+			"first into the second; without [to] the latest migration is used. With --migrations the up " +
+			"sections of the migrations in between are printed as they are instead. With --theirs the " +
+			"schema.prisma changes of this branch and another one since their merge base are classified " +
+			"as ours only, theirs only, made on both or conflicting; conflicts exit with status 1",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "between", Usage: "Tag to diff from"},
+			&cli.BoolFlag{Name: "migrations", Usage: "Concatenate the migrations between the tags instead of diffing"},
+			&cli.StringFlag{Name: "theirs", Usage: "Branch or commit to compare this branch with"},
+			&cli.StringFlag{Name: "ours", Usage: "Commit of our side (default: schema.prisma in the working tree)"},
+			&cli.StringFlag{Name: "base", Usage: "Common ancestor (default: the merge base of HEAD and --theirs)"},
+		},
+		Action: func(c *cli.Context) error {
+			if c.String("theirs") != "" {
+				return runThreeWayDiff(c.String("base"), c.String("ours"), c.String("theirs"))
+			}
+			if c.String("between") == "" {
+				return cli.Exit("Pass --between <tag> to diff tags or --theirs <branch> to compare branches", 1)
+			}
+			return runTagDiff(c.String("between"), c.Args().First(), c.Bool("migrations"))
+		},
+	}
+}
+
+func readTags() ([]*schemaTag, error) {
+	content, err := os.ReadFile(tagsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", tagsFile, err)
+	}
+	var tags []*schemaTag
+	if err := json.Unmarshal(content, &tags); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", tagsFile, err)
+	}
+	return tags, nil
+}
+
+func writeTags(tags []*schemaTag) error {
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Version < tags[j].Version })
+	content, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tagsFile, append(content, '\n'), 0o644)
+}
+
+func findTag(tags []*schemaTag, name string) *schemaTag {
+	for _, t := range tags {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func listTags(tags []*schemaTag) error {
+	if len(tags) == 0 {
+		fmt.Println("No tags.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tVERSION\tSCHEMA HASH\tCREATED")
+	for _, t := range tags {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Name, t.Version, orDash(shortHash(t.SchemaHash)),
+			t.Created.Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+// addTag tags the latest migration. schema.prisma must be fully migrated, otherwise the tag would not
+// describe the schema it names.
+func addTag(tags []*schemaTag, name string, force bool) error {
+	if existing := findTag(tags, name); existing != nil && !force {
+		return cli.Exit("Tag "+name+" already exists at "+existing.Version+" - use --force to move it", 1)
+	}
+	files, err := listMigrationFiles(migrationsDir())
+	if err != nil || len(files) == 0 {
+		return cli.Exit("No migrations to tag", 1)
+	}
+	diff, err := pendingSchemaDiff("schema.prisma", migrationsDir())
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if hasSchemaChanges(diff) {
+		return cli.Exit("schema.prisma has changes without a migration - run 'schema-manager generate' first", 1)
+	}
+
+	tag := &schemaTag{Name: name, Version: migrationVersion(files[len(files)-1]), Created: time.Now().UTC()}
+	if content, err := os.ReadFile("schema.prisma"); err == nil {
+		tag.SchemaHash = schema.SchemaHash(content)
+	}
+	var kept []*schemaTag
+	for _, t := range tags {
+		if t.Name != name {
+			kept = append(kept, t)
+		}
+	}
+	if err := writeTags(append(kept, tag)); err != nil {
+		return cli.Exit("Failed to write "+tagsFile+": "+err.Error(), 1)
+	}
+	fmt.Printf("Tagged %s at migration %s\n", name, tag.Version)
+	return nil
+}
+
+func runTagDiff(fromName, toName string, concat bool) error {
+	tags, err := readTags()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	files, err := listMigrationFiles(migrationsDir())
+	if err != nil {
+		return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+	}
+	from := findTag(tags, fromName)
+	if from == nil {
+		return cli.Exit("Unknown tag "+fromName, 1)
+	}
+	toVersion := ""
+	if len(files) > 0 {
+		toVersion = migrationVersion(files[len(files)-1])
+	}
+	if toName != "" {
+		to := findTag(tags, toName)
+		if to == nil {
+			return cli.Exit("Unknown tag "+toName, 1)
+		}
+		toVersion = to.Version
+	} else {
+		toName = "latest"
+	}
+	if from.Version > toVersion {
+		return cli.Exit(fromName+" is newer than "+toName, 1)
+	}
+
+	var between []string
+	for _, f := range files {
+		if v := migrationVersion(f); v > from.Version && v <= toVersion {
+			between = append(between, f)
+		}
+	}
+	fmt.Printf("-- Schema changes from %s (%s) to %s (%s): %d migration(s)\n",
+		fromName, from.Version, toName, toVersion, len(between))
+	for _, f := range between {
+		fmt.Println("--   " + f)
+	}
+	fmt.Println()
+
+	if concat {
+		for _, f := range between {
+			content, err := os.ReadFile(filepath.Join(migrationsDir(), f))
+			if err != nil {
+				return cli.Exit("Failed to read "+f+": "+err.Error(), 1)
+			}
+			up := strings.TrimPrefix(schema.ParseGooseMigration(string(content)).UpSQL, "-- +goose Up")
+			fmt.Printf("-- %s\n%s\n\n", f, strings.TrimSpace(up))
+		}
+		return nil
+	}
+
+	fromSchema, err := schemaAtVersion(files, from.Version)
+	if err != nil {
+		return cli.Exit("Failed to build schema of "+fromName+": "+err.Error(), 1)
+	}
+	toSchema, err := schemaAtVersion(files, toVersion)
+	if err != nil {
+		return cli.Exit("Failed to build schema of "+toName+": "+err.Error(), 1)
+	}
+	diff := schema.DiffSchemas(fromSchema, toSchema)
+	if !hasSchemaChanges(diff) {
+		fmt.Println("-- No schema changes")
+		return nil
+	}
+	fmt.Println(schema.GenerateMigrationSQL(diff))
+	return nil
+}
+
+// schemaAtVersion builds the schema of the migrations up to and including a version
+func schemaAtVersion(files []string, version string) (*schema.Schema, error) {
+	var skip []string
+	for _, f := range files {
+		if migrationVersion(f) > version {
+			skip = append(skip, f)
+		}
+	}
+	if len(skip) == len(files) {
+		return &schema.Schema{}, nil
+	}
+	return schema.ParseMigrationsToSchema(context.Background(), migrationsDir(), skip...)
+}
+
+// runThreeWayDiff classifies the schema.prisma changes of both branches since their common ancestor
+func runThreeWayDiff(base, ours, theirs string) error {
+	if base == "" {
+		ref := ours
+		if ref == "" {
+			ref = "HEAD"
+		}
+		var err error
+		if base, err = gitOutput("merge-base", ref, theirs); err != nil {
+			return cli.Exit("Cannot find the merge base of "+ref+" and "+theirs+": "+err.Error(), 1)
+		}
+	}
+	baseSchema, err := schemaAtCommit(base)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	theirSchema, err := schemaAtCommit(theirs)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	var ourSchema *schema.Schema
+	if ours == "" {
+		ourSchema, err = (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(context.Background())
+	} else {
+		ourSchema, err = schemaAtCommit(ours)
+	}
+	if err != nil {
+		return cli.Exit("Failed to parse our schema.prisma: "+err.Error(), 1)
+	}
+
+	changes := schema.ThreeWayDiff(baseSchema, ourSchema, theirSchema)
+	if len(changes) == 0 {
+		fmt.Println("Neither branch changed schema.prisma")
+		return nil
+	}
+	sections := []struct{ side, title string }{
+		{schema.ChangeOurs, "Ours only"},
+		{schema.ChangeTheirs, "Theirs only"},
+		{schema.ChangeBoth, "Made on both branches (migrate once)"},
+		{schema.ChangeConflict, "Conflicts"},
+	}
+	conflicts := 0
+	for _, section := range sections {
+		var lines []string
+		for _, c := range changes {
+			if c.Side != section.side {
+				continue
+			}
+			switch c.Side {
+			case schema.ChangeOurs:
+				lines = append(lines, fmt.Sprintf("  %s: %s", c.Object, c.Ours))
+			case schema.ChangeTheirs, schema.ChangeBoth:
+				lines = append(lines, fmt.Sprintf("  %s: %s", c.Object, c.Theirs))
+			case schema.ChangeConflict:
+				lines = append(lines, fmt.Sprintf("  %s: ours %s, theirs %s", c.Object, c.Ours, c.Theirs))
+				conflicts++
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Printf("%s:\n%s\n", section.title, strings.Join(lines, "\n"))
+		}
+	}
+	if conflicts > 0 {
+		return cli.Exit(fmt.Sprintf("%d conflicting change(s) - agree on them before rebasing either migration", conflicts), 1)
+	}
+	return nil
+}
+
+// schemaAtCommit parses schema.prisma as of a commit; a commit without the file has an empty schema
+func schemaAtCommit(ref string) (*schema.Schema, error) {
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown commit %s", ref)
+	}
+	content, err := gitOutput("show", ref+":./schema.prisma")
+	if err != nil {
+		return &schema.Schema{}, nil
+	}
+	s, err := (&schema.StringSource{Name: "schema.prisma", Content: content}).LoadSchema(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema.prisma of %s: %w", ref, err)
+	}
+	return s, nil
+}
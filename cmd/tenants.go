@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/lib/pq"
+	"github.com/urfave/cli/v2"
+)
+
+// tenantResult is the outcome of migrating one tenant schema
+type tenantResult struct {
+	Schema  string
+	Applied int
+	Pending int
+	Err     error
+	Skipped bool // Not started because an earlier tenant failed
+}
+
+func TenantsCommand() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "prefix", Usage: "Migrate every schema whose name starts with this prefix, e.g. tenant_"},
+		&cli.StringFlag{Name: "query", Usage: "SQL query returning the tenant schema names in its first column"},
+		&cli.StringFlag{Name: "table", Usage: "Goose version table, kept in each tenant schema", Value: "goose_db_version"},
+	}
+	return &cli.Command{
+		Name:  "tenants",
+		Usage: "Show or apply pending migrations in every tenant schema of a schema-per-tenant database",
+		Description: "Discover the tenant schemas of DATABASE_URL by name prefix or a query and run the " +
+			"migrations in each of them, with the schema as search_path and its own version table",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "status",
+				Usage: "Show applied and pending migrations per tenant schema",
+				Flags: flags,
+				Action: func(c *cli.Context) error {
+					return runTenants(c, false)
+				},
+			},
+			{
+				Name:  "apply",
+				Usage: "Apply pending migrations to every tenant schema",
+				Flags: append(flags,
+					&cli.IntFlag{Name: "parallel", Usage: "Number of tenant schemas migrated at the same time", Value: 1},
+					&cli.BoolFlag{Name: "keep-going", Usage: "Continue with the remaining tenants after a failure"},
+				),
+				Action: func(c *cli.Context) error {
+					return runTenants(c, true)
+				},
+			},
+		},
+	}
+}
+
+func runTenants(c *cli.Context, apply bool) error {
+	databaseURL, err := resolveDatabaseURL()
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if (c.String("prefix") == "") == (c.String("query") == "") {
+		return cli.Exit("Pass either --prefix or --query to find the tenant schemas", 1)
+	}
+	files, err := listMigrationFiles(migrationsDir())
+	if err != nil {
+		return cli.Exit("Failed to read migrations: "+err.Error(), 1)
+	}
+	if duplicates := duplicateVersions(files); len(duplicates) > 0 {
+		return cli.Exit(fmt.Sprintf("Several migrations share version %s - run 'schema-manager renumber'",
+			strings.Join(duplicates, ", ")), 1)
+	}
+
+	db, err := connectWithSSLFallback(databaseURL)
+	if err != nil {
+		return cli.Exit("Failed to connect to database: "+err.Error(), 1)
+	}
+	tenants, err := discoverTenantSchemas(db, c.String("prefix"), c.String("query"))
+	db.Close()
+	if err != nil {
+		return cli.Exit("Failed to find tenant schemas: "+err.Error(), 1)
+	}
+	if len(tenants) == 0 {
+		fmt.Println("No tenant schemas found.")
+		return nil
+	}
+
+	versionTable := c.String("table")
+	var results []*tenantResult
+	if apply {
+		results = applyTenantMigrations(databaseURL, tenants, versionTable, c.Int("parallel"), c.Bool("keep-going"))
+	} else {
+		for _, tenant := range tenants {
+			results = append(results, tenantStatus(databaseURL, tenant, versionTable, files))
+		}
+	}
+
+	failed := printTenantResults(results, apply)
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("%d of %d tenant schema(s) failed", failed, len(tenants)), 1)
+	}
+	return nil
+}
+
+// discoverTenantSchemas lists the schemas starting with prefix, or those returned by query
+func discoverTenantSchemas(db *sql.DB, prefix, query string) ([]string, error) {
+	var rows *sql.Rows
+	var err error
+	if query != "" {
+		rows, err = db.Query(query)
+	} else {
+		pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+		rows, err = db.Query("SELECT schema_name FROM information_schema.schemata WHERE schema_name LIKE $1 "+
+			"ORDER BY schema_name", pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, name)
+	}
+	return schemas, rows.Err()
+}
+
+// tenantDatabaseURL sets the search_path of every connection to the tenant schema, so unqualified
+// names in migrations and the version table resolve inside it
+func tenantDatabaseURL(databaseURL, tenant string) (string, error) {
+	if !strings.HasPrefix(databaseURL, "postgres://") && !strings.HasPrefix(databaseURL, "postgresql://") {
+		return databaseURL + " search_path='" + pq.QuoteIdentifier(tenant) + "'", nil
+	}
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("search_path", pq.QuoteIdentifier(tenant))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func connectTenant(databaseURL, tenant string) (*sql.DB, error) {
+	tenantURL, err := tenantDatabaseURL(databaseURL, tenant)
+	if err != nil {
+		return nil, err
+	}
+	db, err := connectWithSSLFallback(tenantURL)
+	if err != nil {
+		return nil, err
+	}
+	// Migrations of one tenant run one after another; a single connection keeps the session state
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// tenantStatus counts the pending migrations of a tenant without creating its version table
+func tenantStatus(databaseURL, tenant, versionTable string, files []string) *tenantResult {
+	result := &tenantResult{Schema: tenant}
+	db, err := connectTenant(databaseURL, tenant)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer db.Close()
+
+	// Without a version table every migration is pending
+	versions, _ := appliedVersions(db, versionTable)
+	for _, f := range files {
+		if versions[migrationVersion(f)] {
+			result.Applied++
+		} else {
+			result.Pending++
+		}
+	}
+	return result
+}
+
+// applyTenantMigrations migrates the tenants with up to parallel workers. Unless keepGoing is set,
+// tenants not started yet are skipped once one fails.
+func applyTenantMigrations(databaseURL string, tenants []string, versionTable string, parallel int,
+	keepGoing bool) []*tenantResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]*tenantResult, len(tenants))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	failed := false
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				skip := failed && !keepGoing
+				mu.Unlock()
+				if skip {
+					results[i] = &tenantResult{Schema: tenants[i], Skipped: true}
+					continue
+				}
+
+				result := applyTenantSchema(databaseURL, tenants[i], versionTable, &mu)
+				results[i] = result
+				mu.Lock()
+				if result.Err != nil {
+					failed = true
+					fmt.Printf("❌ %s: %v\n", result.Schema, result.Err)
+				} else {
+					fmt.Printf("✅ %s: %d migration(s) applied\n", result.Schema, result.Applied)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range tenants {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// applyTenantSchema applies the pending migrations of one tenant; out serializes its progress output
+// with the other workers
+func applyTenantSchema(databaseURL, tenant, versionTable string, out *sync.Mutex) *tenantResult {
+	result := &tenantResult{Schema: tenant}
+	db, err := connectTenant(databaseURL, tenant)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer db.Close()
+
+	done, pending, err := migrationStates(db, migrationsDir(), versionTable)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if outOfOrder := outOfOrderMigrations(done, pending); len(outOfOrder) > 0 {
+		result.Err = fmt.Errorf("%s predate the latest applied migration %s - run 'schema-manager renumber'",
+			strings.Join(outOfOrder, ", "), done[len(done)-1])
+		result.Pending = len(pending)
+		return result
+	}
+
+	for i, f := range pending {
+		if err := applyMigration(context.Background(), db, migrationsDir(), f, versionTable, backupPolicy{}, applyLimits{}); err != nil {
+			result.Err = err
+			result.Pending = len(pending) - i
+			return result
+		}
+		result.Applied++
+		out.Lock()
+		fmt.Printf("  %s: applied %s\n", tenant, f)
+		out.Unlock()
+	}
+	return result
+}
+
+// printTenantResults prints one row per tenant and returns the number of failed tenants
+func printTenantResults(results []*tenantResult, apply bool) int {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if apply {
+		fmt.Fprintln(w, "TENANT\tAPPLIED\tSTATUS")
+	} else {
+		fmt.Fprintln(w, "TENANT\tAPPLIED\tPENDING\tSTATUS")
+	}
+	failed := 0
+	for _, r := range results {
+		status := "up to date"
+		switch {
+		case r.Skipped:
+			status = "skipped"
+		case r.Err != nil:
+			status = "failed: " + r.Err.Error()
+			failed++
+		case r.Pending > 0:
+			status = fmt.Sprintf("%d pending", r.Pending)
+		}
+		if apply {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", r.Schema, r.Applied, status)
+		} else {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", r.Schema, r.Applied, r.Pending, status)
+		}
+	}
+	w.Flush()
+	return failed
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change of a line diff
+const diffContextLines = 3
+
+// lineDiff returns a unified diff of two texts, "" when they are equal
+func lineDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	type diffLine struct {
+		kind         byte // ' ', '-' or '+'
+		text         string
+		oldNo, newNo int // 1-based line numbers before and after
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i + 1, j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i], i + 1, j + 1})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j], i + 1, j + 1})
+			j++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- " + oldName + "\n+++ " + newName + "\n")
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			start++
+			continue
+		}
+		// A hunk runs from the context before a change to the context after the last change that is
+		// closer than two contexts to the previous one
+		from := max(start-diffContextLines, 0)
+		end := start
+		for k := start; k < len(lines) && k <= end+2*diffContextLines; k++ {
+			if lines[k].kind != ' ' {
+				end = k
+			}
+		}
+		to := min(end+diffContextLines+1, len(lines))
+
+		oldCount, newCount := 0, 0
+		for _, l := range lines[from:to] {
+			if l.kind != '+' {
+				oldCount++
+			}
+			if l.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", lines[from].oldNo, oldCount, lines[from].newNo, newCount)
+		for _, l := range lines[from:to] {
+			sb.WriteString(string(l.kind) + l.text + "\n")
+		}
+		start = to
+	}
+	return sb.String()
+}
+
+// splitLines splits a text into lines without their line breaks
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phathdt/schema-manager/internal/logger"
+	"github.com/urfave/cli/v2"
+)
+
+// releasesAPI is the GitHub API of the releases the upgrade command installs from
+const releasesAPI = "https://api.github.com/repos/phathdt/schema-manager/releases"
+
+// versionCheckInterval is how long the latest version found by the verbose-mode check is trusted
+const versionCheckInterval = 24 * time.Hour
+
+// githubRelease is the part of a GitHub release the upgrade command reads
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of a release asset, "" when the release doesn't have it
+func (r *githubRelease) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+func UpgradeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "upgrade",
+		Usage: "Replace this binary with the latest release from GitHub",
+		Description: "Download the release binary for this platform, verify it against the checksum " +
+			"published with the release and replace the running executable with it",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "check", Usage: "Only report whether a newer version is available"},
+			&cli.StringFlag{Name: "version", Usage: "Install this release, e.g. v1.4.0, instead of the latest"},
+			&cli.BoolFlag{Name: "force", Usage: "Install even when the release is not newer"},
+		},
+		Action: func(c *cli.Context) error {
+			return runUpgrade(c.Context, c.String("version"), c.Bool("check"), c.Bool("force"))
+		},
+	}
+}
+
+func runUpgrade(ctx context.Context, version string, checkOnly, force bool) error {
+	release, err := fetchRelease(ctx, version)
+	if err != nil {
+		return cli.Exit("Failed to look up the release: "+err.Error(), 1)
+	}
+	newer := isNewerVersion(release.TagName, Version)
+	if checkOnly {
+		if newer {
+			fmt.Printf("schema-manager %s is available (current: %s) - run 'schema-manager upgrade'\n",
+				release.TagName, Version)
+		} else {
+			fmt.Printf("schema-manager %s is up to date\n", Version)
+		}
+		return nil
+	}
+	if !newer && !force && version == "" {
+		fmt.Printf("schema-manager %s is up to date\n", Version)
+		return nil
+	}
+
+	asset := releaseAssetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, checksumURL := release.assetURL(asset), release.assetURL(asset+".sha256")
+	if binaryURL == "" {
+		return cli.Exit(fmt.Sprintf("Release %s has no binary for %s/%s (%s)", release.TagName, runtime.GOOS,
+			runtime.GOARCH, asset), 1)
+	}
+	if checksumURL == "" {
+		return cli.Exit(fmt.Sprintf("Release %s publishes no checksum for %s; download it manually", release.TagName,
+			asset), 1)
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return cli.Exit("Cannot locate the running executable: "+err.Error(), 1)
+	}
+	fmt.Printf("Downloading %s %s...\n", asset, release.TagName)
+	expected, err := downloadChecksum(ctx, checksumURL)
+	if err != nil {
+		return cli.Exit("Failed to download the checksum: "+err.Error(), 1)
+	}
+	// The new binary is written next to the old one so the final rename stays on one file system
+	tmp, err := downloadVerified(ctx, binaryURL, filepath.Dir(exe), expected)
+	if err != nil {
+		return cli.Exit("Failed to download the binary: "+err.Error(), 1)
+	}
+	if err := replaceExecutable(exe, tmp); err != nil {
+		os.Remove(tmp)
+		return cli.Exit("Failed to replace "+exe+": "+err.Error(), 1)
+	}
+	fmt.Printf("✅ Upgraded %s from %s to %s\n", exe, Version, release.TagName)
+	return nil
+}
+
+// fetchRelease returns the latest release, or the one tagged version
+func fetchRelease(ctx context.Context, version string) (*githubRelease, error) {
+	url := releasesAPI + "/latest"
+	if version != "" {
+		url = releasesAPI + "/tags/" + version
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		// Lifts the rate limit of anonymous requests, e.g. on shared CI runners
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// releaseAssetName returns the name of the release binary built for a platform by the release workflow
+func releaseAssetName(goos, goarch string) string {
+	name := "schema-manager-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// downloadChecksum returns the SHA-256 of a sha256sum-formatted checksum file
+func downloadChecksum(ctx context.Context, url string) (string, error) {
+	body, err := httpGet(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	line, err := bufio.NewReader(io.LimitReader(body, 1024)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return "", fmt.Errorf("malformed checksum file %s", url)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// downloadVerified downloads a file into dir and returns its path once its SHA-256 matches expected
+func downloadVerified(ctx context.Context, url, dir, expected string) (string, error) {
+	body, err := httpGet(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	f, err := os.CreateTemp(dir, ".schema-manager-upgrade-*")
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+			err = fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+		}
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o755)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func httpGet(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// replaceExecutable moves the downloaded binary over the running one. Windows can't overwrite a
+// running executable but can rename it, so the old binary is moved aside first.
+func replaceExecutable(exe, downloaded string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(downloaded, exe)
+	}
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(downloaded, exe); err != nil {
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+// isNewerVersion reports whether release version latest is newer than current. Development builds
+// without a release version are never up to date.
+func isNewerVersion(latest, current string) bool {
+	l, ok := parseReleaseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseReleaseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseReleaseVersion parses a vX.Y.Z release version
+func parseReleaseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// versionCheckCache is the latest release found by the verbose-mode check
+type versionCheckCache struct {
+	Latest  string    `json:"latest"`
+	Checked time.Time `json:"checked"`
+}
+
+func versionCheckCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "schema-manager", "latest-version.json"), nil
+}
+
+// hintNewVersion logs a hint when the cached latest release is newer than this binary and refreshes a
+// stale cache in the background, so the command never waits for GitHub. A refresh cut short by the
+// command exiting is retried on the next verbose run.
+func hintNewVersion() {
+	path, err := versionCheckCachePath()
+	if err != nil {
+		return
+	}
+	var cache versionCheckCache
+	if b, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(b, &cache)
+	}
+	if isNewerVersion(cache.Latest, Version) {
+		logger.Info("schema-manager %s is available (current: %s) - run 'schema-manager upgrade'", cache.Latest, Version)
+	}
+	if time.Since(cache.Checked) < versionCheckInterval {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		release, err := fetchRelease(ctx, "")
+		if err != nil {
+			logger.Debug("Version check failed: %v", err)
+			return
+		}
+		b, _ := json.Marshal(versionCheckCache{Latest: release.TagName, Checked: time.Now()})
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return
+		}
+		// Written through a temporary file so an interrupted write never leaves a truncated cache
+		tmp := path + ".tmp"
+		if os.WriteFile(tmp, b, 0o644) == nil {
+			os.Rename(tmp, path)
+		}
+	}()
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/phathdt/schema-manager/internal/schema"
+	"github.com/phathdt/schema-manager/pkg/schema"
 	"github.com/urfave/cli/v2"
 )
 
@@ -12,13 +12,54 @@ func ValidateCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "validate",
 		Usage: "Validate Prisma schema",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "fix", Usage: "Insert missing back-relation fields into schema.prisma"},
+			&cli.BoolFlag{
+				Name:  "prisma-compat",
+				Usage: "Also report constructs the Prisma client rejects and Prisma features schema-manager ignores",
+			},
+		},
 		Action: func(c *cli.Context) error {
 			ctx := context.Background()
 			prismaSource := &schema.PrismaFileSource{Path: "schema.prisma"}
-			_, err := prismaSource.LoadSchema(ctx)
+			s, err := prismaSource.LoadSchema(ctx)
 			if err != nil {
 				return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
 			}
+			if missing := schema.FindMissingBackRelations(s); len(missing) > 0 {
+				if c.Bool("fix") {
+					if err := schema.FixMissingBackRelations(prismaSource.Path, missing, s.Generator); err != nil {
+						return cli.Exit("Failed to fix schema.prisma: "+err.Error(), 1)
+					}
+					fmt.Printf("Added %d missing back-relation field(s) to %s\n", len(missing), prismaSource.Path)
+					if s, err = prismaSource.LoadSchema(ctx); err != nil {
+						return cli.Exit("Failed to parse schema.prisma: "+err.Error(), 1)
+					}
+				} else {
+					for _, r := range missing {
+						fmt.Printf("%s:%d: warning: relation %s.%s has no back-relation field on model %s (run validate --fix)\n",
+							prismaSource.Path, r.Field.Line, r.Model.Name, r.Field.Name, r.RelatedModel.Name)
+					}
+				}
+			}
+			problems := 0
+			errs := append(schema.ValidateSchema(s, prismaSource.Path), schema.CheckSchemaRules(s, prismaSource.Path)...)
+			if c.Bool("prisma-compat") {
+				compat, err := schema.CheckPrismaCompat(prismaSource.Path)
+				if err != nil {
+					return cli.Exit("Failed to read schema.prisma: "+err.Error(), 1)
+				}
+				errs = append(errs, compat...)
+			}
+			for _, e := range errs {
+				fmt.Println(e.Error())
+				if !e.Warning {
+					problems++
+				}
+			}
+			if problems > 0 {
+				return cli.Exit(fmt.Sprintf("Schema invalid: %d problem(s) found", problems), 1)
+			}
 			fmt.Println("Schema valid")
 			return nil
 		},
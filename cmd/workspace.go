@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phathdt/schema-manager/pkg/schema"
+	"github.com/urfave/cli/v2"
+)
+
+// workspaceProject is a service with its own schema.prisma and migrations, listed in the workspace file
+type workspaceProject struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// DatabaseURLEnv names the environment variable holding the DATABASE_URL of the project
+	DatabaseURLEnv string `json:"databaseUrlEnv,omitempty"`
+}
+
+type workspaceConfig struct {
+	Projects []*workspaceProject `json:"projects"`
+}
+
+func WorkspaceCommand() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "config", Usage: "Workspace file listing the projects", Value: "schema-workspace.json"},
+		&cli.StringSliceFlag{Name: "project", Aliases: []string{"p"}, Usage: "Only these projects (repeatable)"},
+	}
+	return &cli.Command{
+		Name:  "workspace",
+		Usage: "Run status, check or plan across every project of a workspace",
+		Description: "Read the projects of schema-workspace.json and aggregate the result of a command over " +
+			"all of them, e.g. to audit the migrations of many services at once",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "status",
+				Usage: "Show migrations, unmigrated schema changes and pending database migrations per project",
+				Flags: flags,
+				Action: func(c *cli.Context) error {
+					projects, err := loadWorkspace(c.String("config"), c.StringSlice("project"))
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					return workspaceStatus(projects)
+				},
+			},
+			{
+				Name:  "check",
+				Usage: "Fail if any project has schema changes without a migration",
+				Flags: flags,
+				Action: func(c *cli.Context) error {
+					projects, err := loadWorkspace(c.String("config"), c.StringSlice("project"))
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					return workspaceCheck(projects)
+				},
+			},
+			{
+				Name:  "plan",
+				Usage: "Print the migration generate would create in each project",
+				Flags: flags,
+				Action: func(c *cli.Context) error {
+					projects, err := loadWorkspace(c.String("config"), c.StringSlice("project"))
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+					return workspacePlan(projects)
+				},
+			},
+		},
+	}
+}
+
+// loadWorkspace reads the workspace file, resolving project paths relative to it, and keeps only the
+// named projects when names are given
+func loadWorkspace(path string, names []string) ([]*workspaceProject, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace file: %w", err)
+	}
+	var config workspaceConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("invalid workspace file %s: %w", path, err)
+	}
+
+	var projects []*workspaceProject
+	for _, p := range config.Projects {
+		if p.Path == "" {
+			return nil, fmt.Errorf("project %q in %s has no path", p.Name, path)
+		}
+		if p.Name == "" {
+			p.Name = filepath.Base(p.Path)
+		}
+		if !filepath.IsAbs(p.Path) {
+			p.Path = filepath.Join(filepath.Dir(path), p.Path)
+		}
+		if len(names) == 0 || containsName(names, p.Name) {
+			projects = append(projects, p)
+		}
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("no projects to run in %s", path)
+	}
+	return projects, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *workspaceProject) schemaDiff() (*schema.SchemaDiff, error) {
+	return pendingSchemaDiff(filepath.Join(p.Path, "schema.prisma"), migrationsDirFor(filepath.Join(p.Path, "schema.prisma")))
+}
+
+// pendingMigrations reads the goose version table of the project database; ok is false when the project
+// has no database configured
+func (p *workspaceProject) pendingMigrations(files []string) (pending int, ok bool, err error) {
+	if p.DatabaseURLEnv == "" || os.Getenv(p.DatabaseURLEnv) == "" {
+		return 0, false, nil
+	}
+	db, err := connectWithSSLFallback(os.Getenv(p.DatabaseURLEnv))
+	if err != nil {
+		return 0, true, err
+	}
+	defer db.Close()
+	versions, err := appliedVersions(db, "goose_db_version")
+	if err != nil {
+		// Without a version table every migration is pending
+		return len(files), true, nil
+	}
+	for _, f := range files {
+		if !versions[migrationVersion(f)] {
+			pending++
+		}
+	}
+	return pending, true, nil
+}
+
+func workspaceStatus(projects []*workspaceProject) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tMIGRATIONS\tLATEST\tSCHEMA\tDATABASE")
+	for _, p := range projects {
+		files, _ := listMigrationFiles(migrationsDirFor(filepath.Join(p.Path, "schema.prisma")))
+		latest := "-"
+		if len(files) > 0 {
+			latest = migrationVersion(files[len(files)-1])
+		}
+
+		schemaState := "up to date"
+		if diff, err := p.schemaDiff(); err != nil {
+			schemaState = "error: " + err.Error()
+		} else if changes := describeSchemaDiff(diff); len(changes) > 0 {
+			schemaState = fmt.Sprintf("%d unmigrated change(s)", len(changes))
+		}
+
+		dbState := "-"
+		if pending, ok, err := p.pendingMigrations(files); err != nil {
+			dbState = "unreachable"
+		} else if ok && pending > 0 {
+			dbState = fmt.Sprintf("%d pending", pending)
+		} else if ok {
+			dbState = "up to date"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", p.Name, len(files), latest, schemaState, dbState)
+	}
+	return w.Flush()
+}
+
+func workspaceCheck(projects []*workspaceProject) error {
+	failed := 0
+	for _, p := range projects {
+		diff, err := p.schemaDiff()
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", p.Name, err)
+			failed++
+			continue
+		}
+		if !hasSchemaChanges(diff) {
+			fmt.Printf("✅ %s\n", p.Name)
+			continue
+		}
+		fmt.Printf("❌ %s has changes that are not represented by any migration:\n", p.Name)
+		for _, change := range describeSchemaDiff(diff) {
+			fmt.Printf("  • %s\n", change)
+		}
+		failed++
+	}
+
+	fmt.Printf("\n%d of %d project(s) up to date\n", len(projects)-failed, len(projects))
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("%d project(s) need a migration", failed), 1)
+	}
+	return nil
+}
+
+func workspacePlan(projects []*workspaceProject) error {
+	var changed, failed []string
+	for _, p := range projects {
+		diff, err := p.schemaDiff()
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n\n", p.Name, err)
+			failed = append(failed, p.Name)
+			continue
+		}
+		if !hasSchemaChanges(diff) {
+			continue
+		}
+		changed = append(changed, p.Name)
+		fmt.Printf("=== %s (%s)\n", p.Name, p.Path)
+		for _, line := range summarizeSchemaDiff(diff) {
+			fmt.Println("  " + line.String())
+		}
+		fmt.Printf("\n%s\n\n", schema.GenerateMigrationSQL(diff))
+	}
+
+	if len(changed) == 0 {
+		fmt.Println("No changes in any project.")
+	} else {
+		fmt.Printf("%d project(s) would get a migration: %s\n", len(changed), strings.Join(changed, ", "))
+	}
+	if len(failed) > 0 {
+		return cli.Exit("Failed to plan "+strings.Join(failed, ", "), 1)
+	}
+	return nil
+}
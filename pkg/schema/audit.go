@@ -0,0 +1,90 @@
+package schema
+
+import "strings"
+
+// AuditFunctionName is the trigger function that sets the updated_at column of audited models; the
+// column name is passed as trigger argument so mapped columns work too
+const AuditFunctionName = "schema_manager_set_updated_at"
+
+const auditFunctionDefinition = `CREATE OR REPLACE FUNCTION ` + AuditFunctionName + `() RETURNS trigger AS $$
+BEGIN
+  NEW := jsonb_populate_record(NEW, jsonb_build_object(TG_ARGV[0], now()));
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql`
+
+// Fields added to audited models that don't declare them
+const (
+	auditCreatedAtField = `createdAt DateTime @default(now()) @map("created_at")`
+	auditUpdatedAtField = `updatedAt DateTime @default(now()) @updatedAt @map("updated_at")`
+)
+
+// applyAuditColumns adds createdAt and updatedAt fields to every model without @@noAudit when the
+// generator enables auditColumns, and a trigger keeping updatedAt current on UPDATE. It runs after
+// the naming strategy so existing fields are found by their final column names.
+func applyAuditColumns(s *Schema) {
+	if !s.Generator.AuditColumns {
+		return
+	}
+	for _, m := range s.Models {
+		if hasModelAttribute(m, "noAudit") {
+			continue
+		}
+		if findAuditField(m, "createdAt", "created_at") == nil {
+			m.Fields = append(m.Fields, parseField(auditCreatedAtField))
+		}
+		updatedAt := findAuditField(m, "updatedAt", "updated_at")
+		if updatedAt == nil {
+			updatedAt = parseField(auditUpdatedAtField)
+			m.Fields = append(m.Fields, updatedAt)
+		}
+
+		name := limitIdentifier(strings.ToLower(m.TableName) + "_set_updated_at")
+		if findTrigger(s.Triggers, name) != nil {
+			continue
+		}
+		s.Triggers = append(s.Triggers, &Trigger{
+			Name:     name,
+			Model:    m.Name,
+			Events:   "UPDATE",
+			Function: AuditFunctionName + "('" + updatedAt.ColumnName + "')",
+		})
+	}
+}
+
+// addAuditFunction adds the trigger function of audit columns unless sql/functions overrides it
+func addAuditFunction(s *Schema) {
+	if !s.Generator.AuditColumns {
+		return
+	}
+	for _, fn := range s.Functions {
+		if fn.Name == AuditFunctionName {
+			return
+		}
+	}
+	for _, t := range s.Triggers {
+		if strings.HasPrefix(t.Function, AuditFunctionName+"(") {
+			s.Functions = append(s.Functions, parseFunctionDefinition(auditFunctionDefinition))
+			return
+		}
+	}
+}
+
+// findAuditField finds a field by its name or column name
+func findAuditField(m *Model, name, column string) *Field {
+	for _, f := range m.Fields {
+		if f.Name == name || f.ColumnName == column {
+			return f
+		}
+	}
+	return nil
+}
+
+func findTrigger(triggers []*Trigger, name string) *Trigger {
+	for _, t := range triggers {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultBackfillBatchSize is the number of rows a batched backfill updates per transaction when the
+// generator block doesn't set backfillBatchSize
+const DefaultBackfillBatchSize = 10000
+
+// stableDefaultFunctions are the function defaults PostgreSQL evaluates once when a column is added,
+// so ADD COLUMN stores them without rewriting the table
+var stableDefaultFunctions = []string{"NOW()", "CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "LOCALTIMESTAMP", "LOCALTIME"}
+
+// BackfillBatch returns the number of rows a batched backfill updates per transaction
+func (g GeneratorConfig) BackfillBatch() int {
+	if g.BackfillBatchSize > 0 {
+		return g.BackfillBatchSize
+	}
+	return DefaultBackfillBatchSize
+}
+
+// GenerateBatchedUpdateSQL returns a DO block running UPDATE table SET assignment on the rows matching
+// where, batchSize rows at a time with a commit after each batch, so no long transaction holds row
+// locks or bloats the table. The block can't run inside a transaction.
+func GenerateBatchedUpdateSQL(table, assignment, where string, batchSize int) string {
+	return fmt.Sprintf(`DO $$
+DECLARE
+  updated integer;
+BEGIN
+  LOOP
+    UPDATE %[1]s SET %[2]s
+    WHERE ctid = ANY (ARRAY(SELECT ctid FROM %[1]s WHERE %[3]s LIMIT %[4]d));
+    GET DIAGNOSTICS updated = ROW_COUNT;
+    EXIT WHEN updated = 0;
+    COMMIT;
+  END LOOP;
+END
+$$;`, quoteIdent(table), assignment, where, batchSize)
+}
+
+// columnDefaultSQL returns the SQL default of a field, "" when it has none or it is generated by a sequence
+func columnDefaultSQL(f *Field, enum *Enum) string {
+	attr := findFieldAttribute(f, "default")
+	if attr == nil || len(attr.Args) == 0 || attr.Args[0] == "autoincrement()" {
+		return ""
+	}
+	if _, ok := parseDbGenerated(attr.Args[0]); !ok && enum != nil {
+		return "'" + strings.Trim(attr.Args[0], "\"") + "'"
+	}
+	return parseDefaultValue(attr.Args[0], f.Type)
+}
+
+// isVolatileDefault reports whether ADD COLUMN with a default computes it for every row, rewriting the
+// table: any function call other than the current date and time
+func isVolatileDefault(value string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	if strings.HasPrefix(upper, "'") || !strings.Contains(upper, "(") {
+		return false
+	}
+	return !containsString(stableDefaultFunctions, upper)
+}
+
+// addColumnBackfillSQL returns the statements adding a NOT NULL column with a volatile default without
+// rewriting the table: the column is added empty, existing rows are filled in committed batches and
+// NOT NULL is set last. It returns nil unless backfillBatchSize is set and the column needs it.
+func addColumnBackfillSQL(fc *FieldChange, generator GeneratorConfig) []string {
+	f := fc.Field
+	if generator.BackfillBatchSize <= 0 || f.IsOptional || f.IsArray || f.SearchVector != nil ||
+		findFieldAttribute(f, "id") != nil || findFieldAttribute(f, "relation") != nil {
+		return nil
+	}
+	value := columnDefaultSQL(f, nil)
+	if !isVolatileDefault(value) {
+		return nil
+	}
+	table, column := quoteIdent(fc.ModelName), quoteIdent(f.ColumnName)
+	stmts := []string{
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, goTypeToSQLType(f.Type, false, f.Attributes)),
+		// New rows get the default from now on, existing ones from the batches
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, value),
+		GenerateBatchedUpdateSQL(fc.ModelName, column+" = "+value, column+" IS NULL", generator.BackfillBatch()),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, column),
+	}
+	if findFieldAttribute(f, "unique") != nil {
+		idxName := generator.IndexName(fc.ModelName, []string{f.ColumnName}, true)
+		stmts = append(stmts, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s(%s);", idxName, table, column))
+	}
+	return stmts
+}
+
+// BackfillsNulls reports whether making a column required replaces its NULL values by the default
+// before setting NOT NULL, so the change can't fail on them
+func (fc *FieldChange) BackfillsNulls(generator GeneratorConfig) bool {
+	return notNullBackfillSQL(fc, generator) != nil
+}
+
+// notNullBackfillSQL returns the statements making an optional column required: its NULL values are
+// replaced by the column default in committed batches before NOT NULL is set. It returns nil unless
+// backfillBatchSize is set and the column has a default to fill in.
+func notNullBackfillSQL(fc *FieldChange, generator GeneratorConfig) []string {
+	if generator.BackfillBatchSize <= 0 || !fc.CurrentField.IsOptional || fc.Field.IsOptional {
+		return nil
+	}
+	value := columnDefaultSQL(fc.Field, fc.TargetEnum)
+	if value == "" {
+		return nil
+	}
+	table, column := quoteIdent(fc.ModelName), quoteIdent(fc.Field.ColumnName)
+	return []string{
+		GenerateBatchedUpdateSQL(fc.ModelName, column+" = "+value, column+" IS NULL", generator.BackfillBatch()),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, column),
+	}
+}
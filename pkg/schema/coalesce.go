@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"strings"
+)
+
+// columnChange is the SQL of one column change of a table, with the warning it carries
+type columnChange struct {
+	table   string
+	sql     string
+	warning string
+}
+
+// coalesceColumnChanges turns the column changes of a migration into one goose statement per table,
+// in the order the tables first appear. The ALTER TABLE actions of a table are combined into a single
+// statement, so the table is locked, and rewritten by type changes, once rather than per column.
+// Comments go before the statement and other statements, such as the indexes of added columns, after it.
+func coalesceColumnChanges(changes []*columnChange) []string {
+	var tables []string
+	byTable := map[string][]*columnChange{}
+	for _, c := range changes {
+		if c.sql == "" {
+			continue
+		}
+		if _, ok := byTable[c.table]; !ok {
+			tables = append(tables, c.table)
+		}
+		byTable[c.table] = append(byTable[c.table], c)
+	}
+
+	var stmts []string
+	for _, table := range tables {
+		prefix := "ALTER TABLE " + quoteIdent(table) + " "
+		var comments, actions, after, warnings []string
+		for _, c := range byTable[table] {
+			if c.warning != "" {
+				warnings = append(warnings, c.warning)
+			}
+			var stmt []string
+			for _, line := range strings.Split(c.sql, "\n") {
+				if strings.HasPrefix(strings.TrimSpace(line), "--") {
+					comments = append(comments, line)
+					continue
+				}
+				stmt = append(stmt, line)
+				if !strings.HasSuffix(strings.TrimSpace(line), ";") {
+					continue
+				}
+				sql := strings.Join(stmt, "\n")
+				stmt = nil
+				if strings.HasPrefix(sql, prefix) && !strings.HasPrefix(sql, prefix+"RENAME ") {
+					actions = append(actions, strings.TrimSuffix(strings.TrimPrefix(sql, prefix), ";"))
+				} else {
+					after = append(after, sql)
+				}
+			}
+		}
+
+		parts := comments
+		switch len(actions) {
+		case 0:
+		case 1:
+			parts = append(parts, prefix+actions[0]+";")
+		default:
+			parts = append(parts, strings.TrimSpace(prefix)+"\n  "+strings.Join(actions, ",\n  ")+";")
+		}
+		sql := strings.Join(append(parts, after...), "\n")
+		if len(warnings) > 0 {
+			stmts = append(stmts, wrapGooseStatementWithWarning(sql, strings.Join(warnings, "\n-- WARNING: ")))
+		} else {
+			stmts = append(stmts, wrapGooseStatement(sql))
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CommentChange is a changed /// doc comment of an existing table, or of a column when Column is set.
+// An empty comment removes the database comment.
+type CommentChange struct {
+	TableName      string
+	Column         string
+	Comment        string
+	CurrentComment string
+}
+
+var commentOnRegex = regexp.MustCompile(`^COMMENT ON (TABLE|COLUMN)\s+` + identPattern + `(?:\.` + identPattern + `)?\s+IS\s+(NULL|'(?:[^']|'')*')$`)
+
+// CommentStatement represents a COMMENT ON TABLE or COMMENT ON COLUMN SQL statement
+type CommentStatement struct {
+	TableName string
+	Column    string
+	Comment   string
+}
+
+func (c *CommentStatement) Apply(schema *Schema) error {
+	for _, model := range schema.Models {
+		if model.TableName != c.TableName {
+			continue
+		}
+		if c.Column == "" {
+			model.Comment = c.Comment
+			return nil
+		}
+		for _, f := range model.Fields {
+			if f.ColumnName == c.Column {
+				f.Comment = c.Comment
+			}
+		}
+		return nil
+	}
+	return nil // Table not found - be permissive like ALTER TABLE
+}
+
+func (c *CommentStatement) String() string {
+	if c.Column != "" {
+		return "COMMENT ON COLUMN " + c.TableName + "." + c.Column
+	}
+	return "COMMENT ON TABLE " + c.TableName
+}
+
+// parseComment parses COMMENT ON TABLE and COMMENT ON COLUMN statements; comments on other objects
+// are skipped
+func parseComment(sql string) (*CommentStatement, error) {
+	matches := commentOnRegex.FindStringSubmatch(strings.TrimSuffix(sql, ";"))
+	if matches == nil || (matches[1] == "COLUMN") != (matches[3] != "") {
+		return nil, nil
+	}
+	stmt := &CommentStatement{TableName: normalizeIdent(matches[2])}
+	if matches[3] != "" {
+		stmt.Column = normalizeIdent(matches[3])
+	}
+	if matches[4] != "NULL" {
+		stmt.Comment = strings.ReplaceAll(strings.Trim(matches[4], "'"), "''", "'")
+	}
+	return stmt, nil
+}
+
+// docComment joins the lines of a /// doc comment, collapsing whitespace the way the migration parser does
+func docComment(lines []string) string {
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// diffComments compares the doc comments of a table and the columns it keeps
+func diffComments(current, target *Model) []*CommentChange {
+	var changes []*CommentChange
+	if current.Comment != target.Comment {
+		changes = append(changes, &CommentChange{TableName: target.TableName, Comment: target.Comment,
+			CurrentComment: current.Comment})
+	}
+	for _, tField := range target.Fields {
+		for _, cField := range current.Fields {
+			if cField.ColumnName == tField.ColumnName && cField.Comment != tField.Comment {
+				changes = append(changes, &CommentChange{TableName: target.TableName, Column: tField.ColumnName,
+					Comment: tField.Comment, CurrentComment: cField.Comment})
+			}
+		}
+	}
+	return changes
+}
+
+// generateCommentSQL sets the comment of a table, or of one of its columns, dropping it when comment is empty
+func generateCommentSQL(table, column, comment string) string {
+	target := "TABLE " + quoteIdent(table)
+	if column != "" {
+		target = "COLUMN " + quoteIdent(table) + "." + quoteIdent(column)
+	}
+	value := "NULL"
+	if comment != "" {
+		value = "'" + strings.ReplaceAll(comment, "'", "''") + "'"
+	}
+	return "COMMENT ON " + target + " IS " + value + ";"
+}
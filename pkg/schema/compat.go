@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Blocks and attributes schema-manager adds to the Prisma schema language
+var (
+	managerBlocks          = []string{"trigger", "policy", "sequence"}
+	managerModelAttributes = []string{"rls", "grant", "partition", "noAudit"}
+	managerFieldAttributes = []string{"using", "fulltext"}
+)
+
+// prismaClientDefaults are @default functions the Prisma client evaluates; the column gets no database
+// default from them
+var prismaClientDefaults = []string{"uuid", "cuid", "nanoid", "ulid"}
+
+var (
+	blockHeaderRegex         = regexp.MustCompile(`^(\w+)\s+(\w+)\s*\{`)
+	prismaClientDefaultRegex = regexp.MustCompile(`@default\(\s*(\w+)\(`)
+	generatorStringRegex     = regexp.MustCompile(`^(?:"(?:[^"\\]|\\.)*"|\[.*\]|env\(.*\))$`)
+)
+
+// CheckPrismaCompat reads a schema file and reports what would keep it from being shared with the
+// Prisma client: constructs schema-manager accepts but Prisma rejects are errors, Prisma features
+// schema-manager ignores are warnings
+func CheckPrismaCompat(path string) ([]*ValidationError, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var errs []*ValidationError
+	report := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{File: path, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{File: path, Line: line, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	type blockLine struct {
+		line int
+		text string
+	}
+	var previewFeatures []string
+	var views, extensions []blockLine
+	block, blockName := "", ""
+	for i, raw := range strings.Split(string(b), "\n") {
+		lineNo := i + 1
+		l := strings.TrimSpace(removeInlineComments(raw))
+		if l == "" {
+			continue
+		}
+		if block == "" {
+			matches := blockHeaderRegex.FindStringSubmatch(l)
+			if matches == nil {
+				continue
+			}
+			block, blockName = matches[1], matches[2]
+			switch {
+			case containsString(managerBlocks, block):
+				report(lineNo, "Prisma doesn't support %s blocks; keep %s %s in a migration created with 'schema-manager empty'",
+					block, block, blockName)
+			case block == "view":
+				views = append(views, blockLine{lineNo, blockName})
+			case block == "type":
+				report(lineNo, "Prisma supports composite types only on MongoDB, so the Prisma client rejects type %s",
+					blockName)
+			}
+			continue
+		}
+		if l == "}" {
+			block = ""
+			continue
+		}
+
+		switch block {
+		case "generator":
+			key, value, _ := strings.Cut(l, "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if key == "previewFeatures" {
+				for _, f := range splitComplexArgs(strings.Trim(value, "[]")) {
+					previewFeatures = append(previewFeatures, strings.Trim(strings.TrimSpace(f), "\""))
+				}
+			}
+			if value != "" && !generatorStringRegex.MatchString(value) {
+				report(lineNo, "Prisma only accepts strings and arrays of strings in generator blocks; write %s = \"%s\"",
+					key, value)
+			}
+		case "datasource":
+			key, value := parseBlockValue(l)
+			switch key {
+			case "extensions":
+				extensions = append(extensions, blockLine{lineNo, value})
+			case "provider":
+				if value != "postgresql" {
+					warn(lineNo, "schema-manager generates PostgreSQL migrations, not %s", value)
+				}
+			}
+		case "model", "view":
+			if strings.HasPrefix(l, "@@") {
+				attr := parseModelAttribute(l)
+				switch {
+				case containsString(managerModelAttributes, attr.Name):
+					report(lineNo, "Prisma doesn't know @@%s on %s %s", attr.Name, block, blockName)
+				case attr.Name == "schema":
+					warn(lineNo, "@@schema on %s is ignored: schema-manager creates tables in the search_path schema",
+						blockName)
+				case attr.Name == "ignore":
+					warn(lineNo, "@@ignore only hides %s from the Prisma client; schema-manager still manages its table",
+						blockName)
+				}
+				continue
+			}
+			f := parseField(l)
+			if f == nil {
+				continue
+			}
+			for _, attr := range f.Attributes {
+				switch {
+				case containsString(managerFieldAttributes, attr.Name):
+					report(lineNo, "Prisma doesn't know @%s on %s.%s", attr.Name, blockName, f.Name)
+				case attr.Name == "ignore":
+					warn(lineNo, "@ignore only hides %s.%s from the Prisma client; schema-manager still manages its column",
+						blockName, f.Name)
+				}
+			}
+			if matches := prismaClientDefaultRegex.FindStringSubmatch(l); matches != nil &&
+				containsString(prismaClientDefaults, matches[1]) {
+				warn(lineNo, "@default(%s()) on %s.%s is generated by the Prisma client, so the column has no database "+
+					"default for rows inserted by other clients; use @default(dbgenerated(\"gen_random_uuid()\")) for a database default",
+					matches[1], blockName, f.Name)
+			}
+		}
+	}
+
+	// Preview features may be enabled in a generator block after the blocks that need them
+	if !containsString(previewFeatures, "views") {
+		for _, v := range views {
+			report(v.line, "Prisma requires previewFeatures = [\"views\"] in the generator block for view %s", v.text)
+		}
+	}
+	if !containsString(previewFeatures, "postgresqlExtensions") {
+		for _, e := range extensions {
+			report(e.line, "Prisma requires previewFeatures = [\"postgresqlExtensions\"] in the generator block for extensions")
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,288 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CompositeTypeChange is a native composite type whose attributes changed
+type CompositeTypeChange struct {
+	Type        *CompositeType
+	CurrentType *CompositeType
+}
+
+// findCompositeType returns the composite type with a name, matched case-insensitively like enums
+// since generated migrations don't quote type names
+func findCompositeType(types []*CompositeType, name string) *CompositeType {
+	for _, ct := range types {
+		if strings.EqualFold(ct.Name, name) {
+			return ct
+		}
+	}
+	return nil
+}
+
+// resolveCompositeTypes names the attributes of composite types and, unless compositeTypes is
+// "native", stores the model fields of a composite type as JSONB columns whose comment documents the
+// structure of the document, so changing the type changes the comment of every column holding it
+func resolveCompositeTypes(s *Schema) {
+	for _, ct := range s.CompositeTypes {
+		for _, f := range ct.Fields {
+			if findFieldAttribute(f, "map") == nil {
+				f.ColumnName = s.Generator.FieldColumnName(f.Name)
+			}
+		}
+	}
+	if s.Generator.CompositeTypes == CompositeTypesNative {
+		return
+	}
+	for _, m := range s.Models {
+		for _, f := range m.Fields {
+			ct := findCompositeType(s.CompositeTypes, f.Type)
+			if ct == nil || f.IsArray || hasNativeTypeAttribute(f) {
+				continue
+			}
+			f.Attributes = append(f.Attributes, &FieldAttribute{Name: "db.JsonB"})
+			if f.Comment != "" {
+				f.Comment += " - "
+			}
+			f.Comment += compositeTypeStructure(ct)
+		}
+	}
+}
+
+// compositeTypeStructure describes the fields of a composite type, e.g.
+// "Address { street String, zip String? }"
+func compositeTypeStructure(ct *CompositeType) string {
+	fields := make([]string, len(ct.Fields))
+	for i, f := range ct.Fields {
+		fields[i] = f.Name + " " + f.Type
+		if f.IsArray {
+			fields[i] += "[]"
+		}
+		if f.IsOptional {
+			fields[i] += "?"
+		}
+	}
+	return ct.Name + " { " + strings.Join(fields, ", ") + " }"
+}
+
+// sortCompositeTypes orders composite types so the types an attribute uses come before it
+func sortCompositeTypes(types []*CompositeType) []*CompositeType {
+	var sorted []*CompositeType
+	done := map[*CompositeType]bool{}
+	var visit func(ct *CompositeType, path map[*CompositeType]bool)
+	visit = func(ct *CompositeType, path map[*CompositeType]bool) {
+		if done[ct] || path[ct] {
+			return
+		}
+		path[ct] = true
+		for _, f := range ct.Fields {
+			if dep := findCompositeType(types, f.Type); dep != nil {
+				visit(dep, path)
+			}
+		}
+		done[ct] = true
+		sorted = append(sorted, ct)
+	}
+	for _, ct := range types {
+		visit(ct, map[*CompositeType]bool{})
+	}
+	return sorted
+}
+
+// reverseCompositeTypes returns composite types in reverse order, the order to drop sorted types in
+func reverseCompositeTypes(types []*CompositeType) []*CompositeType {
+	reversed := make([]*CompositeType, len(types))
+	for i, ct := range types {
+		reversed[len(types)-1-i] = ct
+	}
+	return reversed
+}
+
+// compositeAttributeSQL returns the definition of an attribute of a native composite type. Composite
+// type attributes can't be NOT NULL or have defaults in PostgreSQL, so only the type is kept.
+func compositeAttributeSQL(f *Field) string {
+	sqlType := goTypeToSQLType(f.Type, false, f.Attributes)
+	if f.IsArray {
+		sqlType += "[]"
+	}
+	return quoteIdent(f.ColumnName) + " " + sqlType
+}
+
+// generateCreateCompositeTypeSQL returns a one-line CREATE TYPE like the one of enums, which idempotent
+// migrations wrap in a block ignoring existing types
+func generateCreateCompositeTypeSQL(ct *CompositeType) string {
+	attributes := make([]string, len(ct.Fields))
+	for i, f := range ct.Fields {
+		attributes[i] = compositeAttributeSQL(f)
+	}
+	return "CREATE TYPE " + ct.Name + " AS (" + strings.Join(attributes, ", ") + ");"
+}
+
+func generateDropCompositeTypeSQL(ct *CompositeType) string {
+	return "DROP TYPE IF EXISTS " + ct.Name + ";"
+}
+
+// generateAlterCompositeTypeSQL returns the ALTER TYPE statement turning the attributes of from into
+// the ones of to: attributes are matched by name, and ones whose type changed are altered in place
+func generateAlterCompositeTypeSQL(from, to *CompositeType) string {
+	var actions []string
+	for _, f := range from.Fields {
+		if findCompositeAttribute(to, f.ColumnName) == nil {
+			actions = append(actions, "DROP ATTRIBUTE IF EXISTS "+quoteIdent(f.ColumnName))
+		}
+	}
+	for _, f := range to.Fields {
+		current := findCompositeAttribute(from, f.ColumnName)
+		if current == nil {
+			actions = append(actions, "ADD ATTRIBUTE "+compositeAttributeSQL(f))
+		} else if compositeAttributeType(current) != compositeAttributeType(f) {
+			actions = append(actions, "ALTER ATTRIBUTE "+quoteIdent(f.ColumnName)+" TYPE "+
+				strings.TrimPrefix(compositeAttributeSQL(f), quoteIdent(f.ColumnName)+" "))
+		}
+	}
+	if len(actions) == 0 {
+		return ""
+	}
+	return "ALTER TYPE " + to.Name + " " + strings.Join(actions, ", ") + ";"
+}
+
+func findCompositeAttribute(ct *CompositeType, column string) *Field {
+	for _, f := range ct.Fields {
+		if f.ColumnName == column {
+			return f
+		}
+	}
+	return nil
+}
+
+// compositeAttributeType returns the SQL type of an attribute in the form both Prisma fields and
+// attributes parsed from migrations compare equal in
+func compositeAttributeType(f *Field) string {
+	sqlType := GetSQLTypeForField(f)
+	if f.IsArray {
+		sqlType += "[]"
+	}
+	return sqlType
+}
+
+// diffCompositeTypes compares the native composite types of two schemas. Composite types stored as
+// JSONB have no database object and are compared through the comments of their columns instead.
+func diffCompositeTypes(current, target *Schema) ([]*CompositeType, []*CompositeType, []*CompositeTypeChange) {
+	var targetTypes []*CompositeType
+	if target.Generator.CompositeTypes == CompositeTypesNative {
+		targetTypes = target.CompositeTypes
+	}
+	var added, removed []*CompositeType
+	var modified []*CompositeTypeChange
+	for _, ct := range targetTypes {
+		currentType := findCompositeType(current.CompositeTypes, ct.Name)
+		if currentType == nil {
+			added = append(added, ct)
+		} else if generateAlterCompositeTypeSQL(currentType, ct) != "" {
+			modified = append(modified, &CompositeTypeChange{Type: ct, CurrentType: currentType})
+		}
+	}
+	for _, ct := range current.CompositeTypes {
+		if findCompositeType(targetTypes, ct.Name) == nil {
+			removed = append(removed, ct)
+		}
+	}
+	return sortCompositeTypes(added), removed, modified
+}
+
+var (
+	createCompositeTypeRegex = regexp.MustCompile(`(?s)^CREATE TYPE\s+` + identPattern + `\s+AS\s*\((.*)\)\s*;?$`)
+	alterCompositeTypeRegex  = regexp.MustCompile(`(?s)^ALTER TYPE\s+` + identPattern + `\s+((?:ADD|DROP|ALTER)\s+ATTRIBUTE\b.*?);?$`)
+	addAttributeRegex        = regexp.MustCompile(`(?s)^ADD ATTRIBUTE\s+(.*?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	dropAttributeRegex       = regexp.MustCompile(`^DROP ATTRIBUTE\s+(?:IF EXISTS\s+)?` + identPattern)
+	alterAttributeRegex      = regexp.MustCompile(`(?s)^ALTER ATTRIBUTE\s+` + identPattern + `\s+(?:SET DATA\s+)?TYPE\s+(.*?)(?:\s+(?:COLLATE\s+\S+|CASCADE|RESTRICT))*$`)
+)
+
+// CreateCompositeTypeStatement represents a CREATE TYPE ... AS (...) SQL statement
+type CreateCompositeTypeStatement struct {
+	Type *CompositeType
+}
+
+func (c *CreateCompositeTypeStatement) Apply(schema *Schema) error {
+	if findCompositeType(schema.CompositeTypes, c.Type.Name) == nil {
+		schema.CompositeTypes = append(schema.CompositeTypes, c.Type)
+	}
+	return nil
+}
+
+func (c *CreateCompositeTypeStatement) String() string {
+	return "CREATE TYPE " + c.Type.Name
+}
+
+// AlterCompositeTypeStatement represents ALTER TYPE ... ADD, DROP or ALTER ATTRIBUTE statements
+type AlterCompositeTypeStatement struct {
+	Name    string
+	Actions []string
+}
+
+func (a *AlterCompositeTypeStatement) Apply(schema *Schema) error {
+	ct := findCompositeType(schema.CompositeTypes, a.Name)
+	if ct == nil {
+		return nil
+	}
+	for _, action := range a.Actions {
+		if matches := addAttributeRegex.FindStringSubmatch(action); matches != nil {
+			if f := attributeField(matches[1]); f != nil {
+				ct.Fields = append(ct.Fields, f)
+			}
+		} else if matches := dropAttributeRegex.FindStringSubmatch(action); matches != nil {
+			column := normalizeIdent(matches[1])
+			fields := make([]*Field, 0, len(ct.Fields))
+			for _, f := range ct.Fields {
+				if f.ColumnName != column {
+					fields = append(fields, f)
+				}
+			}
+			ct.Fields = fields
+		} else if matches := alterAttributeRegex.FindStringSubmatch(action); matches != nil {
+			if f := findCompositeAttribute(ct, normalizeIdent(matches[1])); f != nil {
+				if parsed := attributeField(matches[1] + " " + matches[2]); parsed != nil {
+					f.Type, f.IsArray = parsed.Type, parsed.IsArray
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (a *AlterCompositeTypeStatement) String() string {
+	return "ALTER TYPE " + a.Name
+}
+
+// attributeField parses the definition of a composite type attribute, e.g. "street text"
+func attributeField(def string) *Field {
+	col := parseColumnDefinition(strings.TrimSpace(def))
+	if col.Name == "" {
+		return nil
+	}
+	typ, isArray := strings.CutSuffix(col.Type, "[]")
+	return &Field{Name: col.Name, ColumnName: col.Name, Type: typ, IsArray: isArray, IsOptional: true}
+}
+
+// parseCompositeTypeStatement parses CREATE TYPE ... AS (...) and ALTER TYPE ... ATTRIBUTE statements
+func parseCompositeTypeStatement(sql string) SQLStatement {
+	if matches := createCompositeTypeRegex.FindStringSubmatch(sql); matches != nil {
+		ct := &CompositeType{Name: normalizeIdent(matches[1])}
+		for _, part := range smartSplitColumns(matches[2]) {
+			if f := attributeField(part); f != nil {
+				ct.Fields = append(ct.Fields, f)
+			}
+		}
+		return &CreateCompositeTypeStatement{Type: ct}
+	}
+	if matches := alterCompositeTypeRegex.FindStringSubmatch(sql); matches != nil {
+		stmt := &AlterCompositeTypeStatement{Name: normalizeIdent(matches[1])}
+		for _, action := range SplitAlterActions(matches[2]) {
+			stmt.Actions = append(stmt.Actions, strings.TrimSpace(action))
+		}
+		return stmt
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultDatabaseURLEnv is the environment variable read for the connection URL when schema.prisma
+// doesn't name one
+const DefaultDatabaseURLEnv = "DATABASE_URL"
+
+// envCallRegex matches a Prisma env() call, e.g. env("MY_DB_URL")
+var envCallRegex = regexp.MustCompile(`^env\(\s*"([^"]*)"\s*\)$`)
+
+// Datasource holds the connection settings of the datasource block in schema.prisma. Each URL is a
+// literal URL or env("NAME") to read it from an environment variable.
+type Datasource struct {
+	Provider          string
+	URL               string
+	DirectURL         string // Bypasses a connection pooler; migrations and introspection connect with it
+	ShadowDatabaseURL string // Scratch database that check --shadow resets and migrates
+}
+
+// ReadDatasource reads only the datasource block of a schema file. A missing file gives the default
+// datasource, which reads DATABASE_URL.
+func ReadDatasource(path string) (Datasource, error) {
+	var d Datasource
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return d, err
+	}
+	inDatasource := false
+	for _, line := range strings.Split(string(b), "\n") {
+		l := strings.TrimSpace(removeInlineComments(line))
+		switch {
+		case strings.HasPrefix(l, "datasource "):
+			inDatasource = true
+		case inDatasource && l == "}":
+			inDatasource = false
+		case inDatasource && l != "":
+			key, value := parseBlockValue(l)
+			setDatasourceValue(&d, key, value)
+		}
+	}
+	return d, nil
+}
+
+func setDatasourceValue(d *Datasource, key, value string) {
+	switch key {
+	case "provider":
+		d.Provider = value
+	case "url":
+		d.URL = value
+	case "directUrl":
+		d.DirectURL = value
+	case "shadowDatabaseUrl":
+		d.ShadowDatabaseURL = value
+	}
+}
+
+// ResolveURL returns the connection URL of the url field. It fails when the environment variable
+// named by env() is not set.
+func (d Datasource) ResolveURL() (string, error) {
+	if d.URL == "" {
+		return resolveDatasourceURL("url", fmt.Sprintf("env(\"%s\")", DefaultDatabaseURLEnv))
+	}
+	return resolveDatasourceURL("url", d.URL)
+}
+
+// ResolveDirectURL returns the connection URL of directUrl, or of url when directUrl is not set
+func (d Datasource) ResolveDirectURL() (string, error) {
+	if d.DirectURL == "" {
+		return d.ResolveURL()
+	}
+	return resolveDatasourceURL("directUrl", d.DirectURL)
+}
+
+// ResolveShadowDatabaseURL returns the connection URL of shadowDatabaseUrl. It fails when the field
+// is not set or names the same database as url or directUrl, since the shadow database is reset.
+func (d Datasource) ResolveShadowDatabaseURL() (string, error) {
+	if d.ShadowDatabaseURL == "" {
+		return "", fmt.Errorf("the datasource block has no shadowDatabaseUrl")
+	}
+	shadow, err := resolveDatasourceURL("shadowDatabaseUrl", d.ShadowDatabaseURL)
+	if err != nil {
+		return "", err
+	}
+	for _, resolve := range []func() (string, error){d.ResolveURL, d.ResolveDirectURL} {
+		if url, err := resolve(); err == nil && url == shadow {
+			return "", fmt.Errorf("shadowDatabaseUrl must not be the database of url or directUrl - it is reset")
+		}
+	}
+	return shadow, nil
+}
+
+// DatasourceEnv returns the environment variable a datasource URL field reads with env("NAME"), ""
+// for a literal URL
+func DatasourceEnv(value string) string {
+	if matches := envCallRegex.FindStringSubmatch(value); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+func resolveDatasourceURL(field, value string) (string, error) {
+	name := DatasourceEnv(value)
+	if name == "" {
+		if strings.HasPrefix(value, "env(") {
+			return "", fmt.Errorf("datasource %s %s must name the environment variable in double quotes, e.g. env(\"DATABASE_URL\")",
+				field, value)
+		}
+		return value, nil
+	}
+	url := os.Getenv(name)
+	if url == "" {
+		return "", fmt.Errorf("%s environment variable is required", name)
+	}
+	return url, nil
+}
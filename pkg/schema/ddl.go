@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GenerateSchemaSQL renders the complete DDL of a schema as a single script, in the order a migration
+// creating it from scratch runs, without goose annotations. An idempotent script skips objects that
+// already exist and recreates policies and triggers, so it can be run against a database repeatedly.
+func GenerateSchemaSQL(s *Schema, idempotent bool) string {
+	var stmts []string
+	for _, ext := range s.Extensions {
+		stmts = append(stmts, generateExtensionSQL(ext))
+	}
+	for _, seq := range s.Sequences {
+		stmts = append(stmts, generateCreateSequenceSQL(seq))
+	}
+	for _, e := range s.Enums {
+		if idempotent {
+			stmts = append(stmts, ignoreDuplicateObjectSQL(generateEnumSQL(e)))
+		} else {
+			stmts = append(stmts, generateEnumSQL(e))
+		}
+	}
+	if s.Generator.CompositeTypes == CompositeTypesNative {
+		for _, ct := range sortCompositeTypes(s.CompositeTypes) {
+			if idempotent {
+				stmts = append(stmts, ignoreDuplicateObjectSQL(generateCreateCompositeTypeSQL(ct)))
+			} else {
+				stmts = append(stmts, generateCreateCompositeTypeSQL(ct))
+			}
+		}
+	}
+
+	fkNames := map[string]bool{}
+	for _, m := range s.Models {
+		for _, stmt := range generateCreateTableSQL(m, s.Generator, fkNames) {
+			if idempotent {
+				stmt = createIfNotExistsRegex.ReplaceAllString(stmt, "${1}IF NOT EXISTS ")
+			}
+			stmts = append(stmts, stmt)
+		}
+	}
+	for _, seq := range s.Sequences {
+		if seq.OwnerTable != "" {
+			stmts = append(stmts, generateSequenceOwnerSQL(seq))
+		}
+	}
+
+	// Function and view definitions are CREATE OR REPLACE already
+	for _, fn := range s.Functions {
+		stmts = append(stmts, fn.Definition)
+	}
+	for _, v := range s.Views {
+		stmts = append(stmts, v.Definition)
+	}
+	for _, p := range s.Policies {
+		if idempotent {
+			stmts = append(stmts, generateDropPolicySQL(p))
+		}
+		stmts = append(stmts, p.Definition)
+	}
+	for _, t := range s.Triggers {
+		if idempotent {
+			stmts = append(stmts, generateDropTriggerSQL(t))
+		}
+		stmts = append(stmts, t.Definition)
+	}
+	if len(stmts) == 0 {
+		return ""
+	}
+	return strings.Join(stmts, "\n\n") + "\n"
+}
+
+// createIfNotExistsRegex matches the start of CREATE TABLE and CREATE INDEX statements without IF
+// NOT EXISTS
+var createIfNotExistsRegex = regexp.MustCompile(
+	`(?i)^(CREATE\s+TABLE\s+|CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?)(?:IF\s+NOT\s+EXISTS\s+)?`,
+)
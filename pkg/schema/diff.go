@@ -0,0 +1,858 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+type FieldChange struct {
+	ModelName    string
+	Field        *Field   // Target field
+	CurrentField *Field   // Current field (for modifications)
+	Type         string   // "added", "removed", "modified"
+	Indexes      []*Index // Indexes dropped along with a removed column
+	// Enum types of a modified field before and after the change, nil for other types
+	CurrentEnum *Enum
+	TargetEnum  *Enum
+}
+
+// FunctionChange is a managed function whose definition hash changed
+type FunctionChange struct {
+	Function        *Function // Target definition
+	CurrentFunction *Function // Current definition
+}
+
+// ViewChange is a view whose query hash changed
+type ViewChange struct {
+	View        *View // Target definition
+	CurrentView *View // Current definition
+}
+
+// SequenceChange is a sequence whose options or owner changed
+type SequenceChange struct {
+	Sequence        *Sequence // Target definition
+	CurrentSequence *Sequence // Current definition
+}
+
+// IndexChange is an index of an existing table that a unique or index attribute added or removed
+type IndexChange struct {
+	TableName string
+	Index     *Index
+}
+
+// ForeignKeyChange is a foreign key constraint added to or dropped from an existing table
+type ForeignKeyChange struct {
+	TableName  string
+	ForeignKey *ForeignKey
+}
+
+// PrimaryKeyChange is an existing table whose primary key columns changed
+type PrimaryKeyChange struct {
+	TableName      string
+	Columns        []string // Target primary key, empty when the table no longer has one
+	CurrentColumns []string
+}
+
+type SchemaDiff struct {
+	Generator     GeneratorConfig // Options of the target schema that affect generated SQL
+	ModelsAdded   []*Model
+	ModelsRemoved []*Model
+	EnumsAdded    []*Enum
+	EnumsRemoved  []*Enum
+	EnumsModified []*EnumChange
+	// Tables and columns renamed in place, with the indexes and constraints named after them
+	TablesRenamed      []*TableRename
+	ColumnsRenamed     []*FieldChange
+	ConstraintsRenamed []*ConstraintRename
+	// Dropped tables and columns that look renamed to added ones, which stay dropped and added
+	// unless confirmed with DiffSchemasWithRenames
+	RenameCandidates []*RenameCandidate
+	// Native composite types; composite types stored as JSONB have no database object
+	CompositeTypesAdded    []*CompositeType
+	CompositeTypesRemoved  []*CompositeType
+	CompositeTypesModified []*CompositeTypeChange
+	ExtensionsAdded        []*Extension
+	ExtensionsRemoved      []*Extension
+	TriggersAdded          []*Trigger
+	TriggersRemoved        []*Trigger
+	FunctionsAdded         []*Function
+	FunctionsRemoved       []*Function
+	FunctionsModified      []*FunctionChange
+	PoliciesAdded          []*Policy
+	PoliciesRemoved        []*Policy
+	GrantsAdded            []*GrantChange
+	GrantsRevoked          []*GrantChange
+	ViewsAdded             []*View
+	ViewsRemoved           []*View
+	ViewsModified          []*ViewChange
+	SequencesAdded         []*Sequence
+	SequencesRemoved       []*Sequence
+	SequencesModified      []*SequenceChange
+	FieldsAdded            []*FieldChange
+	FieldsRemoved          []*FieldChange
+	FieldsModified         []*FieldChange
+	IndexesAdded           []*IndexChange
+	IndexesRemoved         []*IndexChange
+	ForeignKeysAdded       []*ForeignKeyChange
+	ForeignKeysRemoved     []*ForeignKeyChange
+	PrimaryKeys            []*PrimaryKeyChange
+	Comments               []*CommentChange
+	// Existing models whose row-level security was switched on or off
+	RowLevelSecurityEnabled  []*Model
+	RowLevelSecurityDisabled []*Model
+	// Approximate row counts by table, filled in when a database is available
+	RowEstimates map[string]int64
+}
+
+// DiffSchemas compares the current schema with the target one. Tables and columns that got a @@map or
+// @map keeping their previous default name are renamed; other look-alike renames, including a changed
+// @@map or @map value, are only reported as candidates.
+func DiffSchemas(current, target *Schema) *SchemaDiff {
+	return DiffSchemasWithRenames(current, target, nil)
+}
+
+func diffSchemas(current, target *Schema) *SchemaDiff {
+	// Models diff - use TableName for comparison since that's what matters for SQL
+	modelsAdded := []*Model{}
+	modelsRemoved := []*Model{}
+	fieldsAdded := []*FieldChange{}
+	fieldsRemoved := []*FieldChange{}
+	fieldsModified := []*FieldChange{}
+	indexesAdded := []*IndexChange{}
+	indexesRemoved := []*IndexChange{}
+	foreignKeysAdded := []*ForeignKeyChange{}
+	foreignKeysRemoved := []*ForeignKeyChange{}
+	primaryKeys := []*PrimaryKeyChange{}
+	comments := []*CommentChange{}
+
+	currentModelMap := map[string]*Model{}
+	targetModelMap := map[string]*Model{}
+	for _, m := range current.Models {
+		currentModelMap[m.TableName] = m
+	}
+	for _, m := range target.Models {
+		targetModelMap[m.TableName] = m
+	}
+
+	// Check for models added
+	for tableName, tModel := range targetModelMap {
+		if _, ok := currentModelMap[tableName]; !ok {
+			modelsAdded = append(modelsAdded, tModel)
+		}
+	}
+
+	// Check for models removed
+	for tableName, cModel := range currentModelMap {
+		if _, ok := targetModelMap[tableName]; !ok {
+			modelsRemoved = append(modelsRemoved, cModel)
+		}
+	}
+
+	// Check for field changes within existing models
+	rlsEnabled := []*Model{}
+	rlsDisabled := []*Model{}
+	grantsAdded := []*GrantChange{}
+	grantsRevoked := []*GrantChange{}
+	for tableName, tModel := range targetModelMap {
+		if cModel, ok := currentModelMap[tableName]; ok {
+			// Model exists in both, check for privilege, row-level security and field changes
+			granted, revoked := diffGrants(tableName, cModel.Grants, tModel.Grants)
+			grantsAdded = append(grantsAdded, granted...)
+			grantsRevoked = append(grantsRevoked, revoked...)
+
+			if tModel.RowLevelSecurity && !cModel.RowLevelSecurity {
+				rlsEnabled = append(rlsEnabled, tModel)
+			} else if !tModel.RowLevelSecurity && cModel.RowLevelSecurity {
+				rlsDisabled = append(rlsDisabled, tModel)
+			}
+
+			currentFieldMap := map[string]*Field{}
+			targetFieldMap := map[string]*Field{}
+
+			for _, f := range cModel.Fields {
+				if hasColumn(f) {
+					currentFieldMap[f.ColumnName] = f
+				}
+			}
+			for _, f := range tModel.Fields {
+				if hasColumn(f) {
+					targetFieldMap[f.ColumnName] = f
+				}
+			}
+
+			// Check for fields added
+			for columnName, tField := range targetFieldMap {
+				if _, ok := currentFieldMap[columnName]; !ok {
+					fieldsAdded = append(fieldsAdded, &FieldChange{
+						ModelName: tModel.TableName,
+						Field:     tField,
+						Type:      "added",
+					})
+				}
+			}
+
+			// Check for fields removed
+			for columnName, cField := range currentFieldMap {
+				if _, ok := targetFieldMap[columnName]; !ok {
+					fieldsRemoved = append(fieldsRemoved, &FieldChange{
+						ModelName: cModel.TableName,
+						Field:     cField,
+						Type:      "removed",
+						Indexes:   indexesOnColumn(cModel, cField.ColumnName),
+					})
+				}
+			}
+
+			// Check for fields modified
+			for columnName, tField := range targetFieldMap {
+				if cField, ok := currentFieldMap[columnName]; ok {
+					// Field exists in both, check if it's been modified
+
+					if !fieldsEqual(cField, tField, findEnum(target.Enums, tField.Type)) {
+						fieldsModified = append(fieldsModified, &FieldChange{
+							ModelName:    tModel.TableName,
+							Field:        tField,
+							CurrentField: cField,
+							Type:         "modified",
+							CurrentEnum:  findEnum(current.Enums, cField.Type),
+							TargetEnum:   findEnum(target.Enums, tField.Type),
+						})
+					}
+				}
+			}
+
+			added, removed := diffIndexes(cModel, tModel, target.Generator)
+			indexesAdded = append(indexesAdded, added...)
+			indexesRemoved = append(indexesRemoved, removed...)
+
+			fksAdded, fksRemoved := diffForeignKeys(cModel, tModel, target.Generator)
+			foreignKeysAdded = append(foreignKeysAdded, fksAdded...)
+			foreignKeysRemoved = append(foreignKeysRemoved, fksRemoved...)
+
+			currentKey, targetKey := primaryKeyColumns(cModel), primaryKeyColumns(tModel)
+			if strings.Join(currentKey, ",") != strings.Join(targetKey, ",") {
+				primaryKeys = append(primaryKeys, &PrimaryKeyChange{
+					TableName:      tableName,
+					Columns:        targetKey,
+					CurrentColumns: currentKey,
+				})
+			}
+
+			comments = append(comments, diffComments(cModel, tModel)...)
+		}
+	}
+
+	// Enums diff
+	enumsAdded := []*Enum{}
+	enumsRemoved := []*Enum{}
+	currentEnumMap := map[string]*Enum{}
+	targetEnumMap := map[string]*Enum{}
+	// Enum names are compared case-insensitively as generated migrations don't quote them
+	for _, e := range current.Enums {
+		currentEnumMap[strings.ToLower(e.Name)] = e
+	}
+	for _, e := range target.Enums {
+		targetEnumMap[strings.ToLower(e.Name)] = e
+	}
+	for name, tEnum := range targetEnumMap {
+		if _, ok := currentEnumMap[name]; !ok {
+			enumsAdded = append(enumsAdded, tEnum)
+		}
+	}
+	for name, cEnum := range currentEnumMap {
+		if _, ok := targetEnumMap[name]; !ok {
+			enumsRemoved = append(enumsRemoved, cEnum)
+		}
+	}
+
+	// Extensions diff
+	extensionsAdded := []*Extension{}
+	extensionsRemoved := []*Extension{}
+	for _, ext := range target.Extensions {
+		if findExtension(current.Extensions, ext.Name) == nil {
+			extensionsAdded = append(extensionsAdded, ext)
+		}
+	}
+	for _, ext := range current.Extensions {
+		if findExtension(target.Extensions, ext.Name) == nil {
+			extensionsRemoved = append(extensionsRemoved, ext)
+		}
+	}
+
+	// Triggers diff - a changed definition is dropped and recreated
+	triggersAdded := []*Trigger{}
+	triggersRemoved := []*Trigger{}
+	currentTriggerMap := map[string]*Trigger{}
+	targetTriggerMap := map[string]*Trigger{}
+	for _, t := range current.Triggers {
+		currentTriggerMap[triggerKey(t)] = t
+	}
+	for _, t := range target.Triggers {
+		targetTriggerMap[triggerKey(t)] = t
+	}
+	for _, tTrigger := range target.Triggers {
+		cTrigger, ok := currentTriggerMap[triggerKey(tTrigger)]
+		if !ok {
+			triggersAdded = append(triggersAdded, tTrigger)
+		} else if normalizeSQLDefinition(cTrigger.Definition) != normalizeSQLDefinition(tTrigger.Definition) {
+			triggersRemoved = append(triggersRemoved, cTrigger)
+			triggersAdded = append(triggersAdded, tTrigger)
+		}
+	}
+	for _, cTrigger := range current.Triggers {
+		if _, ok := targetTriggerMap[triggerKey(cTrigger)]; !ok {
+			triggersRemoved = append(triggersRemoved, cTrigger)
+		}
+	}
+
+	// Functions diff - compared by the hash of their definition
+	functionsAdded := []*Function{}
+	functionsRemoved := []*Function{}
+	functionsModified := []*FunctionChange{}
+	currentFunctionMap := map[string]*Function{}
+	targetFunctionMap := map[string]*Function{}
+	for _, fn := range current.Functions {
+		currentFunctionMap[fn.Name] = fn
+	}
+	for _, fn := range target.Functions {
+		targetFunctionMap[fn.Name] = fn
+	}
+	for _, tFunction := range target.Functions {
+		cFunction, ok := currentFunctionMap[tFunction.Name]
+		if !ok {
+			functionsAdded = append(functionsAdded, tFunction)
+		} else if cFunction.Hash != tFunction.Hash {
+			functionsModified = append(functionsModified, &FunctionChange{
+				Function:        tFunction,
+				CurrentFunction: cFunction,
+			})
+		}
+	}
+	for _, cFunction := range current.Functions {
+		if _, ok := targetFunctionMap[cFunction.Name]; !ok {
+			functionsRemoved = append(functionsRemoved, cFunction)
+		}
+	}
+
+	// Policies diff - a changed definition is dropped and recreated
+	policiesAdded := []*Policy{}
+	policiesRemoved := []*Policy{}
+	currentPolicyMap := map[string]*Policy{}
+	targetPolicyMap := map[string]*Policy{}
+	for _, p := range current.Policies {
+		currentPolicyMap[policyKey(p)] = p
+	}
+	for _, p := range target.Policies {
+		targetPolicyMap[policyKey(p)] = p
+	}
+	for _, tPolicy := range target.Policies {
+		cPolicy, ok := currentPolicyMap[policyKey(tPolicy)]
+		if !ok {
+			policiesAdded = append(policiesAdded, tPolicy)
+		} else if normalizeSQLDefinition(cPolicy.Definition) != normalizeSQLDefinition(tPolicy.Definition) {
+			policiesRemoved = append(policiesRemoved, cPolicy)
+			policiesAdded = append(policiesAdded, tPolicy)
+		}
+	}
+	for _, cPolicy := range current.Policies {
+		if _, ok := targetPolicyMap[policyKey(cPolicy)]; !ok {
+			policiesRemoved = append(policiesRemoved, cPolicy)
+		}
+	}
+
+	// Views diff - compared by the hash of their query
+	viewsAdded := []*View{}
+	viewsRemoved := []*View{}
+	viewsModified := []*ViewChange{}
+	currentViewMap := map[string]*View{}
+	targetViewMap := map[string]*View{}
+	for _, v := range current.Views {
+		currentViewMap[v.ViewName] = v
+	}
+	for _, v := range target.Views {
+		targetViewMap[v.ViewName] = v
+	}
+	for _, tView := range target.Views {
+		cView, ok := currentViewMap[tView.ViewName]
+		if !ok {
+			viewsAdded = append(viewsAdded, tView)
+		} else if cView.Hash != tView.Hash {
+			viewsModified = append(viewsModified, &ViewChange{View: tView, CurrentView: cView})
+		}
+	}
+	for _, cView := range current.Views {
+		if _, ok := targetViewMap[cView.ViewName]; !ok {
+			viewsRemoved = append(viewsRemoved, cView)
+		}
+	}
+
+	// Sequences diff - changed options are altered in place to keep the current value
+	sequencesAdded := []*Sequence{}
+	sequencesRemoved := []*Sequence{}
+	sequencesModified := []*SequenceChange{}
+	for _, tSeq := range target.Sequences {
+		cSeq := findSequence(current.Sequences, tSeq.Name)
+		if cSeq == nil {
+			sequencesAdded = append(sequencesAdded, tSeq)
+		} else if !sequencesEqual(cSeq, tSeq) {
+			sequencesModified = append(sequencesModified, &SequenceChange{Sequence: tSeq, CurrentSequence: cSeq})
+		}
+	}
+	for _, cSeq := range current.Sequences {
+		if findSequence(target.Sequences, cSeq.Name) == nil {
+			sequencesRemoved = append(sequencesRemoved, cSeq)
+		}
+	}
+
+	compositeTypesAdded, compositeTypesRemoved, compositeTypesModified := diffCompositeTypes(current, target)
+	// Converting columns to a recreated enum already sets their renamed defaults
+	enumsModified := diffEnumValues(current, target)
+	fieldsModified = withoutEnumDefaultChanges(fieldsModified, enumsModified)
+
+	return &SchemaDiff{
+		Generator:              target.Generator,
+		ModelsAdded:            modelsAdded,
+		ModelsRemoved:          modelsRemoved,
+		EnumsAdded:             enumsAdded,
+		EnumsRemoved:           enumsRemoved,
+		EnumsModified:          enumsModified,
+		CompositeTypesAdded:    compositeTypesAdded,
+		CompositeTypesRemoved:  compositeTypesRemoved,
+		CompositeTypesModified: compositeTypesModified,
+		ExtensionsAdded:        extensionsAdded,
+		ExtensionsRemoved:      extensionsRemoved,
+		TriggersAdded:          triggersAdded,
+		TriggersRemoved:        triggersRemoved,
+		FunctionsAdded:         functionsAdded,
+		FunctionsRemoved:       functionsRemoved,
+		FunctionsModified:      functionsModified,
+		PoliciesAdded:          policiesAdded,
+		PoliciesRemoved:        policiesRemoved,
+		GrantsAdded:            grantsAdded,
+		GrantsRevoked:          grantsRevoked,
+		ViewsAdded:             viewsAdded,
+		ViewsRemoved:           viewsRemoved,
+		ViewsModified:          viewsModified,
+		SequencesAdded:         sequencesAdded,
+		SequencesRemoved:       sequencesRemoved,
+		SequencesModified:      sequencesModified,
+		FieldsAdded:            fieldsAdded,
+		FieldsRemoved:          fieldsRemoved,
+		FieldsModified:         fieldsModified,
+		IndexesAdded:           indexesAdded,
+		IndexesRemoved:         indexesRemoved,
+		ForeignKeysAdded:       foreignKeysAdded,
+		ForeignKeysRemoved:     foreignKeysRemoved,
+		PrimaryKeys:            primaryKeys,
+		Comments:               comments,
+
+		RowLevelSecurityEnabled:  rlsEnabled,
+		RowLevelSecurityDisabled: rlsDisabled,
+	}
+}
+
+// hasColumn reports whether a field is stored in a column of its table: list fields and the fields
+// navigating a relation only exist in the Prisma schema
+func hasColumn(f *Field) bool {
+	return !f.IsArray && !f.IsRelation && findFieldAttribute(f, "relation") == nil
+}
+
+// findEnum returns the enum with a name, matched case-insensitively like unquoted type names
+func findEnum(enums []*Enum, name string) *Enum {
+	for _, e := range enums {
+		if strings.EqualFold(e.Name, name) {
+			return e
+		}
+	}
+	return nil
+}
+
+// diffIndexes compares the indexes the attributes of a model declare with the indexes migrations
+// created on its table, matching them by columns and uniqueness. Only indexes named the way the
+// generator names them are removed, so indexes written by hand in migrations are kept. The indexes
+// of added and removed columns are created and dropped with the columns.
+func diffIndexes(current, target *Model, generator GeneratorConfig) ([]*IndexChange, []*IndexChange) {
+	var declared []*Index
+	for _, stmt := range generateCreateTableSQL(target, generator, map[string]bool{})[1:] {
+		if parsed, err := parseStatement(strings.TrimSuffix(stmt, ";")); err == nil {
+			if create, ok := parsed.(*CreateIndexStatement); ok {
+				declared = append(declared, create.Index)
+			}
+		}
+	}
+
+	currentColumns := map[string]bool{}
+	for _, f := range current.Fields {
+		currentColumns[f.ColumnName] = true
+	}
+	targetFields := map[string]*Field{}
+	for _, f := range target.Fields {
+		targetFields[f.ColumnName] = f
+	}
+
+	var added, removed []*IndexChange
+	for _, idx := range declared {
+		if findIndex(current.Indexes, idx) != nil {
+			continue
+		}
+		if len(idx.Columns) == 1 && !currentColumns[idx.Columns[0]] {
+			// The unique and search indexes of an added column are created with it
+			if f := targetFields[idx.Columns[0]]; f != nil &&
+				(f.SearchVector != nil || idx.Unique && findFieldAttribute(f, "unique") != nil) {
+				continue
+			}
+		}
+		added = append(added, &IndexChange{TableName: target.TableName, Index: idx})
+	}
+	for _, idx := range current.Indexes {
+		if findIndex(declared, idx) != nil ||
+			!strings.EqualFold(idx.Name, generator.IndexName(current.TableName, idx.Columns, idx.Unique)) {
+			continue
+		}
+		onRemovedColumn := false
+		for _, column := range idx.Columns {
+			if targetFields[column] == nil {
+				onRemovedColumn = true
+			}
+		}
+		if !onRemovedColumn {
+			removed = append(removed, &IndexChange{TableName: current.TableName, Index: idx})
+		}
+	}
+	return added, removed
+}
+
+// diffForeignKeys compares the foreign keys the relations of a model declare with the ones migrations
+// created on its table. Like indexes, only foreign keys named the way the generator names them are
+// dropped, and the ones on removed columns go with their columns. With relationMode = "prisma" the
+// relations have no foreign keys, and the existing ones are left alone.
+func diffForeignKeys(current, target *Model, generator GeneratorConfig) ([]*ForeignKeyChange, []*ForeignKeyChange) {
+	if generator.RelationMode == RelationModePrisma {
+		return nil, nil
+	}
+	var declared []*ForeignKey
+	if parsed, err := parseStatement(strings.TrimSuffix(generateCreateTableSQL(target, generator, map[string]bool{})[0], ";")); err == nil {
+		if create, ok := parsed.(*CreateTableStatement); ok {
+			declared = create.ForeignKeys
+		}
+	}
+	targetColumns := map[string]bool{}
+	for _, f := range target.Fields {
+		targetColumns[f.ColumnName] = true
+	}
+
+	var added, removed []*ForeignKeyChange
+	for _, fk := range declared {
+		if findForeignKey(current.ForeignKeys, fk) == nil {
+			added = append(added, &ForeignKeyChange{TableName: target.TableName, ForeignKey: fk})
+		}
+	}
+	for _, fk := range current.ForeignKeys {
+		if findForeignKey(declared, fk) != nil ||
+			!strings.EqualFold(fk.Name, generator.ForeignKeyName(current.TableName, fk.Columns, fk.RefTable)) {
+			continue
+		}
+		onRemovedColumn := false
+		for _, column := range fk.Columns {
+			if !targetColumns[column] {
+				onRemovedColumn = true
+			}
+		}
+		if !onRemovedColumn {
+			removed = append(removed, &ForeignKeyChange{TableName: current.TableName, ForeignKey: fk})
+		}
+	}
+	return added, removed
+}
+
+// findForeignKey returns the foreign key with the same columns, references and delete action as fk
+func findForeignKey(fks []*ForeignKey, fk *ForeignKey) *ForeignKey {
+	onDelete := func(action string) string {
+		if action == "" {
+			return "NO ACTION"
+		}
+		return strings.ToUpper(action)
+	}
+	for _, candidate := range fks {
+		if strings.Join(candidate.Columns, ",") == strings.Join(fk.Columns, ",") &&
+			strings.EqualFold(candidate.RefTable, fk.RefTable) &&
+			strings.Join(candidate.RefColumns, ",") == strings.Join(fk.RefColumns, ",") &&
+			onDelete(candidate.OnDelete) == onDelete(fk.OnDelete) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// findIndex returns the index with the same columns and uniqueness as idx
+func findIndex(indexes []*Index, idx *Index) *Index {
+	for _, candidate := range indexes {
+		if candidate.Unique == idx.Unique && strings.Join(candidate.Columns, ",") == strings.Join(idx.Columns, ",") {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// indexesOnColumn returns the indexes of a model that cover a column
+func indexesOnColumn(m *Model, columnName string) []*Index {
+	var indexes []*Index
+	for _, idx := range m.Indexes {
+		if containsString(idx.Columns, columnName) {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// fieldsEqual compares two fields to see if they are equivalent
+func fieldsEqual(current, target *Field, enum *Enum) bool {
+	// @unique and @id are compared per table, as indexes and primary keys
+	return columnTypesEqual(current, target) && defaultsEqual(current, target, enum)
+}
+
+// columnTypesEqual compares the column types and nullability of two fields, leaving out defaults
+func columnTypesEqual(current, target *Field) bool {
+	// Both schemas now use consistent internal representation from SQL parsing
+	// Compare the SQL types directly - this handles DECIMAL precision/scale automatically
+	currentSQL := GetSQLTypeForField(current)
+	targetSQL := GetSQLTypeForField(target)
+
+	if currentSQL != targetSQL {
+		return false
+	}
+
+	if current.IsOptional != target.IsOptional {
+		return false
+	}
+
+	return current.IsArray == target.IsArray
+}
+
+// defaultsEqual compares the column defaults of two fields as SQL, so the default a migration sets
+// matches the @default it was generated from. enum is the enum type of the target field, if any.
+func defaultsEqual(current, target *Field, enum *Enum) bool {
+	if attr := findFieldAttribute(target, "default"); attr != nil && len(attr.Args) > 0 && attr.Args[0] == "autoincrement()" {
+		// Switching a column to or from a sequence is left to hand-written migrations
+		return true
+	}
+	currentDefault := normalizeDefaultSQL(fieldDefaultSQL(current, enum))
+	targetDefault := normalizeDefaultSQL(fieldDefaultSQL(target, enum))
+	if enum != nil {
+		// Older migrations set enum defaults without quotes
+		currentDefault, targetDefault = strings.Trim(currentDefault, "'"), strings.Trim(targetDefault, "'")
+	}
+	return currentDefault == targetDefault
+}
+
+// DefaultChange returns the SQL default a modified field gets, "" when its default is dropped; changed
+// is false when the column keeps its default
+func (fc *FieldChange) DefaultChange() (value string, changed bool) {
+	if defaultsEqual(fc.CurrentField, fc.Field, fc.TargetEnum) {
+		return "", false
+	}
+	return fieldDefaultSQL(fc.Field, fc.TargetEnum), true
+}
+
+// defaultCastRegex matches a trailing cast like ::text or ::"Status", which pg_dump adds to defaults
+var defaultCastRegex = regexp.MustCompile(`::\s*(?:"[^"]+"|[A-Z_][A-Z0-9_ ]*(?:\(\d+(?:,\s*\d+)?\))?)(?:\[\])?$`)
+
+// normalizeDefaultSQL puts a default expression in a canonical form for comparison
+func normalizeDefaultSQL(expr string) string {
+	expr = normalizeWhitespace(upperOutsideQuotes(strings.TrimSpace(expr)))
+	for {
+		stripped := strings.TrimSpace(defaultCastRegex.ReplaceAllString(expr, ""))
+		if stripped == expr {
+			break
+		}
+		expr = stripped
+	}
+	if expr == "NOW()" {
+		return "CURRENT_TIMESTAMP"
+	}
+	return expr
+}
+
+// primaryKeyColumns returns the primary key columns of a model, from @@id or the @id fields
+func primaryKeyColumns(m *Model) []string {
+	for _, attr := range m.Attributes {
+		if attr.Name == "id" {
+			return parseIndexFields(modelAttributeFields(attr), m.Fields)
+		}
+	}
+	var columns []string
+	for _, f := range m.Fields {
+		if findFieldAttribute(f, "id") != nil {
+			columns = append(columns, f.ColumnName)
+		}
+	}
+	return columns
+}
+
+// NormalizeTypeForComparison converts both PostgreSQL and Prisma types to a common format for comparison.
+// DateTime and Float columns, and String columns with @db.Uuid, normalize to their SQL type (e.g.
+// TIMESTAMP, TIMESTAMPTZ or DATE), since switching between those is a type change.
+func NormalizeTypeForComparison(fieldType string, attributes []*FieldAttribute) string {
+	switch fieldType {
+	case "DateTime", "Float", "String":
+		for _, attr := range attributes {
+			if sqlType, ok := nativeSQLType(strings.TrimPrefix(attr.Name, "db."), nil); ok {
+				return sqlType
+			}
+		}
+		switch fieldType {
+		case "DateTime":
+			return "TIMESTAMP"
+		case "Float":
+			return "DOUBLE PRECISION"
+		}
+		return "String"
+	}
+	if base := nativeBaseType(fieldType); base != "" {
+		return base
+	}
+	if isStringSQLType(fieldType) {
+		// TEXT, VARCHAR(n) and CITEXT differ in SQL type only, like DECIMAL precision
+		return "String"
+	}
+
+	// Handle PostgreSQL types from migrations (lowercase after parsing) - convert to Prisma equivalent
+	switch strings.ToUpper(fieldType) {
+	case "INTEGER":
+		return "Int"
+	case "BIGINT":
+		return "BigInt"
+	case "SERIAL":
+		// SERIAL is PostgreSQL's auto-increment integer - equivalent to Int with @id @default(autoincrement())
+		return "Int"
+	case "BIGSERIAL":
+		return "BigInt"
+	case "BOOLEAN":
+		return "Boolean"
+	case "JSONB", "JSON":
+		return "Json"
+	case "NUMERIC":
+		return "Decimal"
+	default:
+		// Handle DECIMAL(precision, scale) types
+		if upper := strings.ToUpper(fieldType); strings.HasPrefix(upper, "DECIMAL(") || strings.HasPrefix(upper, "NUMERIC(") {
+			return "Decimal"
+		}
+
+		// For Prisma types with @db attributes, normalize to the base type
+		if fieldType == "Decimal" {
+			return "Decimal"
+		}
+
+		// Native types compare upper-cased: Unsupported("email") and email from a migration are EMAIL
+		if nativeType, ok := parseUnsupportedType(fieldType); ok {
+			return strings.ToUpper(nativeType)
+		}
+		if fieldType == strings.ToLower(fieldType) {
+			return strings.ToUpper(fieldType)
+		}
+
+		// For Prisma types, return as-is
+		return fieldType
+	}
+}
+
+// getSQLTypeForField returns the SQL type for a field, considering @db attributes
+func GetSQLTypeForField(field *Field) string {
+	// Generated search columns are always tsvector
+	if field.SearchVector != nil {
+		return "TSVECTOR"
+	}
+
+	// Check for @db type attributes first
+	for _, attr := range field.Attributes {
+		if strings.HasPrefix(attr.Name, "db.") {
+			dbType := strings.TrimPrefix(attr.Name, "db.")
+			if dbType == "VarChar" && len(attr.Args) > 0 {
+				return "VARCHAR(" + attr.Args[0] + ")"
+			}
+			if dbType == "VarChar" {
+				return "VARCHAR"
+			}
+			if dbType == "Text" {
+				return "TEXT"
+			}
+			if dbType == "Citext" {
+				return "CITEXT"
+			}
+			if dbType == "Decimal" && len(attr.Args) >= 2 {
+				return "DECIMAL(" + attr.Args[0] + "," + attr.Args[1] + ")"
+			}
+			if sqlType, ok := nativeSQLType(dbType, attr.Args); ok {
+				return sqlType
+			}
+		}
+	}
+
+	// If field type is already a SQL type (from migrations), normalize and return
+	// Handle case-insensitive DECIMAL types from migrations
+	upperType := strings.ToUpper(field.Type)
+	if strings.HasPrefix(upperType, "DECIMAL(") {
+		// Normalize to uppercase for consistency
+		return upperType
+	}
+	if strings.HasPrefix(upperType, "NUMERIC(") {
+		// NUMERIC(p,s) and DECIMAL(p,s) are the same type
+		return "DECIMAL" + strings.TrimPrefix(upperType, "NUMERIC")
+	}
+
+	if base := nativeBaseType(field.Type); base != "" && timestampBaseType(field.Type) == "" {
+		// Timestamps keep their precision below
+		return base
+	}
+
+	// Handle other SQL types from migrations (normalize to uppercase)
+	switch strings.ToUpper(field.Type) {
+	case "TEXT":
+		return "TEXT"
+	case "INTEGER":
+		return "INTEGER"
+	case "BIGINT":
+		return "BIGINT"
+	case "SERIAL":
+		// SERIAL from migrations should be treated as INTEGER for comparison purposes
+		// since it's functionally equivalent to Int @default(autoincrement())
+		return "INTEGER"
+	case "BIGSERIAL":
+		// BIGSERIAL is the BigInt @default(autoincrement()) equivalent
+		return "BIGINT"
+	case "NUMERIC":
+		return "NUMERIC"
+	case "TIMESTAMP":
+		return "TIMESTAMP"
+	case "BOOLEAN":
+		return "BOOLEAN"
+	}
+
+	// Map Prisma types to SQL types
+	switch field.Type {
+	case "String":
+		return "TEXT"
+	case "Int":
+		// Check if this Int field has autoincrement - if so, it's equivalent to SERIAL
+		// For comparison purposes, we normalize both to INTEGER
+		return "INTEGER"
+	case "BigInt":
+		return "BIGINT"
+	case "Float":
+		return "DOUBLE PRECISION"
+	case "Decimal":
+		return "NUMERIC"
+	case "Boolean":
+		return "BOOLEAN"
+	case "DateTime":
+		return "TIMESTAMP"
+	case "Json":
+		return "JSONB"
+	default:
+		if nativeType, ok := parseUnsupportedType(field.Type); ok {
+			return strings.ToUpper(nativeType)
+		}
+		return strings.ToUpper(field.Type)
+	}
+}
@@ -0,0 +1,514 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnumChange is an existing enum whose values changed. Values replaced in place, e.g. INACTIVE by
+// DISABLED at the same position, are taken as renames and carried over to the rows holding them.
+type EnumChange struct {
+	Enum        *Enum
+	CurrentEnum *Enum
+	Renamed     map[string]string // Current labels and the target labels replacing them
+	// Columns of the enum type in the current schema, which the up migration converts
+	Columns []*EnumColumn
+	// Columns of the enum type in the target schema on tables of the current one, which the down
+	// migration converts back before the tables the up migration created are gone
+	TargetColumns []*EnumColumn
+}
+
+// EnumColumn is a column holding an enum whose values change
+type EnumColumn struct {
+	TableName  string
+	ColumnName string
+	IsArray    bool
+	Default    string // Label the column defaults to, "" without one
+	NewDefault string // Label the column defaults to after the change, which rows holding removed labels get
+}
+
+// AddedLabels returns the labels of the target enum that are new, renames excluded
+func (ec *EnumChange) AddedLabels() []string {
+	var added []string
+	current := enumLabels(ec.CurrentEnum)
+	for _, label := range enumLabels(ec.Enum) {
+		if !slices.Contains(current, label) && !mapContainsValue(ec.Renamed, label) {
+			added = append(added, label)
+		}
+	}
+	return added
+}
+
+// RemovedLabels returns the labels of the current enum that are gone, renames excluded
+func (ec *EnumChange) RemovedLabels() []string {
+	var removed []string
+	target := enumLabels(ec.Enum)
+	for _, label := range enumLabels(ec.CurrentEnum) {
+		if _, renamed := ec.Renamed[label]; !renamed && !slices.Contains(target, label) {
+			removed = append(removed, label)
+		}
+	}
+	return removed
+}
+
+// Description lists the label changes, e.g. + 'ARCHIVED', - 'DRAFT', 'INACTIVE' -> 'DISABLED'
+func (ec *EnumChange) Description() string {
+	var parts []string
+	for _, label := range ec.AddedLabels() {
+		parts = append(parts, "+ "+quoteLiteral(label))
+	}
+	for _, label := range ec.RemovedLabels() {
+		parts = append(parts, "- "+quoteLiteral(label))
+	}
+	for _, label := range enumLabels(ec.CurrentEnum) {
+		if newLabel, ok := ec.Renamed[label]; ok {
+			parts = append(parts, quoteLiteral(label)+" -> "+quoteLiteral(newLabel))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func mapContainsValue(m map[string]string, value string) bool {
+	for _, v := range m {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// enumValueMapRegex matches an enum value of schema.prisma stored under another label, e.g.
+// ADMIN @map("admin")
+var enumValueMapRegex = regexp.MustCompile(`^(\w+)\s+@map\(("(?:[^"\\]|\\.)*")\)\s*$`)
+
+// EnumValueLabel returns the database label of an enum value of schema.prisma without its comment: its
+// @map name or the value itself. Values parsed from migrations are labels already and may contain spaces.
+func EnumValueLabel(value string) string {
+	if matches := enumValueMapRegex.FindStringSubmatch(value); matches != nil {
+		if label, err := strconv.Unquote(matches[2]); err == nil {
+			return label
+		}
+	}
+	return value
+}
+
+// enumLabels returns the database labels of an enum in order
+func enumLabels(e *Enum) []string {
+	labels := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		labels[i] = EnumValueLabel(v)
+	}
+	return labels
+}
+
+// enumDefaultLabel returns the label of an enum default, e.g. @default(ADMIN) of a value mapped to
+// "admin", and the value of other defaults
+func enumDefaultLabel(e *Enum, value string) string {
+	for _, v := range e.Values {
+		if matches := enumValueMapRegex.FindStringSubmatch(v); matches != nil && matches[1] == value {
+			return EnumValueLabel(v)
+		}
+	}
+	return value
+}
+
+// columnEnumDefault returns the label a column of an enum type defaults to, "" when it has no default
+// or defaults to an expression
+func columnEnumDefault(f *Field, e *Enum) string {
+	def := normalizeDefaultSQL(fieldDefaultSQL(f, e))
+	if len(def) < 2 || !strings.HasPrefix(def, "'") || !strings.HasSuffix(def, "'") {
+		return ""
+	}
+	return strings.ReplaceAll(def[1:len(def)-1], "''", "'")
+}
+
+// isEnumField reports whether a field holds an enum, directly or as an array
+func isEnumField(f *Field, e *Enum) (bool, bool) {
+	typ, isArray := strings.CutSuffix(f.Type, "[]")
+	return strings.EqualFold(typ, e.Name), isArray || f.IsArray
+}
+
+// enumColumns returns the columns of models holding an enum. other is the schema on the other side of
+// the change, whose columns give the default rows holding removed labels get; skip lists tables left out.
+func enumColumns(s, other *Schema, e, otherEnum *Enum, skip func(*Model) bool) []*EnumColumn {
+	var columns []*EnumColumn
+	for _, m := range s.Models {
+		if skip(m) {
+			continue
+		}
+		for _, f := range m.Fields {
+			isEnum, isArray := isEnumField(f, e)
+			if !isEnum || f.IsRelation {
+				continue
+			}
+			col := &EnumColumn{TableName: m.TableName, ColumnName: f.ColumnName, IsArray: isArray}
+			if !isArray {
+				col.Default = columnEnumDefault(f, e)
+				if om := findModelByTable(other.Models, m.TableName); om != nil {
+					if of := findFieldByColumn(om, f.ColumnName); of != nil {
+						if isOtherEnum, _ := isEnumField(of, otherEnum); isOtherEnum {
+							col.NewDefault = columnEnumDefault(of, otherEnum)
+						}
+					}
+				}
+			}
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+func findModelByTable(models []*Model, table string) *Model {
+	for _, m := range models {
+		if m.TableName == table {
+			return m
+		}
+	}
+	return nil
+}
+
+func findFieldByColumn(m *Model, column string) *Field {
+	for _, f := range m.Fields {
+		if f.ColumnName == column {
+			return f
+		}
+	}
+	return nil
+}
+
+// renamedEnumLabels pairs the removed labels of an enum with the labels added at the same position
+func renamedEnumLabels(current, target []string) map[string]string {
+	renamed := map[string]string{}
+	for i, label := range current {
+		if i >= len(target) || slices.Contains(target, label) || slices.Contains(current, target[i]) {
+			continue
+		}
+		renamed[label] = target[i]
+	}
+	return renamed
+}
+
+// diffEnumValues returns the enums of both schemas whose labels differ; a new order alone doesn't
+// count, as existing values can't be moved
+func diffEnumValues(current, target *Schema) []*EnumChange {
+	var changes []*EnumChange
+	for _, e := range target.Enums {
+		currentEnum := findEnum(current.Enums, e.Name)
+		if currentEnum == nil {
+			continue
+		}
+		currentLabels, targetLabels := enumLabels(currentEnum), enumLabels(e)
+		same := len(currentLabels) == len(targetLabels)
+		for _, label := range targetLabels {
+			same = same && slices.Contains(currentLabels, label)
+		}
+		if same {
+			continue
+		}
+		newTable := func(m *Model) bool { return findModelByTable(current.Models, m.TableName) == nil }
+		changes = append(changes, &EnumChange{
+			Enum:          e,
+			CurrentEnum:   currentEnum,
+			Renamed:       renamedEnumLabels(currentLabels, targetLabels),
+			Columns:       enumColumns(current, target, currentEnum, e, func(*Model) bool { return false }),
+			TargetColumns: enumColumns(target, current, e, currentEnum, newTable),
+		})
+	}
+	return changes
+}
+
+// generateEnumChangeSQL returns the goose statements turning enum from into to. Labels that are only
+// added are appended in place with ALTER TYPE ... ADD VALUE. PostgreSQL can't drop labels, so removing
+// or renaming one renames the type away, creates it again with the new labels, converts every column
+// with a USING expression mapping renamed labels, and drops the old type.
+func generateEnumChangeSQL(from, to *Enum, renamed map[string]string, columns []*EnumColumn) []string {
+	fromLabels, toLabels := enumLabels(from), enumLabels(to)
+	var removed []string
+	for _, label := range fromLabels {
+		if !slices.Contains(toLabels, label) {
+			removed = append(removed, label)
+		}
+	}
+
+	var stmts []string
+	if len(removed) == 0 {
+		for i, label := range toLabels {
+			if slices.Contains(fromLabels, label) {
+				continue
+			}
+			stmt := "ALTER TYPE " + to.Name + " ADD VALUE " + quoteLiteral(label)
+			for _, next := range toLabels[i+1:] {
+				if slices.Contains(fromLabels, next) {
+					stmt += " BEFORE " + quoteLiteral(next)
+					break
+				}
+			}
+			stmts = append(stmts, wrapGooseStatement(stmt+";"))
+		}
+		return stmts
+	}
+
+	var dropped, renames []string
+	for _, label := range removed {
+		if newLabel, ok := renamed[label]; ok {
+			renames = append(renames, quoteLiteral(label)+" to "+quoteLiteral(newLabel))
+		} else {
+			dropped = append(dropped, quoteLiteral(label))
+		}
+	}
+	var changes []string
+	if len(dropped) > 0 {
+		changes = append(changes, "remove "+strings.Join(dropped, ", "))
+	}
+	if len(renames) > 0 {
+		changes = append(changes, "rename "+strings.Join(renames, ", "))
+	}
+	warning := fmt.Sprintf("Recreating enum %s to %s; columns of the type are converted", to.Name, strings.Join(changes, " and "))
+	if len(renames) > 0 {
+		warning += ". Values replaced at the same position are taken as renames: edit the USING mappings " +
+			"below if a value was removed instead"
+	}
+
+	oldName := to.Name + "_old"
+	stmts = append(stmts,
+		wrapGooseStatementWithWarning("ALTER TYPE "+to.Name+" RENAME TO "+oldName+";", warning),
+		wrapGooseStatement(generateEnumSQL(to)))
+	for _, col := range columns {
+		stmts = append(stmts, convertEnumColumnSQL(col, to, removed, renamed, toLabels)...)
+	}
+	stmts = append(stmts, wrapGooseStatement("DROP TYPE "+oldName+";"))
+	return stmts
+}
+
+// convertEnumColumnSQL converts a column to a recreated enum, mapping renamed labels and, for removed
+// labels, the default the column gets; rows holding other removed labels make the conversion fail
+func convertEnumColumnSQL(col *EnumColumn, to *Enum, removed []string, renamed map[string]string, toLabels []string) []string {
+	table, column := quoteIdent(col.TableName), quoteIdent(col.ColumnName)
+	var stmts []string
+	var unmapped []string
+	var expr string
+	if col.IsArray {
+		// USING can't hold subqueries, so array elements are replaced one label at a time
+		expr = column + "::text[]"
+		for _, label := range removed {
+			if newLabel, ok := renamed[label]; ok {
+				expr = "array_replace(" + expr + ", " + quoteLiteral(label) + ", " + quoteLiteral(newLabel) + ")"
+			} else {
+				expr = "array_remove(" + expr + ", " + quoteLiteral(label) + ")"
+			}
+		}
+		expr += "::" + to.Name + "[]"
+	} else {
+		var cases []string
+		for _, label := range removed {
+			newLabel, ok := renamed[label]
+			if !ok && slices.Contains(toLabels, col.NewDefault) {
+				newLabel, ok = col.NewDefault, true
+			}
+			if ok {
+				cases = append(cases, "WHEN "+quoteLiteral(label)+" THEN "+quoteLiteral(newLabel))
+			} else {
+				unmapped = append(unmapped, quoteLiteral(label))
+			}
+		}
+		expr = column + "::text::" + to.Name
+		if len(cases) > 0 {
+			expr = "(CASE " + column + "::text " + strings.Join(cases, " ") + " ELSE " + column + "::text END)::" + to.Name
+		}
+	}
+
+	if col.Default != "" {
+		stmts = append(stmts, wrapGooseStatement(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, column)))
+	}
+	typ := to.Name
+	if col.IsArray {
+		typ += "[]"
+	}
+	stmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s;", table, column, typ, expr)
+	if len(unmapped) > 0 {
+		warning := fmt.Sprintf("Rows of %s.%s holding %s fail this conversion; update them first, e.g. UPDATE %s SET %s = ... WHERE %s::text IN (%s)",
+			col.TableName, col.ColumnName, strings.Join(unmapped, ", "), table, column, column, strings.Join(unmapped, ", "))
+		stmts = append(stmts, wrapGooseStatementWithWarning(stmt, warning))
+	} else {
+		stmts = append(stmts, wrapGooseStatement(stmt))
+	}
+	if def := convertedEnumDefault(col, renamed, toLabels); def != "" {
+		stmts = append(stmts, wrapGooseStatement(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, quoteLiteral(def))))
+	}
+	return stmts
+}
+
+// convertedEnumDefault returns the label convertEnumColumnSQL sets as the default of a converted
+// column: its renamed default, or the new default when the old one is removed; "" when it sets none
+func convertedEnumDefault(col *EnumColumn, renamed map[string]string, toLabels []string) string {
+	if col.Default == "" {
+		return ""
+	}
+	def := col.Default
+	if newLabel, ok := renamed[def]; ok {
+		def = newLabel
+	} else if !slices.Contains(toLabels, def) {
+		def = col.NewDefault
+	}
+	if !slices.Contains(toLabels, def) {
+		return ""
+	}
+	return def
+}
+
+// recreatesEnum reports whether generateEnumChangeSQL recreates the enum, converting its columns,
+// rather than adding values in place
+func recreatesEnum(from, to *Enum) bool {
+	toLabels := enumLabels(to)
+	for _, label := range enumLabels(from) {
+		if !slices.Contains(toLabels, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutEnumDefaultChanges drops the modified fields whose only change is a default that converting
+// their column to a recreated enum already sets, in the up and the down migration, so it isn't set twice
+func withoutEnumDefaultChanges(changes []*FieldChange, enumChanges []*EnumChange) []*FieldChange {
+	kept := make([]*FieldChange, 0, len(changes))
+	for _, fc := range changes {
+		if !columnTypesEqual(fc.CurrentField, fc.Field) || !enumConversionSetsDefaults(fc, enumChanges) {
+			kept = append(kept, fc)
+		}
+	}
+	return kept
+}
+
+// enumConversionSetsDefaults reports whether the enum change of a modified field's type recreates the
+// enum both ways, setting the field's target default going up and its current default going down
+func enumConversionSetsDefaults(fc *FieldChange, enumChanges []*EnumChange) bool {
+	for _, ec := range enumChanges {
+		if fc.TargetEnum == nil || !strings.EqualFold(ec.Enum.Name, fc.TargetEnum.Name) {
+			continue
+		}
+		if !recreatesEnum(ec.CurrentEnum, ec.Enum) || !recreatesEnum(ec.Enum, ec.CurrentEnum) {
+			return false
+		}
+		up := findEnumColumn(ec.Columns, fc.ModelName, fc.CurrentField.ColumnName)
+		down := findEnumColumn(ec.TargetColumns, fc.ModelName, fc.Field.ColumnName)
+		if up == nil || down == nil {
+			return false
+		}
+		targetDefault, currentDefault := columnEnumDefault(fc.Field, ec.Enum), columnEnumDefault(fc.CurrentField, ec.CurrentEnum)
+		return targetDefault != "" && currentDefault != "" &&
+			convertedEnumDefault(up, ec.Renamed, enumLabels(ec.Enum)) == targetDefault &&
+			convertedEnumDefault(down, invertedRenames(ec.Renamed), enumLabels(ec.CurrentEnum)) == currentDefault
+	}
+	return false
+}
+
+// findEnumColumn returns the enum column of a table with a name
+func findEnumColumn(columns []*EnumColumn, table, column string) *EnumColumn {
+	for _, col := range columns {
+		if col.TableName == table && col.ColumnName == column {
+			return col
+		}
+	}
+	return nil
+}
+
+// invertedRenames returns the renames of an enum change the other way around, for down migrations
+func invertedRenames(renamed map[string]string) map[string]string {
+	inverted := make(map[string]string, len(renamed))
+	for from, to := range renamed {
+		inverted[to] = from
+	}
+	return inverted
+}
+
+var (
+	alterEnumAddValueRegex = regexp.MustCompile(`^ALTER TYPE\s+` + identPattern +
+		`\s+ADD VALUE\s+(?:IF NOT EXISTS\s+)?'((?:[^']|'')*)'(?:\s+(BEFORE|AFTER)\s+'((?:[^']|'')*)')?\s*;?$`)
+	alterEnumRenameValueRegex = regexp.MustCompile(`^ALTER TYPE\s+` + identPattern +
+		`\s+RENAME VALUE\s+'((?:[^']|'')*)'\s+TO\s+'((?:[^']|'')*)'\s*;?$`)
+	alterTypeRenameRegex = regexp.MustCompile(`^ALTER TYPE\s+` + identPattern + `\s+RENAME TO\s+` + identPattern + `\s*;?$`)
+)
+
+// AlterEnumStatement represents ALTER TYPE ... ADD VALUE and RENAME VALUE statements
+type AlterEnumStatement struct {
+	Name     string
+	Value    string
+	Position string // BEFORE or AFTER, empty to append
+	Anchor   string // Label the new value is placed before or after
+	RenameTo string // New label of Value, empty when adding
+}
+
+func (a *AlterEnumStatement) Apply(schema *Schema) error {
+	e := findEnum(schema.Enums, a.Name)
+	if e == nil {
+		return nil
+	}
+	if a.RenameTo != "" {
+		for i, v := range e.Values {
+			if EnumValueLabel(v) == a.Value {
+				e.Values[i] = a.RenameTo
+			}
+		}
+		return nil
+	}
+	if slices.Contains(enumLabels(e), a.Value) {
+		return nil
+	}
+	at := len(e.Values)
+	if i := slices.Index(enumLabels(e), a.Anchor); i >= 0 && a.Position != "" {
+		at = i
+		if a.Position == "AFTER" {
+			at++
+		}
+	}
+	e.Values = slices.Insert(e.Values, at, a.Value)
+	return nil
+}
+
+func (a *AlterEnumStatement) String() string {
+	return "ALTER TYPE " + a.Name
+}
+
+// RenameTypeStatement represents ALTER TYPE ... RENAME TO, which recreated enums start with
+type RenameTypeStatement struct {
+	Name    string
+	NewName string
+}
+
+func (r *RenameTypeStatement) Apply(schema *Schema) error {
+	if e := findEnum(schema.Enums, r.Name); e != nil {
+		e.Name = r.NewName
+	}
+	if ct := findCompositeType(schema.CompositeTypes, r.Name); ct != nil {
+		ct.Name = r.NewName
+	}
+	return nil
+}
+
+func (r *RenameTypeStatement) String() string {
+	return "ALTER TYPE " + r.Name + " RENAME TO " + r.NewName
+}
+
+// parseAlterEnumStatement parses the ALTER TYPE statements changing enum labels or renaming a type
+func parseAlterEnumStatement(sql string) SQLStatement {
+	unquote := func(label string) string { return strings.ReplaceAll(label, "''", "'") }
+	if matches := alterEnumAddValueRegex.FindStringSubmatch(sql); matches != nil {
+		return &AlterEnumStatement{
+			Name:     normalizeIdent(matches[1]),
+			Value:    unquote(matches[2]),
+			Position: matches[3],
+			Anchor:   unquote(matches[4]),
+		}
+	}
+	if matches := alterEnumRenameValueRegex.FindStringSubmatch(sql); matches != nil {
+		return &AlterEnumStatement{Name: normalizeIdent(matches[1]), Value: unquote(matches[2]), RenameTo: unquote(matches[3])}
+	}
+	if matches := alterTypeRenameRegex.FindStringSubmatch(sql); matches != nil {
+		return &RenameTypeStatement{Name: normalizeIdent(matches[1]), NewName: normalizeIdent(matches[2])}
+	}
+	return nil
+}
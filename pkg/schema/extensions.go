@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"strings"
+)
+
+// parseDatasourceExtensions parses the extensions list of a datasource block, e.g.
+// extensions = [pgcrypto, citext(schema: "public"), uuidOssp(map: "uuid-ossp")]
+func parseDatasourceExtensions(line string) []*Extension {
+	start := strings.Index(line, "[")
+	end := strings.LastIndex(line, "]")
+	if start == -1 || end == -1 || end <= start {
+		return nil
+	}
+
+	var extensions []*Extension
+	for _, item := range splitComplexArgs(line[start+1 : end]) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name := item
+		if i := strings.Index(item, "("); i >= 0 {
+			name = item[:i]
+			// map: "..." overrides the database name of the extension
+			for _, arg := range splitComplexArgs(strings.TrimSuffix(item[i+1:], ")")) {
+				arg = strings.TrimSpace(arg)
+				if strings.HasPrefix(arg, "map:") {
+					name = strings.Trim(strings.TrimSpace(strings.TrimPrefix(arg, "map:")), "\"")
+				}
+			}
+		}
+		extensions = append(extensions, &Extension{Name: strings.TrimSpace(name)})
+	}
+	return extensions
+}
+
+// inferExtensions returns the extensions required by the fields of the schema
+func inferExtensions(s *Schema) []*Extension {
+	var extensions []*Extension
+	for _, m := range s.Models {
+		for _, f := range m.Fields {
+			lowerType := strings.ToLower(f.Type)
+			if strings.HasPrefix(lowerType, "unsupported(\"geometry") ||
+				strings.HasPrefix(lowerType, "unsupported(\"geography") {
+				extensions = append(extensions, &Extension{Name: "postgis"})
+			}
+			for _, attr := range f.Attributes {
+				if attr.Name == "db.Citext" {
+					extensions = append(extensions, &Extension{Name: "citext"})
+				}
+				if attr.Name == "default" && len(attr.Args) > 0 {
+					expr, ok := parseDbGenerated(attr.Args[0])
+					if !ok {
+						continue
+					}
+					if strings.Contains(expr, "gen_random_uuid(") {
+						extensions = append(extensions, &Extension{Name: "pgcrypto"})
+					}
+					if strings.Contains(expr, "uuid_generate_v") {
+						extensions = append(extensions, &Extension{Name: "uuid-ossp"})
+					}
+				}
+			}
+		}
+	}
+	return extensions
+}
+
+// addExtensions appends extensions to the schema, skipping ones already present
+func addExtensions(s *Schema, extensions []*Extension) {
+	for _, ext := range extensions {
+		if findExtension(s.Extensions, ext.Name) == nil {
+			s.Extensions = append(s.Extensions, ext)
+		}
+	}
+}
+
+func findExtension(extensions []*Extension, name string) *Extension {
+	for _, ext := range extensions {
+		if ext.Name == name {
+			return ext
+		}
+	}
+	return nil
+}
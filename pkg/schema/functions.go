@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FunctionsDir is the directory, relative to schema.prisma, holding managed function definitions
+const FunctionsDir = "sql/functions"
+
+var createFunctionRegex = regexp.MustCompile(
+	`(?is)^CREATE\s+(OR\s+REPLACE\s+)?(FUNCTION|PROCEDURE)\s+([a-zA-Z0-9_."]+)\s*\(`,
+)
+
+// loadFunctions reads every *.sql file in the functions directory next to the schema file
+func loadFunctions(schemaPath string) ([]*Function, error) {
+	dir := filepath.Join(filepath.Dir(schemaPath), FunctionsDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+
+	var functions []*Function
+	for _, name := range files {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		statements := SplitSQLStatements(string(b))
+		if len(statements) != 1 {
+			return nil, fmt.Errorf("%s/%s must contain exactly one CREATE FUNCTION or CREATE PROCEDURE statement",
+				FunctionsDir, name)
+		}
+		fn := parseFunctionDefinition(statements[0])
+		if fn == nil {
+			return nil, fmt.Errorf("%s/%s is not a CREATE FUNCTION or CREATE PROCEDURE statement", FunctionsDir, name)
+		}
+		functions = append(functions, fn)
+	}
+	return functions, nil
+}
+
+// parseFunctionDefinition parses a CREATE [OR REPLACE] FUNCTION/PROCEDURE statement.
+// The definition is always stored as CREATE OR REPLACE so changed bodies can be replaced in place.
+func parseFunctionDefinition(sql string) *Function {
+	sql = strings.TrimSuffix(strings.TrimSpace(sql), ";")
+	matches := createFunctionRegex.FindStringSubmatchIndex(sql)
+	if matches == nil {
+		return nil
+	}
+
+	kind := strings.ToUpper(sql[matches[4]:matches[5]])
+	name := strings.ToLower(strings.Trim(sql[matches[6]:matches[7]], "\""))
+	definition := "CREATE OR REPLACE " + kind + " " + sql[matches[6]:]
+
+	return &Function{
+		Name:       name,
+		Kind:       kind,
+		Definition: definition + ";",
+		Hash:       hashSQLDefinition(definition),
+	}
+}
+
+// hashSQLDefinition hashes a definition independently of its formatting
+func hashSQLDefinition(sql string) string {
+	sum := sha256.Sum256([]byte(normalizeWhitespace(strings.TrimSuffix(strings.TrimSpace(sql), ";"))))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateDropFunctionSQL(fn *Function) string {
+	return "DROP " + fn.Kind + " IF EXISTS " + fn.Name + ";"
+}
@@ -0,0 +1,1518 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phathdt/schema-manager/internal/logger"
+)
+
+func GenerateMigrationSQL(diff *SchemaDiff) string {
+	var stmts []string
+	// Statements on the tables created here take no lock worth noting
+	created := createdTables(diff.ModelsAdded)
+
+	// Extensions must exist before any type or default that depends on them
+	for _, ext := range diff.ExtensionsAdded {
+		stmts = append(stmts, wrapGooseStatement(generateExtensionSQL(ext)))
+	}
+
+	// Drop removed or changed triggers and policies before the tables they are attached to change
+	for _, t := range diff.TriggersRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateDropTriggerSQL(t)))
+	}
+	for _, p := range diff.PoliciesRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateDropPolicySQL(p)))
+	}
+	// Renamed tables and columns get their new names before anything refers to them
+	stmts = append(stmts, generateRenameSQL(diff)...)
+	for _, m := range diff.RowLevelSecurityDisabled {
+		stmts = append(stmts, wrapGooseStatement(generateRowLevelSecuritySQL(m.TableName, false)))
+	}
+	for _, g := range diff.GrantsRevoked {
+		stmts = append(stmts, wrapGooseStatement(generateRevokeSQL(g)))
+	}
+	for _, v := range diff.ViewsRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateDropViewSQL(v)))
+	}
+
+	// Sequences exist before the column defaults that call nextval on them
+	for _, seq := range diff.SequencesAdded {
+		stmts = append(stmts, wrapGooseStatement(generateCreateSequenceSQL(seq)))
+	}
+
+	// Generate ENUMs first
+	for _, e := range diff.EnumsAdded {
+		enumStmt := generateEnumSQL(e)
+		stmts = append(stmts, wrapGooseStatement(enumStmt))
+	}
+	for _, ec := range diff.EnumsModified {
+		stmts = append(stmts, generateEnumChangeSQL(ec.CurrentEnum, ec.Enum, ec.Renamed, ec.Columns)...)
+	}
+	// Native composite types come next, since their attributes may be enums
+	for _, ct := range diff.CompositeTypesAdded {
+		stmts = append(stmts, wrapGooseStatement(generateCreateCompositeTypeSQL(ct)))
+	}
+	for _, ctChange := range diff.CompositeTypesModified {
+		stmt := generateAlterCompositeTypeSQL(ctChange.CurrentType, ctChange.Type)
+		if strings.Contains(stmt, " ALTER ATTRIBUTE ") {
+			// PostgreSQL only changes attribute types of composite types no column uses yet
+			warning := fmt.Sprintf("Changing attribute types of %s fails while a column uses the type; convert those columns by hand",
+				ctChange.Type.Name)
+			stmts = append(stmts, wrapGooseStatementWithWarning(stmt, warning))
+		} else {
+			stmts = append(stmts, wrapGooseStatement(stmt))
+		}
+	}
+
+	// Drop the indexes of removed unique and index attributes, and the foreign keys of removed relations
+	for _, indexChange := range diff.IndexesRemoved {
+		stmts = append(stmts, wrapGooseIndexStatement(generateDropIndexSQL(indexChange), indexChange.TableName))
+	}
+	for _, fkChange := range diff.ForeignKeysRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateDropForeignKeySQL(fkChange)))
+	}
+
+	// Drop changed primary keys
+	for _, pkChange := range diff.PrimaryKeys {
+		if len(pkChange.CurrentColumns) > 0 {
+			stmts = append(stmts, wrapGooseStatement(generateDropPrimaryKeySQL(pkChange.TableName)))
+		}
+	}
+
+	// Column additions, removals and modifications are coalesced into one ALTER TABLE per table
+	var columnChanges []*columnChange
+	for _, fieldChange := range diff.FieldsAdded {
+		if backfill := addColumnBackfillSQL(fieldChange, diff.Generator); backfill != nil {
+			// The batches commit on their own, so each statement gets a goose statement of its own
+			stmts = append(stmts, joinGooseStatements(backfill))
+			continue
+		}
+		columnChanges = append(columnChanges, &columnChange{table: fieldChange.ModelName, sql: generateAddColumnSQL(fieldChange, diff.Generator)})
+	}
+	for _, fieldChange := range diff.FieldsRemoved {
+		warning := fmt.Sprintf("IRREVERSIBLE: Dropping column %s.%s - all data in this column will be lost!%s",
+			fieldChange.ModelName, fieldChange.Field.ColumnName, diff.RowImpact(fieldChange.ModelName))
+		columnChanges = append(columnChanges, &columnChange{table: fieldChange.ModelName, sql: generateDropColumnSQL(fieldChange), warning: warning})
+	}
+	var backfills []string
+	for _, fieldChange := range diff.FieldsModified {
+		stmt, warning := generateModifyColumnSQLWithWarning(fieldChange, diff.Generator)
+		if warning != "" {
+			warning += diff.RowImpact(fieldChange.ModelName)
+		}
+		columnChanges = append(columnChanges, &columnChange{table: fieldChange.ModelName, sql: stmt, warning: warning})
+		if backfill := notNullBackfillSQL(fieldChange, diff.Generator); backfill != nil {
+			backfills = append(backfills, joinGooseStatements(backfill))
+		}
+	}
+	stmts = append(stmts, coalesceColumnChanges(columnChanges)...)
+	// Batched backfills run once their columns have their new types
+	stmts = append(stmts, backfills...)
+
+	// Indexes of added attributes are created once their columns have their new types
+	for _, indexChange := range diff.IndexesAdded {
+		if indexChange.Index.Unique {
+			warning := fmt.Sprintf("Creating unique index %s fails if %s already has duplicate values", indexChange.Index.Name,
+				indexChange.TableName)
+			stmts = append(stmts, wrapGooseStatementWithWarning(indexChange.Index.Definition, warning))
+		} else {
+			stmts = append(stmts, wrapGooseStatement(indexChange.Index.Definition))
+		}
+	}
+
+	// New primary keys are added once their columns exist and are NOT NULL
+	for _, pkChange := range diff.PrimaryKeys {
+		if len(pkChange.Columns) > 0 {
+			warning := fmt.Sprintf("Adding primary key (%s) on %s fails if the columns hold NULL or duplicate values",
+				strings.Join(pkChange.Columns, ", "), pkChange.TableName)
+			stmts = append(stmts, wrapGooseStatementWithWarning(generateAddPrimaryKeySQL(pkChange.TableName, pkChange.Columns), warning))
+		}
+	}
+
+	// Changed doc comments of existing tables and columns
+	for _, commentChange := range diff.Comments {
+		stmts = append(stmts, wrapGooseStatement(generateCommentSQL(commentChange.TableName, commentChange.Column, commentChange.Comment)))
+	}
+
+	// Constraint names a template maps to the same value get a numeric suffix
+	fkNames := map[string]bool{}
+	for _, m := range diff.ModelsAdded {
+		for _, stmt := range generateCreateTableSQL(m, diff.Generator, fkNames) {
+			stmts = append(stmts, wrapGooseStatementIn(created, stmt))
+		}
+	}
+	// Foreign keys of relations on existing tables are added once the tables they reference exist
+	for _, fkChange := range diff.ForeignKeysAdded {
+		warning := fmt.Sprintf("Adding foreign key %s fails if %s has rows without a matching %s row", fkChange.ForeignKey.Name,
+			fkChange.TableName, fkChange.ForeignKey.RefTable)
+		stmts = append(stmts, wrapGooseStatementWithWarning(generateAddForeignKeySQL(fkChange), warning))
+	}
+	for _, m := range diff.ModelsRemoved {
+		warning := fmt.Sprintf("IRREVERSIBLE: Dropping table %s - all data will be lost!%s", m.TableName, diff.RowImpact(m.TableName))
+		stmts = append(stmts, wrapGooseStatementWithWarning("DROP TABLE IF EXISTS "+quoteIdent(m.TableName)+";", warning))
+	}
+	// Removed composite types, enums and sequences are dropped once no column uses them
+	for _, ct := range reverseCompositeTypes(sortCompositeTypes(diff.CompositeTypesRemoved)) {
+		stmts = append(stmts, wrapGooseStatement(generateDropCompositeTypeSQL(ct)))
+	}
+	for _, e := range diff.EnumsRemoved {
+		stmts = append(stmts, wrapGooseStatement("DROP TYPE IF EXISTS "+e.Name+";"))
+	}
+	for _, seq := range diff.SequencesRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateDropSequenceSQL(seq)))
+	}
+	// and the columns owning new or changed sequences exist
+	for _, seq := range diff.SequencesAdded {
+		if seq.OwnerTable != "" {
+			stmts = append(stmts, wrapGooseStatement(generateSequenceOwnerSQL(seq)))
+		}
+	}
+	for _, seqChange := range diff.SequencesModified {
+		stmts = append(stmts, wrapGooseStatement(generateAlterSequenceSQL(seqChange.Sequence)))
+	}
+	// Functions are created or replaced after tables, and before the triggers that call them
+	for _, fn := range diff.FunctionsAdded {
+		stmts = append(stmts, wrapGooseStatement(fn.Definition))
+	}
+	for _, fnChange := range diff.FunctionsModified {
+		stmts = append(stmts, wrapGooseStatement(fnChange.Function.Definition))
+	}
+
+	// Views are created or replaced once the tables and functions they select from exist
+	for _, v := range diff.ViewsAdded {
+		stmts = append(stmts, wrapGooseStatement(v.Definition))
+	}
+	for _, vChange := range diff.ViewsModified {
+		stmts = append(stmts, wrapGooseStatement(vChange.View.Definition))
+	}
+
+	// Triggers and policies are created last so their tables and functions already exist
+	for _, m := range diff.RowLevelSecurityEnabled {
+		stmts = append(stmts, wrapGooseStatementIn(created, generateRowLevelSecuritySQL(m.TableName, true)))
+	}
+	for _, p := range diff.PoliciesAdded {
+		stmts = append(stmts, wrapGooseStatementIn(created, p.Definition))
+	}
+	for _, t := range diff.TriggersAdded {
+		stmts = append(stmts, wrapGooseStatementIn(created, t.Definition))
+	}
+	for _, g := range diff.GrantsAdded {
+		stmts = append(stmts, wrapGooseStatement(generateGrantSQL(g)))
+	}
+	for _, fn := range diff.FunctionsRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateDropFunctionSQL(fn)))
+	}
+	for _, ext := range diff.ExtensionsRemoved {
+		warning := fmt.Sprintf("Dropping extension %s - objects created outside this schema may depend on it", ext.Name)
+		stmts = append(stmts, wrapGooseStatementWithWarning(generateDropExtensionSQL(ext), warning))
+	}
+	return guardedSQL(diff.Generator, strings.Join(stmts, "\n\n"))
+}
+
+// generateCreateTableSQL returns the CREATE TABLE statement of a model followed by its indexes,
+// row-level security and grants. It serves both models added in up migrations and models removed
+// in down migrations, whose primary keys, defaults, foreign keys and indexes come from migrations.
+func generateCreateTableSQL(m *Model, generator GeneratorConfig, fkNames map[string]bool) []string {
+	cols := []string{}
+	pkCols := []string{}
+	indexes := []string{}
+	uniqueIndexes := []string{}
+	foreignKeys := []string{}
+	var comments []string
+	var relationFields [][]string
+
+	// Check for composite primary key from model attributes
+	compositePK := []string{}
+	for _, attr := range m.Attributes {
+		if attr.Name == "id" {
+			compositePK = attr.Args
+			break
+		}
+	}
+
+	for _, f := range m.Fields {
+		// Skip relation fields that don't have actual columns (array types and fields with @relation)
+		if f.IsArray || f.IsRelation {
+			continue
+		}
+		hasRelationAttr := false
+		for _, attr := range f.Attributes {
+			if attr.Name == "relation" {
+				hasRelationAttr = true
+				break
+			}
+		}
+		if hasRelationAttr {
+			continue
+		}
+		if f.Comment != "" {
+			comments = append(comments, generateCommentSQL(m.TableName, f.ColumnName, f.Comment))
+		}
+
+		isPrimary := false
+		isUnique := false
+		isNotNull := !f.IsOptional
+		var defaultVal string
+		isAutoIncrement := false
+
+		for _, attr := range f.Attributes {
+			switch attr.Name {
+			case "id":
+				isPrimary = true
+			case "unique":
+				isUnique = true
+			case "default":
+				if len(attr.Args) > 0 {
+					if attr.Args[0] == "autoincrement()" && (f.Type == "Int" || f.Type == "BigInt") {
+						isAutoIncrement = true
+					} else {
+						defaultVal = parseDefaultValue(attr.Args[0], f.Type)
+					}
+				}
+			}
+		}
+
+		var col string
+		if f.SearchVector != nil {
+			col = generateSearchColumnSQL(f)
+			indexes = append(indexes, generateSearchIndexSQL(generator, m.TableName, f))
+		} else if isPrimary && isAutoIncrement && len(compositePK) == 0 {
+			col = quoteIdent(f.ColumnName) + " " + serialTypeFor(f.Type) + " PRIMARY KEY"
+		} else {
+			col = quoteIdent(f.ColumnName) + " " + goTypeToSQLType(f.Type, isAutoIncrement, f.Attributes)
+			if defaultVal != "" {
+				col += " DEFAULT " + defaultVal
+			}
+			if isNotNull {
+				col += " NOT NULL"
+			}
+		}
+
+		if isPrimary && !isAutoIncrement {
+			pkCols = append(pkCols, quoteIdent(f.ColumnName))
+		}
+		if isUnique {
+			idxName := generator.IndexName(m.TableName, []string{f.ColumnName}, true)
+			uniqueIndexes = append(
+				uniqueIndexes,
+				"CREATE UNIQUE INDEX "+idxName+" ON "+quoteIdent(m.TableName)+"("+quoteIdent(f.ColumnName)+");",
+			)
+		}
+		cols = append(cols, col)
+	}
+
+	// Generate foreign keys for relation fields
+	for _, f := range m.Fields {
+		for _, attr := range f.Attributes {
+			if attr.Name == "relation" {
+				// Without foreign keys the database can't look up referencing rows, so the scalar fields
+				// get the index Prisma expects instead
+				if generator.RelationMode == RelationModePrisma {
+					if local, _ := relationFieldLists(attr); len(local) > 0 {
+						relationFields = append(relationFields, local)
+					}
+					break
+				}
+				// Debug: Print relation field processing
+				logger.Debug("Processing relation field: %s.%s (type: %s)", m.Name, f.Name, f.Type)
+				// Find the foreign key field referenced by this relation
+				referencedTable := relationTable(f, generator)
+
+				// Extract referenced column and foreign key field from relation args
+				referencedColumn := "id" // default
+				onDelete := ""
+				var foreignKeyField *Field
+
+				logger.Debug("  Total relation args: %d", len(attr.Args))
+				for i, relationArg := range attr.Args {
+					relationArg = strings.TrimSpace(relationArg)
+					logger.Debug("  Processing relation arg[%d]: '%s'", i, relationArg)
+					if strings.HasPrefix(relationArg, "fields:") {
+						// Extract field name from fields: [fieldName]
+						start := strings.Index(relationArg, "[")
+						end := strings.Index(relationArg, "]")
+						if start != -1 && end != -1 {
+							fieldName := strings.TrimSpace(relationArg[start+1 : end])
+							logger.Debug("    Looking for field: %s", fieldName)
+							for _, field := range m.Fields {
+								logger.Debug("      Available field: %s", field.Name)
+								if field.Name == fieldName {
+									foreignKeyField = field
+									logger.Debug("      Found FK field: %s", fieldName)
+									break
+								}
+							}
+						}
+					} else if strings.HasPrefix(relationArg, "references:") {
+						// Extract field name from references: [fieldName]
+						start := strings.Index(relationArg, "[")
+						end := strings.Index(relationArg, "]")
+						if start != -1 && end != -1 {
+							referencedColumn = strings.TrimSpace(relationArg[start+1 : end])
+							logger.Debug("    Referenced column: %s", referencedColumn)
+						}
+					} else if strings.HasPrefix(relationArg, "onDelete:") {
+						parts := strings.Split(relationArg, ":")
+						if len(parts) > 1 {
+							onDelete = strings.TrimSpace(parts[1])
+							logger.Debug("    OnDelete: %s", onDelete)
+						}
+					}
+				}
+
+				if foreignKeyField != nil {
+					fkName := uniqueName(generator.ForeignKeyName(m.TableName,
+						[]string{foreignKeyField.ColumnName}, referencedTable), fkNames)
+					fkStmt := "CONSTRAINT " + fkName + " FOREIGN KEY (" + quoteIdent(foreignKeyField.ColumnName) + ") REFERENCES " +
+						quoteIdent(referencedTable) + "(" + quoteIdent(referencedColumn) + ")"
+					if onDelete != "" {
+						fkStmt += " ON DELETE " + referentialActionSQL(onDelete)
+					}
+					foreignKeys = append(foreignKeys, fkStmt)
+				}
+				break
+			}
+		}
+	}
+	// Table-level unique/index
+	for _, attr := range m.Attributes {
+		switch attr.Name {
+		case "unique":
+			if len(attr.Args) > 0 {
+				idxCols := parseIndexFields(attr.Args, m.Fields)
+				idxName := generator.IndexName(m.TableName, idxCols, true)
+				uniqueIndexes = append(
+					uniqueIndexes,
+					"CREATE UNIQUE INDEX "+idxName+" ON "+quoteIdent(m.TableName)+"("+strings.Join(quoteIdents(idxCols), ", ")+");",
+				)
+			}
+		case "index":
+			if len(attr.Args) > 0 {
+				idxCols := parseIndexFields(attr.Args, m.Fields)
+				idxName := generator.IndexName(m.TableName, idxCols, false)
+				indexes = append(
+					indexes,
+					"CREATE INDEX "+idxName+" ON "+quoteIdent(m.TableName)+"("+strings.Join(quoteIdents(idxCols), ", ")+");",
+				)
+			}
+		}
+	}
+
+	// Handle composite primary key or regular primary key
+	if len(compositePK) > 0 {
+		// Map field names to column names for composite PK
+		compositePKCols := []string{}
+		for _, fieldName := range compositePK {
+			fieldName = strings.Trim(fieldName, "[] \"'")
+			for _, f := range m.Fields {
+				if f.Name == fieldName {
+					compositePKCols = append(compositePKCols, quoteIdent(f.ColumnName))
+					break
+				}
+			}
+		}
+		if len(compositePKCols) > 0 {
+			cols = append(cols, "PRIMARY KEY ("+strings.Join(compositePKCols, ", ")+")")
+		}
+	} else if len(pkCols) > 0 {
+		cols = append(cols, "PRIMARY KEY ("+strings.Join(pkCols, ", ")+")")
+	}
+
+	// Foreign key constraints, including the ones of tables recreated from migrations
+	for _, fk := range m.ForeignKeys {
+		fkName := fk.Name
+		if fkName == "" {
+			fkName = generator.ForeignKeyName(m.TableName, fk.Columns, fk.RefTable)
+		}
+		foreignKeys = append(foreignKeys, foreignKeyConstraintSQL(uniqueName(fkName, fkNames), fk))
+	}
+	cols = append(cols, foreignKeys...)
+	indexes = append(indexes, relationIndexSQL(m, generator, relationFields)...)
+
+	createTable := "CREATE TABLE " + quoteIdent(m.TableName) + " (\n  " + strings.Join(cols, ",\n  ") + "\n)"
+	if m.Partitioning != nil && m.Partitioning.Column != "" {
+		createTable += " PARTITION BY RANGE (" + quoteIdent(m.Partitioning.Column) + ")"
+	}
+	createTable += ";"
+	stmts := []string{createTable}
+	stmts = append(stmts, uniqueIndexes...)
+	stmts = append(stmts, indexes...)
+	for _, idx := range m.Indexes {
+		stmts = append(stmts, idx.Definition)
+	}
+	if m.RowLevelSecurity {
+		stmts = append(stmts, generateRowLevelSecuritySQL(m.TableName, true))
+	}
+	for _, g := range m.Grants {
+		grant := &GrantChange{TableName: m.TableName, Role: g.Role, Privileges: g.Privileges}
+		stmts = append(stmts, generateGrantSQL(grant))
+	}
+	if m.Comment != "" {
+		stmts = append(stmts, generateCommentSQL(m.TableName, "", m.Comment))
+	}
+	stmts = append(stmts, comments...)
+	return stmts
+}
+
+func wrapGooseStatement(sql string) string {
+	return wrapGooseStatementIn(map[string]bool{}, sql)
+}
+
+// wrapGooseStatementIn wraps a statement of a migration creating the created tables, leaving out the
+// locks on those tables since nothing can be using them yet
+func wrapGooseStatementIn(created map[string]bool, sql string) string {
+	return "-- +goose StatementBegin\n" + lockComments(sql, created) + sql + "\n-- +goose StatementEnd"
+}
+
+// wrapGooseIndexStatement wraps a statement dropping or renaming an index, annotated with the lock on
+// the index's table
+func wrapGooseIndexStatement(sql, table string) string {
+	return "-- +goose StatementBegin\n" + indexLockComments(sql, table) + sql + "\n-- +goose StatementEnd"
+}
+
+// createdTables returns the tables of the models a migration creates
+func createdTables(models []*Model) map[string]bool {
+	created := map[string]bool{}
+	for _, m := range models {
+		created[normalizeIdent(quoteIdent(m.TableName))] = true
+	}
+	return created
+}
+
+func wrapGooseStatementWithWarning(sql, warning string) string {
+	return "-- +goose StatementBegin\n-- WARNING: " + warning + "\n" + lockComments(sql, map[string]bool{}) + sql + "\n-- +goose StatementEnd"
+}
+
+// irreversibleWarningRegex matches the warnings of statements that drop a column or table with its data
+var irreversibleWarningRegex = regexp.MustCompile(`-- WARNING: IRREVERSIBLE: Dropping (?:column ([^\s.]+)\.\S+|table (\S+)) -`)
+
+// IrreversibleTables returns the tables whose data a migration section drops, taken from its
+// IRREVERSIBLE warnings, in order of appearance
+func IrreversibleTables(sql string) []string {
+	var tables []string
+	for _, matches := range irreversibleWarningRegex.FindAllStringSubmatch(sql, -1) {
+		table := matches[1] + matches[2]
+		if !containsString(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	return tables
+}
+
+// forwardOnlyDownSQL fails a rollback on purpose, so forward-only migrations can't be reverted by accident
+const forwardOnlyDownSQL = "DO $$\nBEGIN\n  RAISE EXCEPTION 'This migration is forward-only and cannot be rolled back';\nEND\n$$;"
+
+func GenerateDownMigrationSQL(diff *SchemaDiff) string {
+	if diff.Generator.ForwardOnly {
+		return wrapGooseStatement(forwardOnlyDownSQL)
+	}
+
+	var stmts []string
+	created := createdTables(diff.ModelsRemoved)
+	// For extensions removed, we need to recreate them before anything that depends on them
+	for _, ext := range diff.ExtensionsRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateExtensionSQL(ext)))
+	}
+
+	// For triggers and policies added, we need to drop them before their tables
+	for _, t := range diff.TriggersAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropTriggerSQL(t)))
+	}
+	for _, p := range diff.PoliciesAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropPolicySQL(p)))
+	}
+	for _, m := range diff.RowLevelSecurityEnabled {
+		stmts = append(stmts, wrapGooseStatement(generateRowLevelSecuritySQL(m.TableName, false)))
+	}
+	for _, g := range diff.GrantsAdded {
+		stmts = append(stmts, wrapGooseStatement(generateRevokeSQL(g)))
+	}
+	for _, v := range diff.ViewsAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropViewSQL(v)))
+	}
+
+	// For functions added, we need to drop them once no trigger uses them
+	for _, fn := range diff.FunctionsAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropFunctionSQL(fn)))
+	}
+
+	// For foreign keys added, we need to drop them before the tables they reference
+	for _, fkChange := range diff.ForeignKeysAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropForeignKeySQL(fkChange)))
+	}
+
+	// For models added, we need to drop them in down migration
+	for _, m := range diff.ModelsAdded {
+		stmts = append(stmts, wrapGooseStatement("DROP TABLE IF EXISTS "+quoteIdent(m.TableName)+";"))
+	}
+
+	// For enums, composite types and sequences removed, we need to recreate them before the columns
+	// that use them
+	for _, e := range diff.EnumsRemoved {
+		enumStmt := generateEnumSQL(e)
+		stmts = append(stmts, wrapGooseStatement(enumStmt))
+	}
+	for _, ec := range diff.EnumsModified {
+		stmts = append(stmts, generateEnumChangeSQL(ec.Enum, ec.CurrentEnum, invertedRenames(ec.Renamed), ec.TargetColumns)...)
+	}
+	for _, ct := range sortCompositeTypes(diff.CompositeTypesRemoved) {
+		stmts = append(stmts, wrapGooseStatement(generateCreateCompositeTypeSQL(ct)))
+	}
+	for _, ctChange := range diff.CompositeTypesModified {
+		stmts = append(stmts, wrapGooseStatement(generateAlterCompositeTypeSQL(ctChange.Type, ctChange.CurrentType)))
+	}
+	for _, seq := range diff.SequencesRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateCreateSequenceSQL(seq)))
+	}
+
+	// For indexes added, we need to drop them in down migration
+	for _, indexChange := range diff.IndexesAdded {
+		stmts = append(stmts, wrapGooseIndexStatement(generateDropIndexSQL(indexChange), indexChange.TableName))
+	}
+	for _, pkChange := range diff.PrimaryKeys {
+		if len(pkChange.Columns) > 0 {
+			stmts = append(stmts, wrapGooseStatement(generateDropPrimaryKeySQL(pkChange.TableName)))
+		}
+	}
+
+	// For fields added, removed and modified, we need to drop, add back and revert them, coalesced
+	// into one ALTER TABLE per table
+	var columnChanges []*columnChange
+	for _, fieldChange := range diff.FieldsAdded {
+		columnChanges = append(columnChanges, &columnChange{table: fieldChange.ModelName, sql: generateDropColumnSQL(fieldChange)})
+	}
+	for _, fieldChange := range diff.FieldsRemoved {
+		columnChanges = append(columnChanges, &columnChange{table: fieldChange.ModelName, sql: generateAddColumnSQL(fieldChange, diff.Generator)})
+	}
+	for _, fieldChange := range diff.FieldsModified {
+		columnChanges = append(columnChanges, &columnChange{table: fieldChange.ModelName, sql: generateReverseModifyColumnSQL(fieldChange, diff.Generator)})
+	}
+	stmts = append(stmts, coalesceColumnChanges(columnChanges)...)
+
+	// and restore the indexes of removed fields once every column of a composite index is back
+	restoredIndexes := map[string]bool{}
+	for _, fieldChange := range diff.FieldsRemoved {
+		for _, idx := range fieldChange.Indexes {
+			if !restoredIndexes[idx.Name] {
+				restoredIndexes[idx.Name] = true
+				stmts = append(stmts, wrapGooseStatement(idx.Definition))
+			}
+		}
+	}
+
+	// For indexes removed, we need to recreate them once their columns have their previous types
+	for _, indexChange := range diff.IndexesRemoved {
+		stmts = append(stmts, wrapGooseStatement(indexChange.Index.Definition))
+	}
+	for _, pkChange := range diff.PrimaryKeys {
+		if len(pkChange.CurrentColumns) > 0 {
+			stmts = append(stmts, wrapGooseStatement(generateAddPrimaryKeySQL(pkChange.TableName, pkChange.CurrentColumns)))
+		}
+	}
+	for _, commentChange := range diff.Comments {
+		stmts = append(stmts, wrapGooseStatement(generateCommentSQL(commentChange.TableName, commentChange.Column, commentChange.CurrentComment)))
+	}
+
+	// For composite types, enums and sequences added, we need to drop them once no column uses them
+	for _, ct := range reverseCompositeTypes(diff.CompositeTypesAdded) {
+		stmts = append(stmts, wrapGooseStatement(generateDropCompositeTypeSQL(ct)))
+	}
+	for _, e := range diff.EnumsAdded {
+		stmts = append(stmts, wrapGooseStatement("DROP TYPE IF EXISTS "+e.Name+";"))
+	}
+	for _, seq := range diff.SequencesAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropSequenceSQL(seq)))
+	}
+
+	// For models removed, we need to recreate them in down migration
+	fkNames := map[string]bool{}
+	for _, m := range diff.ModelsRemoved {
+		for _, stmt := range generateCreateTableSQL(m, diff.Generator, fkNames) {
+			stmts = append(stmts, wrapGooseStatementIn(created, stmt))
+		}
+	}
+	// For foreign keys removed, we need to restore them once the tables they reference exist again
+	for _, fkChange := range diff.ForeignKeysRemoved {
+		stmts = append(stmts, wrapGooseStatement(generateAddForeignKeySQL(fkChange)))
+	}
+	// For tables and columns renamed, we need to restore their names before the objects using them
+	stmts = append(stmts, generateRevertRenameSQL(diff)...)
+
+	// For sequences modified or removed, we need to restore their options and owners once the
+	// owning columns exist again
+	for _, seqChange := range diff.SequencesModified {
+		stmts = append(stmts, wrapGooseStatement(generateAlterSequenceSQL(seqChange.CurrentSequence)))
+	}
+	for _, seq := range diff.SequencesRemoved {
+		if seq.OwnerTable != "" {
+			stmts = append(stmts, wrapGooseStatement(generateSequenceOwnerSQL(seq)))
+		}
+	}
+
+	// For functions modified or removed, we need to restore the previous definition
+	for _, fnChange := range diff.FunctionsModified {
+		stmts = append(stmts, wrapGooseStatement(fnChange.CurrentFunction.Definition))
+	}
+	for _, fn := range diff.FunctionsRemoved {
+		stmts = append(stmts, wrapGooseStatement(fn.Definition))
+	}
+
+	// For views modified or removed, we need to restore the previous query
+	for _, vChange := range diff.ViewsModified {
+		stmts = append(stmts, wrapGooseStatement(vChange.CurrentView.Definition))
+	}
+	for _, v := range diff.ViewsRemoved {
+		stmts = append(stmts, wrapGooseStatement(v.Definition))
+	}
+
+	// For triggers and policies removed, we need to recreate them once their tables exist again
+	for _, m := range diff.RowLevelSecurityDisabled {
+		stmts = append(stmts, wrapGooseStatementIn(created, generateRowLevelSecuritySQL(m.TableName, true)))
+	}
+	for _, p := range diff.PoliciesRemoved {
+		stmts = append(stmts, wrapGooseStatementIn(created, p.Definition))
+	}
+	for _, t := range diff.TriggersRemoved {
+		stmts = append(stmts, wrapGooseStatementIn(created, t.Definition))
+	}
+	for _, g := range diff.GrantsRevoked {
+		stmts = append(stmts, wrapGooseStatement(generateGrantSQL(g)))
+	}
+
+	// For extensions added, drop them last since tables may depend on them
+	for _, ext := range diff.ExtensionsAdded {
+		stmts = append(stmts, wrapGooseStatement(generateDropExtensionSQL(ext)))
+	}
+	return guardedSQL(diff.Generator, strings.Join(stmts, "\n\n"))
+}
+
+// serialTypeFor returns the auto-increment column type for an Int or BigInt field
+func serialTypeFor(t string) string {
+	if t == "BigInt" {
+		return "BIGSERIAL"
+	}
+	return "SERIAL"
+}
+
+func goTypeToSQLType(t string, isAutoIncrement bool, attributes []*FieldAttribute) string {
+	// Check for @db type attributes first
+	for _, attr := range attributes {
+		if strings.HasPrefix(attr.Name, "db.") {
+			dbType := strings.TrimPrefix(attr.Name, "db.")
+			if dbType == "VarChar" && len(attr.Args) > 0 {
+				return "VARCHAR(" + attr.Args[0] + ")"
+			}
+			if dbType == "VarChar" {
+				return "VARCHAR"
+			}
+			if dbType == "Text" {
+				return "TEXT"
+			}
+			if dbType == "Citext" {
+				return "CITEXT"
+			}
+			if dbType == "Decimal" && len(attr.Args) >= 2 {
+				return "DECIMAL(" + attr.Args[0] + "," + attr.Args[1] + ")"
+			}
+			if sqlType, ok := nativeSQLType(dbType, attr.Args); ok {
+				return sqlType
+			}
+		}
+	}
+
+	switch t {
+	case "Int":
+		if isAutoIncrement {
+			return "SERIAL"
+		}
+		return "INTEGER"
+	case "BigInt":
+		if isAutoIncrement {
+			return "BIGSERIAL"
+		}
+		return "BIGINT"
+	case "String":
+		return "TEXT"
+	case "DateTime":
+		return "TIMESTAMP"
+	case "Boolean":
+		return "BOOLEAN"
+	case "Float":
+		return "DOUBLE PRECISION"
+	case "Decimal":
+		return "NUMERIC" // Default without precision/scale
+	case "Json":
+		return "JSONB"
+	default:
+		// Unsupported("...") carries a native database type
+		if nativeType, ok := parseUnsupportedType(t); ok {
+			return nativeType
+		}
+		// Check if it's a custom enum type
+		return t // Will be handled as enum type
+	}
+}
+
+func generateEnumSQL(e *Enum) string {
+	values := make([]string, len(e.Values))
+	for i, label := range enumLabels(e) {
+		values[i] = quoteLiteral(label)
+	}
+	return "CREATE TYPE " + e.Name + " AS ENUM (" + strings.Join(values, ", ") + ");"
+}
+
+// parseUnsupportedType extracts the native type from an Unsupported("...") field type
+func parseUnsupportedType(t string) (string, bool) {
+	if !strings.HasPrefix(t, "Unsupported(") || !strings.HasSuffix(t, ")") {
+		return "", false
+	}
+	return strings.Trim(strings.TrimSuffix(strings.TrimPrefix(t, "Unsupported("), ")"), "\""), true
+}
+
+func generateExtensionSQL(ext *Extension) string {
+	return "CREATE EXTENSION IF NOT EXISTS \"" + ext.Name + "\";"
+}
+
+func generateDropExtensionSQL(ext *Extension) string {
+	return "DROP EXTENSION IF EXISTS \"" + ext.Name + "\";"
+}
+
+func isRelationField(field *Field) bool {
+	for _, attr := range field.Attributes {
+		if attr.Name == "relation" {
+			return true
+		}
+	}
+	// Also check if it's an array type or custom type (not basic types)
+	if field.IsArray {
+		return true
+	}
+	// Check if it's a custom model type
+	if field.Type != "Int" && field.Type != "String" && field.Type != "DateTime" && field.Type != "Boolean" &&
+		field.Type != "Float" {
+		// Could be a custom model or enum - check if it has relation attributes
+		return len(field.Attributes) == 0 // Relations usually have no attributes or only @relation
+	}
+	return false
+}
+
+// foreignKeyConstraintSQL returns the CONSTRAINT ... FOREIGN KEY clause of a foreign key
+func foreignKeyConstraintSQL(name string, fk *ForeignKey) string {
+	sql := "CONSTRAINT " + name + " FOREIGN KEY (" + strings.Join(quoteIdents(fk.Columns), ", ") +
+		") REFERENCES " + quoteIdent(fk.RefTable) + "(" + strings.Join(quoteIdents(fk.RefColumns), ", ") + ")"
+	if fk.OnDelete != "" {
+		sql += " ON DELETE " + fk.OnDelete
+	}
+	return sql
+}
+
+func generateAddForeignKeySQL(fkChange *ForeignKeyChange) string {
+	return "ALTER TABLE " + quoteIdent(fkChange.TableName) + " ADD " +
+		foreignKeyConstraintSQL(fkChange.ForeignKey.Name, fkChange.ForeignKey) + ";"
+}
+
+func generateDropForeignKeySQL(fkChange *ForeignKeyChange) string {
+	return "ALTER TABLE " + quoteIdent(fkChange.TableName) + " DROP CONSTRAINT IF EXISTS " + fkChange.ForeignKey.Name + ";"
+}
+
+// referentialActionSQL returns the SQL of a Prisma referential action, e.g. SET NULL for SetNull
+func referentialActionSQL(action string) string {
+	switch action {
+	case "NoAction":
+		return "NO ACTION"
+	case "SetNull":
+		return "SET NULL"
+	case "SetDefault":
+		return "SET DEFAULT"
+	}
+	return strings.ToUpper(action)
+}
+
+// relationTable returns the table a relation field's foreign key references: the table of the related
+// model, with its @@map and naming strategy, or the pluralized type for fields not resolved against a schema
+func relationTable(f *Field, generator GeneratorConfig) string {
+	if f.RelationTable != "" {
+		return f.RelationTable
+	}
+	return strings.ToLower(generator.Pluralize(f.Type))
+}
+
+func getRelationInfo(field *Field) (string, string, string) {
+	// Returns: referencedTable, referencedColumn, onDelete
+	var referencedTable, referencedColumn, onDelete string
+	for _, attr := range field.Attributes {
+		if attr.Name == "relation" {
+			if len(attr.Args) >= 2 {
+				// @relation(fields: [organizationId], references: [id])
+				for _, arg := range attr.Args {
+					arg = strings.TrimSpace(arg)
+					if strings.HasPrefix(arg, "fields:") {
+						// Skip - this is the local field
+					} else if strings.HasPrefix(arg, "references:") {
+						// Extract referenced column
+						refPart := strings.TrimPrefix(arg, "references:")
+						refPart = strings.Trim(refPart, " []")
+						referencedColumn = refPart
+					} else if strings.HasPrefix(arg, "onDelete:") {
+						onDelete = strings.TrimPrefix(arg, "onDelete:")
+						onDelete = strings.TrimSpace(onDelete)
+					}
+				}
+			}
+		}
+	}
+
+	// Extract referenced table from the related model
+	if field.Type != "Int" && field.Type != "String" {
+		referencedTable = relationTable(field, GeneratorConfig{})
+	}
+
+	if referencedColumn == "" {
+		referencedColumn = "id" // Default reference column
+	}
+
+	return referencedTable, referencedColumn, onDelete
+}
+
+func parseDefaultValue(val, typ string) string {
+	// dbgenerated("...") holds a raw SQL expression that is passed through untouched
+	if expr, ok := parseDbGenerated(val); ok {
+		return expr
+	}
+
+	v := strings.Trim(val, "\"")
+	// uuid(), cuid() and the like are generated by the Prisma client, the column has no default
+	if name, _, found := strings.Cut(v, "("); found && containsString(prismaClientDefaults, name) {
+		return ""
+	}
+	switch typ {
+	case "String":
+		return "'" + v + "'"
+	case "DateTime":
+		if v == "now()" {
+			return "CURRENT_TIMESTAMP"
+		}
+		return v
+	case "Boolean":
+		if v == "true" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		if v == "autoincrement()" {
+			return "" // This should be handled by SERIAL, so we return empty for default
+		}
+		// Any other type is an enum, whose labels are string literals
+		if !scalarTypes[typ] && !strings.HasPrefix(typ, "Unsupported(") {
+			return "'" + v + "'"
+		}
+		return v
+	}
+}
+
+// parseDbGenerated extracts the SQL expression from a dbgenerated("...") default
+func parseDbGenerated(val string) (string, bool) {
+	val = strings.TrimSpace(val)
+	if !strings.HasPrefix(val, "dbgenerated(") || !strings.HasSuffix(val, ")") {
+		return "", false
+	}
+	inner := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(val, "dbgenerated("), ")"))
+	if expr, err := strconv.Unquote(inner); err == nil {
+		return expr, true
+	}
+	return strings.Trim(inner, "\""), true
+}
+
+func generateAddColumnSQL(fieldChange *FieldChange, generator GeneratorConfig) string {
+	f := fieldChange.Field
+
+	// Skip relation fields that don't have actual columns (array types and fields with @relation)
+	if f.IsArray || f.IsRelation {
+		return ""
+	}
+	hasRelationAttr := false
+	for _, attr := range f.Attributes {
+		if attr.Name == "relation" {
+			hasRelationAttr = true
+			break
+		}
+	}
+	if hasRelationAttr {
+		return ""
+	}
+
+	isPrimary := false
+	isUnique := false
+	isNotNull := !f.IsOptional
+	var defaultVal string
+	isAutoIncrement := false
+
+	for _, attr := range f.Attributes {
+		switch attr.Name {
+		case "id":
+			isPrimary = true
+		case "unique":
+			isUnique = true
+		case "default":
+			if len(attr.Args) > 0 {
+				if attr.Args[0] == "autoincrement()" && (f.Type == "Int" || f.Type == "BigInt") {
+					isAutoIncrement = true
+				} else {
+					defaultVal = parseDefaultValue(attr.Args[0], f.Type)
+				}
+			}
+		}
+	}
+
+	var col string
+	if f.SearchVector != nil {
+		col = generateSearchColumnSQL(f)
+	} else if isPrimary && isAutoIncrement {
+		// The primary key is added with the primary key change of the table
+		col = quoteIdent(f.ColumnName) + " " + serialTypeFor(f.Type)
+	} else {
+		col = quoteIdent(f.ColumnName) + " " + goTypeToSQLType(f.Type, isAutoIncrement, f.Attributes)
+		if defaultVal != "" {
+			col += " DEFAULT " + defaultVal
+		}
+		if isNotNull {
+			col += " NOT NULL"
+		}
+	}
+
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", quoteIdent(fieldChange.ModelName), col)
+
+	// Handle unique constraint separately
+	if isUnique {
+		idxName := generator.IndexName(fieldChange.ModelName, []string{f.ColumnName}, true)
+		stmt += fmt.Sprintf("\nCREATE UNIQUE INDEX %s ON %s(%s);", idxName, quoteIdent(fieldChange.ModelName),
+			quoteIdent(f.ColumnName))
+	}
+
+	// Generated search columns are backed by a GIN index
+	if f.SearchVector != nil {
+		stmt += "\n" + generateSearchIndexSQL(generator, fieldChange.ModelName, f)
+	}
+
+	if f.Comment != "" {
+		stmt += "\n" + generateCommentSQL(fieldChange.ModelName, f.ColumnName, f.Comment)
+	}
+
+	return stmt
+}
+
+func generateDropColumnSQL(fieldChange *FieldChange) string {
+	f := fieldChange.Field
+
+	// Skip relation fields that don't have actual columns
+	if f.IsArray || f.IsRelation {
+		return ""
+	}
+	hasRelationAttr := false
+	for _, attr := range f.Attributes {
+		if attr.Name == "relation" {
+			hasRelationAttr = true
+			break
+		}
+	}
+	if hasRelationAttr {
+		return ""
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", quoteIdent(fieldChange.ModelName),
+		quoteIdent(f.ColumnName))
+}
+
+// generateDropPrimaryKeySQL drops the primary key of a table by the name PostgreSQL gives it
+func generateDropPrimaryKeySQL(table string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", quoteIdent(table), quoteIdent(primaryKeyName(table)))
+}
+
+func generateAddPrimaryKeySQL(table string, columns []string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);", quoteIdent(table), strings.Join(quoteIdents(columns), ", "))
+}
+
+// generateDropIndexSQL drops an index, through its constraint when a UNIQUE constraint created it
+func generateDropIndexSQL(indexChange *IndexChange) string {
+	if strings.HasPrefix(indexChange.Index.Definition, "ALTER TABLE") {
+		return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", quoteIdent(indexChange.TableName),
+			quoteIdent(indexChange.Index.Name))
+	}
+	return "DROP INDEX IF EXISTS " + quoteIdent(indexChange.Index.Name) + ";"
+}
+
+// relationIndexSQL returns the indexes on the scalar fields of relations in relationMode "prisma",
+// skipping the fields a primary key, unique or index of the model already starts with
+func relationIndexSQL(m *Model, generator GeneratorConfig, relationFields [][]string) []string {
+	var keys [][]string
+	for _, attr := range m.Attributes {
+		switch attr.Name {
+		case "id", "unique", "index":
+			keys = append(keys, modelAttributeFields(attr))
+		}
+	}
+	for _, f := range m.Fields {
+		if findFieldAttribute(f, "id") != nil || findFieldAttribute(f, "unique") != nil {
+			keys = append(keys, []string{f.Name})
+		}
+	}
+
+	var stmts []string
+	for _, local := range relationFields {
+		covered := false
+		for _, key := range keys {
+			if hasFieldPrefix(key, local) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		keys = append(keys, local)
+		idxCols := parseIndexFields(local, m.Fields)
+		stmts = append(stmts, "CREATE INDEX "+generator.IndexName(m.TableName, idxCols, false)+" ON "+
+			quoteIdent(m.TableName)+"("+strings.Join(quoteIdents(idxCols), ", ")+");")
+	}
+	return stmts
+}
+
+func parseIndexFields(args []string, fields []*Field) []string {
+	var cols []string
+	for _, a := range args {
+		s := strings.Trim(a, "[] \"'")
+		if s == "" {
+			continue
+		}
+		for _, f := range fields {
+			if f.Name == s {
+				cols = append(cols, f.ColumnName)
+			}
+		}
+	}
+	return cols
+}
+
+func generateModifyColumnSQLWithWarning(fieldChange *FieldChange, generator GeneratorConfig) (string, string) {
+	currentField := fieldChange.CurrentField
+	targetField := fieldChange.Field
+
+	// Skip relation fields
+	if targetField.IsArray || targetField.IsRelation {
+		return "", ""
+	}
+	hasRelationAttr := false
+	for _, attr := range targetField.Attributes {
+		if attr.Name == "relation" {
+			hasRelationAttr = true
+			break
+		}
+	}
+	if hasRelationAttr {
+		return "", ""
+	}
+
+	var stmts []string
+	var warnings []string
+	table, column := quoteIdent(fieldChange.ModelName), quoteIdent(targetField.ColumnName)
+
+	// Compare types using the same logic as field comparison
+	currentNormalizedType, targetNormalizedType := fieldChange.NormalizedTypes()
+	hasTypeChange := currentNormalizedType != targetNormalizedType
+	if fromType, toType, castResult, changed := fieldChange.TypeChange(generator); changed {
+		newSQLType := GetSQLTypeForField(targetField)
+		if fieldChange.TargetEnum != nil {
+			newSQLType = fieldChange.TargetEnum.Name
+		}
+		dropDefault, setDefault := enumDefaultSQL(table, column, currentField, targetField, fieldChange.CurrentEnum, fieldChange.TargetEnum)
+
+		if castResult.CanCast {
+			if hasTypeChange && dropDefault != "" {
+				stmts = append(stmts, dropDefault)
+			}
+			if castResult.CastExpression != "" {
+				// Use explicit casting
+				stmt := fmt.Sprintf(
+					"ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s;",
+					table,
+					column,
+					newSQLType,
+					castResult.UsingExpression(column),
+				)
+				stmts = append(stmts, stmt)
+			} else {
+				// Simple type change
+				stmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+					table, column, newSQLType)
+				stmts = append(stmts, stmt)
+			}
+			if hasTypeChange && setDefault != "" {
+				stmts = append(stmts, setDefault)
+			}
+
+			// Collect warnings for risky conversions
+			if castResult.IsRisky {
+				warning := fmt.Sprintf(
+					"RISKY CONVERSION: %s.%s from %s to %s - %s. This cannot be safely rolled back!",
+					fieldChange.ModelName,
+					targetField.ColumnName,
+					fromType,
+					toType,
+					castResult.WarningMessage,
+				)
+				warnings = append(warnings, warning)
+				LogTypeCastWarning(fieldChange.ModelName, targetField.ColumnName, castResult)
+			}
+		} else {
+			// Cannot cast automatically
+			logger.Error("Cannot automatically convert column %s.%s - %s",
+				fieldChange.ModelName, targetField.ColumnName, castResult.WarningMessage)
+			stmts = append(stmts, fmt.Sprintf("-- ERROR: %s\n-- Manual migration required for %s.%s",
+				castResult.WarningMessage, fieldChange.ModelName, targetField.ColumnName))
+			warning := fmt.Sprintf("MANUAL INTERVENTION REQUIRED: %s", castResult.WarningMessage)
+			warnings = append(warnings, warning)
+		}
+	}
+
+	// Check if the default changed; a change between enum types already resets it
+	enumTypeChange := hasTypeChange && (fieldChange.CurrentEnum != nil || fieldChange.TargetEnum != nil)
+	if !enumTypeChange && !defaultsEqual(currentField, targetField, fieldChange.TargetEnum) {
+		stmts = append(stmts, alterDefaultSQL(table, column, targetField, fieldChange.TargetEnum))
+	}
+
+	// Check if nullability changed
+	if currentField.IsOptional != targetField.IsOptional {
+		if targetField.IsOptional {
+			// Make column nullable
+			nullStmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;",
+				table, column)
+			stmts = append(stmts, nullStmt)
+		} else if notNullBackfillSQL(fieldChange, generator) == nil {
+			// Make column not nullable - this is risky
+			nullStmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;",
+				table, column)
+			stmts = append(stmts, nullStmt)
+			warning := fmt.Sprintf("RISKY: Making %s.%s NOT NULL - will fail if NULL values exist. Cannot be safely rolled back if data is modified!",
+				fieldChange.ModelName, targetField.ColumnName)
+			warnings = append(warnings, warning)
+		}
+	}
+
+	if len(stmts) == 0 && notNullBackfillSQL(fieldChange, generator) != nil {
+		// The batched backfill makes the column required on its own
+		return "", ""
+	}
+	if len(stmts) == 0 {
+		// No actual changes detected
+		return fmt.Sprintf("-- No changes detected for %s.%s", fieldChange.ModelName, targetField.ColumnName), ""
+	}
+
+	var combinedWarning string
+	if len(warnings) > 0 {
+		combinedWarning = strings.Join(warnings, " | ")
+	}
+
+	return strings.Join(stmts, "\n"), combinedWarning
+}
+
+// enumDefaultSQL returns the statements dropping a column's default before a type change and setting
+// the new one after it, for changes to, from or between enums whose defaults PostgreSQL can't cast.
+// Both are empty for other columns.
+func enumDefaultSQL(table, column string, from, to *Field, fromEnum, toEnum *Enum) (string, string) {
+	if fromEnum == nil && toEnum == nil {
+		return "", ""
+	}
+	var dropDefault, setDefault string
+	if findFieldAttribute(from, "default") != nil {
+		dropDefault = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, column)
+	}
+	if value := fieldDefaultSQL(to, toEnum); value != "" {
+		setDefault = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, value)
+	}
+	return dropDefault, setDefault
+}
+
+// fieldDefaultSQL returns the SQL expression of the @default of a field, "" without one. The labels
+// of enum fields are quoted as string literals.
+func fieldDefaultSQL(f *Field, enum *Enum) string {
+	attr := findFieldAttribute(f, "default")
+	if attr == nil || len(attr.Args) == 0 {
+		return ""
+	}
+	if expr, ok := parseDbGenerated(attr.Args[0]); ok {
+		return expr
+	}
+	if enum != nil {
+		return quoteLiteral(enumDefaultLabel(enum, strings.Trim(attr.Args[0], "\"")))
+	}
+	return parseDefaultValue(attr.Args[0], f.Type)
+}
+
+// alterDefaultSQL sets the default of a column to the @default of a field, or drops it
+func alterDefaultSQL(table, column string, f *Field, enum *Enum) string {
+	if value := fieldDefaultSQL(f, enum); value != "" {
+		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, value)
+	}
+	return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, column)
+}
+
+// handleStringTypeChange handles changes between TEXT, VARCHAR(n) and CITEXT columns; only a
+// shorter length limit can fail
+func handleStringTypeChange(currentType, targetType string) TypeCastResult {
+	currentLength, targetLength := varcharLength(currentType), varcharLength(targetType)
+	if targetLength > 0 && (currentLength == 0 || currentLength > targetLength) {
+		return TypeCastResult{
+			CanCast: true,
+			IsRisky: true,
+			WarningMessage: fmt.Sprintf(
+				"Converting %s to %s fails if existing values are longer than %d characters",
+				currentType, targetType, targetLength,
+			),
+		}
+	}
+	return TypeCastResult{CanCast: true}
+}
+
+// handleDecimalPrecisionChange handles changes between different DECIMAL precision/scale configurations
+func handleDecimalPrecisionChange(currentType, targetType string) TypeCastResult {
+	// Extract precision and scale from both types
+	currentPrec, currentScale := extractDecimalPrecisionScale(currentType)
+	targetPrec, targetScale := extractDecimalPrecisionScale(targetType)
+
+	if currentPrec == -1 || targetPrec == -1 {
+		// Fallback if we can't parse the precision/scale
+		return TypeCastResult{
+			CanCast:        false,
+			WarningMessage: "Cannot parse DECIMAL precision/scale for comparison",
+		}
+	}
+
+	// Check if this is a safe conversion
+	isRisky := false
+	warningMessage := ""
+
+	// Reducing precision is risky (data might not fit)
+	if targetPrec < currentPrec {
+		isRisky = true
+		warningMessage = fmt.Sprintf(
+			"Reducing precision from %d to %d may cause data loss if values exceed the new precision",
+			currentPrec,
+			targetPrec,
+		)
+	}
+
+	// Reducing scale is risky (decimal places will be truncated)
+	if targetScale < currentScale {
+		isRisky = true
+		if warningMessage != "" {
+			warningMessage += "; "
+		}
+		warningMessage += fmt.Sprintf(
+			"Reducing scale from %d to %d will truncate decimal places",
+			currentScale,
+			targetScale,
+		)
+	}
+
+	// Generate appropriate success message for safe changes
+	if !isRisky {
+		if targetPrec > currentPrec && targetScale > currentScale {
+			warningMessage = "Increasing both precision and scale - safe operation"
+		} else if targetPrec > currentPrec {
+			warningMessage = "Increasing precision - safe operation"
+		} else if targetScale > currentScale {
+			warningMessage = "Increasing scale - safe operation"
+		} else {
+			warningMessage = "DECIMAL precision/scale change"
+		}
+	}
+
+	return TypeCastResult{
+		CanCast:        true,
+		CastExpression: "", // No special casting needed for DECIMAL changes
+		IsRisky:        isRisky,
+		WarningMessage: warningMessage,
+	}
+}
+
+// extractDecimalPrecisionScale extracts precision and scale from a DECIMAL type string
+// Returns (-1, -1) if parsing fails
+func extractDecimalPrecisionScale(decimalType string) (int, int) {
+	// Normalize to uppercase for consistent parsing
+	decimalType = strings.ToUpper(decimalType)
+
+	// Handle DECIMAL(precision, scale) format
+	if !strings.HasPrefix(decimalType, "DECIMAL(") {
+		return -1, -1
+	}
+
+	start := strings.Index(decimalType, "(")
+	end := strings.Index(decimalType, ")")
+	if start == -1 || end == -1 || end <= start {
+		return -1, -1
+	}
+
+	params := decimalType[start+1 : end]
+	parts := strings.Split(params, ",")
+	if len(parts) != 2 {
+		return -1, -1
+	}
+
+	precision, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	scale, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+
+	if err1 != nil || err2 != nil {
+		return -1, -1
+	}
+
+	return precision, scale
+}
+
+func generateModifyColumnSQL(fieldChange *FieldChange, generator GeneratorConfig) string {
+	sql, _ := generateModifyColumnSQLWithWarning(fieldChange, generator)
+	return sql
+}
+
+func generateReverseModifyColumnSQL(fieldChange *FieldChange, generator GeneratorConfig) string {
+	currentField := fieldChange.CurrentField // What it was before
+	targetField := fieldChange.Field         // What it became
+
+	// Skip relation fields
+	if targetField.IsArray || targetField.IsRelation {
+		return ""
+	}
+	hasRelationAttr := false
+	for _, attr := range targetField.Attributes {
+		if attr.Name == "relation" {
+			hasRelationAttr = true
+			break
+		}
+	}
+	if hasRelationAttr {
+		return ""
+	}
+
+	var stmts []string
+	table, column := quoteIdent(fieldChange.ModelName), quoteIdent(targetField.ColumnName)
+
+	// Reverse type changes
+	currentNormalizedType, targetNormalizedType := fieldChange.NormalizedTypes()
+
+	// Get the actual SQL types to compare precision/scale differences
+	currentSQLType := GetSQLTypeForField(currentField)
+	targetSQLType := GetSQLTypeForField(targetField)
+
+	// Check if we have a type change (normalized types differ) or DECIMAL precision/scale change
+	hasTypeChange := currentNormalizedType != targetNormalizedType
+	hasDecimalChange := currentNormalizedType == "Decimal" && targetNormalizedType == "Decimal" &&
+		currentSQLType != targetSQLType
+	hasTimestampPrecisionChange := !hasTypeChange && timestampBaseType(currentNormalizedType) != "" &&
+		currentSQLType != targetSQLType
+	hasStringTypeChange := !hasTypeChange && currentNormalizedType == "String" && currentSQLType != targetSQLType
+
+	if hasTypeChange || hasDecimalChange || hasTimestampPrecisionChange || hasStringTypeChange {
+		// Need to reverse the type change: target -> current
+		fromType, toType := currentNormalizedType, targetNormalizedType
+		if !hasTypeChange {
+			fromType, toType = currentSQLType, targetSQLType
+		}
+		originalSQLType := currentSQLType
+		var castResult TypeCastResult
+
+		if hasDecimalChange {
+			// Special handling for DECIMAL precision/scale changes - reverse direction
+			castResult = handleDecimalPrecisionChange(targetSQLType, currentSQLType)
+		} else if hasTimestampPrecisionChange {
+			castResult = TypeCastResult{CanCast: true}
+		} else if hasStringTypeChange {
+			castResult = handleStringTypeChange(targetSQLType, currentSQLType)
+		} else {
+			castResult = fieldChange.CastType(generator, true)
+		}
+		using := UsingOverride(targetField, true)
+		if using != "" {
+			castResult = TypeCastResult{CanCast: true, CastExpression: using}
+		}
+
+		if fieldChange.CurrentEnum != nil {
+			originalSQLType = fieldChange.CurrentEnum.Name
+		}
+		dropDefault, setDefault := enumDefaultSQL(table, column, targetField, currentField, fieldChange.TargetEnum, fieldChange.CurrentEnum)
+		if castResult.CanCast && hasTypeChange && dropDefault != "" {
+			stmts = append(stmts, dropDefault)
+		}
+
+		if castResult.CanCast && !castResult.IsRisky {
+			// Safe to reverse
+			if (hasDecimalChange && using == "") || castResult.CastExpression == "" {
+				// DECIMAL changes or no casting needed
+				stmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+					table, column, originalSQLType)
+				stmts = append(stmts, stmt)
+			} else {
+				stmt := fmt.Sprintf(
+					"ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s;",
+					table,
+					column,
+					originalSQLType,
+					castResult.UsingExpression(column),
+				)
+				stmts = append(stmts, stmt)
+			}
+		} else if castResult.CanCast && castResult.IsRisky {
+			// Risky reversal - warn but allow
+			if hasDecimalChange {
+				// DECIMAL changes don't need USING clause
+				stmt := fmt.Sprintf("-- WARNING: Risky type reversal from %s to %s\n-- %s\nALTER TABLE %s ALTER COLUMN %s TYPE %s;",
+					toType, fromType, castResult.WarningMessage,
+					table, column, originalSQLType)
+				stmts = append(stmts, stmt)
+			} else {
+				stmt := fmt.Sprintf("-- WARNING: Risky type reversal from %s to %s\n-- %s\nALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s;",
+					toType, fromType, castResult.WarningMessage,
+					table, column, originalSQLType, castResult.UsingExpression(column))
+				stmts = append(stmts, stmt)
+			}
+		}
+		if castResult.CanCast && hasTypeChange && setDefault != "" {
+			stmts = append(stmts, setDefault)
+		}
+		if !castResult.CanCast {
+			// Cannot reverse automatically
+			stmt := fmt.Sprintf("-- ERROR: Cannot automatically reverse type change for %s.%s\n-- From %s back to %s: %s\n-- Manual intervention required",
+				fieldChange.ModelName, targetField.ColumnName, toType, fromType, castResult.WarningMessage)
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	// Reverse default changes
+	enumTypeChange := hasTypeChange && (fieldChange.CurrentEnum != nil || fieldChange.TargetEnum != nil)
+	if !enumTypeChange && !defaultsEqual(currentField, targetField, fieldChange.TargetEnum) {
+		stmts = append(stmts, alterDefaultSQL(table, column, currentField, fieldChange.CurrentEnum))
+	}
+
+	// Reverse nullability changes
+	if currentField.IsOptional != targetField.IsOptional {
+		if currentField.IsOptional {
+			// Original was nullable, target became not null -> reverse to nullable
+			nullStmt := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;",
+				table, column)
+			stmts = append(stmts, nullStmt)
+		} else {
+			// Original was not null, target became nullable -> reverse to not null
+			// This is potentially dangerous if NULL values were inserted
+			nullStmt := fmt.Sprintf("-- WARNING: Setting NOT NULL may fail if NULL values exist\nALTER TABLE %s ALTER COLUMN %s SET NOT NULL;",
+				table, column)
+			stmts = append(stmts, nullStmt)
+		}
+	}
+
+	if len(stmts) == 0 {
+		return fmt.Sprintf("-- No reverse changes needed for %s.%s", fieldChange.ModelName, targetField.ColumnName)
+	}
+
+	return strings.Join(stmts, "\n")
+}
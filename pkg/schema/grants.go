@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+)
+
+// GrantChange is a set of privileges granted to or revoked from a role on a table
+type GrantChange struct {
+	TableName  string
+	Role       string
+	Privileges []string
+}
+
+// parseGrantAttribute parses @@grant("role", [select, insert])
+func parseGrantAttribute(attr *ModelAttribute) *Grant {
+	if len(attr.Args) < 2 {
+		return nil
+	}
+	grant := &Grant{Role: strings.ToLower(strings.Trim(attr.Args[0], "\" "))}
+	for _, arg := range attr.Args[1:] {
+		for _, privilege := range strings.Split(strings.Trim(arg, "[] "), ",") {
+			grant.Privileges = addPrivilege(grant.Privileges, privilege)
+		}
+	}
+	return grant
+}
+
+// addPrivilege adds a privilege to a sorted, de-duplicated privilege list
+func addPrivilege(privileges []string, privilege string) []string {
+	privilege = strings.ToUpper(strings.TrimSpace(privilege))
+	if privilege == "" || containsPrivilege(privileges, privilege) {
+		return privileges
+	}
+	privileges = append(privileges, privilege)
+	sort.Strings(privileges)
+	return privileges
+}
+
+func containsPrivilege(privileges []string, privilege string) bool {
+	for _, p := range privileges {
+		if p == privilege {
+			return true
+		}
+	}
+	return false
+}
+
+// findGrant returns the grant for a role, creating it when create is set
+func findGrant(model *Model, role string, create bool) *Grant {
+	for _, g := range model.Grants {
+		if g.Role == role {
+			return g
+		}
+	}
+	if !create {
+		return nil
+	}
+	grant := &Grant{Role: role}
+	model.Grants = append(model.Grants, grant)
+	return grant
+}
+
+// diffGrants compares the privileges of each role on a table
+func diffGrants(tableName string, current, target []*Grant) ([]*GrantChange, []*GrantChange) {
+	var granted, revoked []*GrantChange
+
+	privilegesByRole := func(grants []*Grant) map[string][]string {
+		m := map[string][]string{}
+		for _, g := range grants {
+			m[g.Role] = g.Privileges
+		}
+		return m
+	}
+	currentPrivileges := privilegesByRole(current)
+	targetPrivileges := privilegesByRole(target)
+
+	for _, g := range target {
+		var added []string
+		for _, p := range g.Privileges {
+			if !containsPrivilege(currentPrivileges[g.Role], p) {
+				added = append(added, p)
+			}
+		}
+		if len(added) > 0 {
+			granted = append(granted, &GrantChange{TableName: tableName, Role: g.Role, Privileges: added})
+		}
+	}
+	for _, g := range current {
+		var removed []string
+		for _, p := range g.Privileges {
+			if !containsPrivilege(targetPrivileges[g.Role], p) {
+				removed = append(removed, p)
+			}
+		}
+		if len(removed) > 0 {
+			revoked = append(revoked, &GrantChange{TableName: tableName, Role: g.Role, Privileges: removed})
+		}
+	}
+	return granted, revoked
+}
+
+func generateGrantSQL(change *GrantChange) string {
+	return "GRANT " + strings.Join(change.Privileges, ", ") + " ON " + quoteIdent(change.TableName) + " TO " + change.Role + ";"
+}
+
+func generateRevokeSQL(change *GrantChange) string {
+	return "REVOKE " + strings.Join(change.Privileges, ", ") + " ON " + quoteIdent(change.TableName) + " FROM " + change.Role + ";"
+}
@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	gooseStatementRegex  = regexp.MustCompile(`(?s)(-- \+goose StatementBegin\n)(.*?)(\n-- \+goose StatementEnd)`)
+	guardAlterTableRegex = regexp.MustCompile(`(?is)^ALTER TABLE\s+(?:ONLY\s+)?` + identPattern + `\s+(.*)$`)
+
+	guardAddConstraintRegex  = regexp.MustCompile(`(?i)^ADD\s+CONSTRAINT\s+` + identPattern)
+	guardAddPrimaryKeyRegex  = regexp.MustCompile(`(?i)^ADD\s+PRIMARY\s+KEY\b`)
+	guardAddColumnRegex      = regexp.MustCompile(`(?i)^ADD\s+(?:COLUMN\s+)?` + identPattern)
+	guardDropConstraintRegex = regexp.MustCompile(`(?i)^DROP\s+CONSTRAINT\s+` + identPattern)
+	guardDropColumnRegex     = regexp.MustCompile(`(?i)^DROP\s+(?:COLUMN\s+)?` + identPattern)
+	guardAlterColumnRegex    = regexp.MustCompile(`(?i)^ALTER\s+(?:COLUMN\s+)?` + identPattern)
+	guardRenameColumnRegex   = regexp.MustCompile(`(?i)^RENAME\s+(?:COLUMN\s+)?` + identPattern + `\s+TO\s+` + identPattern)
+	guardRenameTableRegex    = regexp.MustCompile(`(?i)^RENAME\s+TO\s+` + identPattern)
+	// Actions that already check the state themselves, and table constraints without a name to look up
+	guardSkipRegex = regexp.MustCompile(`(?i)^(?:\w+\s+)?(?:\w+\s+)?IF\s+(?:NOT\s+)?EXISTS\b|^(?:ADD|ALTER)\s+(?:UNIQUE|FOREIGN|CHECK|EXCLUDE|CONSTRAINT)\b`)
+)
+
+// guardStatements rewrites the ALTER TABLE statements of a migration section into DO blocks that run
+// each action only when the database is in the state it expects: a column is added when it is
+// missing, dropped or altered when it exists, a constraint added when it is absent and so on. The same
+// migration then applies cleanly to an environment that was partially patched by hand. Actions
+// without a state to check run unconditionally inside the block.
+func guardStatements(sql string) string {
+	return gooseStatementRegex.ReplaceAllStringFunc(sql, func(block string) string {
+		parts := gooseStatementRegex.FindStringSubmatch(block)
+		var comments []string
+		for _, line := range strings.Split(parts[2], "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "--") {
+				comments = append(comments, line)
+			}
+		}
+
+		guarded := false
+		var stmts []string
+		for _, stmt := range SplitSQLStatements(parts[2]) {
+			if doBlock, ok := guardAlterTable(stmt); ok {
+				stmt, guarded = doBlock, true
+			} else {
+				stmt += ";"
+			}
+			stmts = append(stmts, stmt)
+		}
+		if !guarded {
+			return block
+		}
+		return parts[1] + strings.Join(append(comments, stmts...), "\n") + parts[3]
+	})
+}
+
+// guardedSQL returns a migration section with the existence clauses and guarded ALTER TABLE
+// statements the generator asks for
+func guardedSQL(g GeneratorConfig, sql string) string {
+	if g.Idempotent {
+		sql = idempotentStatements(sql)
+	}
+	if g.GuardedDDL {
+		sql = guardStatements(sql)
+	}
+	return sql
+}
+
+// guardAlterTable returns an ALTER TABLE statement as a DO block with one guarded ALTER TABLE per
+// action, or false when none of its actions has a state to check
+func guardAlterTable(stmt string) (string, bool) {
+	matches := guardAlterTableRegex.FindStringSubmatch(stmt)
+	if matches == nil {
+		return "", false
+	}
+	table := normalizeIdent(matches[1])
+	prefix := "ALTER TABLE " + quoteIdent(table) + " "
+
+	guarded := false
+	var sb strings.Builder
+	sb.WriteString("DO $$\nBEGIN\n")
+	for _, action := range SplitAlterActions(matches[2]) {
+		condition := actionGuard(table, action)
+		if condition == "" {
+			sb.WriteString("  " + prefix + action + ";\n")
+			continue
+		}
+		guarded = true
+		sb.WriteString("  IF " + condition + " THEN\n")
+		sb.WriteString("    " + prefix + action + ";\n")
+		sb.WriteString("  END IF;\n")
+	}
+	sb.WriteString("END\n$$;")
+	return sb.String(), guarded
+}
+
+// actionGuard returns the condition under which an ALTER TABLE action still has to run, or an empty
+// string for actions without a state to check
+func actionGuard(table, action string) string {
+	if matches := guardAddConstraintRegex.FindStringSubmatch(action); matches != nil {
+		return "NOT " + constraintExists(table, normalizeIdent(matches[1]))
+	}
+	if guardSkipRegex.MatchString(action) {
+		return ""
+	}
+	if guardAddPrimaryKeyRegex.MatchString(action) {
+		return fmt.Sprintf("NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = to_regclass(%s) AND contype = 'p')",
+			quoteLiteral(quoteIdent(table)))
+	}
+	if matches := guardAddColumnRegex.FindStringSubmatch(action); matches != nil {
+		return "NOT " + columnExists(table, normalizeIdent(matches[1]))
+	}
+	if matches := guardDropConstraintRegex.FindStringSubmatch(action); matches != nil {
+		return constraintExists(table, normalizeIdent(matches[1]))
+	}
+	if matches := guardDropColumnRegex.FindStringSubmatch(action); matches != nil {
+		return columnExists(table, normalizeIdent(matches[1]))
+	}
+	if matches := guardAlterColumnRegex.FindStringSubmatch(action); matches != nil {
+		return columnExists(table, normalizeIdent(matches[1]))
+	}
+	if matches := guardRenameColumnRegex.FindStringSubmatch(action); matches != nil {
+		return columnExists(table, normalizeIdent(matches[1])) + " AND NOT " + columnExists(table, normalizeIdent(matches[2]))
+	}
+	if matches := guardRenameTableRegex.FindStringSubmatch(action); matches != nil {
+		return fmt.Sprintf("to_regclass(%s) IS NULL", quoteLiteral(quoteIdent(normalizeIdent(matches[1]))))
+	}
+	return ""
+}
+
+// columnExists returns an EXISTS condition for a column of a table in the current schema
+func columnExists(table, column string) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM information_schema.columns WHERE table_schema = current_schema() "+
+		"AND table_name = %s AND column_name = %s)", quoteLiteral(table), quoteLiteral(column))
+}
+
+// constraintExists returns an EXISTS condition for a named constraint of a table
+func constraintExists(table, constraint string) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = to_regclass(%s) AND conname = %s)",
+		quoteLiteral(quoteIdent(table)), quoteLiteral(constraint))
+}
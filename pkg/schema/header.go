@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// MigrationHeader is the metadata comment generated migrations start with, kept for audits
+type MigrationHeader struct {
+	Tool       string // schema-manager version that generated the migration
+	Author     string // git user.name <user.email>
+	Ticket     string
+	SchemaHash string // Hash of the schema.prisma the migration was generated from
+}
+
+// Keys of the header lines, written as `-- Key: value`
+var migrationHeaderKeys = []string{"Generated-By", "Author", "Ticket", "Schema-Hash"}
+
+// SchemaHash returns the hash recorded in migration headers for the content of a schema file
+func SchemaHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (h *MigrationHeader) fields() []*string {
+	return []*string{&h.Tool, &h.Author, &h.Ticket, &h.SchemaHash}
+}
+
+// String renders the header as comment lines followed by a blank line; empty fields are left out
+func (h *MigrationHeader) String() string {
+	var sb strings.Builder
+	for i, value := range h.fields() {
+		if *value != "" {
+			sb.WriteString("-- " + migrationHeaderKeys[i] + ": " + *value + "\n")
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return sb.String() + "\n"
+}
+
+// ParseMigrationHeader reads the header of a migration file. Only the comments before the first
+// goose annotation or statement are considered; a file without a header yields an empty header.
+func ParseMigrationHeader(content string) *MigrationHeader {
+	h := &MigrationHeader{}
+	fields := h.fields()
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "-- ") || strings.HasPrefix(line, "-- +goose") {
+			break
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "-- "), ": ")
+		if !ok {
+			continue
+		}
+		for i, k := range migrationHeaderKeys {
+			if k == key {
+				*fields[i] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return h
+}
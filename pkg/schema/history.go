@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HistoryConflict is a statement of a migration that does not fit the schema built by the migrations
+// before it, typically because two branches changed the same table
+type HistoryConflict struct {
+	File    string
+	Message string
+}
+
+func (c *HistoryConflict) String() string {
+	return c.File + ": " + c.Message
+}
+
+// ValidateMigrationHistory replays the SQL migrations of a directory in version order and reports the
+// statements that conflict with the schema so far: tables created twice, altered tables that don't
+// exist, columns added twice or dropped without existing.
+func ValidateMigrationHistory(dir string) ([]*HistoryConflict, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(files)
+
+	schema := &Schema{}
+	var conflicts []*HistoryConflict
+	for _, f := range files {
+		content, err := os.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return nil, err
+		}
+		up, _ := gooseSections(string(content))
+		for _, sql := range SplitSQLStatements(up) {
+			stmt, err := ParseSQLStatement(sql)
+			if err != nil || stmt == nil {
+				continue
+			}
+			if message := historyConflict(schema, stmt); message != "" {
+				conflicts = append(conflicts, &HistoryConflict{File: f, Message: message})
+			}
+			if err := stmt.Apply(schema); err != nil {
+				return nil, fmt.Errorf("%s: %w", f, err)
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// historyConflict describes why a statement can't run against the schema, or returns ""
+func historyConflict(schema *Schema, stmt SQLStatement) string {
+	switch s := stmt.(type) {
+	case *CreateTableStatement:
+		if modelByTable(schema, s.TableName) != nil {
+			return "table " + s.TableName + " is created again"
+		}
+	case *AlterTableStatement:
+		model := modelByTable(schema, s.TableName)
+		if model == nil {
+			return "table " + s.TableName + " is altered but does not exist"
+		}
+		ops, ok := s.Operation.(AlterOperations)
+		if !ok {
+			ops = AlterOperations{s.Operation}
+		}
+		for _, op := range ops {
+			switch op := op.(type) {
+			case *AddColumnOperation:
+				if fieldByColumn(model, op.Column.Name) != nil {
+					return "column " + s.TableName + "." + op.Column.Name + " is added again"
+				}
+			case *DropColumnOperation:
+				if fieldByColumn(model, op.ColumnName) == nil {
+					return "column " + s.TableName + "." + op.ColumnName + " is dropped but does not exist"
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func modelByTable(schema *Schema, table string) *Model {
+	for _, m := range schema.Models {
+		if m.TableName == table {
+			return m
+		}
+	}
+	return nil
+}
+
+func fieldByColumn(model *Model, column string) *Field {
+	for _, f := range model.Fields {
+		if f.ColumnName == column {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Statements and actions that take IF NOT EXISTS or IF EXISTS right after the matched text
+	ifNotExistsRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*CREATE TABLE\s+`),
+		regexp.MustCompile(`(?i)^\s*CREATE (?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?`),
+		regexp.MustCompile(`(?i)^\s*CREATE (?:EXTENSION|SEQUENCE)\s+`),
+		regexp.MustCompile(`(?i)\bADD COLUMN\s+`),
+		regexp.MustCompile(`(?i)\bADD VALUE\s+`),
+	}
+	ifExistsRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*DROP (?:TABLE|INDEX(?:\s+CONCURRENTLY)?|VIEW|SEQUENCE|TYPE|EXTENSION|FUNCTION|PROCEDURE|TRIGGER|POLICY)\s+`),
+		regexp.MustCompile(`(?i)\bDROP (?:COLUMN|CONSTRAINT)\s+`),
+	}
+	createTypeLineRegex = regexp.MustCompile(`(?i)^\s*CREATE TYPE\s.*;\s*$`)
+)
+
+// ignoreDuplicateObjectSQL runs a CREATE statement without IF NOT EXISTS, such as CREATE TYPE, in a DO
+// block that does nothing when the object exists
+func ignoreDuplicateObjectSQL(stmt string) string {
+	return "DO $$ BEGIN\n  " + stmt + "\nEXCEPTION WHEN duplicate_object THEN NULL;\nEND $$;"
+}
+
+// idempotentStatements adds IF NOT EXISTS to the CREATE TABLE, CREATE INDEX and ADD COLUMN statements
+// of a migration section and IF EXISTS to its DROP statements, and skips CREATE TYPE when the type
+// exists, so the section can run again against a database that already has its changes
+func idempotentStatements(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		if createTypeLineRegex.MatchString(line) {
+			lines[i] = ignoreDuplicateObjectSQL(strings.TrimSpace(line))
+			continue
+		}
+		for _, re := range ifNotExistsRegexes {
+			line = addExistenceClause(line, re, "IF NOT EXISTS ")
+		}
+		for _, re := range ifExistsRegexes {
+			line = addExistenceClause(line, re, "IF EXISTS ")
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addExistenceClause inserts clause after every match of re in line that isn't already followed by one
+func addExistenceClause(line string, re *regexp.Regexp, clause string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		sb.WriteString(line[last:loc[1]])
+		if !strings.HasPrefix(strings.ToUpper(line[loc[1]:]), "IF ") {
+			sb.WriteString(clause)
+		}
+		last = loc[1]
+	}
+	sb.WriteString(line[last:])
+	return sb.String()
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// maxIdentifierLength is PostgreSQL's NAMEDATALEN - 1; longer identifiers are silently truncated
+const maxIdentifierLength = 63
+
+// plainIdentRegex matches identifiers PostgreSQL keeps as written without quotes
+var plainIdentRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// identPattern matches a quoted or unquoted identifier in SQL; use normalizeIdent on the match
+const identPattern = `("[^"]+"|[a-zA-Z0-9_]+)`
+
+// reservedWords are the PostgreSQL reserved key words that cannot be used as unquoted table or
+// column names, plus the SQL:2016 words that are commonly reserved in other dialects
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "asymmetric": true, "authorization": true, "between": true,
+	"binary": true, "both": true, "case": true, "cast": true, "check": true, "collate": true,
+	"collation": true, "column": true, "concurrently": true, "constraint": true, "create": true,
+	"cross": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_schema": true, "current_time": true, "current_timestamp": true, "current_user": true,
+	"default": true, "deferrable": true, "desc": true, "distinct": true, "do": true, "else": true,
+	"end": true, "except": true, "false": true, "fetch": true, "for": true, "foreign": true,
+	"freeze": true, "from": true, "full": true, "grant": true, "group": true, "having": true,
+	"ilike": true, "in": true, "initially": true, "inner": true, "intersect": true, "into": true,
+	"is": true, "isnull": true, "join": true, "lateral": true, "leading": true, "left": true,
+	"like": true, "limit": true, "localtime": true, "localtimestamp": true, "natural": true,
+	"not": true, "notnull": true, "null": true, "offset": true, "on": true, "only": true, "or": true,
+	"order": true, "outer": true, "overlaps": true, "placing": true, "primary": true,
+	"references": true, "returning": true, "right": true, "select": true, "session_user": true,
+	"similar": true, "some": true, "symmetric": true, "system_user": true, "table": true,
+	"tablesample": true, "then": true, "to": true, "trailing": true, "true": true, "union": true,
+	"unique": true, "user": true, "using": true, "variadic": true, "verbose": true, "when": true,
+	"where": true, "window": true, "with": true,
+}
+
+// IsReservedWord reports whether name is a SQL reserved word that must be quoted as an identifier
+func IsReservedWord(name string) bool {
+	return reservedWords[strings.ToLower(name)]
+}
+
+// quoteIdent quotes a table or column name when it would otherwise be parsed as a key word or
+// folded to lower case, so mapped names like @map("createdAt") are created exactly as declared
+func quoteIdent(name string) string {
+	if IsReservedWord(name) || !plainIdentRegex.MatchString(name) {
+		return "\"" + name + "\""
+	}
+	return name
+}
+
+// QuoteIdent quotes a table or column name the way generated migrations do
+func QuoteIdent(name string) string {
+	return quoteIdent(name)
+}
+
+// quoteLiteral quotes a value as a SQL string literal
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// normalizeIdent returns the name PostgreSQL stores for an identifier: quoted identifiers keep
+// their case, unquoted ones are folded to lower case
+func normalizeIdent(ident string) string {
+	if len(ident) >= 2 && strings.HasPrefix(ident, "\"") && strings.HasSuffix(ident, "\"") {
+		return ident[1 : len(ident)-1]
+	}
+	return strings.ToLower(ident)
+}
+
+// upperOutsideQuotes upper-cases SQL for keyword matching while keeping quoted identifiers and
+// string literals intact
+func upperOutsideQuotes(sql string) string {
+	var sb strings.Builder
+	var quoteChar rune
+	for _, r := range sql {
+		switch {
+		case quoteChar == 0 && (r == '"' || r == '\''):
+			quoteChar = r
+		case r == quoteChar:
+			quoteChar = 0
+			sb.WriteRune(r)
+			continue
+		}
+		if quoteChar != 0 {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteString(strings.ToUpper(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// quoteIdents quotes every identifier of a column list
+func quoteIdents(names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return quoted
+}
+
+// limitIdentifier shortens a generated name to the PostgreSQL identifier limit, replacing the tail
+// with a hash of the full name so distinct long names stay distinct and regenerate identically
+func limitIdentifier(name string) string {
+	if len(name) <= maxIdentifierLength {
+		return name
+	}
+	sum := sha1.Sum([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return strings.TrimRight(name[:maxIdentifierLength-len(hash)-1], "_") + "_" + hash
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"fmt"
+)
+
+// RowImpact returns a note like " (affects ~2.3M rows)" for a table whose row count was estimated
+// from the database, or an empty string when no estimate is available
+func (d *SchemaDiff) RowImpact(table string) string {
+	rows, ok := d.RowEstimates[table]
+	if !ok {
+		return ""
+	}
+	return " (affects ~" + formatRowCount(rows) + " rows)"
+}
+
+// AffectedTables returns the tables whose rows are rewritten or lost by the diff, or that new indexes
+// are built on
+func (d *SchemaDiff) AffectedTables() []string {
+	seen := map[string]bool{}
+	var tables []string
+	add := func(table string) {
+		if !seen[table] {
+			seen[table] = true
+			tables = append(tables, table)
+		}
+	}
+	for _, m := range d.ModelsRemoved {
+		add(m.TableName)
+	}
+	for _, fieldChange := range d.FieldsRemoved {
+		add(fieldChange.ModelName)
+	}
+	for _, fieldChange := range d.FieldsModified {
+		add(fieldChange.ModelName)
+	}
+	// Indexes built on existing tables, including the ones of added unique and search columns
+	for _, indexChange := range d.IndexesAdded {
+		add(indexChange.TableName)
+	}
+	for _, fieldChange := range d.FieldsAdded {
+		add(fieldChange.ModelName)
+	}
+	// Added foreign keys are validated against every existing row
+	for _, fkChange := range d.ForeignKeysAdded {
+		add(fkChange.TableName)
+	}
+	return tables
+}
+
+// defaultLargeTableRows is the row count from which a table counts as large unless largeTableRows is set
+const defaultLargeTableRows = 1_000_000
+
+// LargeTableIndexes returns the CREATE INDEX statements of sql that are built without CONCURRENTLY on
+// a table whose estimated row count reaches the largeTableRows threshold of the generator block. Writes
+// to such a table are blocked for as long as the index build takes.
+func (d *SchemaDiff) LargeTableIndexes(sql string) []*StatementLock {
+	threshold := d.Generator.LargeTableRows
+	if threshold <= 0 {
+		threshold = defaultLargeTableRows
+	}
+	var locks []*StatementLock
+	for _, lock := range AnalyzeLocks(sql) {
+		rows, ok := d.RowEstimates[lock.Table]
+		// Only CREATE INDEX without CONCURRENTLY takes a SHARE lock
+		if ok && rows >= threshold && lock.Lock == "SHARE" {
+			locks = append(locks, lock)
+		}
+	}
+	return locks
+}
+
+// formatRowCount abbreviates a row count, e.g. 2300000 becomes 2.3M
+func formatRowCount(rows int64) string {
+	switch {
+	case rows >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(rows)/1_000_000_000)
+	case rows >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(rows)/1_000_000)
+	case rows >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(rows)/1_000)
+	}
+	return fmt.Sprintf("%d", rows)
+}
@@ -0,0 +1,186 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StatementLock is the strongest table lock a statement takes, per the PostgreSQL documentation
+type StatementLock struct {
+	Statement string
+	Lock      string // e.g. ACCESS EXCLUSIVE
+	Table     string
+	Long      bool   // Held while the table is rewritten, scanned or indexed rather than briefly
+	Reason    string // Why the lock is held long
+}
+
+var (
+	createTableLockRegex  = regexp.MustCompile(`^CREATE (?:UNLOGGED\s+)?TABLE\s+(?:IF NOT EXISTS\s+)?` + identPattern)
+	alterTableLockRegex   = regexp.MustCompile(`^ALTER TABLE\s+(?:IF EXISTS\s+)?(?:ONLY\s+)?` + identPattern + `\s+(.*)$`)
+	createIndexLockRegex  = regexp.MustCompile(`^CREATE (?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\sON\s+(?:ONLY\s+)?` + identPattern)
+	dropIndexLockRegex    = regexp.MustCompile(`^DROP INDEX\s+(CONCURRENTLY\s+)?(?:IF EXISTS\s+)?` + identPattern)
+	alterIndexLockRegex   = regexp.MustCompile(`^ALTER INDEX\s+(?:IF EXISTS\s+)?` + identPattern + `\s+RENAME TO\s`)
+	dropTableLockRegex    = regexp.MustCompile(`^DROP TABLE\s+(?:IF EXISTS\s+)?` + identPattern)
+	triggerLockRegex      = regexp.MustCompile(`^(CREATE|DROP) (?:OR REPLACE\s+)?TRIGGER\s+.*?\sON\s+` + identPattern)
+	policyLockRegex       = regexp.MustCompile(`^(?:CREATE|DROP|ALTER) POLICY\s+.*?\sON\s+` + identPattern)
+	commentLockRegex      = regexp.MustCompile(`^COMMENT ON (?:TABLE|COLUMN)\s+` + identPattern)
+	volatileDefaultRegex  = regexp.MustCompile(`DEFAULT\s+(?:GEN_RANDOM_UUID|UUID_GENERATE_V\d|RANDOM|CLOCK_TIMESTAMP|NEXTVAL)\s*\(`)
+	addForeignKeyRegex    = regexp.MustCompile(`ADD (?:CONSTRAINT\s+\S+\s+)?FOREIGN KEY`)
+	addCheckNotValidRegex = regexp.MustCompile(`\sNOT VALID\b`)
+	alterColumnTypeRegex  = regexp.MustCompile(`^ALTER (?:COLUMN\s+)?` + identPattern + `\s+(?:SET DATA\s+)?TYPE\b`)
+)
+
+// AnalyzeLocks returns the lock taken by each statement of a SQL script that locks an existing table.
+// Statements creating new objects, grants and function definitions are left out, and so are the
+// indexes, comments and other statements on tables the script creates itself. The statements of DO
+// blocks, such as guarded ALTER TABLE actions, are analyzed as if they ran on their own.
+func AnalyzeLocks(sql string) []*StatementLock {
+	return analyzeLocks(sql, map[string]bool{})
+}
+
+// analyzeLocks is AnalyzeLocks for a statement of a migration that creates the created tables before
+// it; the tables the statement creates itself are added to created
+func analyzeLocks(sql string, created map[string]bool) []*StatementLock {
+	var locks []*StatementLock
+	add := func(stmt string) {
+		if matches := createTableLockRegex.FindStringSubmatch(normalizeWhitespace(upperOutsideQuotes(stmt))); matches != nil {
+			created[normalizeIdent(matches[1])] = true
+		} else if lock := statementLock(stmt); lock != nil && !created[lock.Table] {
+			locks = append(locks, lock)
+		}
+	}
+	for _, stmt := range SplitSQLStatements(sql) {
+		if block := doBlockRegex.FindStringSubmatch(stmt); block != nil {
+			for _, inner := range SplitSQLStatements(block[1]) {
+				add(doBlockGuardRegex.ReplaceAllString(inner, ""))
+			}
+			continue
+		}
+		add(stmt)
+	}
+	return locks
+}
+
+func statementLock(stmt string) *StatementLock {
+	sql := normalizeWhitespace(upperOutsideQuotes(stmt))
+	lock := &StatementLock{Statement: stmt}
+
+	if matches := alterTableLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table = normalizeIdent(matches[1])
+		// A statement with several actions holds the strongest lock of any of them as long as the
+		// slowest one takes
+		var reasons []string
+		for _, action := range SplitAlterActions(matches[2]) {
+			lockName, long, reason := alterTableLock(action)
+			if lock.Lock == "" || lockName == "ACCESS EXCLUSIVE" {
+				lock.Lock = lockName
+			}
+			if long && !containsString(reasons, reason) {
+				reasons = append(reasons, reason)
+			}
+		}
+		lock.Long, lock.Reason = len(reasons) > 0, strings.Join(reasons, "; ")
+		return lock
+	}
+
+	if matches := createIndexLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table = normalizeIdent(matches[2])
+		if matches[1] != "" {
+			lock.Lock = "SHARE UPDATE EXCLUSIVE"
+			return lock
+		}
+		lock.Lock = "SHARE"
+		lock.Long, lock.Reason = true, "writes are blocked while the index is built; consider CREATE INDEX CONCURRENTLY"
+		return lock
+	}
+	if matches := dropIndexLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table = normalizeIdent(matches[2])
+		lock.Lock = "ACCESS EXCLUSIVE"
+		if matches[1] != "" {
+			lock.Lock = "SHARE UPDATE EXCLUSIVE"
+		}
+		return lock
+	}
+	if matches := alterIndexLockRegex.FindStringSubmatch(sql); matches != nil {
+		// Renaming an index locks it, like its table, against concurrent schema changes only
+		lock.Table, lock.Lock = normalizeIdent(matches[1]), "SHARE UPDATE EXCLUSIVE"
+		return lock
+	}
+	if matches := dropTableLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table, lock.Lock = normalizeIdent(matches[1]), "ACCESS EXCLUSIVE"
+		return lock
+	}
+	if matches := triggerLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table, lock.Lock = normalizeIdent(matches[2]), "ACCESS EXCLUSIVE"
+		if matches[1] == "CREATE" {
+			lock.Lock = "SHARE ROW EXCLUSIVE"
+		}
+		return lock
+	}
+	if matches := policyLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table, lock.Lock = normalizeIdent(matches[1]), "ACCESS EXCLUSIVE"
+		return lock
+	}
+	if matches := commentLockRegex.FindStringSubmatch(sql); matches != nil {
+		lock.Table, lock.Lock = normalizeIdent(matches[1]), "SHARE UPDATE EXCLUSIVE"
+		return lock
+	}
+	return nil
+}
+
+// alterTableLock returns the lock one action of an ALTER TABLE statement takes
+func alterTableLock(operation string) (lockName string, long bool, reason string) {
+	switch {
+	case strings.Contains(operation, "ENABLE ROW LEVEL SECURITY"), strings.Contains(operation, "DISABLE ROW LEVEL SECURITY"):
+		lockName = "ACCESS EXCLUSIVE"
+	case addForeignKeyRegex.MatchString(operation):
+		lockName = "SHARE ROW EXCLUSIVE"
+		if !addCheckNotValidRegex.MatchString(operation) {
+			long, reason = true, "existing rows are validated against the referenced table"
+		}
+	case strings.HasPrefix(operation, "ADD COLUMN"):
+		lockName = "ACCESS EXCLUSIVE"
+		if strings.Contains(operation, "GENERATED ALWAYS AS") {
+			long, reason = true, "the table is rewritten to compute the stored column"
+		} else if volatileDefaultRegex.MatchString(operation) {
+			long, reason = true, "the table is rewritten to fill in the volatile default"
+		}
+	case alterColumnTypeRegex.MatchString(operation):
+		lockName = "ACCESS EXCLUSIVE"
+		long, reason = true, "the table is rewritten with the new column type"
+	case strings.Contains(operation, "SET NOT NULL"):
+		lockName = "ACCESS EXCLUSIVE"
+		long, reason = true, "the whole table is scanned for NULL values"
+	default:
+		lockName = "ACCESS EXCLUSIVE"
+	}
+	return lockName, long, reason
+}
+
+// lockComments returns the -- LOCK: annotations of the statements in sql, one line per statement,
+// leaving out the tables the migration creates
+func lockComments(sql string, created map[string]bool) string {
+	return formatLockComments(analyzeLocks(sql, created))
+}
+
+// indexLockComments returns the lock annotations of a statement dropping or renaming an index of
+// table. DROP INDEX and ALTER INDEX only name the index, so the table comes from the change.
+func indexLockComments(sql, table string) string {
+	locks := AnalyzeLocks(sql)
+	for _, lock := range locks {
+		lock.Table = table
+	}
+	return formatLockComments(locks)
+}
+
+func formatLockComments(locks []*StatementLock) string {
+	var sb strings.Builder
+	for _, lock := range locks {
+		sb.WriteString("-- LOCK: " + lock.Lock + " on " + lock.Table)
+		if lock.Long {
+			sb.WriteString(" (long: " + lock.Reason + ")")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
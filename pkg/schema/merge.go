@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeConflict is an object two merged schemas declare differently
+type MergeConflict struct {
+	Kind   string // model, table, enum, trigger, policy, function, view or sequence
+	Name   string
+	First  int // Index of the schema that declared it first
+	Second int // Index of the schema declaring it again
+}
+
+// MergeConflictError lists the conflicts that kept schemas from being merged
+type MergeConflictError struct {
+	Conflicts []*MergeConflict
+	Names     []string // Names of the merged schemas for the message, such as their paths; indexes when empty
+}
+
+func (e *MergeConflictError) Error() string {
+	name := func(i int) string {
+		if i < len(e.Names) {
+			return e.Names[i]
+		}
+		return fmt.Sprintf("schema %d", i+1)
+	}
+	msgs := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		msgs[i] = fmt.Sprintf("%s %s is declared differently in %s and %s", c.Kind, c.Name, name(c.First), name(c.Second))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// MergeSchemas combines schemas into one, e.g. a shared base schema with audit tables and the schema of
+// a service. The datasource and generator of the first schema are kept. An object declared by several
+// schemas is kept once when the declarations are identical and is a conflict otherwise; all conflicts
+// are returned in a *MergeConflictError.
+func MergeSchemas(schemas ...*Schema) (*Schema, error) {
+	merged := &Schema{}
+	if len(schemas) > 0 {
+		merged.Datasource = schemas[0].Datasource
+		merged.Generator = schemas[0].Generator
+	}
+
+	type declaration struct {
+		schema      int
+		fingerprint string
+	}
+	declared := map[string]*declaration{}
+	var conflicts []*MergeConflict
+	// add reports whether an object is new, recording a conflict when it was declared differently before
+	add := func(i int, kind, name, fingerprint string) bool {
+		key := kind + "\x00" + name
+		prev, ok := declared[key]
+		if !ok {
+			declared[key] = &declaration{i, fingerprint}
+			return true
+		}
+		if prev.fingerprint != fingerprint {
+			conflicts = append(conflicts, &MergeConflict{Kind: kind, Name: name, First: prev.schema, Second: i})
+		}
+		return false
+	}
+
+	for i, s := range schemas {
+		for _, m := range s.Models {
+			// Models are compared by the table they create, which leaves out positions in the file
+			table := strings.Join(generateCreateTableSQL(m, GeneratorConfig{}, map[string]bool{}), "\n")
+			if add(i, "model", m.Name, table) && add(i, "table", m.TableName, table) {
+				merged.Models = append(merged.Models, m)
+			}
+		}
+		for _, e := range s.Enums {
+			if add(i, "enum", e.Name, strings.Join(e.Values, ",")) {
+				merged.Enums = append(merged.Enums, e)
+			}
+		}
+		for _, ct := range s.CompositeTypes {
+			if add(i, "type", ct.Name, compositeTypeStructure(ct)) {
+				merged.CompositeTypes = append(merged.CompositeTypes, ct)
+			}
+		}
+		for _, ext := range s.Extensions {
+			if add(i, "extension", ext.Name, ext.Name) {
+				merged.Extensions = append(merged.Extensions, ext)
+			}
+		}
+		for _, t := range s.Triggers {
+			if add(i, "trigger", t.Name, t.Definition) {
+				merged.Triggers = append(merged.Triggers, t)
+			}
+		}
+		for _, f := range s.Functions {
+			if add(i, "function", f.Name, f.Definition) {
+				merged.Functions = append(merged.Functions, f)
+			}
+		}
+		for _, p := range s.Policies {
+			if add(i, "policy", p.TableName+"."+p.Name, p.Definition) {
+				merged.Policies = append(merged.Policies, p)
+			}
+		}
+		for _, v := range s.Views {
+			if add(i, "view", v.Name, v.Definition) {
+				merged.Views = append(merged.Views, v)
+			}
+		}
+		for _, seq := range s.Sequences {
+			if add(i, "sequence", seq.Name, fmt.Sprintf("%+v", *seq)) {
+				merged.Sequences = append(merged.Sequences, seq)
+			}
+		}
+		merged.Partitions = append(merged.Partitions, s.Partitions...)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &MergeConflictError{Conflicts: conflicts}
+	}
+	return merged, nil
+}
+
+// mergeIncludes merges the schema files listed by the include option of the generator block into a
+// parsed schema. Includes are resolved relative to the including file and may include files themselves.
+func mergeIncludes(ctx context.Context, s *Schema, path string, including []string) (*Schema, error) {
+	if len(s.Generator.Include) == 0 {
+		return s, nil
+	}
+	including = append(including, absPath(path))
+	schemas, names := []*Schema{s}, []string{path}
+	for _, include := range s.Generator.Include {
+		includePath := include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), include)
+		}
+		if containsString(including, absPath(includePath)) {
+			return nil, fmt.Errorf("include cycle: %s includes %s", path, includePath)
+		}
+		b, err := os.ReadFile(includePath)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+		included, err := parsePrisma(ctx, string(b), includePath, including)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+		schemas = append(schemas, included)
+		names = append(names, includePath)
+	}
+	merged, err := MergeSchemas(schemas...)
+	if conflictErr, ok := err.(*MergeConflictError); ok {
+		conflictErr.Names = names
+	}
+	return merged, err
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
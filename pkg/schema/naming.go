@@ -0,0 +1,307 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NamingSnakeCase maps models and fields without @@map/@map to snake_case table and column names
+const NamingSnakeCase = "snake_case"
+
+// Index names used when the generator block doesn't configure indexName or uniqueIndexName
+const (
+	defaultIndexNameTemplate       = "idx_{table}_{columns}"
+	defaultUniqueIndexNameTemplate = "idx_uniq_{table}_{columns}"
+	defaultForeignKeyNameTemplate  = "fk_{table}_{columns}"
+)
+
+// Backups selectable with backup in the generator block
+const (
+	BackupPgDump = "pg_dump"
+	BackupCSV    = "csv"
+)
+
+// setGeneratorValue applies a `key = value` line of the schema-manager generator block
+func setGeneratorValue(g *GeneratorConfig, key, value string) {
+	switch key {
+	case "naming":
+		g.Naming = value
+	case "output":
+		g.Output = value
+	case "include":
+		// include = ["../shared/audit.prisma"]
+		for _, item := range splitComplexArgs(strings.Trim(value, "[]")) {
+			if item = strings.Trim(strings.TrimSpace(item), "\""); item != "" {
+				g.Include = append(g.Include, item)
+			}
+		}
+	case "pluralize":
+		g.DisablePluralization = value == "false"
+	case "indexName":
+		g.IndexNameTemplate = value
+	case "uniqueIndexName":
+		g.UniqueIndexNameTemplate = value
+	case "foreignKeyName":
+		g.ForeignKeyNameTemplate = value
+	case "forwardOnly":
+		g.ForwardOnly = value == "true"
+	case "guardedDdl":
+		g.GuardedDDL = value == "true"
+	case "idempotent":
+		g.Idempotent = value == "true"
+	case "dateTimeType":
+		g.DateTimeType = strings.ToLower(value)
+	case "compositeTypes":
+		g.CompositeTypes = strings.ToLower(value)
+	case "floatType":
+		g.FloatType = strings.ToLower(strings.Join(strings.Fields(value), " "))
+	case "stringType":
+		g.StringType = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	case "backup":
+		g.Backup = strings.ToLower(value)
+	case "backupDir":
+		g.BackupDir = value
+	case "auditColumns":
+		g.AuditColumns = value == "true"
+	case "backfillBatchSize":
+		g.BackfillBatchSize, _ = strconv.Atoi(value)
+	case "largeTableRows":
+		g.LargeTableRows, _ = strconv.ParseInt(value, 10, 64)
+	case "statementTimeout":
+		g.StatementTimeout, _ = time.ParseDuration(value)
+	case "lockTimeout":
+		g.LockTimeout, _ = time.ParseDuration(value)
+	case "applyTimeout":
+		g.ApplyTimeout, _ = time.ParseDuration(value)
+	case "applyRetries":
+		g.ApplyRetries, _ = strconv.Atoi(value)
+	case "castRules":
+		// castRules = ["MONEY -> NUMERIC USING %s::NUMERIC", "TEXT -> EMAIL USING %s::email RISKY"]
+		for _, item := range splitComplexArgs(strings.Trim(value, "[]")) {
+			g.CastRules = append(g.CastRules, parseCastRule(strings.Trim(strings.TrimSpace(item), "\"")))
+		}
+	case "rules":
+		// rules = ["require-timestamps: error", "fk-index: off", "model has field tenantId: warning"]
+		for _, item := range splitComplexArgs(strings.Trim(value, "[]")) {
+			if item = strings.Trim(strings.TrimSpace(item), "\""); item != "" {
+				g.Rules = append(g.Rules, parseRuleSetting(item))
+			}
+		}
+	case "irregulars":
+		// irregulars = ["person:people", "status:statuses", "data:data"]
+		for _, item := range splitComplexArgs(strings.Trim(value, "[]")) {
+			singular, plural, found := strings.Cut(strings.Trim(strings.TrimSpace(item), "\""), ":")
+			if found && singular != "" && plural != "" {
+				g.Irregulars = append(g.Irregulars, &IrregularWord{
+					Singular: strings.ToLower(strings.TrimSpace(singular)),
+					Plural:   strings.ToLower(strings.TrimSpace(plural)),
+				})
+			}
+		}
+	}
+}
+
+// ReadGeneratorConfig reads only the generator block of a schema file, for commands that rewrite
+// the file; a missing file yields the default configuration
+func ReadGeneratorConfig(path string) (GeneratorConfig, error) {
+	var g GeneratorConfig
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return g, err
+	}
+	inGenerator := false
+	for _, line := range strings.Split(string(b), "\n") {
+		l := strings.TrimSpace(removeInlineComments(line))
+		switch {
+		case strings.HasPrefix(l, "generator "):
+			inGenerator = true
+		case inGenerator && l == "}":
+			inGenerator = false
+		case inGenerator && l != "":
+			key, value := parseBlockValue(l)
+			setGeneratorValue(&g, key, value)
+		}
+	}
+	return g, nil
+}
+
+// DefaultMigrationsDir is the migrations directory when the generator block sets no output
+const DefaultMigrationsDir = "migrations"
+
+// MigrationsDir returns the directory of the migrations of a schema file: the generator output,
+// resolved relative to the schema file like Prisma does
+func (g GeneratorConfig) MigrationsDir(schemaPath string) string {
+	dir := g.Output
+	if dir == "" {
+		dir = DefaultMigrationsDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(schemaPath), dir)
+}
+
+// IndexName returns the name of a generated index from the configured template, e.g.
+// {table}_{columns}_idx for table posts and columns author_id, created_at gives
+// posts_author_id_created_at_idx. Names over the identifier limit end in a hash instead.
+func (g GeneratorConfig) IndexName(table string, columns []string, unique bool) string {
+	template := g.IndexNameTemplate
+	if template == "" {
+		template = defaultIndexNameTemplate
+	}
+	if unique {
+		template = g.UniqueIndexNameTemplate
+		if template == "" {
+			template = defaultUniqueIndexNameTemplate
+		}
+	}
+	return limitIdentifier(strings.NewReplacer("{table}", table, "{columns}", strings.Join(columns, "_")).Replace(template))
+}
+
+// ForeignKeyName returns the name of a foreign key constraint from the configured template, e.g.
+// {table}_{columns}_fkey for table posts and column author_id gives posts_author_id_fkey
+func (g GeneratorConfig) ForeignKeyName(table string, columns []string, refTable string) string {
+	template := g.ForeignKeyNameTemplate
+	if template == "" {
+		template = defaultForeignKeyNameTemplate
+	}
+	return limitIdentifier(strings.NewReplacer(
+		"{table}", table,
+		"{columns}", strings.Join(columns, "_"),
+		"{refTable}", refTable,
+	).Replace(template))
+}
+
+// uniqueName returns name, or name with the first free numeric suffix when it is already used,
+// and records the result as used
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = limitIdentifier(fmt.Sprintf("%s_%d", name, i))
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// FieldColumnName returns the column a field without @map is stored in: the field name in lower case,
+// or in snake_case with naming = "snake_case"
+func (g GeneratorConfig) FieldColumnName(field string) string {
+	if g.Naming == NamingSnakeCase {
+		return toSnakeCase(field)
+	}
+	return strings.ToLower(field)
+}
+
+// Pluralize returns the table name guessed for a model name: irregular words first, then an
+// appended "s" unless the word already ends with one or pluralization is disabled
+func (g GeneratorConfig) Pluralize(word string) string {
+	for _, w := range g.Irregulars {
+		if replaced, ok := replaceWordSuffix(word, w.Singular, w.Plural); ok {
+			return replaced
+		}
+	}
+	if g.DisablePluralization || strings.HasSuffix(word, "s") {
+		return word
+	}
+	return word + "s"
+}
+
+// Singularize returns the model name for a table name, the inverse of Pluralize
+func (g GeneratorConfig) Singularize(word string) string {
+	for _, w := range g.Irregulars {
+		if replaced, ok := replaceWordSuffix(word, w.Plural, w.Singular); ok {
+			return replaced
+		}
+	}
+	if g.DisablePluralization || word == "" {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		// categories -> category, companies -> company
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ses"):
+		// addresses -> address, processes -> process
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		// users -> user, wallets -> wallet (but not address -> addres)
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+// replaceWordSuffix replaces the last word of a PascalCase or snake_case name when it matches from
+// case-insensitively, keeping the case of its first letter: UserPeople with people -> person becomes
+// UserPerson, while Inbox is not matched by ox
+func replaceWordSuffix(word, from, to string) (string, bool) {
+	if len(word) < len(from) || !strings.EqualFold(word[len(word)-len(from):], from) {
+		return "", false
+	}
+	start := len(word) - len(from)
+	suffix := word[start:]
+	if start > 0 && word[start-1] != '_' && !unicode.IsUpper(rune(suffix[0])) {
+		return "", false
+	}
+	if to != "" && unicode.IsUpper(rune(suffix[0])) {
+		to = strings.ToUpper(to[:1]) + to[1:]
+	}
+	return word[:len(word)-len(from)] + to, true
+}
+
+// applyNamingStrategy derives the table and column names of models and fields that don't
+// declare them explicitly with @@map or @map
+func applyNamingStrategy(s *Schema) {
+	if s.Generator.Naming != NamingSnakeCase {
+		return
+	}
+	for _, m := range s.Models {
+		if !hasModelAttribute(m, "map") {
+			m.TableName = toSnakeCase(m.Name)
+		}
+		for _, f := range m.Fields {
+			if findFieldAttribute(f, "map") == nil {
+				f.ColumnName = toSnakeCase(f.Name)
+			}
+		}
+	}
+}
+
+func hasModelAttribute(m *Model, name string) bool {
+	for _, attr := range m.Attributes {
+		if attr.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// toSnakeCase converts PascalCase and camelCase names to snake_case, keeping acronyms together:
+// createdAt → created_at, UserProfile → user_profile, userID → user_id, HTTPRequest → http_request
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' {
+				prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+					sb.WriteRune('_')
+				}
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 )
 
-func ParseMigrationsToSchema(ctx context.Context, dir string) (*Schema, error) {
+func ParseMigrationsToSchema(ctx context.Context, dir string, skip ...string) (*Schema, error) {
 	// Use the new SQL parser-based approach
-	return ApplyMigrationsFromDir(ctx, dir)
+	return ApplyMigrationsFromDir(ctx, dir, skip...)
 }
 
 // These legacy functions are no longer needed with the new SQL parser
@@ -0,0 +1,449 @@
+package schema
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/phathdt/schema-manager/internal/logger"
+)
+
+// removeInlineComments removes inline comments (// comment) from a line
+func removeInlineComments(line string) string {
+	if idx := strings.Index(line, "//"); idx != -1 {
+		return strings.TrimSpace(line[:idx])
+	}
+	return line
+}
+
+func ParsePrismaFileToSchema(ctx context.Context, path string) (*Schema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrismaToSchema(ctx, string(b), path)
+}
+
+// ParsePrismaToSchema parses the content of a Prisma schema. Trigger files and the views and functions
+// directories are looked up next to path, which doesn't need to exist.
+func ParsePrismaToSchema(ctx context.Context, content, path string) (*Schema, error) {
+	return parsePrisma(ctx, content, path, nil)
+}
+
+// parsePrisma parses a Prisma schema included by the files of including
+func parsePrisma(ctx context.Context, content, path string, including []string) (*Schema, error) {
+	lines := strings.Split(content, "\n")
+	schema := &Schema{}
+	var currentModel *Model
+	var currentEnum *Enum
+	var currentCompositeType *CompositeType
+	var currentTrigger *Trigger
+	var currentPolicy *Policy
+	var currentView *View
+	var currentSequence *Sequence
+	inDatasource := false
+	inGenerator := false
+	// Lines of the /// doc comment above the next model or field
+	var docLines []string
+	for i, line := range lines {
+		lineNo := i + 1
+		if doc, ok := strings.CutPrefix(strings.TrimSpace(line), "///"); ok {
+			docLines = append(docLines, doc)
+			continue
+		}
+		comment := docComment(docLines)
+		docLines = nil
+		// Remove inline comments first, then trim whitespace
+		l := strings.TrimSpace(removeInlineComments(line))
+		if l == "" {
+			continue
+		}
+		if strings.HasPrefix(l, "datasource ") {
+			inDatasource = true
+			continue
+		}
+		if inDatasource {
+			if l == "}" {
+				inDatasource = false
+			} else if strings.HasPrefix(l, "extensions") {
+				addExtensions(schema, parseDatasourceExtensions(l))
+			} else if key, value := parseBlockValue(l); key == "relationMode" {
+				schema.Generator.RelationMode = value
+			} else {
+				setDatasourceValue(&schema.Datasource, key, value)
+			}
+			continue
+		}
+		if strings.HasPrefix(l, "generator ") {
+			inGenerator = true
+			continue
+		}
+		if inGenerator {
+			if l == "}" {
+				inGenerator = false
+			} else {
+				key, value := parseBlockValue(l)
+				setGeneratorValue(&schema.Generator, key, value)
+			}
+			continue
+		}
+		if currentModel == nil && currentEnum == nil && currentCompositeType == nil && strings.HasPrefix(l, "type ") &&
+			strings.HasSuffix(l, "{") {
+			currentCompositeType = &CompositeType{Name: strings.Fields(l)[1], Line: lineNo}
+			schema.CompositeTypes = append(schema.CompositeTypes, currentCompositeType)
+			continue
+		}
+		if currentCompositeType != nil {
+			if l == "}" {
+				currentCompositeType = nil
+			} else if f := parseField(l); f != nil {
+				f.Line = lineNo
+				f.Comment = comment
+				currentCompositeType.Fields = append(currentCompositeType.Fields, f)
+			}
+			continue
+		}
+		if currentModel == nil && currentEnum == nil && strings.HasPrefix(l, "trigger ") {
+			currentTrigger = &Trigger{Name: strings.Fields(l)[1]}
+			schema.Triggers = append(schema.Triggers, currentTrigger)
+			continue
+		}
+		if currentTrigger != nil {
+			if l == "}" {
+				currentTrigger = nil
+			} else {
+				key, value := parseBlockValue(l)
+				setTriggerValue(currentTrigger, key, value)
+			}
+			continue
+		}
+		if currentModel == nil && currentEnum == nil && strings.HasPrefix(l, "policy ") {
+			currentPolicy = &Policy{Name: strings.Fields(l)[1]}
+			schema.Policies = append(schema.Policies, currentPolicy)
+			continue
+		}
+		if currentPolicy != nil {
+			if l == "}" {
+				currentPolicy = nil
+			} else {
+				key, value := parseBlockValue(l)
+				setPolicyValue(currentPolicy, key, value)
+			}
+			continue
+		}
+		if currentModel == nil && currentEnum == nil && strings.HasPrefix(l, "sequence ") {
+			currentSequence = &Sequence{Name: strings.Fields(l)[1]}
+			schema.Sequences = append(schema.Sequences, currentSequence)
+			continue
+		}
+		if currentSequence != nil {
+			if l == "}" {
+				currentSequence = nil
+			} else {
+				key, value := parseBlockValue(l)
+				setSequenceValue(currentSequence, key, value)
+			}
+			continue
+		}
+		if currentModel == nil && currentEnum == nil && strings.HasPrefix(l, "view ") {
+			name := strings.Fields(l)[1]
+			currentView = &View{Name: name, ViewName: name}
+			schema.Views = append(schema.Views, currentView)
+			continue
+		}
+		if currentView != nil {
+			if l == "}" {
+				currentView = nil
+			} else if strings.HasPrefix(l, "@@") {
+				attr := parseModelAttribute(l)
+				if attr.Name == "map" && len(attr.Args) > 0 {
+					currentView.ViewName = strings.Trim(attr.Args[0], "\"")
+				}
+			} else if f := parseField(l); f != nil {
+				currentView.Fields = append(currentView.Fields, f)
+			}
+			continue
+		}
+		if strings.HasPrefix(l, "model ") {
+			name := strings.Fields(l)[1]
+			currentModel = &Model{Name: name, TableName: name, Line: lineNo, Comment: comment}
+			schema.Models = append(schema.Models, currentModel)
+			continue
+		}
+		if strings.HasPrefix(l, "enum ") {
+			name := strings.Fields(l)[1]
+			currentEnum = &Enum{Name: name, Line: lineNo}
+			schema.Enums = append(schema.Enums, currentEnum)
+			continue
+		}
+		if currentModel != nil && l == "}" {
+			currentModel.EndLine = lineNo
+			currentModel = nil
+			continue
+		}
+		if currentEnum != nil && l == "}" {
+			currentEnum = nil
+			continue
+		}
+		if currentModel != nil {
+			if strings.HasPrefix(l, "@@") {
+				attr := parseModelAttribute(l)
+				attr.Line = lineNo
+				currentModel.Attributes = append(currentModel.Attributes, attr)
+				if attr.Name == "map" && len(attr.Args) > 0 {
+					currentModel.TableName = strings.Trim(attr.Args[0], "\"")
+				}
+				if attr.Name == "rls" {
+					currentModel.RowLevelSecurity = true
+				}
+				if attr.Name == "grant" {
+					if grant := parseGrantAttribute(attr); grant != nil {
+						existing := findGrant(currentModel, grant.Role, true)
+						for _, privilege := range grant.Privileges {
+							existing.Privileges = addPrivilege(existing.Privileges, privilege)
+						}
+					}
+				}
+				continue
+			}
+			f := parseField(l)
+			if f != nil {
+				f.Line = lineNo
+				f.Comment = comment
+				currentModel.Fields = append(currentModel.Fields, f)
+			}
+			continue
+		}
+		if currentEnum != nil {
+			if !strings.HasPrefix(l, "enum ") && l != "{" && l != "}" {
+				currentEnum.Values = append(currentEnum.Values, l)
+			}
+			continue
+		}
+	}
+
+	// The generator block may follow the models, so names and column types are derived once the
+	// whole file is read
+	applyNamingStrategy(schema)
+	applyAuditColumns(schema)
+	resolvePartitioning(schema)
+	applyTypeMapping(schema)
+	for _, m := range schema.Models {
+		resolveSearchVectors(m)
+	}
+
+	// Triggers may reference models declared later in the file
+	for _, t := range schema.Triggers {
+		if err := resolveTrigger(t, schema, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range schema.Policies {
+		if err := resolvePolicy(p, schema); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, seq := range schema.Sequences {
+		if err := resolveSequence(seq, schema); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, v := range schema.Views {
+		if err := resolveView(v, path); err != nil {
+			return nil, err
+		}
+	}
+
+	functions, err := loadFunctions(path)
+	if err != nil {
+		return nil, err
+	}
+	schema.Functions = functions
+	addAuditFunction(schema)
+
+	// Extensions required by field defaults and types don't need to be declared explicitly
+	addExtensions(schema, inferExtensions(schema))
+	merged, err := mergeIncludes(ctx, schema, path, including)
+	if err != nil {
+		return nil, err
+	}
+	// Relations and composite types may point at models and types of included files
+	markRelationFields(merged)
+	resolveCompositeTypes(merged)
+	return merged, nil
+}
+
+// markRelationFields flags the fields whose type is a model, including back-relation fields like
+// `profile Profile?` that carry no @relation attribute, and records the table of the related model
+func markRelationFields(s *Schema) {
+	tables := map[string]string{}
+	for _, m := range s.Models {
+		tables[m.Name] = m.TableName
+	}
+	for _, m := range s.Models {
+		for _, f := range m.Fields {
+			f.RelationTable, f.IsRelation = tables[f.Type]
+		}
+	}
+}
+
+// parseBlockValue splits a `key = value` line of a configuration block
+func parseBlockValue(line string) (string, string) {
+	idx := strings.Index(line, "=")
+	if idx < 0 {
+		return strings.TrimSpace(line), ""
+	}
+	key := strings.TrimSpace(line[:idx])
+	value := strings.Trim(strings.TrimSpace(line[idx+1:]), "\"")
+	return key, value
+}
+
+func parseField(line string) *Field {
+	if strings.HasPrefix(line, "@@") || line == "{" || line == "}" {
+		return nil
+	}
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return nil
+	}
+	f := &Field{Name: parts[0], ColumnName: strings.ToLower(parts[0]), Type: parts[1]}
+	logger.Debug("parseField line: '%s'", line)
+	logger.Debug("parseField parts: %v", parts)
+
+	// Parse attributes by finding @ symbols and handling parentheses properly
+	attributeStart := -1
+	for i := 2; i < len(parts); i++ {
+		part := parts[i]
+		if strings.HasPrefix(part, "@") {
+			if attributeStart >= 0 {
+				// Process previous attribute
+				attr := parseFieldAttributeFromParts(parts[attributeStart:i])
+				f.Attributes = append(f.Attributes, attr)
+				if attr.Name == "map" && len(attr.Args) > 0 {
+					f.ColumnName = strings.Trim(attr.Args[0], "\"")
+				}
+			}
+			attributeStart = i
+		}
+	}
+
+	// Process last attribute
+	if attributeStart >= 0 && attributeStart < len(parts) {
+		attr := parseFieldAttributeFromParts(parts[attributeStart:])
+		f.Attributes = append(f.Attributes, attr)
+		if attr.Name == "map" && len(attr.Args) > 0 {
+			f.ColumnName = strings.Trim(attr.Args[0], "\"")
+		}
+	}
+
+	if strings.HasSuffix(f.Type, "?") {
+		f.IsOptional = true
+		f.Type = strings.TrimSuffix(f.Type, "?")
+	}
+	if strings.HasSuffix(f.Type, "[]") {
+		f.IsArray = true
+		f.Type = strings.TrimSuffix(f.Type, "[]")
+	}
+	return f
+}
+
+func parseFieldAttributeFromParts(parts []string) *FieldAttribute {
+	if len(parts) == 0 {
+		return &FieldAttribute{Name: "", Args: []string{}}
+	}
+
+	// Reconstruct the full attribute token
+	fullToken := strings.Join(parts, " ")
+	logger.Debug("parseFieldAttributeFromParts fullToken: '%s'", fullToken)
+
+	return parseFieldAttribute(fullToken)
+}
+
+func parseFieldAttribute(token string) *FieldAttribute {
+	logger.Debug("parseFieldAttribute token: '%s'", token)
+	token = strings.TrimPrefix(token, "@")
+	name := token
+	var args []string
+	if i := strings.Index(token, "("); i >= 0 {
+		name = token[:i]
+		argsStr := strings.TrimSuffix(token[i+1:], ")")
+		logger.Debug("argsStr: '%s'", argsStr)
+		// Handle complex args like "fields: [organizationId], references: [id]" or
+		// dbgenerated("timezone('utc', now())") - split by commas, but be careful with
+		// nested brackets, parentheses and quoted strings
+		parts := splitComplexArgs(argsStr)
+		for _, part := range parts {
+			args = append(args, strings.TrimSpace(part))
+		}
+
+		// Debug: print parsed args
+		logger.Debug("Parsed @%s args: %v", name, args)
+	}
+	return &FieldAttribute{Name: name, Args: args}
+}
+
+func splitComplexArgs(argsStr string) []string {
+	var args []string
+	var current strings.Builder
+	inBrackets := 0
+	var quoteChar rune
+	escaped := false
+
+	// Debug: print input
+	logger.Debug("splitComplexArgs input: '%s'", argsStr)
+
+	for _, char := range argsStr {
+		if quoteChar != 0 {
+			// Inside a quoted string - only look for the closing quote
+			if escaped {
+				escaped = false
+			} else if char == '\\' {
+				escaped = true
+			} else if char == quoteChar {
+				quoteChar = 0
+			}
+			current.WriteRune(char)
+			continue
+		}
+		if char == '"' {
+			quoteChar = char
+		} else if char == '[' || char == '(' {
+			inBrackets++
+		} else if char == ']' || char == ')' {
+			inBrackets--
+		} else if char == ',' && inBrackets == 0 {
+			if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(char)
+	}
+
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+
+	logger.Debug("splitComplexArgs output: %v", args)
+	return args
+}
+
+func parseModelAttribute(line string) *ModelAttribute {
+	l := strings.TrimPrefix(line, "@@")
+	l = strings.TrimSpace(l)
+	name := l
+	var args []string
+	if i := strings.Index(l, "("); i >= 0 {
+		name = l[:i]
+		argsStr := strings.TrimSuffix(l[i+1:], ")")
+		args = strings.Split(argsStr, ",")
+		for i := range args {
+			args[i] = strings.TrimSpace(args[i])
+		}
+	}
+	return &ModelAttribute{Name: name, Args: args}
+}
@@ -0,0 +1,307 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Partition intervals accepted by @@partition
+const (
+	PartitionDay   = "day"
+	PartitionWeek  = "week"
+	PartitionMonth = "month"
+	PartitionYear  = "year"
+)
+
+const partitionBoundsPattern = `FOR VALUES FROM\s*\('([^']+)'\)\s*TO\s*\('([^']+)'\)`
+
+// partitionBoundLayout is the format of partition bounds in FOR VALUES FROM ... TO ...
+const partitionBoundLayout = "2006-01-02"
+
+// defaultPartitionPremake is the number of upcoming partitions kept created when @@partition has no premake
+const defaultPartitionPremake = 3
+
+// Partitioning is the time-range partitioning of a model, declared with
+// @@partition([createdAt], interval: "month", premake: 3, retain: 12)
+type Partitioning struct {
+	Field    string
+	Column   string
+	Interval string
+	Premake  int // Partitions created ahead of the current one
+	Retain   int // Past partitions kept attached, 0 to keep all
+}
+
+// Partition is a range partition of a table, created by migrations
+type Partition struct {
+	Name   string
+	Parent string
+	From   string // Inclusive lower bound, e.g. 2026-10-01
+	To     string // Exclusive upper bound
+}
+
+// PartitionPlan lists the partitions a partitioned table needs created and may have removed
+type PartitionPlan struct {
+	Table  string
+	Create []*Partition
+	Prune  []*Partition // Older than the retention period
+}
+
+var (
+	partitionByRegex = regexp.MustCompile(`\)\s*PARTITION BY RANGE\s*\(\s*` + identPattern + `\s*\)\s*;?$`)
+	partitionOfRegex = regexp.MustCompile(`^CREATE TABLE\s+(?:IF NOT EXISTS\s+)?` + identPattern + `\s+PARTITION OF\s+` +
+		identPattern + `\s+` + partitionBoundsPattern)
+	attachPartitionRegex = regexp.MustCompile(`^ALTER TABLE\s+` + identPattern + `\s+ATTACH PARTITION\s+` + identPattern +
+		`\s+` + partitionBoundsPattern)
+	detachPartitionRegex = regexp.MustCompile(`^ALTER TABLE\s+` + identPattern + `\s+DETACH PARTITION\s+` + identPattern)
+)
+
+// AttachPartitionStatement represents CREATE TABLE ... PARTITION OF and ALTER TABLE ... ATTACH PARTITION
+type AttachPartitionStatement struct {
+	Partition *Partition
+}
+
+func (a *AttachPartitionStatement) Apply(schema *Schema) error {
+	schema.Partitions = append(removePartition(schema.Partitions, a.Partition.Name), a.Partition)
+	return nil
+}
+
+func (a *AttachPartitionStatement) String() string {
+	return "ATTACH PARTITION " + a.Partition.Name
+}
+
+// DetachPartitionStatement represents ALTER TABLE ... DETACH PARTITION
+type DetachPartitionStatement struct {
+	Name string
+}
+
+func (d *DetachPartitionStatement) Apply(schema *Schema) error {
+	schema.Partitions = removePartition(schema.Partitions, d.Name)
+	return nil
+}
+
+func (d *DetachPartitionStatement) String() string {
+	return "DETACH PARTITION " + d.Name
+}
+
+func removePartition(partitions []*Partition, name string) []*Partition {
+	var kept []*Partition
+	for _, p := range partitions {
+		if !strings.EqualFold(p.Name, name) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// parsePartitionStatement parses the partition statements of migrations; sql is upper-cased outside quotes
+func parsePartitionStatement(sql string) SQLStatement {
+	sql = strings.TrimSuffix(sql, ";")
+	if matches := partitionOfRegex.FindStringSubmatch(sql); matches != nil {
+		return &AttachPartitionStatement{Partition: &Partition{
+			Name: normalizeIdent(matches[1]), Parent: normalizeIdent(matches[2]), From: matches[3], To: matches[4],
+		}}
+	}
+	if matches := attachPartitionRegex.FindStringSubmatch(sql); matches != nil {
+		return &AttachPartitionStatement{Partition: &Partition{
+			Name: normalizeIdent(matches[2]), Parent: normalizeIdent(matches[1]), From: matches[3], To: matches[4],
+		}}
+	}
+	if matches := detachPartitionRegex.FindStringSubmatch(sql); matches != nil {
+		return &DetachPartitionStatement{Name: normalizeIdent(matches[2])}
+	}
+	return nil
+}
+
+// resolvePartitioning reads the @@partition attribute of every model once column names are known
+func resolvePartitioning(s *Schema) {
+	for _, m := range s.Models {
+		for _, attr := range m.Attributes {
+			if attr.Name != "partition" {
+				continue
+			}
+			p := &Partitioning{Interval: PartitionMonth, Premake: defaultPartitionPremake}
+			if fields := modelAttributeFields(attr); len(fields) > 0 {
+				p.Field = fields[0]
+				if f, ok := findFieldByName(m, p.Field); ok {
+					p.Column = f.ColumnName
+				}
+			}
+			for _, arg := range attr.Args {
+				key, value, found := strings.Cut(arg, ":")
+				if !found {
+					continue
+				}
+				value = strings.Trim(strings.TrimSpace(value), "\"")
+				switch strings.TrimSpace(key) {
+				case "interval":
+					p.Interval = value
+				case "premake":
+					p.Premake, _ = strconv.Atoi(value)
+				case "retain":
+					p.Retain, _ = strconv.Atoi(value)
+				}
+			}
+			m.Partitioning = p
+		}
+	}
+}
+
+// validatePartitioning returns the problems of a @@partition attribute
+func validatePartitioning(m *Model) []string {
+	p := m.Partitioning
+	var problems []string
+	if p.Column == "" {
+		return []string{fmt.Sprintf("@@partition on model %s must name an existing field, e.g. @@partition([createdAt])",
+			m.Name)}
+	}
+	if f, _ := findFieldByName(m, p.Field); f.Type != "DateTime" {
+		problems = append(problems, fmt.Sprintf("@@partition on model %s needs a DateTime field, not %s %s",
+			m.Name, p.Field, f.Type))
+	}
+	switch p.Interval {
+	case PartitionDay, PartitionWeek, PartitionMonth, PartitionYear:
+	default:
+		problems = append(problems, fmt.Sprintf("@@partition on model %s has interval %q; use day, week, month or year",
+			m.Name, p.Interval))
+	}
+	if p.Premake < 0 || p.Retain < 0 {
+		problems = append(problems, fmt.Sprintf("@@partition on model %s must have positive premake and retain", m.Name))
+	}
+
+	// PostgreSQL requires the partition key in every primary key and unique constraint
+	for _, f := range m.Fields {
+		for _, name := range []string{"id", "unique"} {
+			if findFieldAttribute(f, name) != nil && f.Name != p.Field {
+				problems = append(problems, fmt.Sprintf("@%s on %s.%s must include the partition field %s; use @@%s([%s, %s])",
+					name, m.Name, f.Name, p.Field, name, f.Name, p.Field))
+			}
+		}
+	}
+	for _, attr := range m.Attributes {
+		if (attr.Name == "id" || attr.Name == "unique") && !containsString(modelAttributeFields(attr), p.Field) {
+			problems = append(problems, fmt.Sprintf("@@%s of partitioned model %s must include the partition field %s",
+				attr.Name, m.Name, p.Field))
+		}
+	}
+	return problems
+}
+
+// partitionPeriodStart truncates t to the start of its partition interval, in UTC
+func partitionPeriodStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case PartitionDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case PartitionWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)) // Weeks start on Monday
+	case PartitionYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// addPartitionPeriods moves a period start by n intervals
+func addPartitionPeriods(t time.Time, interval string, n int) time.Time {
+	switch interval {
+	case PartitionDay:
+		return t.AddDate(0, 0, n)
+	case PartitionWeek:
+		return t.AddDate(0, 0, 7*n)
+	case PartitionYear:
+		return t.AddDate(n, 0, 0)
+	default:
+		return t.AddDate(0, n, 0)
+	}
+}
+
+// partitionName names the partition of a table starting at from: events_p202610 for months,
+// events_p20261012 for days and weeks, events_p2026 for years
+func partitionName(table string, from time.Time, interval string) string {
+	layout := "200601"
+	switch interval {
+	case PartitionDay, PartitionWeek:
+		layout = "20060102"
+	case PartitionYear:
+		layout = "2006"
+	}
+	return limitIdentifier(table + "_p" + from.Format(layout))
+}
+
+// PlanPartitions returns the partitions a partitioned model needs at time now: the current period and
+// the premade upcoming ones that migrations don't create yet, and the existing ones that ended before
+// the retention period
+func PlanPartitions(m *Model, existing []*Partition, now time.Time) *PartitionPlan {
+	p := m.Partitioning
+	plan := &PartitionPlan{Table: m.TableName}
+	created := map[string]bool{}
+	for _, part := range existing {
+		if strings.EqualFold(part.Parent, m.TableName) {
+			created[part.From] = true
+		}
+	}
+
+	start := partitionPeriodStart(now, p.Interval)
+	for i := 0; i <= p.Premake; i++ {
+		from := addPartitionPeriods(start, p.Interval, i)
+		if created[from.Format(partitionBoundLayout)] {
+			continue
+		}
+		plan.Create = append(plan.Create, &Partition{
+			Name:   partitionName(m.TableName, from, p.Interval),
+			Parent: m.TableName,
+			From:   from.Format(partitionBoundLayout),
+			To:     addPartitionPeriods(from, p.Interval, 1).Format(partitionBoundLayout),
+		})
+	}
+
+	if p.Retain > 0 {
+		cutoff := addPartitionPeriods(start, p.Interval, -p.Retain)
+		for _, part := range existing {
+			to, err := time.Parse(partitionBoundLayout, part.To)
+			if err == nil && strings.EqualFold(part.Parent, m.TableName) && !to.After(cutoff) {
+				plan.Prune = append(plan.Prune, part)
+			}
+		}
+	}
+	return plan
+}
+
+// GeneratePartitionMigrationSQL returns the up and down sections creating the planned partitions and
+// detaching, or with drop also dropping, the pruned ones
+func GeneratePartitionMigrationSQL(plans []*PartitionPlan, drop bool) (string, string) {
+	var up, down []string
+	for _, plan := range plans {
+		for _, part := range plan.Create {
+			up = append(up, wrapGooseStatement(generateCreatePartitionSQL(part)))
+			down = append(down, wrapGooseStatement("DROP TABLE IF EXISTS "+quoteIdent(part.Name)+";"))
+		}
+		for _, part := range plan.Prune {
+			detach := "ALTER TABLE " + quoteIdent(part.Parent) + " DETACH PARTITION " + quoteIdent(part.Name) + ";"
+			attach := "ALTER TABLE " + quoteIdent(part.Parent) + " ATTACH PARTITION " + quoteIdent(part.Name) +
+				" FOR VALUES FROM ('" + part.From + "') TO ('" + part.To + "');"
+			if drop {
+				up = append(up, wrapGooseStatement(detach), wrapGooseStatement("DROP TABLE "+quoteIdent(part.Name)+";"))
+				down = append(down, wrapGooseStatement("-- WARNING: the rows of "+part.Name+
+					" were dropped and are not restored\n"+generateCreatePartitionSQL(part)))
+			} else {
+				up = append(up, wrapGooseStatement(detach))
+				down = append(down, wrapGooseStatement(attach))
+			}
+		}
+	}
+	// Undo in reverse order
+	for i, j := 0, len(down)-1; i < j; i, j = i+1, j-1 {
+		down[i], down[j] = down[j], down[i]
+	}
+	return strings.Join(up, "\n\n"), strings.Join(down, "\n\n")
+}
+
+func generateCreatePartitionSQL(part *Partition) string {
+	return "CREATE TABLE IF NOT EXISTS " + quoteIdent(part.Name) + " PARTITION OF " + quoteIdent(part.Parent) +
+		" FOR VALUES FROM ('" + part.From + "') TO ('" + part.To + "');"
+}
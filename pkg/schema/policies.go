@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setPolicyValue applies a single `key = value` line of a policy block
+func setPolicyValue(p *Policy, key, value string) {
+	switch key {
+	case "model":
+		p.Model = value
+	case "table":
+		p.TableName = value
+	case "command":
+		p.Command = strings.ToUpper(value)
+	case "roles":
+		p.Roles = value
+	case "using":
+		p.Using = value
+	case "withCheck":
+		p.WithCheck = value
+	}
+}
+
+// resolvePolicy fills in the table name and SQL definition of a parsed policy block
+func resolvePolicy(p *Policy, s *Schema) error {
+	if p.Model != "" {
+		for _, m := range s.Models {
+			if m.Name == p.Model {
+				p.TableName = m.TableName
+				break
+			}
+		}
+		if p.TableName == "" {
+			return fmt.Errorf("policy %s references unknown model %s", p.Name, p.Model)
+		}
+	}
+	if p.TableName == "" {
+		return fmt.Errorf("policy %s must define model or table", p.Name)
+	}
+	if p.Using == "" && p.WithCheck == "" {
+		return fmt.Errorf("policy %s must define using or withCheck", p.Name)
+	}
+	if p.Command == "" {
+		p.Command = "ALL"
+	}
+	if p.Roles == "" {
+		p.Roles = "PUBLIC"
+	}
+
+	def := "CREATE POLICY " + p.Name + " ON " + quoteIdent(p.TableName) + " FOR " + p.Command + " TO " + p.Roles
+	if p.Using != "" {
+		def += " USING (" + p.Using + ")"
+	}
+	if p.WithCheck != "" {
+		def += " WITH CHECK (" + p.WithCheck + ")"
+	}
+	p.Definition = def + ";"
+	return nil
+}
+
+func policyKey(p *Policy) string {
+	return strings.ToLower(p.TableName + "." + p.Name)
+}
+
+func generateDropPolicySQL(p *Policy) string {
+	return "DROP POLICY IF EXISTS " + p.Name + " ON " + quoteIdent(p.TableName) + ";"
+}
+
+func generateRowLevelSecuritySQL(tableName string, enable bool) string {
+	if enable {
+		return "ALTER TABLE " + quoteIdent(tableName) + " ENABLE ROW LEVEL SECURITY;"
+	}
+	return "ALTER TABLE " + quoteIdent(tableName) + " DISABLE ROW LEVEL SECURITY;"
+}
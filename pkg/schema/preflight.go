@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PreflightProbe is a query counting the existing rows a change of a diff would fail on, run against
+// the database before the migration is written so the warnings say whether it will succeed
+type PreflightProbe struct {
+	Table       string
+	Change      string // The risky change, e.g. "email SET NOT NULL"
+	Description string // What the counted rows are, e.g. "row(s) with NULL in email"
+	SQL         string // SELECT returning a single count
+}
+
+// PreflightProbes returns the probes of the changes that fail on existing data: columns becoming NOT
+// NULL without a backfill, unique indexes on existing tables and type changes that narrow the values a
+// column holds. Narrowing casts other than shorter VARCHARs are probed with pg_input_is_valid, which
+// needs PostgreSQL 16.
+func (d *SchemaDiff) PreflightProbes() []*PreflightProbe {
+	var probes []*PreflightProbe
+	for _, fc := range d.FieldsModified {
+		if fc.Field.IsArray || findFieldAttribute(fc.Field, "relation") != nil {
+			continue
+		}
+		table, column := quoteIdent(fc.ModelName), quoteIdent(fc.Field.ColumnName)
+
+		if fc.CurrentField.IsOptional && !fc.Field.IsOptional && !fc.BackfillsNulls(d.Generator) {
+			probes = append(probes, &PreflightProbe{
+				Table:       fc.ModelName,
+				Change:      fc.Field.ColumnName + " SET NOT NULL",
+				Description: "row(s) with NULL in " + fc.Field.ColumnName,
+				SQL:         fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NULL", table, column),
+			})
+		}
+
+		_, _, cast, changed := fc.TypeChange(d.Generator)
+		if !changed || !cast.CanCast || !cast.IsRisky {
+			continue
+		}
+		targetType := GetSQLTypeForField(fc.Field)
+		if fc.TargetEnum != nil {
+			targetType = fc.TargetEnum.Name
+		}
+		condition := fmt.Sprintf("NOT pg_input_is_valid(%s::text, '%s')", column, strings.ReplaceAll(targetType, "'", "''"))
+		if n := varcharLength(targetType); n > 0 {
+			condition = fmt.Sprintf("length(%s) > %d", column, n)
+		}
+		probes = append(probes, &PreflightProbe{
+			Table:       fc.ModelName,
+			Change:      fc.Field.ColumnName + " TYPE " + targetType,
+			Description: "row(s) whose " + fc.Field.ColumnName + " doesn't fit " + targetType,
+			SQL:         fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NOT NULL AND %s", table, column, condition),
+		})
+	}
+
+	for _, ic := range d.IndexesAdded {
+		// Partial and expression indexes can't be probed with a GROUP BY of their columns
+		if !ic.Index.Unique || len(ic.Index.Columns) == 0 || strings.Contains(strings.ToUpper(ic.Index.Definition), " WHERE ") ||
+			strings.ContainsAny(strings.Join(ic.Index.Columns, ""), "( ") {
+			continue
+		}
+		columns := quoteIdents(ic.Index.Columns)
+		// NULLs never conflict in a unique index, so rows with one don't count as duplicates
+		var notNull []string
+		for _, column := range columns {
+			notNull = append(notNull, column+" IS NOT NULL")
+		}
+		list := strings.Join(columns, ", ")
+		probes = append(probes, &PreflightProbe{
+			Table:       ic.TableName,
+			Change:      "UNIQUE (" + strings.Join(ic.Index.Columns, ", ") + ")",
+			Description: "row(s) sharing their " + strings.Join(ic.Index.Columns, ", ") + " with another row",
+			SQL: fmt.Sprintf("SELECT coalesce(sum(n), 0) FROM (SELECT count(*) AS n FROM %s WHERE %s GROUP BY %s "+
+				"HAVING count(*) > 1) duplicates", quoteIdent(ic.TableName), strings.Join(notNull, " AND "), list),
+		})
+	}
+	return probes
+}
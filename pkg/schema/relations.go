@@ -0,0 +1,178 @@
+package schema
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MissingBackRelation is a relation declared with @relation(fields: ...) whose related model has no
+// field pointing back, which Prisma rejects
+type MissingBackRelation struct {
+	Model        *Model // Model declaring the relation
+	Field        *Field // Relation field with @relation(fields: ...)
+	RelatedModel *Model // Model missing the back-relation field
+	RelationName string
+	OneToOne     bool
+}
+
+// FindMissingBackRelations returns the one-sided relations of a schema
+func FindMissingBackRelations(s *Schema) []*MissingBackRelation {
+	models := map[string]*Model{}
+	for _, m := range s.Models {
+		models[m.Name] = m
+	}
+
+	var missing []*MissingBackRelation
+	for _, m := range s.Models {
+		for _, f := range m.Fields {
+			related, ok := models[f.Type]
+			if !ok {
+				continue
+			}
+			attr := findFieldAttribute(f, "relation")
+			if attr == nil {
+				continue
+			}
+			local, _ := relationFieldLists(attr)
+			if len(local) == 0 {
+				continue
+			}
+
+			name := relationName(attr)
+			if hasBackRelation(related, m, f, name) {
+				continue
+			}
+			missing = append(missing, &MissingBackRelation{
+				Model:        m,
+				Field:        f,
+				RelatedModel: related,
+				RelationName: name,
+				OneToOne:     isUniqueFieldList(m, local),
+			})
+		}
+	}
+	return missing
+}
+
+// hasBackRelation reports whether related has a field of the declaring model's type for the relation
+func hasBackRelation(related, m *Model, relationField *Field, name string) bool {
+	for _, f := range related.Fields {
+		if f == relationField || f.Type != m.Name {
+			continue
+		}
+		backName := ""
+		if attr := findFieldAttribute(f, "relation"); attr != nil {
+			backName = relationName(attr)
+		}
+		if backName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// relationName returns the name of a @relation("Name", ...) or @relation(name: "Name", ...) attribute
+func relationName(attr *FieldAttribute) string {
+	for _, arg := range attr.Args {
+		arg = strings.TrimSpace(arg)
+		if strings.HasPrefix(arg, "\"") {
+			return strings.Trim(arg, "\"")
+		}
+		if key, value, found := strings.Cut(arg, ":"); found && strings.TrimSpace(key) == "name" {
+			return strings.Trim(strings.TrimSpace(value), "\"")
+		}
+	}
+	return ""
+}
+
+// isUniqueFieldList reports whether the fields form a unique key of the model, making the relation one-to-one
+func isUniqueFieldList(m *Model, names []string) bool {
+	if len(names) == 1 {
+		if f, ok := findFieldByName(m, names[0]); ok {
+			if findFieldAttribute(f, "unique") != nil || findFieldAttribute(f, "id") != nil {
+				return true
+			}
+		}
+	}
+	for _, attr := range m.Attributes {
+		if attr.Name == "unique" || attr.Name == "id" {
+			if strings.Join(modelAttributeFields(attr), ",") == strings.Join(names, ",") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func findFieldAttribute(f *Field, name string) *FieldAttribute {
+	for _, attr := range f.Attributes {
+		if attr.Name == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+// backRelationFieldLine returns the field declaration that completes a one-sided relation, naming
+// list fields with the generator's pluralization
+func backRelationFieldLine(r *MissingBackRelation, generator GeneratorConfig) string {
+	name := lowerFirst(r.Model.Name)
+	fieldType := r.Model.Name + "?"
+	if !r.OneToOne {
+		name = generator.Pluralize(name)
+		fieldType = r.Model.Name + "[]"
+	}
+	if _, exists := findFieldByName(r.RelatedModel, name); exists {
+		// e.g. a second Post relation on User through Post.editor becomes editorPosts
+		name = r.Field.Name + strings.ToUpper(name[:1]) + name[1:]
+	}
+
+	line := name + " " + fieldType
+	if r.RelationName != "" {
+		line += " @relation(\"" + r.RelationName + "\")"
+	}
+	return line
+}
+
+// FixMissingBackRelations inserts the missing back-relation fields into the schema file, after
+// the last field of each related model
+func FixMissingBackRelations(path string, missing []*MissingBackRelation, generator GeneratorConfig) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(b), "\n")
+
+	// Insert from the bottom of the file up so earlier line numbers stay valid
+	sorted := append([]*MissingBackRelation{}, missing...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].RelatedModel.EndLine != sorted[j].RelatedModel.EndLine {
+			return sorted[i].RelatedModel.EndLine > sorted[j].RelatedModel.EndLine
+		}
+		return sorted[i].Field.Line > sorted[j].Field.Line
+	})
+	for _, r := range sorted {
+		at := r.RelatedModel.EndLine - 1
+		indent := "  "
+		if fields := r.RelatedModel.Fields; len(fields) > 0 && fields[len(fields)-1].Line > 0 {
+			last := lines[fields[len(fields)-1].Line-1]
+			indent = last[:len(last)-len(strings.TrimLeftFunc(last, unicode.IsSpace))]
+			at = fields[len(fields)-1].Line
+		}
+		if at < 0 || at > len(lines) {
+			continue
+		}
+		insert := indent + backRelationFieldLine(r, generator)
+		lines = append(lines[:at], append([]string{insert}, lines[at:]...)...)
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
@@ -0,0 +1,571 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TableRename is a table the target schema gives another name, which migrations rename in place
+// instead of dropping it and creating the new one
+type TableRename struct {
+	CurrentName string
+	Model       *Model // Target model
+}
+
+// ConstraintRename is an index or constraint named after a renamed table or column, renamed along with
+// it so it keeps the name the generator gives it
+type ConstraintRename struct {
+	TableName string // Target name of the table
+	Name      string
+	NewName   string
+	Index     bool // An index renamed with ALTER INDEX rather than a table constraint
+}
+
+// RenameCandidate is a dropped table or column that looks renamed to an added one, as their columns or
+// types are the same. Unlike a changed @@map or @map it is only renamed once confirmed.
+type RenameCandidate struct {
+	TableName string // Table of the column by its target name, or the current name of a renamed table
+	Column    string // Current name of the column, "" for a table
+	NewName   string // Target name of the table or column
+}
+
+func (rc *RenameCandidate) String() string {
+	if rc.Column == "" {
+		return "table " + rc.TableName + " → " + rc.NewName
+	}
+	return "column " + rc.TableName + "." + rc.Column + " → " + rc.NewName
+}
+
+func (rc *RenameCandidate) matches(table, column, newName string) bool {
+	return rc.TableName == table && rc.Column == column && rc.NewName == newName
+}
+
+// DiffSchemasWithRenames compares two schemas like DiffSchemas, renaming the tables and columns of
+// the confirmed candidates in place instead of dropping and adding them
+func DiffSchemasWithRenames(current, target *Schema, confirmed []*RenameCandidate) *SchemaDiff {
+	tables, columns, candidates := detectRenames(current, target, confirmed)
+	var constraints []*ConstraintRename
+	if len(tables) > 0 || len(columns) > 0 {
+		// Everything else is compared against the current schema with the renames applied, as
+		// migrations parse after the rename statements
+		current, constraints = renamedSchema(current, target.Generator, tables, columns)
+	}
+	diff := diffSchemas(current, target)
+	diff.TablesRenamed = tables
+	diff.ColumnsRenamed = columns
+	diff.ConstraintsRenamed = constraints
+	diff.RenameCandidates = candidates
+	return diff
+}
+
+// defaultTableName returns the table a model is stored in without @@map
+func defaultTableName(m *Model, generator GeneratorConfig) string {
+	if generator.Naming == NamingSnakeCase {
+		return toSnakeCase(m.Name)
+	}
+	return m.Name
+}
+
+// detectRenames pairs the tables and columns the target schema drops with the ones it adds. A table
+// is renamed when its model got a @@map keeping the model name, and a column when its field got a
+// @map keeping the field name, e.g. email becoming email String @map("email_address"). Pairs with
+// the same columns or type are candidates, renamed when confirmed; so are tables whose columns have the
+// same types in the same order, as some of their columns may be renamed too. Once such a table rename
+// is confirmed, its columns are paired the same way.
+func detectRenames(current, target *Schema, confirmed []*RenameCandidate) ([]*TableRename, []*FieldChange, []*RenameCandidate) {
+	var removed, added []*Model
+	for _, m := range current.Models {
+		if findModelByTable(target.Models, m.TableName) == nil {
+			removed = append(removed, m)
+		}
+	}
+	for _, m := range target.Models {
+		if findModelByTable(current.Models, m.TableName) == nil {
+			added = append(added, m)
+		}
+	}
+
+	var tables []*TableRename
+	var candidates []*RenameCandidate
+	// pairs holds the current model of every target model that keeps its table or renames one
+	pairs := map[*Model]*Model{}
+	for _, m := range target.Models {
+		if cm := findModelByTable(current.Models, m.TableName); cm != nil {
+			pairs[m] = cm
+		}
+	}
+	renamed := func(removedAt, addedAt int) bool {
+		cm, m := removed[removedAt], added[addedAt]
+		for _, rc := range confirmed {
+			if rc.matches(cm.TableName, "", m.TableName) {
+				return true
+			}
+		}
+		return hasModelAttribute(m, "map") && cm.TableName == defaultTableName(m, target.Generator)
+	}
+	similar := func(removedAt, addedAt int) bool {
+		cm, m := removed[removedAt], added[addedAt]
+		return sameColumns(cm, m, target.Enums) || sameColumnTypes(cm, m)
+	}
+	for _, pair := range matchRenames(len(removed), len(added), renamed, similar) {
+		cm, m := removed[pair.removed], added[pair.added]
+		if !pair.confirmed {
+			candidates = append(candidates, &RenameCandidate{TableName: cm.TableName, NewName: m.TableName})
+			continue
+		}
+		tables = append(tables, &TableRename{CurrentName: cm.TableName, Model: m})
+		pairs[m] = cm
+	}
+
+	var columns []*FieldChange
+	for _, m := range target.Models {
+		cm := pairs[m]
+		if cm == nil {
+			continue
+		}
+		var removedFields, addedFields []*Field
+		for _, f := range cm.Fields {
+			if hasColumn(f) && findColumnField(m, f.ColumnName) == nil {
+				removedFields = append(removedFields, f)
+			}
+		}
+		for _, f := range m.Fields {
+			if hasColumn(f) && findColumnField(cm, f.ColumnName) == nil {
+				addedFields = append(addedFields, f)
+			}
+		}
+		renamed := func(removedAt, addedAt int) bool {
+			cf, f := removedFields[removedAt], addedFields[addedAt]
+			for _, rc := range confirmed {
+				if rc.matches(m.TableName, cf.ColumnName, f.ColumnName) {
+					return true
+				}
+			}
+			return findFieldAttribute(f, "map") != nil && cf.ColumnName == target.Generator.FieldColumnName(f.Name)
+		}
+		similar := func(removedAt, addedAt int) bool {
+			f := addedFields[addedAt]
+			return fieldsEqual(removedFields[removedAt], f, findEnum(target.Enums, f.Type))
+		}
+		for _, pair := range matchRenames(len(removedFields), len(addedFields), renamed, similar) {
+			cf, f := removedFields[pair.removed], addedFields[pair.added]
+			if !pair.confirmed {
+				candidates = append(candidates, &RenameCandidate{TableName: m.TableName, Column: cf.ColumnName, NewName: f.ColumnName})
+				continue
+			}
+			columns = append(columns, &FieldChange{ModelName: m.TableName, Field: f, CurrentField: cf, Type: "renamed"})
+		}
+	}
+	return tables, columns, candidates
+}
+
+// renamePair is a removed and an added item of matchRenames
+type renamePair struct {
+	removed, added int
+	confirmed      bool // Renamed, rather than a candidate
+}
+
+// matchRenames pairs removed and added items: first the ones renamed outright, then the ones that are
+// similar to each other and to nothing else left, as candidates
+func matchRenames(removed, added int, renamed, similar func(int, int) bool) []renamePair {
+	var pairs []renamePair
+	removedUsed, addedUsed := make([]bool, removed), make([]bool, added)
+	for j := 0; j < added; j++ {
+		for i := 0; i < removed; i++ {
+			if !removedUsed[i] && renamed(i, j) {
+				pairs = append(pairs, renamePair{removed: i, added: j, confirmed: true})
+				removedUsed[i], addedUsed[j] = true, true
+				break
+			}
+		}
+	}
+	for j := 0; j < added; j++ {
+		if addedUsed[j] {
+			continue
+		}
+		var matches []int
+		for i := 0; i < removed; i++ {
+			if !removedUsed[i] && similar(i, j) {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) != 1 {
+			continue
+		}
+		unique := true
+		for k := 0; k < added; k++ {
+			if k != j && !addedUsed[k] && similar(matches[0], k) {
+				unique = false
+			}
+		}
+		if unique {
+			pairs = append(pairs, renamePair{removed: matches[0], added: j})
+		}
+	}
+	return pairs
+}
+
+// sameColumns reports whether two models have the same columns with the same types
+func sameColumns(current, target *Model, enums []*Enum) bool {
+	columns := 0
+	for _, f := range target.Fields {
+		if !hasColumn(f) {
+			continue
+		}
+		cf := findColumnField(current, f.ColumnName)
+		if cf == nil || !fieldsEqual(cf, f, findEnum(enums, f.Type)) {
+			return false
+		}
+		columns++
+	}
+	currentColumns := 0
+	for _, f := range current.Fields {
+		if hasColumn(f) {
+			currentColumns++
+		}
+	}
+	return columns > 0 && columns == currentColumns
+}
+
+// sameColumnTypes reports whether two models have as many columns with the same types in the same
+// order, whatever the columns are named
+func sameColumnTypes(current, target *Model) bool {
+	var currentFields, targetFields []*Field
+	for _, f := range current.Fields {
+		if hasColumn(f) {
+			currentFields = append(currentFields, f)
+		}
+	}
+	for _, f := range target.Fields {
+		if hasColumn(f) {
+			targetFields = append(targetFields, f)
+		}
+	}
+	if len(targetFields) == 0 || len(targetFields) != len(currentFields) {
+		return false
+	}
+	for i, f := range targetFields {
+		if !columnTypesEqual(currentFields[i], f) {
+			return false
+		}
+	}
+	return true
+}
+
+// findColumnField returns the field of a model stored in a column
+func findColumnField(m *Model, column string) *Field {
+	for _, f := range m.Fields {
+		if hasColumn(f) && f.ColumnName == column {
+			return f
+		}
+	}
+	return nil
+}
+
+// renamedSchema returns a copy of the current schema with tables and columns renamed the way the
+// migration renames them, along with the indexes and constraints named after them that are renamed too
+func renamedSchema(current *Schema, generator GeneratorConfig, tables []*TableRename, columns []*FieldChange) (*Schema, []*ConstraintRename) {
+	s := copySchemaModels(current)
+	previousTables := map[string]string{}
+	for _, tr := range tables {
+		(&RenameTableStatement{TableName: tr.CurrentName, NewName: tr.Model.TableName}).Apply(s)
+		previousTables[tr.Model.TableName] = tr.CurrentName
+	}
+	previousColumns := map[string]map[string]string{}
+	for _, fc := range columns {
+		rename := &RenameColumnOperation{ColumnName: fc.CurrentField.ColumnName, NewName: fc.Field.ColumnName}
+		(&AlterTableStatement{TableName: fc.ModelName, Operation: rename}).Apply(s)
+		if previousColumns[fc.ModelName] == nil {
+			previousColumns[fc.ModelName] = map[string]string{}
+		}
+		previousColumns[fc.ModelName][fc.Field.ColumnName] = fc.CurrentField.ColumnName
+	}
+	previous := func(table string, columns []string) (string, []string) {
+		before := make([]string, len(columns))
+		for i, column := range columns {
+			before[i] = column
+			if name, ok := previousColumns[table][column]; ok {
+				before[i] = name
+			}
+		}
+		if name, ok := previousTables[table]; ok {
+			return name, before
+		}
+		return table, before
+	}
+
+	var constraints []*ConstraintRename
+	for _, m := range s.Models {
+		if name, ok := previousTables[m.TableName]; ok && len(primaryKeyColumns(m)) > 0 {
+			constraints = append(constraints, &ConstraintRename{
+				TableName: m.TableName,
+				Name:      primaryKeyName(name),
+				NewName:   primaryKeyName(m.TableName),
+			})
+		}
+		for _, idx := range m.Indexes {
+			table, columns := previous(m.TableName, idx.Columns)
+			// Generated index and foreign key names are written unquoted, so PostgreSQL folds them
+			newName := normalizeIdent(generator.IndexName(m.TableName, idx.Columns, idx.Unique))
+			if strings.EqualFold(idx.Name, generator.IndexName(table, columns, idx.Unique)) && idx.Name != newName {
+				constraints = append(constraints, &ConstraintRename{TableName: m.TableName, Name: idx.Name, NewName: newName, Index: true})
+			}
+		}
+		for _, fk := range m.ForeignKeys {
+			table, columns := previous(m.TableName, fk.Columns)
+			refTable, _ := previous(fk.RefTable, nil)
+			newName := normalizeIdent(generator.ForeignKeyName(m.TableName, fk.Columns, fk.RefTable))
+			if strings.EqualFold(fk.Name, generator.ForeignKeyName(table, columns, refTable)) && fk.Name != newName {
+				constraints = append(constraints, &ConstraintRename{TableName: m.TableName, Name: fk.Name, NewName: newName})
+			}
+		}
+	}
+	for _, cr := range constraints {
+		if cr.Index {
+			(&RenameIndexStatement{Name: cr.Name, NewName: cr.NewName}).Apply(s)
+		} else {
+			(&AlterTableStatement{TableName: cr.TableName, Operation: &RenameConstraintOperation{Name: cr.Name, NewName: cr.NewName}}).Apply(s)
+		}
+	}
+	return s, constraints
+}
+
+// copySchemaModels copies the models and sequences of a schema deep enough to rename tables, columns,
+// indexes and constraints without changing the original
+func copySchemaModels(s *Schema) *Schema {
+	c := *s
+	c.Models = make([]*Model, len(s.Models))
+	for i, m := range s.Models {
+		mc := *m
+		mc.Fields = make([]*Field, len(m.Fields))
+		for j, f := range m.Fields {
+			fc := *f
+			mc.Fields[j] = &fc
+		}
+		mc.Indexes = make([]*Index, len(m.Indexes))
+		for j, idx := range m.Indexes {
+			ic := *idx
+			ic.Columns = append([]string(nil), idx.Columns...)
+			mc.Indexes[j] = &ic
+		}
+		mc.ForeignKeys = make([]*ForeignKey, len(m.ForeignKeys))
+		for j, fk := range m.ForeignKeys {
+			fc := *fk
+			fc.Columns = append([]string(nil), fk.Columns...)
+			fc.RefColumns = append([]string(nil), fk.RefColumns...)
+			mc.ForeignKeys[j] = &fc
+		}
+		c.Models[i] = &mc
+	}
+	c.Sequences = make([]*Sequence, len(s.Sequences))
+	for i, seq := range s.Sequences {
+		sc := *seq
+		c.Sequences[i] = &sc
+	}
+	return &c
+}
+
+// generateRenameSQL returns the statements renaming tables, then their columns, then the indexes and
+// constraints named after them
+func generateRenameSQL(diff *SchemaDiff) []string {
+	var stmts []string
+	for _, tr := range diff.TablesRenamed {
+		stmts = append(stmts, wrapGooseStatement(renameTableSQL(tr.CurrentName, tr.Model.TableName)))
+	}
+	for _, fc := range diff.ColumnsRenamed {
+		stmts = append(stmts, wrapGooseStatement(renameColumnSQL(fc.ModelName, fc.CurrentField.ColumnName, fc.Field.ColumnName)))
+	}
+	for _, cr := range diff.ConstraintsRenamed {
+		stmts = append(stmts, wrapGooseIndexStatement(renameConstraintSQL(cr.TableName, cr.Name, cr.NewName, cr.Index), cr.TableName))
+	}
+	return stmts
+}
+
+// generateRevertRenameSQL returns the statements giving renamed tables, columns and constraints their
+// previous names, in reverse order
+func generateRevertRenameSQL(diff *SchemaDiff) []string {
+	var stmts []string
+	for i := len(diff.ConstraintsRenamed) - 1; i >= 0; i-- {
+		cr := diff.ConstraintsRenamed[i]
+		stmts = append(stmts, wrapGooseIndexStatement(renameConstraintSQL(cr.TableName, cr.NewName, cr.Name, cr.Index), cr.TableName))
+	}
+	for i := len(diff.ColumnsRenamed) - 1; i >= 0; i-- {
+		fc := diff.ColumnsRenamed[i]
+		stmts = append(stmts, wrapGooseStatement(renameColumnSQL(fc.ModelName, fc.Field.ColumnName, fc.CurrentField.ColumnName)))
+	}
+	for i := len(diff.TablesRenamed) - 1; i >= 0; i-- {
+		tr := diff.TablesRenamed[i]
+		stmts = append(stmts, wrapGooseStatement(renameTableSQL(tr.Model.TableName, tr.CurrentName)))
+	}
+	return stmts
+}
+
+func renameTableSQL(table, newName string) string {
+	return "ALTER TABLE " + quoteIdent(table) + " RENAME TO " + quoteIdent(newName) + ";"
+}
+
+func renameColumnSQL(table, column, newName string) string {
+	return "ALTER TABLE " + quoteIdent(table) + " RENAME COLUMN " + quoteIdent(column) + " TO " + quoteIdent(newName) + ";"
+}
+
+func renameConstraintSQL(table, name, newName string, index bool) string {
+	if index {
+		return "ALTER INDEX " + quoteIdent(name) + " RENAME TO " + quoteIdent(newName) + ";"
+	}
+	return "ALTER TABLE " + quoteIdent(table) + " RENAME CONSTRAINT " + quoteIdent(name) + " TO " + quoteIdent(newName) + ";"
+}
+
+var (
+	renameTableRegex      = regexp.MustCompile(`^ALTER TABLE\s+(?:IF EXISTS\s+)?` + identPattern + `\s+RENAME TO\s+` + identPattern + `\s*;?$`)
+	renameIndexRegex      = regexp.MustCompile(`^ALTER INDEX\s+(?:IF EXISTS\s+)?` + identPattern + `\s+RENAME TO\s+` + identPattern + `\s*;?$`)
+	renameConstraintRegex = regexp.MustCompile(`^RENAME CONSTRAINT\s+` + identPattern + `\s+TO\s+` + identPattern + `\s*;?$`)
+)
+
+// RenameTableStatement represents ALTER TABLE ... RENAME TO. The indexes, foreign keys and sequences
+// of the table follow it, as PostgreSQL tracks them by OID.
+type RenameTableStatement struct {
+	TableName string
+	NewName   string
+}
+
+func (r *RenameTableStatement) Apply(schema *Schema) error {
+	for _, m := range schema.Models {
+		if m.TableName == r.TableName {
+			if m.Name == m.TableName {
+				m.Name = r.NewName
+			}
+			m.TableName = r.NewName
+			for _, idx := range m.Indexes {
+				idx.Definition = renameIndexDefinition(idx.Definition, r.TableName, r.NewName, false)
+			}
+		}
+		for _, fk := range m.ForeignKeys {
+			if fk.RefTable == r.TableName {
+				fk.RefTable = r.NewName
+			}
+		}
+	}
+	for _, seq := range schema.Sequences {
+		if seq.OwnerTable == r.TableName {
+			seq.OwnerTable = r.NewName
+		}
+	}
+	return nil
+}
+
+func (r *RenameTableStatement) String() string {
+	return "ALTER TABLE " + r.TableName + " RENAME TO " + r.NewName
+}
+
+// RenameIndexStatement represents ALTER INDEX ... RENAME TO
+type RenameIndexStatement struct {
+	Name    string
+	NewName string
+}
+
+func (r *RenameIndexStatement) Apply(schema *Schema) error {
+	for _, m := range schema.Models {
+		for _, idx := range m.Indexes {
+			if idx.Name == r.Name {
+				idx.Name = r.NewName
+				idx.Definition = renameIdentInSQL(idx.Definition, r.Name, r.NewName, 1)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RenameIndexStatement) String() string {
+	return "ALTER INDEX " + r.Name + " RENAME TO " + r.NewName
+}
+
+// RenameConstraintOperation represents ALTER TABLE RENAME CONSTRAINT of a foreign key or a UNIQUE
+// constraint
+type RenameConstraintOperation struct {
+	Name    string
+	NewName string
+}
+
+func (r *RenameConstraintOperation) Apply(model *Model) error {
+	for _, fk := range model.ForeignKeys {
+		if fk.Name == r.Name {
+			fk.Name = r.NewName
+		}
+	}
+	for _, idx := range model.Indexes {
+		if idx.Name == r.Name {
+			idx.Name = r.NewName
+			idx.Definition = renameIdentInSQL(idx.Definition, r.Name, r.NewName, 1)
+		}
+	}
+	return nil
+}
+
+func (r *RenameConstraintOperation) String() string {
+	return "RENAME CONSTRAINT " + r.Name + " TO " + r.NewName
+}
+
+// parseRenameStatement parses the ALTER TABLE and ALTER INDEX statements renaming a table or an index
+func parseRenameStatement(sql string) SQLStatement {
+	if matches := renameTableRegex.FindStringSubmatch(sql); matches != nil {
+		return &RenameTableStatement{TableName: normalizeIdent(matches[1]), NewName: normalizeIdent(matches[2])}
+	}
+	if matches := renameIndexRegex.FindStringSubmatch(sql); matches != nil {
+		return &RenameIndexStatement{Name: normalizeIdent(matches[1]), NewName: normalizeIdent(matches[2])}
+	}
+	return nil
+}
+
+// renameReferencedColumn points the foreign keys referencing a renamed column at its new name
+func renameReferencedColumn(schema *Schema, table, column, newName string) {
+	for _, m := range schema.Models {
+		for _, fk := range m.ForeignKeys {
+			if fk.RefTable != table {
+				continue
+			}
+			for i, col := range fk.RefColumns {
+				if col == column {
+					fk.RefColumns[i] = newName
+				}
+			}
+		}
+	}
+}
+
+// indexOnRegex matches the ON keyword of a CREATE INDEX statement, which the table name follows
+var indexOnRegex = regexp.MustCompile(`(?i)\sON\s+(?:ONLY\s+)?`)
+
+// renameIndexDefinition renames the table of a CREATE INDEX statement, or with column a column of its
+// column list, so replaying it after the rename creates the same index
+func renameIndexDefinition(definition, name, newName string, column bool) string {
+	loc := indexOnRegex.FindStringIndex(definition)
+	if loc == nil {
+		return definition
+	}
+	head, tail := definition[:loc[1]], definition[loc[1]:]
+	if !column {
+		return head + renameIdentInSQL(tail, name, newName, 1)
+	}
+	open := strings.Index(tail, "(")
+	if open < 0 {
+		return definition
+	}
+	return head + tail[:open] + renameIdentInSQL(tail[open:], name, newName, -1)
+}
+
+// renameIdentInSQL replaces the first n occurrences, or all with n < 0, of an identifier of a SQL
+// statement, quoted or not, with another
+func renameIdentInSQL(sql, name, newName string, n int) string {
+	identRegex := regexp.MustCompile(`(^|[^\w"$.])("` + regexp.QuoteMeta(name) + `"|(?i:` + regexp.QuoteMeta(name) + `))([^\w"$]|$)`)
+	var sb strings.Builder
+	for ; n != 0; n-- {
+		loc := identRegex.FindStringSubmatchIndex(sql)
+		if loc == nil {
+			break
+		}
+		sb.WriteString(sql[:loc[4]] + quoteIdent(newName))
+		sql = sql[loc[5]:]
+	}
+	sb.WriteString(sql)
+	return sb.String()
+}
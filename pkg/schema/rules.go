@@ -0,0 +1,354 @@
+package schema
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Severities of schema rules, set per rule with rules in the generator block
+const (
+	RuleError   = "error"
+	RuleWarning = "warning"
+	RuleOff     = "off"
+)
+
+// RuleSetting is an item of rules in the generator block: a built-in rule name or an expression,
+// followed by its severity
+type RuleSetting struct {
+	Rule       string
+	Severity   string // RuleError, RuleWarning or RuleOff, "" when the item has no valid severity
+	Definition string // As written in the generator block
+}
+
+// builtinRule is a policy checked on every model of a schema
+type builtinRule struct {
+	Name        string
+	Severity    string // Used unless the generator block configures the rule
+	Description string
+	Check       func(g GeneratorConfig, m *Model, violate func(line int, format string, args ...interface{}))
+}
+
+// builtinRules lists the built-in rules in the order they are reported
+var builtinRules = []*builtinRule{
+	{"require-id", RuleError, "Every model has an @id or @@id", checkRequireID},
+	{"require-timestamps", RuleOff, "Every model has createdAt and updatedAt fields", checkRequireTimestamps},
+	{"fk-index", RuleWarning, "The foreign key columns of every relation are indexed", checkForeignKeyIndex},
+	{"snake-case-tables", RuleOff, "Table names are snake_case", checkSnakeCaseTables},
+	{"plural-tables", RuleOff, "Table names are plural", checkPluralTables},
+	{"singular-tables", RuleOff, "Table names are singular", checkSingularTables},
+	{"decimal-money", RuleOff, "Money fields such as price or amount are Decimal, not Float", checkDecimalMoney},
+	{"require-map", RuleOff, "camelCase fields choose their column name with @map", checkRequireMap},
+}
+
+var (
+	snakeCaseRegex  = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	moneyFieldRegex = regexp.MustCompile(`(?i)(price|amount|cost|total|balance|fee|salary|money|payment)`)
+)
+
+// Rule expressions, e.g. "model has field tenantId" or "field *Id has type Int"
+var (
+	modelHasFieldRuleRegex     = regexp.MustCompile(`^model has field (\w+)$`)
+	modelHasAttributeRuleRegex = regexp.MustCompile(`^model has attribute @@(\w+)$`)
+	fieldHasTypeRuleRegex      = regexp.MustCompile(`^field (\S+) has type (\w+)$`)
+	fieldHasAttributeRuleRegex = regexp.MustCompile(`^field (\S+) has attribute @([\w.]+)$`)
+)
+
+// parseRuleSetting parses "rule: severity"; the severity is separated by the last colon so
+// expressions may contain colons themselves
+func parseRuleSetting(definition string) *RuleSetting {
+	setting := &RuleSetting{Rule: definition, Definition: definition}
+	if i := strings.LastIndex(definition, ":"); i >= 0 {
+		severity := strings.ToLower(strings.TrimSpace(definition[i+1:]))
+		switch severity {
+		case RuleError, RuleWarning, RuleOff:
+			setting.Rule = strings.Join(strings.Fields(definition[:i]), " ")
+			setting.Severity = severity
+		}
+	}
+	return setting
+}
+
+// validRule reports whether a rule setting names a built-in rule or is a known expression
+func (r *RuleSetting) validRule() bool {
+	if findBuiltinRule(r.Rule) != nil {
+		return true
+	}
+	return ruleExpressionCheck(r.Rule) != nil
+}
+
+// SchemaRuleDescription describes a built-in rule; rule expressions describe themselves
+func SchemaRuleDescription(rule string) string {
+	if builtin := findBuiltinRule(rule); builtin != nil {
+		return builtin.Description
+	}
+	return rule
+}
+
+func findBuiltinRule(name string) *builtinRule {
+	for _, rule := range builtinRules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// CheckSchemaRules evaluates the built-in rules and the rule expressions of the generator block on
+// every model. Violations of rules with warning severity are returned as warnings; invalid rule
+// settings are skipped here and reported by ValidateSchema.
+func CheckSchemaRules(s *Schema, path string) []*ValidationError {
+	severities := map[string]string{}
+	for _, rule := range builtinRules {
+		severities[rule.Name] = rule.Severity
+	}
+	var expressions []*RuleSetting
+	for _, setting := range s.Generator.Rules {
+		if setting.Severity == "" || !setting.validRule() {
+			continue
+		}
+		if findBuiltinRule(setting.Rule) != nil {
+			severities[setting.Rule] = setting.Severity
+		} else {
+			expressions = append(expressions, setting)
+		}
+	}
+
+	var errs []*ValidationError
+	for _, m := range s.Models {
+		for _, rule := range builtinRules {
+			rule.Check(s.Generator, m, ruleViolation(&errs, path, rule.Name, severities[rule.Name]))
+		}
+		for _, setting := range expressions {
+			ruleExpressionCheck(setting.Rule)(m, ruleViolation(&errs, path, setting.Rule, setting.Severity))
+		}
+	}
+	return errs
+}
+
+// ruleViolation returns the function a rule calls for each violation, recording it with the rule
+// name unless the rule is off
+func ruleViolation(errs *[]*ValidationError, path, rule, severity string) func(int, string, ...interface{}) {
+	return func(line int, format string, args ...interface{}) {
+		if severity == RuleOff {
+			return
+		}
+		*errs = append(*errs, &ValidationError{
+			File:    path,
+			Line:    line,
+			Message: fmt.Sprintf(format, args...),
+			Warning: severity == RuleWarning,
+			Rule:    rule,
+		})
+	}
+}
+
+// checkRequireID reports models without a primary key
+func checkRequireID(_ GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	if hasModelAttribute(m, "id") {
+		return
+	}
+	for _, f := range m.Fields {
+		if findFieldAttribute(f, "id") != nil {
+			return
+		}
+	}
+	violate(m.Line, "model %s has no @id or @@id", m.Name)
+}
+
+// checkRequireTimestamps reports models without createdAt and updatedAt fields
+func checkRequireTimestamps(_ GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	for _, name := range []string{"createdAt", "updatedAt"} {
+		found := false
+		for _, f := range m.Fields {
+			if f.Name == name || f.ColumnName == toSnakeCase(name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			violate(m.Line, "model %s has no %s field", m.Name, name)
+		}
+	}
+}
+
+// checkForeignKeyIndex reports relations whose foreign key columns are not the leading columns of
+// an index, unique constraint or primary key, which makes joins and cascading deletes scan the table
+func checkForeignKeyIndex(g GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	// In relationMode "prisma" the migrations index the fields of every relation
+	if g.RelationMode == RelationModePrisma {
+		return
+	}
+	var keys [][]string
+	for _, attr := range m.Attributes {
+		switch attr.Name {
+		case "id", "unique", "index":
+			keys = append(keys, modelAttributeFields(attr))
+		}
+	}
+	for _, f := range m.Fields {
+		if findFieldAttribute(f, "id") != nil || findFieldAttribute(f, "unique") != nil {
+			keys = append(keys, []string{f.Name})
+		}
+	}
+
+	for _, f := range m.Fields {
+		attr := findFieldAttribute(f, "relation")
+		if attr == nil {
+			continue
+		}
+		local, _ := relationFieldLists(attr)
+		if len(local) == 0 {
+			continue
+		}
+		covered := false
+		for _, key := range keys {
+			if hasFieldPrefix(key, local) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			violate(f.Line, "relation %s.%s has no index on %s; add @@index([%s])",
+				m.Name, f.Name, strings.Join(local, ", "), strings.Join(local, ", "))
+		}
+	}
+}
+
+// checkSnakeCaseTables reports tables that are not lower-case words joined by underscores
+func checkSnakeCaseTables(_ GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	if !snakeCaseRegex.MatchString(m.TableName) {
+		violate(m.Line, "table %s of model %s is not snake_case; map it to %s",
+			m.TableName, m.Name, toSnakeCase(m.TableName))
+	}
+}
+
+// checkPluralTables reports tables whose last word is singular
+func checkPluralTables(g GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	if !isPluralWord(g, lastWord(m.TableName)) {
+		violate(m.Line, "table %s of model %s is not plural", m.TableName, m.Name)
+	}
+}
+
+// checkSingularTables reports tables whose last word is plural
+func checkSingularTables(g GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	word := lastWord(m.TableName)
+	if isPluralWord(g, word) && !isIrregularSingular(g, word) {
+		violate(m.Line, "table %s of model %s is not singular", m.TableName, m.Name)
+	}
+}
+
+// checkDecimalMoney reports Float fields named like amounts of money, which lose cents to rounding
+func checkDecimalMoney(_ GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	for _, f := range m.Fields {
+		if f.Type == "Float" && moneyFieldRegex.MatchString(f.Name) {
+			violate(f.Line, "field %s.%s holds money but is a Float; use Decimal", m.Name, f.Name)
+		}
+	}
+}
+
+// checkRequireMap reports camelCase fields that rely on the naming strategy or lower-casing for
+// their column name
+func checkRequireMap(_ GeneratorConfig, m *Model, violate func(int, string, ...interface{})) {
+	for _, f := range m.Fields {
+		if f.Name != strings.ToLower(f.Name) && findFieldAttribute(f, "map") == nil && !f.IsArray &&
+			findFieldAttribute(f, "relation") == nil {
+			violate(f.Line, "field %s.%s has no @map; add @map(\"%s\")", m.Name, f.Name, toSnakeCase(f.Name))
+		}
+	}
+}
+
+// lastWord returns the part of a snake_case or camelCase name after the last word boundary, lower-cased
+func lastWord(name string) string {
+	words := strings.Split(toSnakeCase(name), "_")
+	return words[len(words)-1]
+}
+
+// isPluralWord guesses whether a word is plural from the irregular words and the suffix rules of
+// Singularize, even when pluralization is disabled
+func isPluralWord(g GeneratorConfig, word string) bool {
+	for _, w := range g.Irregulars {
+		if strings.HasSuffix(word, w.Plural) {
+			return true
+		}
+	}
+	g.DisablePluralization = false
+	return g.Singularize(word) != word
+}
+
+func isIrregularSingular(g GeneratorConfig, word string) bool {
+	for _, w := range g.Irregulars {
+		if strings.HasSuffix(word, w.Singular) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFieldPrefix reports whether key starts with the given fields, in any order
+func hasFieldPrefix(key, fields []string) bool {
+	if len(key) < len(fields) {
+		return false
+	}
+	leading := map[string]bool{}
+	for _, name := range key[:len(fields)] {
+		leading[name] = true
+	}
+	for _, name := range fields {
+		if !leading[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleExpressionCheck compiles a rule expression, returning nil when it is not one of:
+//
+//	model has field <name>
+//	model has attribute @@<name>
+//	field <pattern> has type <Type>
+//	field <pattern> has attribute @<name>
+//
+// where pattern matches field names with * and ? wildcards
+func ruleExpressionCheck(expression string) func(*Model, func(int, string, ...interface{})) {
+	if matches := modelHasFieldRuleRegex.FindStringSubmatch(expression); matches != nil {
+		return func(m *Model, violate func(int, string, ...interface{})) {
+			if _, ok := findFieldByName(m, matches[1]); !ok {
+				violate(m.Line, "model %s has no %s field", m.Name, matches[1])
+			}
+		}
+	}
+	if matches := modelHasAttributeRuleRegex.FindStringSubmatch(expression); matches != nil {
+		return func(m *Model, violate func(int, string, ...interface{})) {
+			if !hasModelAttribute(m, matches[1]) {
+				violate(m.Line, "model %s has no @@%s", m.Name, matches[1])
+			}
+		}
+	}
+	if matches := fieldHasTypeRuleRegex.FindStringSubmatch(expression); matches != nil {
+		if _, err := path.Match(matches[1], ""); err != nil {
+			return nil
+		}
+		return func(m *Model, violate func(int, string, ...interface{})) {
+			for _, f := range m.Fields {
+				if ok, _ := path.Match(matches[1], f.Name); ok && f.Type != matches[2] {
+					violate(f.Line, "field %s.%s is %s, not %s", m.Name, f.Name, f.Type, matches[2])
+				}
+			}
+		}
+	}
+	if matches := fieldHasAttributeRuleRegex.FindStringSubmatch(expression); matches != nil {
+		if _, err := path.Match(matches[1], ""); err != nil {
+			return nil
+		}
+		return func(m *Model, violate func(int, string, ...interface{})) {
+			for _, f := range m.Fields {
+				if ok, _ := path.Match(matches[1], f.Name); ok && findFieldAttribute(f, matches[2]) == nil {
+					violate(f.Line, "field %s.%s has no @%s", m.Name, f.Name, matches[2])
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// RelationSpec describes a relation to add to schema.prisma, e.g. Post.author -> User.id
+type RelationSpec struct {
+	Model        string // Model that holds the foreign key, e.g. Post
+	Field        string // Relation field added to Model, e.g. author
+	RelatedModel string // Model the relation points at, e.g. User
+	References   string // Unique field of RelatedModel the key references, the @id field when empty
+	ForeignKey   string // Scalar field holding the key, <Field>Id when empty
+	BackRelation string // Field added to RelatedModel pointing back, derived from Model when empty
+	Optional     bool   // The foreign key may be NULL
+	OneToOne     bool   // The foreign key is unique and the back-relation a single optional field
+	OnDelete     string // Referential action, e.g. Cascade or SetNull
+}
+
+// ScaffoldedRelation lists the declarations AddRelation inserted
+type ScaffoldedRelation struct {
+	ModelLines   []string // Fields and attributes added to the model holding the key
+	RelatedLines []string // Back-relation field added to the related model
+}
+
+// scaffoldInsert is a block of lines inserted before a 0-based line index of the schema file
+type scaffoldInsert struct {
+	at    int
+	lines []string
+}
+
+// AddRelation inserts the relation field, the scalar foreign key field with its index and the
+// back-relation field of a relation into the schema file, aligning the fields of both models the way
+// prisma format does. The file is left untouched when the result would not validate.
+func AddRelation(ctx context.Context, path string, spec *RelationSpec) (*ScaffoldedRelation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParsePrismaToSchema(ctx, string(b), path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := findModelByName(s.Models, spec.Model)
+	if m == nil {
+		return nil, fmt.Errorf("model %s not found", spec.Model)
+	}
+	related := findModelByName(s.Models, spec.RelatedModel)
+	if related == nil {
+		return nil, fmt.Errorf("model %s not found", spec.RelatedModel)
+	}
+	lines := strings.Split(string(b), "\n")
+	for _, model := range []*Model{m, related} {
+		// Models of included files can't be edited here
+		if model.Line == 0 || model.Line > len(lines) || !strings.Contains(lines[model.Line-1], "model "+model.Name) {
+			return nil, fmt.Errorf("model %s is not declared in %s", model.Name, path)
+		}
+	}
+	if _, exists := findFieldByName(m, spec.Field); exists {
+		return nil, fmt.Errorf("model %s already has a field %s", m.Name, spec.Field)
+	}
+
+	refName := spec.References
+	if refName == "" {
+		if refName = idFieldName(related); refName == "" {
+			return nil, fmt.Errorf("model %s has no single @id field; name the referenced field, e.g. %s.email",
+				related.Name, related.Name)
+		}
+	}
+	ref, ok := findFieldByName(related, refName)
+	if !ok {
+		return nil, fmt.Errorf("model %s has no field %s", related.Name, refName)
+	}
+	if !isUniqueFieldList(related, []string{refName}) {
+		return nil, fmt.Errorf("%s.%s is neither @id nor @unique, so a relation can't reference it", related.Name, refName)
+	}
+
+	fkName := spec.ForeignKey
+	if fkName == "" {
+		fkName = spec.Field + strings.ToUpper(refName[:1]) + refName[1:]
+	}
+	if _, exists := findFieldByName(m, fkName); exists {
+		return nil, fmt.Errorf("model %s already has a field %s; choose another with --field", m.Name, fkName)
+	}
+
+	// Relations between the same two models, and relations of a model to itself, need names to tell
+	// them apart
+	relation := ""
+	if m == related || hasFieldOfType(m, related.Name) || hasFieldOfType(related, m.Name) {
+		relation = m.Name + strings.ToUpper(spec.Field[:1]) + spec.Field[1:]
+	}
+
+	optional := ""
+	if spec.Optional {
+		optional = "?"
+	}
+	args := []string{"fields: [" + fkName + "]", "references: [" + refName + "]"}
+	if relation != "" {
+		args = append([]string{"\"" + relation + "\""}, args...)
+	}
+	if spec.OnDelete != "" {
+		args = append(args, "onDelete: "+spec.OnDelete)
+	}
+	relationLine := spec.Field + " " + related.Name + optional + " @relation(" + strings.Join(args, ", ") + ")"
+
+	fkAttrs := []string{}
+	if spec.OneToOne {
+		fkAttrs = append(fkAttrs, "@unique")
+	}
+	if column := toSnakeCase(fkName); s.Generator.Naming != NamingSnakeCase && column != strings.ToLower(fkName) {
+		fkAttrs = append(fkAttrs, "@map(\""+column+"\")")
+	}
+	// The key must have the native type of the column it references, e.g. @db.Uuid
+	for _, attr := range ref.Attributes {
+		if strings.HasPrefix(attr.Name, "db.") {
+			fkAttrs = append(fkAttrs, "@"+attr.Name+attributeArgs(attr.Args))
+		}
+	}
+	fkLine := strings.TrimSpace(fkName + " " + ref.Type + optional + " " + strings.Join(fkAttrs, " "))
+
+	back := &MissingBackRelation{
+		Model:        m,
+		Field:        &Field{Name: spec.Field},
+		RelatedModel: related,
+		RelationName: relation,
+		OneToOne:     spec.OneToOne,
+	}
+	backLine := backRelationFieldLine(back, s.Generator)
+	if spec.BackRelation != "" {
+		if _, exists := findFieldByName(related, spec.BackRelation); exists {
+			return nil, fmt.Errorf("model %s already has a field %s", related.Name, spec.BackRelation)
+		}
+		_, rest, _ := strings.Cut(backLine, " ")
+		backLine = spec.BackRelation + " " + rest
+	}
+
+	result := &ScaffoldedRelation{ModelLines: []string{relationLine, fkLine}, RelatedLines: []string{backLine}}
+	indent := modelIndent(m, lines)
+	inserts := []scaffoldInsert{{at: lastFieldLine(m), lines: []string{indent + relationLine, indent + fkLine}}}
+	if m == related {
+		inserts[0].lines = append(inserts[0].lines, indent+backLine)
+	} else {
+		inserts = append(inserts, scaffoldInsert{at: lastFieldLine(related), lines: []string{modelIndent(related, lines) + backLine}})
+	}
+	// Unique keys are indexed already, plain ones get the index the fk-index rule asks for
+	if !spec.OneToOne {
+		index := "@@index([" + fkName + "])"
+		result.ModelLines = append(result.ModelLines, index)
+		if at := lastModelAttributeLine(m); at > 0 {
+			inserts = append(inserts, scaffoldInsert{at: at, lines: []string{indent + index}})
+		} else {
+			inserts = append(inserts, scaffoldInsert{at: m.EndLine - 1, lines: []string{"", indent + index}})
+		}
+	}
+
+	// Insert from the bottom of the file up so earlier line numbers stay valid
+	sort.SliceStable(inserts, func(i, j int) bool { return inserts[i].at > inserts[j].at })
+	for _, insert := range inserts {
+		lines = append(lines[:insert.at], append(insert.lines, lines[insert.at:]...)...)
+	}
+
+	content := strings.Join(lines, "\n")
+	updated, err := ParsePrismaToSchema(ctx, content, path)
+	if err != nil {
+		return nil, fmt.Errorf("the relation would break %s: %w", path, err)
+	}
+	// Only problems the relation introduces count, not the ones the schema already had
+	existing := map[string]bool{}
+	for _, e := range ValidateSchema(s, path) {
+		existing[e.Message] = true
+	}
+	for _, e := range ValidateSchema(updated, path) {
+		if !e.Warning && !existing[e.Message] {
+			return nil, fmt.Errorf("the relation would make %s invalid: %s", path, e.Message)
+		}
+	}
+	lines = strings.Split(content, "\n")
+	for _, name := range []string{m.Name, related.Name} {
+		alignFieldLines(lines, findModelByName(updated.Models, name))
+	}
+	return result, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644)
+}
+
+// AlignModelFields aligns the fields of the named models of a schema file the way AddRelation does,
+// for commands rewriting model blocks. The content must parse.
+func AlignModelFields(ctx context.Context, content, path string, models []string) (string, error) {
+	s, err := ParsePrismaToSchema(ctx, content, path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(content, "\n")
+	for _, name := range models {
+		alignFieldLines(lines, findModelByName(s.Models, name))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func findModelByName(models []*Model, name string) *Model {
+	for _, m := range models {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// idFieldName returns the field of a model marked @id, or "" for models with a composite key
+func idFieldName(m *Model) string {
+	for _, f := range m.Fields {
+		if findFieldAttribute(f, "id") != nil {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+func hasFieldOfType(m *Model, typ string) bool {
+	for _, f := range m.Fields {
+		if f.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// attributeArgs returns the argument list of an attribute as written, e.g. (36) for @db.VarChar(36)
+func attributeArgs(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+// lastFieldLine returns the 0-based index of the line after the last field of a model declared in
+// the file, which is where new fields go
+func lastFieldLine(m *Model) int {
+	at := m.EndLine - 1
+	for _, f := range m.Fields {
+		if f.Line > 0 && f.Line < m.EndLine {
+			at = f.Line
+		}
+	}
+	return at
+}
+
+// lastModelAttributeLine returns the 0-based index of the line after the last @@ attribute of a model,
+// or 0 when it has none
+func lastModelAttributeLine(m *Model) int {
+	at := 0
+	for _, attr := range m.Attributes {
+		if attr.Line > at && attr.Line < m.EndLine {
+			at = attr.Line
+		}
+	}
+	return at
+}
+
+// modelIndent returns the indentation of the fields of a model
+func modelIndent(m *Model, lines []string) string {
+	for _, f := range m.Fields {
+		if f.Line > 0 && f.Line <= len(lines) {
+			line := lines[f.Line-1]
+			return line[:len(line)-len(strings.TrimLeftFunc(line, unicode.IsSpace))]
+		}
+	}
+	return "  "
+}
+
+// alignFieldLines pads the names and types of each run of consecutive field lines of a model to
+// common widths, the layout prisma format produces
+func alignFieldLines(lines []string, m *Model) {
+	if m == nil {
+		return
+	}
+	fieldLines := map[int]bool{}
+	for _, f := range m.Fields {
+		if f.Line > 0 && f.Line <= len(lines) {
+			fieldLines[f.Line-1] = true
+		}
+	}
+	for start := m.Line; start < m.EndLine-1 && start < len(lines); start++ {
+		if !fieldLines[start] {
+			continue
+		}
+		end := start
+		for end < len(lines) && fieldLines[end] {
+			end++
+		}
+		alignFieldRun(lines[start:end])
+		start = end
+	}
+}
+
+func alignFieldRun(run []string) {
+	type parts struct{ indent, name, typ, rest string }
+	split := make([]parts, len(run))
+	nameWidth, typeWidth := 0, 0
+	for i, line := range run {
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		p := parts{indent: line[:len(line)-len(trimmed)]}
+		p.name, trimmed, _ = strings.Cut(trimmed, " ")
+		trimmed = strings.TrimSpace(trimmed)
+		// Types like Unsupported("circle") may hold spaces inside their parentheses
+		end := strings.IndexFunc(trimmed, unicode.IsSpace)
+		if open := strings.Index(trimmed, "("); open >= 0 && (end < 0 || open < end) {
+			if closing := strings.Index(trimmed, ")"); closing > 0 {
+				end = closing + 1 + strings.IndexFunc(trimmed[closing+1:]+" ", unicode.IsSpace)
+			}
+		}
+		if end < 0 {
+			end = len(trimmed)
+		}
+		p.typ, p.rest = trimmed[:end], strings.TrimSpace(trimmed[end:])
+		split[i] = p
+		nameWidth = max(nameWidth, len(p.name))
+		typeWidth = max(typeWidth, len(p.typ))
+	}
+	for i, p := range split {
+		if p.rest == "" {
+			run[i] = p.indent + fmt.Sprintf("%-*s %s", nameWidth, p.name, p.typ)
+			continue
+		}
+		run[i] = p.indent + fmt.Sprintf("%-*s %-*s %s", nameWidth, p.name, typeWidth, p.typ, p.rest)
+	}
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"strings"
+)
+
+const defaultSearchLanguage = "english"
+
+// resolveSearchVectors turns @fulltext(fields: [...], language: "...") attributes into
+// SearchVector definitions, mapping the referenced field names to their column names
+func resolveSearchVectors(model *Model) {
+	for _, f := range model.Fields {
+		for _, attr := range f.Attributes {
+			if attr.Name != "fulltext" {
+				continue
+			}
+			search := &SearchVector{Language: defaultSearchLanguage}
+			for _, arg := range attr.Args {
+				arg = strings.TrimSpace(arg)
+				switch {
+				case strings.HasPrefix(arg, "language:"):
+					search.Language = strings.Trim(strings.TrimSpace(strings.TrimPrefix(arg, "language:")), "\"")
+				case strings.HasPrefix(arg, "fields:"):
+					arg = strings.TrimSpace(strings.TrimPrefix(arg, "fields:"))
+					fallthrough
+				default:
+					for _, name := range strings.Split(strings.Trim(arg, "[]"), ",") {
+						name = strings.TrimSpace(name)
+						if name == "" {
+							continue
+						}
+						columnName := strings.ToLower(name)
+						for _, source := range model.Fields {
+							if source.Name == name {
+								columnName = source.ColumnName
+								break
+							}
+						}
+						search.Columns = append(search.Columns, columnName)
+					}
+				}
+			}
+			f.SearchVector = search
+		}
+	}
+}
+
+// searchVectorExpression builds the to_tsvector() expression for a generated search column
+func searchVectorExpression(search *SearchVector) string {
+	parts := make([]string, len(search.Columns))
+	for i, col := range search.Columns {
+		parts[i] = "coalesce(" + quoteIdent(col) + ", '')"
+	}
+	return "to_tsvector('" + search.Language + "', " + strings.Join(parts, " || ' ' || ") + ")"
+}
+
+// generateSearchColumnSQL returns the column definition for a generated tsvector column
+func generateSearchColumnSQL(f *Field) string {
+	col := quoteIdent(f.ColumnName) + " TSVECTOR GENERATED ALWAYS AS (" + searchVectorExpression(f.SearchVector) + ") STORED"
+	if !f.IsOptional {
+		col += " NOT NULL"
+	}
+	return col
+}
+
+// generateSearchIndexSQL returns the GIN index backing a generated tsvector column
+func generateSearchIndexSQL(generator GeneratorConfig, tableName string, f *Field) string {
+	idxName := generator.IndexName(tableName, []string{f.ColumnName}, false)
+	return "CREATE INDEX " + idxName + " ON " + quoteIdent(tableName) + " USING GIN (" + quoteIdent(f.ColumnName) + ");"
+}
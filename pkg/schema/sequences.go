@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// setSequenceValue applies a single `key = value` line of a sequence block
+func setSequenceValue(seq *Sequence, key, value string) {
+	switch key {
+	case "start":
+		seq.Start, _ = strconv.ParseInt(value, 10, 64)
+	case "increment":
+		seq.Increment, _ = strconv.ParseInt(value, 10, 64)
+	case "ownedBy":
+		seq.OwnedBy = value
+	}
+}
+
+// resolveSequence fills in the owning column of a parsed sequence block. ownedBy names a model field
+// (Invoice.number) or a table column (invoices.number).
+func resolveSequence(seq *Sequence, s *Schema) error {
+	if seq.Start == 0 {
+		seq.Start = 1
+	}
+	if seq.Increment == 0 {
+		seq.Increment = 1
+	}
+	if seq.OwnedBy == "" {
+		return nil
+	}
+
+	owner, column, ok := strings.Cut(seq.OwnedBy, ".")
+	if !ok {
+		return fmt.Errorf("sequence %s: ownedBy must be Model.field or table.column, got %s", seq.Name, seq.OwnedBy)
+	}
+	for _, m := range s.Models {
+		if m.Name != owner && m.TableName != owner {
+			continue
+		}
+		for _, f := range m.Fields {
+			if f.Name == column || f.ColumnName == column {
+				seq.OwnerTable, seq.OwnerColumn = m.TableName, f.ColumnName
+				return nil
+			}
+		}
+		return fmt.Errorf("sequence %s is owned by unknown field %s", seq.Name, seq.OwnedBy)
+	}
+	return fmt.Errorf("sequence %s is owned by unknown model %s", seq.Name, owner)
+}
+
+// findSequence returns the sequence with a name, matched case-insensitively like unquoted names
+func findSequence(sequences []*Sequence, name string) *Sequence {
+	for _, seq := range sequences {
+		if strings.EqualFold(seq.Name, name) {
+			return seq
+		}
+	}
+	return nil
+}
+
+// sequenceOwner returns the OWNED BY target of a sequence, NONE when no column owns it
+func sequenceOwner(seq *Sequence) string {
+	if seq.OwnerTable == "" {
+		return "NONE"
+	}
+	return quoteIdent(seq.OwnerTable) + "." + quoteIdent(seq.OwnerColumn)
+}
+
+// sequencesEqual reports whether two sequences have the same options and owner
+func sequencesEqual(a, b *Sequence) bool {
+	return a.Start == b.Start && a.Increment == b.Increment &&
+		strings.EqualFold(a.OwnerTable, b.OwnerTable) && strings.EqualFold(a.OwnerColumn, b.OwnerColumn)
+}
+
+func generateCreateSequenceSQL(seq *Sequence) string {
+	return fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s START WITH %d INCREMENT BY %d;",
+		seq.Name, seq.Start, seq.Increment)
+}
+
+// generateSequenceOwnerSQL ties a sequence to its column, so dropping the column drops the sequence.
+// It runs once the column exists.
+func generateSequenceOwnerSQL(seq *Sequence) string {
+	return "ALTER SEQUENCE " + seq.Name + " OWNED BY " + sequenceOwner(seq) + ";"
+}
+
+// generateAlterSequenceSQL changes the options and owner of an existing sequence; START WITH only
+// affects later restarts, the current value is kept
+func generateAlterSequenceSQL(seq *Sequence) string {
+	return fmt.Sprintf("ALTER SEQUENCE %s START WITH %d INCREMENT BY %d OWNED BY %s;",
+		seq.Name, seq.Start, seq.Increment, sequenceOwner(seq))
+}
+
+func generateDropSequenceSQL(seq *Sequence) string {
+	return "DROP SEQUENCE IF EXISTS " + seq.Name + ";"
+}
+
+var nextvalRegex = regexp.MustCompile(`(?i)nextval\(\s*'([^']+)'`)
+
+// nextvalSequence returns the sequence a dbgenerated("nextval('name')") default draws from
+func nextvalSequence(f *Field) string {
+	attr := findFieldAttribute(f, "default")
+	if attr == nil || len(attr.Args) == 0 {
+		return ""
+	}
+	expr, ok := parseDbGenerated(attr.Args[0])
+	if !ok {
+		return ""
+	}
+	if matches := nextvalRegex.FindStringSubmatch(expr); matches != nil {
+		return strings.TrimSuffix(matches[1], "'::regclass")
+	}
+	return ""
+}
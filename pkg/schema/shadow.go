@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShadowColumnSuffix names the column a zero-downtime type change fills before swapping it in
+const ShadowColumnSuffix = "__new"
+
+// shadowOldSuffix names the original column between the swap and its removal
+const shadowOldSuffix = "__old"
+
+// ShadowColumnStep is one migration of a zero-downtime type change
+type ShadowColumnStep struct {
+	Name string // Appended to the migration name, e.g. shadow_email
+	Up   string
+	Down string
+}
+
+// CanUseShadowColumn reports whether a modified field's type change can be rolled out through a
+// shadow column. Primary keys, relations and conversions that need a manual migration keep the single
+// ALTER COLUMN TYPE.
+func (fc *FieldChange) CanUseShadowColumn(generator GeneratorConfig) bool {
+	if fc.Field.IsArray || findFieldAttribute(fc.Field, "relation") != nil || findFieldAttribute(fc.Field, "id") != nil {
+		return false
+	}
+	_, _, cast, changed := fc.TypeChange(generator)
+	return changed && cast.CanCast
+}
+
+// ShadowColumnRecipe rolls out the type change of a field without rewriting the table under lock, in
+// four migrations deployed one after another:
+//
+//  1. shadow: add the column with the new type and a trigger writing converted values to it
+//  2. backfill: convert the existing rows in committed batches
+//  3. swap: rename the new column into place, keeping the old one without constraints
+//  4. drop: drop the old column once no deployed code reads it
+func ShadowColumnRecipe(fc *FieldChange, generator GeneratorConfig) []*ShadowColumnStep {
+	_, _, cast, _ := fc.TypeChange(generator)
+	table := quoteIdent(fc.ModelName)
+	name := fc.Field.ColumnName
+	column, shadow, old := quoteIdent(name), quoteIdent(name+ShadowColumnSuffix), quoteIdent(name+shadowOldSuffix)
+	newType, oldType := GetSQLTypeForField(fc.Field), GetSQLTypeForField(fc.CurrentField)
+	if fc.TargetEnum != nil {
+		newType = fc.TargetEnum.Name
+	}
+	if fc.CurrentEnum != nil {
+		oldType = fc.CurrentEnum.Name
+	}
+	convert := func(source string) string {
+		if expr := cast.UsingExpression(source); expr != "" {
+			return expr
+		}
+		return source
+	}
+
+	function := limitIdentifier(strings.ToLower(fc.ModelName) + "_" + name + "_shadow_sync")
+	createSync := []string{
+		fmt.Sprintf("CREATE OR REPLACE FUNCTION %s() RETURNS trigger AS $$\nBEGIN\n  NEW.%s := %s;\n  RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;",
+			function, shadow, convert("NEW."+column)),
+		fmt.Sprintf("CREATE TRIGGER %s BEFORE INSERT OR UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s();",
+			function, table, function),
+	}
+	dropSync := []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", function, table),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s;", function),
+	}
+
+	shadowStep := &ShadowColumnStep{
+		Name: "shadow_" + name,
+		Up: joinGooseStatements(append([]string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, shadow, newType),
+		}, createSync...)),
+		Down: joinGooseStatements(append(dropSync, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;", table, shadow))),
+	}
+
+	backfillStep := &ShadowColumnStep{
+		Name: "backfill_" + name,
+		Up: wrapGooseStatement(GenerateBatchedUpdateSQL(fc.ModelName, shadow+" = "+convert(column),
+			shadow+" IS DISTINCT FROM "+convert(column), generator.BackfillBatch())),
+		Down: wrapGooseStatement("-- Nothing to undo: the shadow column is dropped by the previous migration's down section"),
+	}
+
+	var swapUp, swapDown []string
+	swapUp = append(swapUp, dropSync...)
+	swapUp = append(swapUp,
+		fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", table, column, old),
+		fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", table, shadow, column),
+	)
+	// Code still deployed with the old column must keep inserting, so it loses its constraints
+	if !fc.CurrentField.IsOptional {
+		swapUp = append(swapUp, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, old))
+	}
+	if findFieldAttribute(fc.CurrentField, "default") != nil {
+		swapUp = append(swapUp, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, old))
+	}
+	if value := columnDefaultSQL(fc.Field, fc.TargetEnum); value != "" {
+		swapUp = append(swapUp, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, value))
+		swapDown = append(swapDown, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, column))
+	}
+	if !fc.Field.IsOptional {
+		swapUp = append(swapUp, fmt.Sprintf("-- WARNING: scans %s to verify there are no NULL values\n"+
+			"ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", fc.ModelName, table, column))
+		swapDown = append(swapDown, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, column))
+	}
+	swapDown = append(swapDown,
+		fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", table, column, shadow),
+		fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", table, old, column),
+	)
+	if !fc.CurrentField.IsOptional {
+		swapDown = append(swapDown, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, column))
+	}
+	if value := columnDefaultSQL(fc.CurrentField, fc.CurrentEnum); value != "" {
+		swapDown = append(swapDown, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, column, value))
+	}
+	swapDown = append(swapDown, createSync...)
+	swapStep := &ShadowColumnStep{
+		Name: "swap_" + name,
+		Up: "-- WARNING: indexes and constraints of " + name + " stay on " + name + shadowOldSuffix +
+			" and are dropped with it; recreate them on the new column\n" + joinGooseStatements(swapUp),
+		Down: joinGooseStatements(swapDown),
+	}
+
+	dropStep := &ShadowColumnStep{
+		Name: "drop_" + name + shadowOldSuffix,
+		Up:   wrapGooseStatement(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, old)),
+		Down: wrapGooseStatement(fmt.Sprintf("-- WARNING: the values of %s are not restored\n"+
+			"ALTER TABLE %s ADD COLUMN %s %s;", name+shadowOldSuffix, table, old, oldType)),
+	}
+	return []*ShadowColumnStep{shadowStep, backfillStep, swapStep, dropStep}
+}
+
+func joinGooseStatements(stmts []string) string {
+	wrapped := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		wrapped[i] = wrapGooseStatement(stmt)
+	}
+	return strings.Join(wrapped, "\n\n")
+}
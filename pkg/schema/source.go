@@ -0,0 +1,311 @@
+// Package schema parses Prisma schemas and goose migrations, compares them and generates the SQL of
+// the migrations between them. It is the implementation behind every schema-manager command:
+//
+//	target, err := (&schema.PrismaFileSource{Path: "schema.prisma"}).LoadSchema(ctx)
+//	current, err := (&schema.MigrationsFolderSource{Dir: "migrations"}).LoadSchema(ctx)
+//	diff := schema.DiffSchemas(current, target)
+//	up, down := schema.GenerateMigrationSQL(diff), schema.GenerateDownMigrationSQL(diff)
+package schema
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Model struct {
+	Name             string
+	TableName        string
+	Fields           []*Field
+	Attributes       []*ModelAttribute
+	RowLevelSecurity bool
+	Grants           []*Grant
+	Indexes          []*Index      // Indexes created by migrations, restored when down migrations re-add columns
+	ForeignKeys      []*ForeignKey // Foreign key constraints created by migrations
+	Partitioning     *Partitioning // Time-range partitioning declared with @@partition
+	Line             int           // Position in schema.prisma, 0 when not parsed from a file
+	EndLine          int           // Line of the closing brace
+	Comment          string        // /// doc comment, kept in the database with COMMENT ON TABLE
+}
+
+// Grant is a set of table privileges given to a role with @@grant
+type Grant struct {
+	Role       string
+	Privileges []string
+}
+
+// Index is a CREATE INDEX statement of a migration
+type Index struct {
+	Name       string
+	Columns    []string
+	Unique     bool
+	Definition string
+}
+
+// ForeignKey is a FOREIGN KEY table constraint of a migration
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+}
+
+type Enum struct {
+	Name   string
+	Values []string
+	Line   int
+}
+
+// CompositeType is a Prisma composite type block, stored as a JSONB document or as a PostgreSQL
+// composite type depending on the compositeTypes generator option
+type CompositeType struct {
+	Name   string
+	Fields []*Field
+	Line   int
+}
+
+type Extension struct {
+	Name string
+}
+
+// Trigger is a managed CREATE TRIGGER declared with a trigger block
+type Trigger struct {
+	Name       string
+	TableName  string
+	Model      string
+	Timing     string
+	Events     string
+	ForEach    string
+	When       string
+	Function   string
+	File       string
+	Definition string
+}
+
+// Sequence is a named sequence declared with a sequence block, used by defaults such as
+// dbgenerated("nextval('invoice_seq')")
+type Sequence struct {
+	Name        string
+	Start       int64
+	Increment   int64
+	OwnedBy     string // Model.field or table.column as declared
+	OwnerTable  string
+	OwnerColumn string
+}
+
+type Field struct {
+	Name          string
+	ColumnName    string
+	Type          string
+	Attributes    []*FieldAttribute
+	IsOptional    bool
+	IsArray       bool
+	IsRelation    bool   // The type is a model: the field navigates a relation and has no column
+	RelationTable string // Table of the related model of a relation field, which its foreign key references
+	SearchVector  *SearchVector
+	Comment       string // /// doc comment, kept in the database with COMMENT ON COLUMN
+	Line          int
+}
+
+// SearchVector describes a generated tsvector column declared with @fulltext
+type SearchVector struct {
+	Language string
+	Columns  []string
+}
+
+type FieldAttribute struct {
+	Name string
+	Args []string
+}
+
+type ModelAttribute struct {
+	Name string
+	Args []string
+	Line int
+}
+
+// Policy is a row-level security policy declared with a policy block
+type Policy struct {
+	Name       string
+	TableName  string
+	Model      string
+	Command    string
+	Roles      string
+	Using      string
+	WithCheck  string
+	Definition string
+}
+
+// Function is a managed function or stored procedure loaded from sql/functions/*.sql
+type Function struct {
+	Name       string
+	Kind       string // FUNCTION or PROCEDURE
+	Definition string
+	Hash       string
+}
+
+// View is a database view declared with a view block; its query lives in sql/views/<name>.sql
+type View struct {
+	Name       string
+	ViewName   string
+	Fields     []*Field
+	Query      string
+	Definition string
+	Hash       string
+}
+
+// Relation modes selectable with relationMode in the datasource block
+const (
+	RelationModeForeignKeys = "foreignKeys"
+	RelationModePrisma      = "prisma"
+)
+
+// GeneratorConfig holds the options of the schema-manager generator block in schema.prisma
+type GeneratorConfig struct {
+	Naming string // Naming strategy for unmapped models and fields, e.g. "snake_case"
+	// Table naming rules used to guess referenced tables and to name introspected models
+	DisablePluralization bool
+	Irregulars           []*IrregularWord
+	// Templates for generated index names with {table} and {columns} placeholders
+	IndexNameTemplate       string
+	UniqueIndexNameTemplate string
+	// Template for foreign key constraint names with {table}, {columns} and {refTable} placeholders
+	ForeignKeyNameTemplate string
+	ForwardOnly            bool // Down sections raise an error instead of reverting the migration
+	// ALTER TABLE actions run inside DO blocks that check the column or constraint is (still) there,
+	// for environments that were partially patched by hand
+	GuardedDDL bool
+	// CREATE and ADD COLUMN statements get IF NOT EXISTS and DROP statements IF EXISTS, so migrations
+	// can run again against snapshots that already have their changes
+	Idempotent bool
+	// Column type of DateTime fields without @db.Timestamp or @db.Timestamptz: "timestamptz" unless
+	// set to "timestamp"
+	DateTimeType string
+	StringType   string // Column type of String fields without a @db attribute: "text" (default) or "varchar(n)"
+	FloatType    string // Column type of Float fields without a @db attribute: "double precision" (default) or "real"
+	// Storage of fields of composite types: "jsonb" (default) or "native" for CREATE TYPE ... AS (...)
+	CompositeTypes string
+	CastRules      []*CastRule // Type conversions that extend or override the built-in cast matrix
+	// Backup taken of the tables a migration drops data from before it is applied: "pg_dump", "csv"
+	// or "" for none, written to BackupDir ("backups" by default)
+	Backup    string
+	BackupDir string
+	// Add createdAt and updatedAt columns, and a trigger maintaining updatedAt, to models without @@noAudit
+	AuditColumns bool
+	// Rows per committed batch when backfilling new NOT NULL values; 0 keeps single-statement changes
+	BackfillBatchSize int
+	// Estimated row count from which building an index without CONCURRENTLY is warned about; 0 uses
+	// 1,000,000
+	LargeTableRows int64
+	// Limits of applying migrations: server-side timeouts per statement and for lock waits, a deadline
+	// for the whole run, and how often a migration is retried after a transient error. 0 means no limit.
+	StatementTimeout time.Duration
+	LockTimeout      time.Duration
+	ApplyTimeout     time.Duration
+	ApplyRetries     int
+	// "prisma" when relationMode = "prisma" is set in the datasource block: relations get indexes on
+	// their scalar fields instead of foreign key constraints
+	RelationMode string
+	// Directory of the migrations, relative to schema.prisma; "migrations" unless set with output
+	Output string
+	// Schema files merged into this one, e.g. a base schema shared by several services
+	Include []string
+	// Severities of built-in schema rules and rule expressions checked by validate and check
+	Rules []*RuleSetting
+}
+
+// IrregularWord is a singular/plural pair the pluralization heuristics get wrong, e.g. person/people
+type IrregularWord struct {
+	Singular string
+	Plural   string
+}
+
+type Schema struct {
+	Datasource Datasource
+	Generator  GeneratorConfig
+	Models     []*Model
+	Enums      []*Enum
+	// Composite types of a Prisma schema, or the native composite types migrations created
+	CompositeTypes []*CompositeType
+	Extensions     []*Extension
+	Triggers       []*Trigger
+	Functions      []*Function
+	Policies       []*Policy
+	Views          []*View
+	Sequences      []*Sequence
+	Partitions     []*Partition // Partitions created by migrations
+}
+
+type SchemaSource interface {
+	LoadSchema(ctx context.Context) (*Schema, error)
+	SourceName() string
+}
+
+type PrismaFileSource struct {
+	Path string
+}
+
+func (p *PrismaFileSource) LoadSchema(ctx context.Context) (*Schema, error) {
+	return ParsePrismaFileToSchema(ctx, p.Path)
+}
+
+func (p *PrismaFileSource) SourceName() string {
+	return "PrismaFileSource: " + p.Path
+}
+
+// DefaultSchemaPath names schemas loaded from memory; their trigger files, views and functions are
+// looked up in the current directory like those of ./schema.prisma
+const DefaultSchemaPath = "schema.prisma"
+
+// StringSource loads a Prisma schema held in memory, such as one embedded with go:embed
+type StringSource struct {
+	Name    string // Path the schema stands for, DefaultSchemaPath when empty
+	Content string
+}
+
+func (s *StringSource) LoadSchema(ctx context.Context) (*Schema, error) {
+	return ParsePrismaToSchema(ctx, s.Content, sourcePath(s.Name))
+}
+
+func (s *StringSource) SourceName() string {
+	return "StringSource: " + sourcePath(s.Name)
+}
+
+// ReaderSource loads a Prisma schema from a reader, which is read to the end
+type ReaderSource struct {
+	Name   string // Path the schema stands for, DefaultSchemaPath when empty
+	Reader io.Reader
+}
+
+func (r *ReaderSource) LoadSchema(ctx context.Context) (*Schema, error) {
+	b, err := io.ReadAll(r.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrismaToSchema(ctx, string(b), sourcePath(r.Name))
+}
+
+func (r *ReaderSource) SourceName() string {
+	return "ReaderSource: " + sourcePath(r.Name)
+}
+
+func sourcePath(name string) string {
+	if name == "" {
+		return DefaultSchemaPath
+	}
+	return name
+}
+
+type MigrationsFolderSource struct {
+	Dir  string
+	Skip []string // Migration file names left out, such as a migration being regenerated
+}
+
+func (m *MigrationsFolderSource) LoadSchema(ctx context.Context) (*Schema, error) {
+	return ParseMigrationsToSchema(ctx, m.Dir, m.Skip...)
+}
+
+func (m *MigrationsFolderSource) SourceName() string {
+	return "MigrationsFolderSource: " + m.Dir
+}
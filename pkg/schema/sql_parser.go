@@ -0,0 +1,1809 @@
+package schema
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SQLStatement represents a parsed SQL statement that can be applied to a schema
+type SQLStatement interface {
+	Apply(schema *Schema) error
+	String() string
+}
+
+// ColumnDefinition represents a column in a table
+type ColumnDefinition struct {
+	Name          string
+	Type          string
+	NotNull       bool
+	Default       string
+	PrimaryKey    bool
+	AutoIncrement bool
+	Unique        bool
+}
+
+// CreateTableStatement represents a CREATE TABLE SQL statement
+type CreateTableStatement struct {
+	TableName   string
+	Columns     []ColumnDefinition
+	PrimaryKey  []string // Columns of a table-level PRIMARY KEY constraint
+	ForeignKeys []*ForeignKey
+	Uniques     []*Index // Column and table UNIQUE constraints
+	// Column of PARTITION BY RANGE, "" for tables that are not partitioned
+	PartitionColumn string
+}
+
+func (c *CreateTableStatement) Apply(schema *Schema) error {
+	model := &Model{
+		Name:        c.TableName,
+		TableName:   c.TableName,
+		Fields:      make([]*Field, 0, len(c.Columns)),
+		ForeignKeys: c.ForeignKeys,
+		Indexes:     c.Uniques,
+	}
+
+	for _, col := range c.Columns {
+		// Columns of a table-level primary key are implicitly NOT NULL
+		if containsString(c.PrimaryKey, col.Name) {
+			col.NotNull = true
+		}
+		model.Fields = append(model.Fields, columnField(col))
+	}
+	if len(c.PrimaryKey) > 0 {
+		model.Attributes = append(model.Attributes, parseModelAttribute("@@id(["+strings.Join(c.PrimaryKey, ", ")+"])"))
+	}
+	if c.PartitionColumn != "" {
+		model.Partitioning = &Partitioning{Field: c.PartitionColumn, Column: c.PartitionColumn}
+	}
+
+	schema.Models = append(schema.Models, model)
+	return nil
+}
+
+// columnField converts a parsed column into a field, keeping its primary key and default as
+// attributes so the table can be recreated from the migration schema
+func columnField(col ColumnDefinition) *Field {
+	field := &Field{
+		Name:       col.Name,
+		ColumnName: col.Name,
+		Type:       col.Type,
+		IsOptional: !col.NotNull && !col.PrimaryKey,
+	}
+	if col.PrimaryKey {
+		field.Attributes = append(field.Attributes, &FieldAttribute{Name: "id"})
+	}
+	if col.Default != "" {
+		field.Attributes = append(field.Attributes, &FieldAttribute{
+			Name: "default",
+			Args: []string{"dbgenerated(" + strconv.Quote(col.Default) + ")"},
+		})
+	}
+	return field
+}
+
+func (c *CreateTableStatement) String() string {
+	return "CREATE TABLE " + c.TableName
+}
+
+// AlterTableStatement represents various ALTER TABLE operations
+type AlterTableStatement struct {
+	TableName string
+	Operation AlterOperation
+}
+
+type AlterOperation interface {
+	Apply(model *Model) error
+	String() string
+}
+
+// AddColumnOperation represents ALTER TABLE ADD COLUMN
+type AddColumnOperation struct {
+	Column ColumnDefinition
+}
+
+func (a *AddColumnOperation) Apply(model *Model) error {
+	model.Fields = append(model.Fields, columnField(a.Column))
+	return nil
+}
+
+func (a *AddColumnOperation) String() string {
+	return "ADD COLUMN " + a.Column.Name
+}
+
+// DropColumnOperation represents ALTER TABLE DROP COLUMN
+type DropColumnOperation struct {
+	ColumnName string
+}
+
+func (d *DropColumnOperation) Apply(model *Model) error {
+	newFields := make([]*Field, 0, len(model.Fields))
+	for _, field := range model.Fields {
+		if field.ColumnName != d.ColumnName {
+			newFields = append(newFields, field)
+		}
+	}
+	model.Fields = newFields
+
+	// PostgreSQL drops the indexes of a dropped column along with it
+	newIndexes := make([]*Index, 0, len(model.Indexes))
+	for _, idx := range model.Indexes {
+		if !containsString(idx.Columns, d.ColumnName) {
+			newIndexes = append(newIndexes, idx)
+		}
+	}
+	model.Indexes = newIndexes
+	return nil
+}
+
+func (d *DropColumnOperation) String() string {
+	return "DROP COLUMN " + d.ColumnName
+}
+
+// DropConstraintOperation represents ALTER TABLE DROP CONSTRAINT of a foreign key, the primary key or
+// a UNIQUE constraint, whose index is dropped along with it
+type DropConstraintOperation struct {
+	Name string
+}
+
+func (d *DropConstraintOperation) Apply(model *Model) error {
+	newIndexes := make([]*Index, 0, len(model.Indexes))
+	for _, idx := range model.Indexes {
+		if idx.Name != d.Name {
+			newIndexes = append(newIndexes, idx)
+		}
+	}
+	model.Indexes = newIndexes
+
+	newForeignKeys := make([]*ForeignKey, 0, len(model.ForeignKeys))
+	for _, fk := range model.ForeignKeys {
+		if fk.Name != d.Name {
+			newForeignKeys = append(newForeignKeys, fk)
+		}
+	}
+	model.ForeignKeys = newForeignKeys
+
+	if d.Name == primaryKeyName(model.TableName) {
+		for _, field := range model.Fields {
+			fieldAttrs := make([]*FieldAttribute, 0, len(field.Attributes))
+			for _, attr := range field.Attributes {
+				if attr.Name != "id" {
+					fieldAttrs = append(fieldAttrs, attr)
+				}
+			}
+			field.Attributes = fieldAttrs
+		}
+		attrs := make([]*ModelAttribute, 0, len(model.Attributes))
+		for _, attr := range model.Attributes {
+			if attr.Name != "id" {
+				attrs = append(attrs, attr)
+			}
+		}
+		model.Attributes = attrs
+	}
+	return nil
+}
+
+func (d *DropConstraintOperation) String() string {
+	return "DROP CONSTRAINT " + d.Name
+}
+
+// RenameColumnOperation represents ALTER TABLE RENAME COLUMN
+type RenameColumnOperation struct {
+	ColumnName string
+	NewName    string
+}
+
+func (r *RenameColumnOperation) Apply(model *Model) error {
+	for _, field := range model.Fields {
+		if field.ColumnName == r.ColumnName {
+			if field.Name == field.ColumnName {
+				field.Name = r.NewName
+			}
+			field.ColumnName = r.NewName
+		}
+	}
+	// Indexes and foreign keys follow the renamed column
+	for _, idx := range model.Indexes {
+		for i, col := range idx.Columns {
+			if col == r.ColumnName {
+				idx.Columns[i] = r.NewName
+				idx.Definition = renameIndexDefinition(idx.Definition, r.ColumnName, r.NewName, true)
+			}
+		}
+	}
+	for _, fk := range model.ForeignKeys {
+		for i, col := range fk.Columns {
+			if col == r.ColumnName {
+				fk.Columns[i] = r.NewName
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RenameColumnOperation) String() string {
+	return "RENAME COLUMN " + r.ColumnName + " TO " + r.NewName
+}
+
+// AlterColumnTypeOperation represents ALTER TABLE ALTER COLUMN TYPE
+type AlterColumnTypeOperation struct {
+	ColumnName string
+	NewType    string
+}
+
+func (a *AlterColumnTypeOperation) Apply(model *Model) error {
+	for _, field := range model.Fields {
+		if field.ColumnName == a.ColumnName {
+			field.Type = a.NewType
+			break
+		}
+	}
+	return nil
+}
+
+func (a *AlterColumnTypeOperation) String() string {
+	return "ALTER COLUMN " + a.ColumnName + " TYPE " + a.NewType
+}
+
+// AlterColumnOperation represents ALTER TABLE ALTER COLUMN SET/DROP DEFAULT and SET/DROP NOT NULL
+type AlterColumnOperation struct {
+	ColumnName string
+	Action     string // SET DEFAULT, DROP DEFAULT, SET NOT NULL or DROP NOT NULL
+	Default    string
+}
+
+func (a *AlterColumnOperation) Apply(model *Model) error {
+	for _, field := range model.Fields {
+		if field.ColumnName != a.ColumnName {
+			continue
+		}
+		switch a.Action {
+		case "SET NOT NULL":
+			field.IsOptional = false
+		case "DROP NOT NULL":
+			field.IsOptional = true
+		case "SET DEFAULT", "DROP DEFAULT":
+			attrs := make([]*FieldAttribute, 0, len(field.Attributes))
+			for _, attr := range field.Attributes {
+				if attr.Name != "default" {
+					attrs = append(attrs, attr)
+				}
+			}
+			if a.Action == "SET DEFAULT" {
+				attrs = append(attrs, &FieldAttribute{
+					Name: "default",
+					Args: []string{"dbgenerated(" + strconv.Quote(a.Default) + ")"},
+				})
+			}
+			field.Attributes = attrs
+		}
+		break
+	}
+	return nil
+}
+
+func (a *AlterColumnOperation) String() string {
+	return "ALTER COLUMN " + a.ColumnName + " " + a.Action
+}
+
+// AddConstraintOperation represents ALTER TABLE ADD [CONSTRAINT name] PRIMARY KEY, UNIQUE or FOREIGN
+// KEY, the way pg_dump writes table constraints
+type AddConstraintOperation struct {
+	Definition string // The constraint clause after ADD
+	PrimaryKey []string
+	Unique     *Index
+	ForeignKey *ForeignKey
+}
+
+func (a *AddConstraintOperation) Apply(model *Model) error {
+	switch {
+	case len(a.PrimaryKey) == 1:
+		for _, field := range model.Fields {
+			if field.ColumnName == a.PrimaryKey[0] && findFieldAttribute(field, "id") == nil {
+				field.Attributes = append(field.Attributes, &FieldAttribute{Name: "id"})
+				field.IsOptional = false
+			}
+		}
+	case len(a.PrimaryKey) > 1:
+		model.Attributes = append(model.Attributes, parseModelAttribute("@@id(["+strings.Join(a.PrimaryKey, ", ")+"])"))
+	case a.Unique != nil:
+		// Down migrations restore the constraint along with its columns
+		a.Unique.Definition = "ALTER TABLE " + quoteIdent(model.TableName) + " ADD " + a.Definition + ";"
+		model.Indexes = append(model.Indexes, a.Unique)
+	case a.ForeignKey != nil:
+		model.ForeignKeys = append(model.ForeignKeys, a.ForeignKey)
+	}
+	return nil
+}
+
+func (a *AddConstraintOperation) String() string {
+	return "ADD CONSTRAINT"
+}
+
+// RowLevelSecurityOperation represents ALTER TABLE ENABLE/DISABLE ROW LEVEL SECURITY
+type RowLevelSecurityOperation struct {
+	Enable bool
+}
+
+func (r *RowLevelSecurityOperation) Apply(model *Model) error {
+	model.RowLevelSecurity = r.Enable
+	return nil
+}
+
+func (r *RowLevelSecurityOperation) String() string {
+	if r.Enable {
+		return "ENABLE ROW LEVEL SECURITY"
+	}
+	return "DISABLE ROW LEVEL SECURITY"
+}
+
+// AlterOperations are the actions of an ALTER TABLE statement with several, applied in order
+type AlterOperations []AlterOperation
+
+func (ops AlterOperations) Apply(model *Model) error {
+	for _, op := range ops {
+		if err := op.Apply(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ops AlterOperations) String() string {
+	actions := make([]string, len(ops))
+	for i, op := range ops {
+		actions[i] = op.String()
+	}
+	return strings.Join(actions, ", ")
+}
+
+func (a *AlterTableStatement) Apply(schema *Schema) error {
+	// Find the model to alter
+	for _, model := range schema.Models {
+		if model.TableName == a.TableName {
+			if err := a.Operation.Apply(model); err != nil {
+				return err
+			}
+			// Foreign keys of other tables follow a column they reference
+			if rename, ok := a.Operation.(*RenameColumnOperation); ok {
+				renameReferencedColumn(schema, a.TableName, rename.ColumnName, rename.NewName)
+			}
+			return nil
+		}
+	}
+	return nil // Table not found - could be an error but we'll be permissive
+}
+
+func (a *AlterTableStatement) String() string {
+	return "ALTER TABLE " + a.TableName + " " + a.Operation.String()
+}
+
+// CreateIndexStatement represents a CREATE [UNIQUE] INDEX SQL statement
+type CreateIndexStatement struct {
+	TableName string
+	Index     *Index
+}
+
+func (c *CreateIndexStatement) Apply(schema *Schema) error {
+	for _, model := range schema.Models {
+		if model.TableName == c.TableName {
+			model.Indexes = append(model.Indexes, c.Index)
+			return nil
+		}
+	}
+	return nil // Table not found - be permissive like ALTER TABLE
+}
+
+func (c *CreateIndexStatement) String() string {
+	return "CREATE INDEX " + c.Index.Name + " ON " + c.TableName
+}
+
+// DropIndexStatement represents a DROP INDEX SQL statement
+type DropIndexStatement struct {
+	Name string
+}
+
+func (d *DropIndexStatement) Apply(schema *Schema) error {
+	for _, model := range schema.Models {
+		newIndexes := make([]*Index, 0, len(model.Indexes))
+		for _, idx := range model.Indexes {
+			if idx.Name != d.Name {
+				newIndexes = append(newIndexes, idx)
+			}
+		}
+		model.Indexes = newIndexes
+	}
+	return nil
+}
+
+func (d *DropIndexStatement) String() string {
+	return "DROP INDEX " + d.Name
+}
+
+// CreateExtensionStatement represents a CREATE EXTENSION SQL statement
+type CreateExtensionStatement struct {
+	Name string
+}
+
+func (c *CreateExtensionStatement) Apply(schema *Schema) error {
+	addExtensions(schema, []*Extension{{Name: c.Name}})
+	return nil
+}
+
+func (c *CreateExtensionStatement) String() string {
+	return "CREATE EXTENSION " + c.Name
+}
+
+// DropExtensionStatement represents a DROP EXTENSION SQL statement
+type DropExtensionStatement struct {
+	Name string
+}
+
+func (d *DropExtensionStatement) Apply(schema *Schema) error {
+	newExtensions := make([]*Extension, 0, len(schema.Extensions))
+	for _, ext := range schema.Extensions {
+		if ext.Name != d.Name {
+			newExtensions = append(newExtensions, ext)
+		}
+	}
+	schema.Extensions = newExtensions
+	return nil
+}
+
+func (d *DropExtensionStatement) String() string {
+	return "DROP EXTENSION " + d.Name
+}
+
+// CreateEnumStatement represents a CREATE TYPE ... AS ENUM SQL statement
+type CreateEnumStatement struct {
+	Enum *Enum
+}
+
+func (c *CreateEnumStatement) Apply(schema *Schema) error {
+	if findEnum(schema.Enums, c.Enum.Name) == nil {
+		schema.Enums = append(schema.Enums, c.Enum)
+	}
+	return nil
+}
+
+func (c *CreateEnumStatement) String() string {
+	return "CREATE TYPE " + c.Enum.Name
+}
+
+// DropTypeStatement represents a DROP TYPE SQL statement
+type DropTypeStatement struct {
+	Names []string
+}
+
+func (d *DropTypeStatement) Apply(schema *Schema) error {
+	dropped := func(name string) bool {
+		for _, n := range d.Names {
+			if strings.EqualFold(n, name) {
+				return true
+			}
+		}
+		return false
+	}
+	newEnums := make([]*Enum, 0, len(schema.Enums))
+	for _, e := range schema.Enums {
+		if !dropped(e.Name) {
+			newEnums = append(newEnums, e)
+		}
+	}
+	schema.Enums = newEnums
+	newTypes := make([]*CompositeType, 0, len(schema.CompositeTypes))
+	for _, ct := range schema.CompositeTypes {
+		if !dropped(ct.Name) {
+			newTypes = append(newTypes, ct)
+		}
+	}
+	schema.CompositeTypes = newTypes
+	return nil
+}
+
+func (d *DropTypeStatement) String() string {
+	return "DROP TYPE " + strings.Join(d.Names, ", ")
+}
+
+// DropTableStatement represents a DROP TABLE SQL statement
+type DropTableStatement struct {
+	Names []string
+}
+
+func (d *DropTableStatement) Apply(schema *Schema) error {
+	newModels := make([]*Model, 0, len(schema.Models))
+	for _, m := range schema.Models {
+		dropped := false
+		for _, name := range d.Names {
+			dropped = dropped || strings.EqualFold(name, m.TableName)
+		}
+		if !dropped {
+			newModels = append(newModels, m)
+		}
+	}
+	schema.Models = newModels
+	for _, name := range d.Names {
+		schema.Partitions = removePartition(schema.Partitions, name)
+	}
+	return nil
+}
+
+func (d *DropTableStatement) String() string {
+	return "DROP TABLE " + strings.Join(d.Names, ", ")
+}
+
+// CreateSequenceStatement represents a CREATE SEQUENCE SQL statement
+type CreateSequenceStatement struct {
+	Sequence *Sequence
+}
+
+func (c *CreateSequenceStatement) Apply(schema *Schema) error {
+	if findSequence(schema.Sequences, c.Sequence.Name) == nil {
+		schema.Sequences = append(schema.Sequences, c.Sequence)
+	}
+	return nil
+}
+
+func (c *CreateSequenceStatement) String() string {
+	return "CREATE SEQUENCE " + c.Sequence.Name
+}
+
+// AlterSequenceStatement represents an ALTER SEQUENCE SQL statement
+type AlterSequenceStatement struct {
+	Name    string
+	Options string
+}
+
+func (a *AlterSequenceStatement) Apply(schema *Schema) error {
+	if seq := findSequence(schema.Sequences, a.Name); seq != nil {
+		applySequenceOptions(seq, a.Options)
+	}
+	return nil
+}
+
+func (a *AlterSequenceStatement) String() string {
+	return "ALTER SEQUENCE " + a.Name
+}
+
+// DropSequenceStatement represents a DROP SEQUENCE SQL statement
+type DropSequenceStatement struct {
+	Names []string
+}
+
+func (d *DropSequenceStatement) Apply(schema *Schema) error {
+	newSequences := make([]*Sequence, 0, len(schema.Sequences))
+	for _, seq := range schema.Sequences {
+		dropped := false
+		for _, name := range d.Names {
+			dropped = dropped || strings.EqualFold(name, seq.Name)
+		}
+		if !dropped {
+			newSequences = append(newSequences, seq)
+		}
+	}
+	schema.Sequences = newSequences
+	return nil
+}
+
+func (d *DropSequenceStatement) String() string {
+	return "DROP SEQUENCE " + strings.Join(d.Names, ", ")
+}
+
+// CreateTriggerStatement represents a CREATE TRIGGER SQL statement
+type CreateTriggerStatement struct {
+	Trigger *Trigger
+}
+
+func (c *CreateTriggerStatement) Apply(schema *Schema) error {
+	key := triggerKey(c.Trigger)
+	for i, t := range schema.Triggers {
+		if triggerKey(t) == key {
+			schema.Triggers[i] = c.Trigger
+			return nil
+		}
+	}
+	schema.Triggers = append(schema.Triggers, c.Trigger)
+	return nil
+}
+
+func (c *CreateTriggerStatement) String() string {
+	return "CREATE TRIGGER " + c.Trigger.Name
+}
+
+// DropTriggerStatement represents a DROP TRIGGER SQL statement
+type DropTriggerStatement struct {
+	Name      string
+	TableName string
+}
+
+func (d *DropTriggerStatement) Apply(schema *Schema) error {
+	key := triggerKey(&Trigger{Name: d.Name, TableName: d.TableName})
+	newTriggers := make([]*Trigger, 0, len(schema.Triggers))
+	for _, t := range schema.Triggers {
+		if triggerKey(t) != key {
+			newTriggers = append(newTriggers, t)
+		}
+	}
+	schema.Triggers = newTriggers
+	return nil
+}
+
+func (d *DropTriggerStatement) String() string {
+	return "DROP TRIGGER " + d.Name
+}
+
+// GrantStatement represents a GRANT or REVOKE of table privileges
+type GrantStatement struct {
+	TableName  string
+	Role       string
+	Privileges []string
+	Revoke     bool
+}
+
+func (g *GrantStatement) Apply(schema *Schema) error {
+	for _, model := range schema.Models {
+		if model.TableName != g.TableName {
+			continue
+		}
+		grant := findGrant(model, g.Role, !g.Revoke)
+		if grant == nil {
+			return nil
+		}
+		if !g.Revoke {
+			for _, privilege := range g.Privileges {
+				grant.Privileges = addPrivilege(grant.Privileges, privilege)
+			}
+			return nil
+		}
+		remaining := []string{}
+		for _, privilege := range grant.Privileges {
+			if !containsPrivilege(g.Privileges, privilege) && !containsPrivilege(g.Privileges, "ALL") {
+				remaining = append(remaining, privilege)
+			}
+		}
+		grant.Privileges = remaining
+		return nil
+	}
+	return nil // Table not found - be permissive like ALTER TABLE
+}
+
+func (g *GrantStatement) String() string {
+	if g.Revoke {
+		return "REVOKE ON " + g.TableName + " FROM " + g.Role
+	}
+	return "GRANT ON " + g.TableName + " TO " + g.Role
+}
+
+// CreatePolicyStatement represents a CREATE POLICY SQL statement
+type CreatePolicyStatement struct {
+	Policy *Policy
+}
+
+func (c *CreatePolicyStatement) Apply(schema *Schema) error {
+	key := policyKey(c.Policy)
+	for i, p := range schema.Policies {
+		if policyKey(p) == key {
+			schema.Policies[i] = c.Policy
+			return nil
+		}
+	}
+	schema.Policies = append(schema.Policies, c.Policy)
+	return nil
+}
+
+func (c *CreatePolicyStatement) String() string {
+	return "CREATE POLICY " + c.Policy.Name
+}
+
+// DropPolicyStatement represents a DROP POLICY SQL statement
+type DropPolicyStatement struct {
+	Name      string
+	TableName string
+}
+
+func (d *DropPolicyStatement) Apply(schema *Schema) error {
+	key := policyKey(&Policy{Name: d.Name, TableName: d.TableName})
+	newPolicies := make([]*Policy, 0, len(schema.Policies))
+	for _, p := range schema.Policies {
+		if policyKey(p) != key {
+			newPolicies = append(newPolicies, p)
+		}
+	}
+	schema.Policies = newPolicies
+	return nil
+}
+
+func (d *DropPolicyStatement) String() string {
+	return "DROP POLICY " + d.Name
+}
+
+// CreateFunctionStatement represents a CREATE FUNCTION or CREATE PROCEDURE SQL statement
+type CreateFunctionStatement struct {
+	Function *Function
+}
+
+func (c *CreateFunctionStatement) Apply(schema *Schema) error {
+	for i, fn := range schema.Functions {
+		if fn.Name == c.Function.Name {
+			schema.Functions[i] = c.Function
+			return nil
+		}
+	}
+	schema.Functions = append(schema.Functions, c.Function)
+	return nil
+}
+
+func (c *CreateFunctionStatement) String() string {
+	return "CREATE " + c.Function.Kind + " " + c.Function.Name
+}
+
+// DropFunctionStatement represents a DROP FUNCTION or DROP PROCEDURE SQL statement
+type DropFunctionStatement struct {
+	Name string
+}
+
+func (d *DropFunctionStatement) Apply(schema *Schema) error {
+	newFunctions := make([]*Function, 0, len(schema.Functions))
+	for _, fn := range schema.Functions {
+		if fn.Name != d.Name {
+			newFunctions = append(newFunctions, fn)
+		}
+	}
+	schema.Functions = newFunctions
+	return nil
+}
+
+func (d *DropFunctionStatement) String() string {
+	return "DROP FUNCTION " + d.Name
+}
+
+// CreateViewStatement represents a CREATE [OR REPLACE] VIEW SQL statement
+type CreateViewStatement struct {
+	View *View
+}
+
+func (c *CreateViewStatement) Apply(schema *Schema) error {
+	for i, v := range schema.Views {
+		if v.ViewName == c.View.ViewName {
+			schema.Views[i] = c.View
+			return nil
+		}
+	}
+	schema.Views = append(schema.Views, c.View)
+	return nil
+}
+
+func (c *CreateViewStatement) String() string {
+	return "CREATE VIEW " + c.View.ViewName
+}
+
+// DropViewStatement represents a DROP VIEW SQL statement
+type DropViewStatement struct {
+	Name string
+}
+
+func (d *DropViewStatement) Apply(schema *Schema) error {
+	newViews := make([]*View, 0, len(schema.Views))
+	for _, v := range schema.Views {
+		if v.ViewName != d.Name {
+			newViews = append(newViews, v)
+		}
+	}
+	schema.Views = newViews
+	return nil
+}
+
+func (d *DropViewStatement) String() string {
+	return "DROP VIEW " + d.Name
+}
+
+// MinifySQL takes raw SQL content and returns clean, normalized statements
+func MinifySQL(sql string) []string {
+	var result []string
+	for _, stmt := range SplitSQLStatements(sql) {
+		// Normalize whitespace
+		result = append(result, normalizeWhitespace(stmt))
+	}
+
+	return result
+}
+
+// SplitSQLStatements removes comments and splits SQL content into statements, keeping their
+// original formatting. Semicolons inside quotes and dollar-quoted bodies ($$ ... $$) don't split.
+func SplitSQLStatements(sql string) []string {
+	// Remove SQL comments
+	sql = removeComments(sql)
+
+	var statements []string
+	var current strings.Builder
+	var quoteChar byte
+	dollarTag := ""
+
+	for i := 0; i < len(sql); i++ {
+		char := sql[i]
+
+		switch {
+		case dollarTag != "":
+			if strings.HasPrefix(sql[i:], dollarTag) {
+				current.WriteString(dollarTag)
+				i += len(dollarTag) - 1
+				dollarTag = ""
+				continue
+			}
+		case quoteChar != 0:
+			if char == quoteChar {
+				quoteChar = 0
+			}
+		case char == '\'' || char == '"':
+			quoteChar = char
+		case char == '$':
+			if tag := dollarQuoteRegex.FindString(sql[i:]); tag != "" {
+				dollarTag = tag
+				current.WriteString(tag)
+				i += len(tag) - 1
+				continue
+			}
+		case char == ';':
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+			continue
+		}
+
+		current.WriteByte(char)
+	}
+
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+
+	return statements
+}
+
+var publicSchemaRegex = regexp.MustCompile(`(?i)\bpublic\.|"public"\.`)
+
+var dollarQuoteRegex = regexp.MustCompile(`^\$[a-zA-Z_]*\$`)
+
+// removeComments removes both -- and /* */ style comments from SQL
+func removeComments(sql string) string {
+	// Remove -- comments (single line)
+	lines := strings.Split(sql, "\n")
+	var cleanLines []string
+
+	for _, line := range lines {
+		// Find -- comment start (but not inside quotes)
+		inQuote := false
+		quoteChar := byte(0)
+		commentPos := -1
+
+		for i := 0; i < len(line); i++ {
+			char := line[i]
+
+			if !inQuote && (char == '\'' || char == '"') {
+				inQuote = true
+				quoteChar = char
+			} else if inQuote && char == quoteChar {
+				inQuote = false
+			} else if !inQuote && i < len(line)-1 && line[i:i+2] == "--" {
+				commentPos = i
+				break
+			}
+		}
+
+		if commentPos >= 0 {
+			line = line[:commentPos]
+		}
+
+		cleanLines = append(cleanLines, line)
+	}
+
+	sql = strings.Join(cleanLines, "\n")
+
+	// Remove /* */ comments (multi-line)
+	blockCommentRegex := regexp.MustCompile(`/\*.*?\*/`)
+	sql = blockCommentRegex.ReplaceAllString(sql, "")
+
+	return sql
+}
+
+// normalizeWhitespace collapses multiple whitespace characters into single spaces
+func normalizeWhitespace(sql string) string {
+	// Replace multiple whitespace (including newlines) with single spaces
+	whitespaceRegex := regexp.MustCompile(`\s+`)
+	return strings.TrimSpace(whitespaceRegex.ReplaceAllString(sql, " "))
+}
+
+// ParseSQLStatement parses a single SQL statement into a SQLStatement interface
+func ParseSQLStatement(sql string) (SQLStatement, error) {
+	stmt, err := parseStatement(sql)
+	// The statement parsers return typed pointers, so a statement they skip arrives as a nil pointer
+	// in a non-nil interface
+	if stmt != nil && reflect.ValueOf(stmt).IsNil() {
+		return nil, err
+	}
+	return stmt, err
+}
+
+func parseStatement(sql string) (SQLStatement, error) {
+	// Definitions that are replayed verbatim keep their original case
+	original := strings.TrimSpace(sql)
+	sql = normalizeWhitespace(upperOutsideQuotes(original))
+	// pg_dump qualifies names with the public schema and alters tables with ONLY
+	original = publicSchemaRegex.ReplaceAllString(original, "")
+	sql = publicSchemaRegex.ReplaceAllString(sql, "")
+	sql = strings.Replace(sql, "ALTER TABLE ONLY ", "ALTER TABLE ", 1)
+
+	if stmt := parsePartitionStatement(sql); stmt != nil {
+		return stmt, nil
+	} else if strings.HasPrefix(sql, "DO ") {
+		return parseDoBlock(original)
+	} else if strings.HasPrefix(sql, "CREATE TABLE") {
+		return parseCreateTable(sql)
+	} else if matches := dropTableRegex.FindStringSubmatch(strings.TrimSuffix(sql, ";")); matches != nil {
+		return &DropTableStatement{Names: parseIdentList(matches[1])}, nil
+	} else if stmt := parseRenameStatement(sql); stmt != nil {
+		return stmt, nil
+	} else if strings.HasPrefix(sql, "ALTER TABLE") {
+		// Unsupported operations such as SET DEFAULT parse to nil, which must not become a typed nil
+		if stmt, err := parseAlterTable(sql); stmt != nil || err != nil {
+			return stmt, err
+		}
+	} else if strings.HasPrefix(sql, "CREATE TRIGGER") || strings.HasPrefix(sql, "CREATE OR REPLACE TRIGGER") {
+		return parseCreateTrigger(original)
+	} else if strings.HasPrefix(sql, "DROP TRIGGER") {
+		return parseDropTrigger(sql)
+	} else if createFunctionRegex.MatchString(sql) {
+		if fn := parseFunctionDefinition(original); fn != nil {
+			return &CreateFunctionStatement{Function: fn}, nil
+		}
+	} else if strings.HasPrefix(sql, "DROP FUNCTION") || strings.HasPrefix(sql, "DROP PROCEDURE") {
+		return parseDropFunction(sql)
+	} else if createViewRegex.MatchString(sql) {
+		return parseCreateView(original)
+	} else if strings.HasPrefix(sql, "DROP VIEW") {
+		return parseDropView(sql)
+	} else if strings.HasPrefix(sql, "GRANT ") || strings.HasPrefix(sql, "REVOKE ") {
+		return parseGrant(sql)
+	} else if strings.HasPrefix(sql, "CREATE POLICY") {
+		return parseCreatePolicy(original)
+	} else if strings.HasPrefix(sql, "DROP POLICY") {
+		return parseDropPolicy(sql)
+	} else if strings.HasPrefix(sql, "CREATE INDEX") || strings.HasPrefix(sql, "CREATE UNIQUE INDEX") {
+		return parseCreateIndex(sql, original)
+	} else if strings.HasPrefix(sql, "DROP INDEX") {
+		return parseDropIndex(sql)
+	} else if strings.HasPrefix(sql, "CREATE TYPE") {
+		if e := parseCreateEnum(sql); e != nil {
+			return &CreateEnumStatement{Enum: e}, nil
+		}
+		if stmt := parseCompositeTypeStatement(sql); stmt != nil {
+			return stmt, nil
+		}
+	} else if strings.HasPrefix(sql, "ALTER TYPE") {
+		if stmt := parseAlterEnumStatement(sql); stmt != nil {
+			return stmt, nil
+		}
+		if stmt := parseCompositeTypeStatement(sql); stmt != nil {
+			return stmt, nil
+		}
+	} else if strings.HasPrefix(sql, "DROP TYPE") {
+		if names := parseDropType(sql); len(names) > 0 {
+			return &DropTypeStatement{Names: names}, nil
+		}
+	} else if matches := createSequenceRegex.FindStringSubmatch(sql); matches != nil {
+		seq := &Sequence{Name: normalizeIdent(matches[1]), Start: 1, Increment: 1}
+		applySequenceOptions(seq, matches[2])
+		return &CreateSequenceStatement{Sequence: seq}, nil
+	} else if matches := alterSequenceRegex.FindStringSubmatch(sql); matches != nil {
+		return &AlterSequenceStatement{Name: normalizeIdent(matches[1]), Options: matches[2]}, nil
+	} else if matches := dropSequenceRegex.FindStringSubmatch(strings.TrimSuffix(sql, ";")); matches != nil {
+		return &DropSequenceStatement{Names: parseIdentList(matches[1])}, nil
+	} else if strings.HasPrefix(sql, "COMMENT ON") {
+		return parseComment(sql)
+	} else if strings.HasPrefix(sql, "CREATE EXTENSION") {
+		return parseCreateExtension(sql)
+	} else if strings.HasPrefix(sql, "DROP EXTENSION") {
+		return parseDropExtension(sql)
+	}
+
+	// Ignore other statements
+	return nil, nil
+}
+
+var (
+	doBlockRegex      = regexp.MustCompile(`(?is)^DO\s+\$[a-zA-Z_]*\$(.*)\$[a-zA-Z_]*\$\s*;?$`)
+	doBlockGuardRegex = regexp.MustCompile(`(?is)^(?:BEGIN\s+)?(?:IF\s+.*?\s+THEN\s+)?`)
+)
+
+// DoBlockStatement represents the DDL statements of a DO block, such as the conditional CREATE TABLE
+// statements of baseline migrations
+type DoBlockStatement struct {
+	Statements []SQLStatement
+}
+
+func (d *DoBlockStatement) Apply(schema *Schema) error {
+	for _, stmt := range d.Statements {
+		if err := stmt.Apply(schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DoBlockStatement) String() string {
+	return "DO block with " + strconv.Itoa(len(d.Statements)) + " statements"
+}
+
+// parseDoBlock parses the statements of a DO block that its BEGIN and IF ... THEN guards run;
+// procedural statements such as loops and RAISE are skipped
+func parseDoBlock(sql string) (*DoBlockStatement, error) {
+	matches := doBlockRegex.FindStringSubmatch(sql)
+	if matches == nil {
+		return nil, nil
+	}
+	var block DoBlockStatement
+	for _, inner := range SplitSQLStatements(matches[1]) {
+		inner = doBlockGuardRegex.ReplaceAllString(inner, "")
+		stmt, err := ParseSQLStatement(inner)
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+	}
+	if len(block.Statements) == 0 {
+		return nil, nil
+	}
+	return &block, nil
+}
+
+// parseCreateTable parses CREATE TABLE statements
+func parseCreateTable(sql string) (*CreateTableStatement, error) {
+	// Extract table name
+	tableNameRegex := regexp.MustCompile(`CREATE TABLE\s+(?:IF NOT EXISTS\s+)?` + identPattern + `\s*\(`)
+	matches := tableNameRegex.FindStringSubmatch(sql)
+	if len(matches) < 2 {
+		return nil, nil // Skip malformed statements
+	}
+
+	tableName := normalizeIdent(matches[1])
+
+	// A trailing PARTITION BY clause is not part of the column definitions
+	partitionColumn := ""
+	if loc := partitionByRegex.FindStringSubmatchIndex(sql); loc != nil {
+		partitionColumn = normalizeIdent(sql[loc[2]:loc[3]])
+		sql = sql[:loc[0]+1]
+	}
+
+	// Extract column definitions - find content between parentheses
+	parenStart := strings.Index(sql, "(")
+	parenEnd := strings.LastIndex(sql, ")")
+	if parenStart == -1 || parenEnd == -1 || parenEnd <= parenStart {
+		return nil, nil
+	}
+
+	columnsStr := sql[parenStart+1 : parenEnd]
+	stmt := &CreateTableStatement{
+		TableName:       tableName,
+		Columns:         parseColumnDefinitions(columnsStr),
+		PartitionColumn: partitionColumn,
+	}
+	for _, part := range smartSplitColumns(columnsStr) {
+		part = strings.TrimSpace(part)
+		if !isConstraint(part) {
+			continue
+		}
+		if matches := primaryKeyConstraintRegex.FindStringSubmatch(part); len(matches) > 1 {
+			stmt.PrimaryKey = parseIdentList(matches[1])
+		} else if fk := parseForeignKeyConstraint(part); fk != nil {
+			stmt.ForeignKeys = append(stmt.ForeignKeys, fk)
+		} else if matches := uniqueConstraintRegex.FindStringSubmatch(part); len(matches) > 2 {
+			name := normalizeIdent(matches[1])
+			columns := parseIdentList(matches[2])
+			if name == "" {
+				name = uniqueConstraintName(tableName, columns)
+			}
+			stmt.Uniques = append(stmt.Uniques, uniqueConstraint(tableName, name, columns))
+		}
+	}
+	for _, col := range stmt.Columns {
+		if col.Unique {
+			name := uniqueConstraintName(tableName, []string{col.Name})
+			stmt.Uniques = append(stmt.Uniques, uniqueConstraint(tableName, name, []string{col.Name}))
+		}
+	}
+
+	return stmt, nil
+}
+
+var (
+	primaryKeyConstraintRegex = regexp.MustCompile(`^(?:CONSTRAINT\s+\S+\s+)?PRIMARY KEY\s*\(([^)]*)\)`)
+	foreignKeyConstraintRegex = regexp.MustCompile(
+		`^(?:CONSTRAINT\s+` + identPattern + `\s+)?FOREIGN KEY\s*\(([^)]*)\)\s*REFERENCES\s+` + identPattern +
+			`\s*\(([^)]*)\)(?:.*ON DELETE\s+(CASCADE|RESTRICT|NO ACTION|SET NULL|SET DEFAULT))?`,
+	)
+	columnNameRegex    = regexp.MustCompile(`^` + identPattern)
+	columnDefaultRegex = regexp.MustCompile(
+		`\sDEFAULT\s+(.+?)(?:\s+(?:NOT NULL|NULL|PRIMARY KEY|UNIQUE|REFERENCES|CHECK|CONSTRAINT|GENERATED)\b.*)?$`,
+	)
+)
+
+// parseForeignKeyConstraint parses a [CONSTRAINT name] FOREIGN KEY (...) REFERENCES table(...) clause
+func parseForeignKeyConstraint(part string) *ForeignKey {
+	matches := foreignKeyConstraintRegex.FindStringSubmatch(part)
+	if len(matches) < 6 {
+		return nil
+	}
+	fk := &ForeignKey{
+		Columns:    parseIdentList(matches[2]),
+		RefTable:   normalizeIdent(matches[3]),
+		RefColumns: parseIdentList(matches[4]),
+		OnDelete:   matches[5],
+	}
+	if matches[1] != "" {
+		fk.Name = normalizeIdent(matches[1])
+	}
+	return fk
+}
+
+// parseIdentList parses a comma-separated list of identifiers such as the columns of a constraint
+func parseIdentList(list string) []string {
+	var idents []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			idents = append(idents, normalizeIdent(item))
+		}
+	}
+	return idents
+}
+
+// parseCreateTrigger parses CREATE TRIGGER statements
+func parseCreateTrigger(sql string) (*CreateTriggerStatement, error) {
+	triggerRegex := regexp.MustCompile(`(?i)CREATE (?:OR REPLACE\s+)?TRIGGER\s+([a-zA-Z0-9_]+)\s+.*?\sON\s+` + identPattern)
+	matches := triggerRegex.FindStringSubmatch(sql)
+	if len(matches) < 3 {
+		return nil, nil
+	}
+
+	return &CreateTriggerStatement{Trigger: &Trigger{
+		Name:       strings.ToLower(matches[1]),
+		TableName:  normalizeIdent(matches[2]),
+		Definition: sql + ";",
+	}}, nil
+}
+
+// parseDropTrigger parses DROP TRIGGER statements
+func parseDropTrigger(sql string) (*DropTriggerStatement, error) {
+	triggerRegex := regexp.MustCompile(`DROP TRIGGER\s+(?:IF EXISTS\s+)?([a-zA-Z0-9_]+)\s+ON\s+` + identPattern)
+	matches := triggerRegex.FindStringSubmatch(sql)
+	if len(matches) < 3 {
+		return nil, nil
+	}
+
+	return &DropTriggerStatement{Name: strings.ToLower(matches[1]), TableName: normalizeIdent(matches[2])}, nil
+}
+
+// parseGrant parses GRANT and REVOKE statements on tables
+func parseGrant(sql string) (*GrantStatement, error) {
+	grantRegex := regexp.MustCompile(
+		`^(GRANT|REVOKE)\s+(.+?)\s+ON\s+(?:TABLE\s+)?` + identPattern + `\s+(?:TO|FROM)\s+"?([a-zA-Z0-9_]+)"?`,
+	)
+	matches := grantRegex.FindStringSubmatch(sql)
+	if len(matches) < 5 {
+		return nil, nil
+	}
+
+	stmt := &GrantStatement{
+		TableName: normalizeIdent(matches[3]),
+		Role:      strings.ToLower(matches[4]),
+		Revoke:    matches[1] == "REVOKE",
+	}
+	for _, privilege := range strings.Split(matches[2], ",") {
+		privilege = strings.TrimSuffix(strings.TrimSpace(privilege), " PRIVILEGES")
+		stmt.Privileges = addPrivilege(stmt.Privileges, privilege)
+	}
+	return stmt, nil
+}
+
+// parseCreatePolicy parses CREATE POLICY statements
+func parseCreatePolicy(sql string) (*CreatePolicyStatement, error) {
+	policyRegex := regexp.MustCompile(`(?i)CREATE POLICY\s+([a-zA-Z0-9_]+)\s+ON\s+` + identPattern)
+	matches := policyRegex.FindStringSubmatch(sql)
+	if len(matches) < 3 {
+		return nil, nil
+	}
+
+	return &CreatePolicyStatement{Policy: &Policy{
+		Name:       strings.ToLower(matches[1]),
+		TableName:  normalizeIdent(matches[2]),
+		Definition: sql + ";",
+	}}, nil
+}
+
+// parseDropPolicy parses DROP POLICY statements
+func parseDropPolicy(sql string) (*DropPolicyStatement, error) {
+	policyRegex := regexp.MustCompile(`DROP POLICY\s+(?:IF EXISTS\s+)?([a-zA-Z0-9_]+)\s+ON\s+` + identPattern)
+	matches := policyRegex.FindStringSubmatch(sql)
+	if len(matches) < 3 {
+		return nil, nil
+	}
+
+	return &DropPolicyStatement{Name: strings.ToLower(matches[1]), TableName: normalizeIdent(matches[2])}, nil
+}
+
+// parseDropFunction parses DROP FUNCTION and DROP PROCEDURE statements
+func parseDropFunction(sql string) (*DropFunctionStatement, error) {
+	functionRegex := regexp.MustCompile(`DROP (?:FUNCTION|PROCEDURE)\s+(?:IF EXISTS\s+)?([a-zA-Z0-9_."]+)`)
+	matches := functionRegex.FindStringSubmatch(sql)
+	if len(matches) < 2 {
+		return nil, nil
+	}
+
+	return &DropFunctionStatement{Name: strings.ToLower(strings.Trim(matches[1], "\""))}, nil
+}
+
+// parseCreateView parses CREATE [OR REPLACE] VIEW statements, keeping the original query text
+func parseCreateView(sql string) (*CreateViewStatement, error) {
+	matches := createViewRegex.FindStringSubmatchIndex(sql)
+	if matches == nil {
+		return nil, nil
+	}
+
+	name := strings.ToLower(sql[matches[2]:matches[3]])
+	view := &View{Name: name, ViewName: name}
+	setViewQuery(view, sql[matches[1]:])
+	return &CreateViewStatement{View: view}, nil
+}
+
+// parseDropView parses DROP VIEW statements
+func parseDropView(sql string) (*DropViewStatement, error) {
+	viewRegex := regexp.MustCompile(`DROP VIEW\s+(?:IF EXISTS\s+)?"?([a-zA-Z0-9_]+)"?`)
+	matches := viewRegex.FindStringSubmatch(sql)
+	if len(matches) < 2 {
+		return nil, nil
+	}
+
+	return &DropViewStatement{Name: strings.ToLower(matches[1])}, nil
+}
+
+// parseCreateIndex parses CREATE [UNIQUE] INDEX statements, keeping the original statement so it
+// can be replayed
+func parseCreateIndex(sql, original string) (*CreateIndexStatement, error) {
+	indexRegex := regexp.MustCompile(`^CREATE (UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF NOT EXISTS\s+)?` +
+		identPattern + `\s+ON\s+(?:ONLY\s+)?` + identPattern + `\s*(?:USING\s+\w+\s*)?\((.*)\)`)
+	matches := indexRegex.FindStringSubmatch(sql)
+	if len(matches) < 5 {
+		return nil, nil
+	}
+
+	idx := &Index{
+		Name:       normalizeIdent(matches[2]),
+		Unique:     matches[1] != "",
+		Definition: original + ";",
+	}
+	for _, column := range smartSplitColumns(matches[4]) {
+		if parts := strings.Fields(column); len(parts) > 0 {
+			idx.Columns = append(idx.Columns, normalizeIdent(parts[0]))
+		}
+	}
+	return &CreateIndexStatement{TableName: normalizeIdent(matches[3]), Index: idx}, nil
+}
+
+// parseDropIndex parses DROP INDEX statements
+func parseDropIndex(sql string) (*DropIndexStatement, error) {
+	indexRegex := regexp.MustCompile(`DROP INDEX\s+(?:CONCURRENTLY\s+)?(?:IF EXISTS\s+)?` + identPattern)
+	matches := indexRegex.FindStringSubmatch(sql)
+	if len(matches) < 2 {
+		return nil, nil
+	}
+
+	return &DropIndexStatement{Name: normalizeIdent(matches[1])}, nil
+}
+
+// parseCreateExtension parses CREATE EXTENSION statements
+func parseCreateExtension(sql string) (*CreateExtensionStatement, error) {
+	extensionRegex := regexp.MustCompile(`CREATE EXTENSION\s+(?:IF NOT EXISTS\s+)?"?([a-zA-Z0-9_-]+)"?`)
+	matches := extensionRegex.FindStringSubmatch(sql)
+	if len(matches) < 2 {
+		return nil, nil
+	}
+
+	return &CreateExtensionStatement{Name: strings.ToLower(matches[1])}, nil
+}
+
+var (
+	createEnumRegex = regexp.MustCompile(`^CREATE TYPE\s+` + identPattern + `\s+AS ENUM\s*\((.*)\)`)
+	enumLabelRegex  = regexp.MustCompile(`'((?:[^']|'')*)'`)
+	dropTypeRegex   = regexp.MustCompile(`^DROP TYPE\s+(?:IF EXISTS\s+)?(.+?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	dropTableRegex  = regexp.MustCompile(`^DROP TABLE\s+(?:IF EXISTS\s+)?(.+?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+)
+
+// parseCreateEnum parses CREATE TYPE ... AS ENUM statements; the labels keep their case since they
+// are quoted
+func parseCreateEnum(sql string) *Enum {
+	matches := createEnumRegex.FindStringSubmatch(sql)
+	if len(matches) < 3 {
+		return nil
+	}
+	e := &Enum{Name: normalizeIdent(matches[1])}
+	for _, label := range enumLabelRegex.FindAllStringSubmatch(matches[2], -1) {
+		e.Values = append(e.Values, strings.ReplaceAll(label[1], "''", "'"))
+	}
+	return e
+}
+
+// parseDropType parses the type names of DROP TYPE statements
+func parseDropType(sql string) []string {
+	matches := dropTypeRegex.FindStringSubmatch(strings.TrimSuffix(sql, ";"))
+	if len(matches) < 2 {
+		return nil
+	}
+	return parseIdentList(matches[1])
+}
+
+var (
+	createSequenceRegex    = regexp.MustCompile(`^CREATE SEQUENCE\s+(?:IF NOT EXISTS\s+)?` + identPattern + `(.*)$`)
+	alterSequenceRegex     = regexp.MustCompile(`^ALTER SEQUENCE\s+(?:IF EXISTS\s+)?` + identPattern + `(.*)$`)
+	dropSequenceRegex      = regexp.MustCompile(`^DROP SEQUENCE\s+(?:IF EXISTS\s+)?(.+?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	sequenceStartRegex     = regexp.MustCompile(`\bSTART\s+(?:WITH\s+)?(-?\d+)`)
+	sequenceIncrementRegex = regexp.MustCompile(`\bINCREMENT\s+(?:BY\s+)?(-?\d+)`)
+	sequenceOwnedByRegex   = regexp.MustCompile(`\bOWNED BY\s+(?:NONE|` + identPattern + `\.` + identPattern + `)`)
+)
+
+// applySequenceOptions applies the START, INCREMENT and OWNED BY options of a CREATE or ALTER
+// SEQUENCE statement; RESTART and other options don't change the declared sequence
+func applySequenceOptions(seq *Sequence, options string) {
+	if matches := sequenceStartRegex.FindStringSubmatch(options); matches != nil {
+		seq.Start, _ = strconv.ParseInt(matches[1], 10, 64)
+	}
+	if matches := sequenceIncrementRegex.FindStringSubmatch(options); matches != nil {
+		seq.Increment, _ = strconv.ParseInt(matches[1], 10, 64)
+	}
+	if matches := sequenceOwnedByRegex.FindStringSubmatch(options); matches != nil {
+		seq.OwnerTable, seq.OwnerColumn = "", ""
+		if matches[1] != "" {
+			seq.OwnerTable, seq.OwnerColumn = normalizeIdent(matches[1]), normalizeIdent(matches[2])
+		}
+	}
+}
+
+// parseDropExtension parses DROP EXTENSION statements
+func parseDropExtension(sql string) (*DropExtensionStatement, error) {
+	extensionRegex := regexp.MustCompile(`DROP EXTENSION\s+(?:IF EXISTS\s+)?"?([a-zA-Z0-9_-]+)"?`)
+	matches := extensionRegex.FindStringSubmatch(sql)
+	if len(matches) < 2 {
+		return nil, nil
+	}
+
+	return &DropExtensionStatement{Name: strings.ToLower(matches[1])}, nil
+}
+
+// parseAlterTable parses ALTER TABLE statements
+func parseAlterTable(sql string) (*AlterTableStatement, error) {
+	// Extract table name
+	tableNameRegex := regexp.MustCompile(`ALTER TABLE\s+` + identPattern + `\s+(.+)`)
+	matches := tableNameRegex.FindStringSubmatch(sql)
+	if len(matches) < 3 {
+		return nil, nil
+	}
+
+	tableName := normalizeIdent(matches[1])
+
+	// A statement may carry several comma-separated actions; unsupported ones are skipped
+	var ops AlterOperations
+	for _, action := range SplitAlterActions(strings.TrimSpace(matches[2])) {
+		if op := parseAlterOperation(action); op != nil {
+			ops = append(ops, op)
+		}
+	}
+
+	var op AlterOperation
+	switch len(ops) {
+	case 0:
+		return nil, nil // Unsupported operation
+	case 1:
+		op = ops[0]
+	default:
+		op = ops
+	}
+
+	return &AlterTableStatement{
+		TableName: tableName,
+		Operation: op,
+	}, nil
+}
+
+// parseAlterOperation parses one action of an ALTER TABLE statement, or returns nil when it is not
+// supported
+func parseAlterOperation(operation string) AlterOperation {
+	// The parse functions return typed pointers, which are only returned when set
+	if strings.HasPrefix(operation, "ADD COLUMN") {
+		if add := parseAddColumn(operation); add != nil {
+			return add
+		}
+	} else if strings.HasPrefix(operation, "DROP COLUMN") {
+		if drop := parseDropColumn(operation); drop != nil {
+			return drop
+		}
+	} else if matches := dropConstraintRegex.FindStringSubmatch(operation); matches != nil {
+		return &DropConstraintOperation{Name: normalizeIdent(matches[1])}
+	} else if matches := renameConstraintRegex.FindStringSubmatch(operation); matches != nil {
+		return &RenameConstraintOperation{Name: normalizeIdent(matches[1]), NewName: normalizeIdent(matches[2])}
+	} else if matches := renameColumnRegex.FindStringSubmatch(operation); matches != nil {
+		return &RenameColumnOperation{ColumnName: normalizeIdent(matches[1]), NewName: normalizeIdent(matches[2])}
+	} else if strings.HasPrefix(operation, "ADD ") {
+		if constraint := parseAddConstraint(operation); constraint != nil {
+			return constraint
+		}
+	} else if alter := parseAlterColumn(operation); alter != nil {
+		return alter
+	} else if strings.HasPrefix(operation, "ALTER COLUMN") && strings.Contains(operation, "TYPE") {
+		if alterType := parseAlterColumnType(operation); alterType != nil {
+			return alterType
+		}
+	} else if strings.HasPrefix(operation, "ENABLE ROW LEVEL SECURITY") {
+		return &RowLevelSecurityOperation{Enable: true}
+	} else if strings.HasPrefix(operation, "DISABLE ROW LEVEL SECURITY") {
+		return &RowLevelSecurityOperation{Enable: false}
+	}
+	return nil
+}
+
+// SplitAlterActions splits the actions of an ALTER TABLE statement at the commas outside parentheses
+// and quotes, e.g. ADD COLUMN a INT, ALTER COLUMN b TYPE DECIMAL(10,2)
+func SplitAlterActions(operation string) []string {
+	var actions []string
+	var current strings.Builder
+	depth := 0
+	var quote rune
+	for _, r := range operation {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			actions = append(actions, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if last := strings.TrimSpace(current.String()); last != "" {
+		actions = append(actions, last)
+	}
+	return actions
+}
+
+// primaryKeyName returns the name PostgreSQL gives the primary key of a table
+func primaryKeyName(table string) string {
+	return limitIdentifier(table + "_pkey")
+}
+
+// uniqueConstraintName returns the name PostgreSQL gives a UNIQUE constraint declared without one
+func uniqueConstraintName(table string, columns []string) string {
+	return limitIdentifier(table + "_" + strings.Join(columns, "_") + "_key")
+}
+
+// uniqueConstraint returns the index of a UNIQUE constraint, with the statement that adds it back
+func uniqueConstraint(table, name string, columns []string) *Index {
+	return &Index{
+		Name:    name,
+		Columns: columns,
+		Unique:  true,
+		Definition: "ALTER TABLE " + quoteIdent(table) + " ADD CONSTRAINT " + name + " UNIQUE (" +
+			strings.Join(quoteIdents(columns), ", ") + ");",
+	}
+}
+
+// parseColumnDefinitions parses the column definitions inside CREATE TABLE
+func parseColumnDefinitions(columnsStr string) []ColumnDefinition {
+	var columns []ColumnDefinition
+
+	// Split by commas, but be careful about commas inside types like DECIMAL(10, 2)
+	parts := smartSplitColumns(columnsStr)
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" || isConstraint(part) {
+			continue // Skip empty parts and constraints
+		}
+
+		col := parseColumnDefinition(part)
+		if col.Name != "" {
+			columns = append(columns, col)
+		}
+	}
+
+	return columns
+}
+
+// smartSplitColumns splits column definitions by comma, handling parentheses properly
+func smartSplitColumns(s string) []string {
+	var parts []string
+	var current strings.Builder
+	parenDepth := 0
+
+	for _, char := range s {
+		if char == '(' {
+			parenDepth++
+		} else if char == ')' {
+			parenDepth--
+		} else if char == ',' && parenDepth == 0 {
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+
+		current.WriteRune(char)
+	}
+
+	// Add the last part
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+// isConstraint checks if a part is a table constraint rather than a column
+func isConstraint(part string) bool {
+	part = strings.ToUpper(strings.TrimSpace(part))
+	return strings.HasPrefix(part, "PRIMARY KEY") ||
+		strings.HasPrefix(part, "UNIQUE") ||
+		strings.HasPrefix(part, "CONSTRAINT") ||
+		strings.HasPrefix(part, "FOREIGN KEY") ||
+		strings.HasPrefix(part, "CHECK")
+}
+
+// parseColumnDefinition parses a single column definition
+func parseColumnDefinition(def string) ColumnDefinition {
+	name := columnNameRegex.FindString(def)
+	parts := strings.Fields(def[len(name):])
+	if name == "" || len(parts) < 1 {
+		return ColumnDefinition{}
+	}
+
+	col := ColumnDefinition{
+		Name: normalizeIdent(name),
+		Type: extractTypeFromParts(parts),
+	}
+
+	// Check for constraints
+	defUpper := strings.ToUpper(def)
+	col.NotNull = strings.Contains(defUpper, "NOT NULL")
+	col.PrimaryKey = strings.Contains(defUpper, "PRIMARY KEY")
+	col.Unique = containsString(strings.Fields(defUpper), "UNIQUE")
+	col.AutoIncrement = strings.Contains(defUpper, "SERIAL") || strings.Contains(defUpper, "AUTO_INCREMENT")
+	if matches := columnDefaultRegex.FindStringSubmatch(def); len(matches) > 1 {
+		col.Default = strings.TrimSpace(matches[1])
+	}
+
+	return col
+}
+
+// extractTypeFromParts extracts the type from column definition parts, handling complex types
+func extractTypeFromParts(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+
+	// For types with parentheses (like DECIMAL(10,2)), find the complete type
+	typeStr := parts[0]
+
+	if strings.Contains(typeStr, "(") && !strings.Contains(typeStr, ")") {
+		// Multi-part type, find the closing parenthesis
+		for i := 1; i < len(parts); i++ {
+			typeStr += " " + parts[i]
+			if strings.Contains(parts[i], ")") {
+				break
+			}
+		}
+	}
+
+	// Clean up and normalize the type
+	typeStr = strings.ToLower(typeStr)
+	typeStr = strings.ReplaceAll(typeStr, " ", "") // Remove spaces within type
+
+	// character varying(n) is spelled varchar(n)
+	if typeStr == "character" && len(parts) > 1 && strings.HasPrefix(strings.ToLower(parts[1]), "varying") {
+		typeStr = "varchar" + strings.TrimPrefix(strings.ToLower(parts[1]), "varying")
+	}
+
+	// double precision would otherwise be cut to double
+	if typeStr == "double" && len(parts) > 1 && strings.EqualFold(parts[1], "precision") {
+		typeStr = "double precision"
+	}
+
+	// timestamp [(p)] with time zone is spelled timestamptz [(p)]
+	if rest := strings.ToLower(strings.Join(parts[1:], " ")); strings.HasPrefix(typeStr, "timestamp") &&
+		strings.Contains(rest, "with time zone") && !strings.Contains(rest, "without time zone") {
+		typeStr = strings.Replace(typeStr, "timestamp", "timestamptz", 1)
+	}
+
+	return typeStr
+}
+
+// parseAddColumn parses ADD COLUMN operations
+func parseAddColumn(operation string) *AddColumnOperation {
+	// Extract column definition after "ADD COLUMN"
+	addColumnRegex := regexp.MustCompile(`ADD COLUMN\s+(?:IF NOT EXISTS\s+)?(.+)`)
+	matches := addColumnRegex.FindStringSubmatch(operation)
+	if len(matches) < 2 {
+		return nil
+	}
+
+	colDef := parseColumnDefinition(matches[1])
+	if colDef.Name == "" {
+		return nil
+	}
+
+	return &AddColumnOperation{Column: colDef}
+}
+
+// parseDropColumn parses DROP COLUMN operations
+func parseDropColumn(operation string) *DropColumnOperation {
+	dropColumnRegex := regexp.MustCompile(`DROP COLUMN\s+(?:IF EXISTS\s+)?` + identPattern)
+	matches := dropColumnRegex.FindStringSubmatch(operation)
+	if len(matches) < 2 {
+		return nil
+	}
+
+	return &DropColumnOperation{ColumnName: normalizeIdent(matches[1])}
+}
+
+var (
+	alterColumnRegex      = regexp.MustCompile(`^ALTER (?:COLUMN\s+)?` + identPattern + `\s+(SET DEFAULT|DROP DEFAULT|SET NOT NULL|DROP NOT NULL)\s*(.*?);?$`)
+	dropConstraintRegex   = regexp.MustCompile(`^DROP CONSTRAINT\s+(?:IF EXISTS\s+)?` + identPattern)
+	renameColumnRegex     = regexp.MustCompile(`^RENAME (?:COLUMN\s+)?` + identPattern + `\s+TO\s+` + identPattern + `\s*;?$`)
+	uniqueConstraintRegex = regexp.MustCompile(`^(?:CONSTRAINT\s+` + identPattern + `\s+)?UNIQUE\s*\(([^)]*)\)`)
+)
+
+// parseAlterColumn parses ALTER COLUMN SET/DROP DEFAULT and SET/DROP NOT NULL operations
+func parseAlterColumn(operation string) *AlterColumnOperation {
+	matches := alterColumnRegex.FindStringSubmatch(operation)
+	if len(matches) < 4 {
+		return nil
+	}
+	return &AlterColumnOperation{
+		ColumnName: normalizeIdent(matches[1]),
+		Action:     matches[2],
+		Default:    strings.TrimSpace(matches[3]),
+	}
+}
+
+// parseAddConstraint parses ADD [CONSTRAINT name] PRIMARY KEY, UNIQUE and FOREIGN KEY operations
+func parseAddConstraint(operation string) *AddConstraintOperation {
+	constraint := strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(operation, "ADD ")), ";")
+	if matches := primaryKeyConstraintRegex.FindStringSubmatch(constraint); len(matches) > 1 {
+		return &AddConstraintOperation{PrimaryKey: parseIdentList(matches[1])}
+	}
+	if matches := uniqueConstraintRegex.FindStringSubmatch(constraint); len(matches) > 2 {
+		idx := &Index{Columns: parseIdentList(matches[2]), Unique: true}
+		if matches[1] != "" {
+			idx.Name = normalizeIdent(matches[1])
+		}
+		return &AddConstraintOperation{Definition: constraint, Unique: idx}
+	}
+	if fk := parseForeignKeyConstraint(constraint); fk != nil {
+		return &AddConstraintOperation{ForeignKey: fk}
+	}
+	return nil
+}
+
+// parseAlterColumnType parses ALTER COLUMN TYPE operations
+func parseAlterColumnType(operation string) *AlterColumnTypeOperation {
+	alterColumnRegex := regexp.MustCompile(`ALTER COLUMN\s+` + identPattern + `\s+TYPE\s+(.+)`)
+	matches := alterColumnRegex.FindStringSubmatch(operation)
+	if len(matches) < 3 {
+		return nil
+	}
+
+	columnName := normalizeIdent(matches[1])
+	// The USING expression converts existing values and isn't part of the type
+	typeDef, _, _ := strings.Cut(strings.TrimSuffix(strings.TrimSpace(matches[2]), ";"), " USING ")
+	newType := extractTypeFromParts(strings.Fields(typeDef))
+
+	return &AlterColumnTypeOperation{
+		ColumnName: columnName,
+		NewType:    newType,
+	}
+}
+
+// ApplyMigrationsFromDir reads and applies all migrations from a directory. Goose Go migrations
+// (*.go) are skipped, since they transform data rather than the schema, as are the files named in skip.
+func ApplyMigrationsFromDir(ctx context.Context, dir string, skip ...string) (*Schema, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrationFiles []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".sql") && !containsString(skip, f.Name()) {
+			migrationFiles = append(migrationFiles, f.Name())
+		}
+	}
+
+	// Sort files to apply in chronological order
+	sort.Strings(migrationFiles)
+
+	schema := &Schema{
+		Models:     make([]*Model, 0),
+		Enums:      make([]*Enum, 0),
+		Extensions: make([]*Extension, 0),
+		Views:      make([]*View, 0),
+	}
+
+	for _, fname := range migrationFiles {
+		if err := applyMigrationFile(schema, dir+"/"+fname); err != nil {
+			return nil, err
+		}
+	}
+
+	return schema, nil
+}
+
+// applyMigrationFile applies a single migration file to the schema
+func applyMigrationFile(schema *Schema, filepath string) error {
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		return err
+	}
+
+	// Only the "UP" section of goose migrations builds the schema
+	up, _ := gooseSections(string(content))
+	return applySQL(schema, up)
+}
+
+// ParseSQLToSchema builds a schema from a DDL script such as a pg_dump --schema-only dump, without a
+// database connection. Statements the parser doesn't support are skipped.
+func ParseSQLToSchema(sql string) (*Schema, error) {
+	schema := &Schema{}
+	if err := applySQL(schema, sql); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// applySQL applies the statements of a SQL script to the schema
+func applySQL(schema *Schema, sql string) error {
+	statements := SplitSQLStatements(sql)
+
+	for _, stmt := range statements {
+		sqlStmt, err := ParseSQLStatement(stmt)
+		if err != nil {
+			continue // Skip malformed statements
+		}
+
+		if sqlStmt != nil {
+			if err := sqlStmt.Apply(schema); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sides of a three-way diff change
+const (
+	ChangeOurs     = "ours"     // Only our branch changed the object
+	ChangeTheirs   = "theirs"   // Only their branch changed the object
+	ChangeBoth     = "both"     // Both branches made the same change
+	ChangeConflict = "conflict" // The branches changed the object differently
+)
+
+// ThreeWayChange is an object that at least one branch changed since the common base
+type ThreeWayChange struct {
+	Object string // e.g. "column users.email"
+	Side   string // ChangeOurs, ChangeTheirs, ChangeBoth or ChangeConflict
+	Ours   string // What our branch did, e.g. "added INTEGER NOT NULL"; "" when it left the object alone
+	Theirs string
+}
+
+// schemaChange is what a diff does to an object: a description and a fingerprint of the outcome that
+// tells apart changes described alike
+type schemaChange struct {
+	description string
+	outcome     string
+}
+
+// ThreeWayDiff compares two branches that diverged from base. Objects changed on one side only can be
+// taken over as they are; the same change made on both sides must be migrated once; conflicting
+// changes need a decision before either branch's migration is rebased onto the other.
+func ThreeWayDiff(base, ours, theirs *Schema) []*ThreeWayChange {
+	ourChanges := diffChanges(DiffSchemas(base, ours))
+	theirChanges := diffChanges(DiffSchemas(base, theirs))
+
+	objects := map[string]bool{}
+	for object := range ourChanges {
+		objects[object] = true
+	}
+	for object := range theirChanges {
+		objects[object] = true
+	}
+	var changes []*ThreeWayChange
+	for object := range objects {
+		ourChange, oursOK := ourChanges[object]
+		theirChange, theirsOK := theirChanges[object]
+		change := &ThreeWayChange{Object: object, Ours: ourChange.description, Theirs: theirChange.description}
+		switch {
+		case !theirsOK:
+			change.Side = ChangeOurs
+		case !oursOK:
+			change.Side = ChangeTheirs
+		case ourChange.outcome == theirChange.outcome:
+			change.Side = ChangeBoth
+		default:
+			change.Side = ChangeConflict
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Object < changes[j].Object })
+	return changes
+}
+
+// diffChanges returns what a diff does to each object it touches. Removals are recorded before
+// additions, so an object dropped and recreated, like a changed trigger, ends up changed.
+func diffChanges(diff *SchemaDiff) map[string]schemaChange {
+	changes := map[string]schemaChange{}
+	set := func(object, description, outcome string) {
+		changes[object] = schemaChange{description, outcome}
+	}
+	dropped := func(object string) {
+		set(object, "dropped", "dropped")
+	}
+
+	for _, m := range diff.ModelsRemoved {
+		dropped("table " + m.TableName)
+	}
+	for _, fc := range diff.FieldsRemoved {
+		dropped("column " + fc.ModelName + "." + fc.Field.ColumnName)
+	}
+	for _, ic := range diff.IndexesRemoved {
+		dropped("index " + ic.TableName + "." + ic.Index.Name)
+	}
+	for _, fc := range diff.ForeignKeysRemoved {
+		dropped("foreign key " + fc.TableName + "." + fc.ForeignKey.Name)
+	}
+	for _, e := range diff.EnumsRemoved {
+		dropped("enum " + e.Name)
+	}
+	for _, ct := range diff.CompositeTypesRemoved {
+		dropped("type " + ct.Name)
+	}
+	for _, ext := range diff.ExtensionsRemoved {
+		dropped("extension " + ext.Name)
+	}
+	for _, t := range diff.TriggersRemoved {
+		dropped("trigger " + t.Name)
+	}
+	for _, fn := range diff.FunctionsRemoved {
+		dropped("function " + fn.Name)
+	}
+	for _, p := range diff.PoliciesRemoved {
+		dropped("policy " + p.TableName + "." + p.Name)
+	}
+	for _, g := range diff.GrantsRevoked {
+		set("grant on "+g.TableName+" to "+g.Role, "revoked", "revoked")
+	}
+	for _, v := range diff.ViewsRemoved {
+		dropped("view " + v.ViewName)
+	}
+	for _, seq := range diff.SequencesRemoved {
+		dropped("sequence " + seq.Name)
+	}
+
+	for _, tr := range diff.TablesRenamed {
+		set("table "+tr.CurrentName, "renamed to "+tr.Model.TableName, "renamed "+tr.Model.TableName)
+	}
+	for _, fc := range diff.ColumnsRenamed {
+		set("column "+fc.ModelName+"."+fc.CurrentField.ColumnName, "renamed to "+fc.Field.ColumnName, "renamed "+fc.Field.ColumnName)
+	}
+	for _, m := range diff.ModelsAdded {
+		set("table "+m.TableName, "created",
+			strings.Join(generateCreateTableSQL(m, GeneratorConfig{}, map[string]bool{}), "\n"))
+	}
+	for _, fc := range diff.FieldsAdded {
+		definition := fieldDefinition(fc.Field)
+		set("column "+fc.ModelName+"."+fc.Field.ColumnName, "added "+definition, definition)
+	}
+	for _, fc := range diff.FieldsModified {
+		definition := fieldDefinition(fc.Field)
+		set("column "+fc.ModelName+"."+fc.Field.ColumnName, "changed to "+definition, definition)
+	}
+	for _, ic := range diff.IndexesAdded {
+		columns := strings.Join(ic.Index.Columns, ", ")
+		set("index "+ic.TableName+"."+ic.Index.Name, "created on "+columns, fmt.Sprintf("%t %s", ic.Index.Unique, columns))
+	}
+	for _, fc := range diff.ForeignKeysAdded {
+		columns := strings.Join(fc.ForeignKey.Columns, ", ")
+		set("foreign key "+fc.TableName+"."+fc.ForeignKey.Name, "created on "+columns+" → "+fc.ForeignKey.RefTable,
+			columns+" "+fc.ForeignKey.RefTable+" "+fc.ForeignKey.OnDelete)
+	}
+	for _, pk := range diff.PrimaryKeys {
+		columns := strings.Join(pk.Columns, ", ")
+		set("primary key of "+pk.TableName, "changed to ("+columns+")", columns)
+	}
+	for _, cc := range diff.Comments {
+		object := "comment on " + cc.TableName
+		if cc.Column != "" {
+			object += "." + cc.Column
+		}
+		set(object, fmt.Sprintf("changed to %q", cc.Comment), cc.Comment)
+	}
+	for _, e := range diff.EnumsAdded {
+		set("enum "+e.Name, "created with "+strings.Join(e.Values, ", "), strings.Join(e.Values, ","))
+	}
+	for _, ec := range diff.EnumsModified {
+		labels := enumLabels(ec.Enum)
+		set("enum "+ec.Enum.Name, "values changed to "+strings.Join(labels, ", "), strings.Join(labels, ","))
+	}
+	for _, ct := range diff.CompositeTypesAdded {
+		set("type "+ct.Name, "created as "+compositeTypeStructure(ct), generateCreateCompositeTypeSQL(ct))
+	}
+	for _, ctc := range diff.CompositeTypesModified {
+		set("type "+ctc.Type.Name, "changed to "+compositeTypeStructure(ctc.Type), generateCreateCompositeTypeSQL(ctc.Type))
+	}
+	for _, ext := range diff.ExtensionsAdded {
+		set("extension "+ext.Name, "created", ext.Name)
+	}
+	for _, t := range diff.TriggersAdded {
+		changes["trigger "+t.Name] = createdOrChanged(changes["trigger "+t.Name], t.Definition)
+	}
+	for _, fn := range diff.FunctionsAdded {
+		set("function "+fn.Name, "created", fn.Definition)
+	}
+	for _, fnChange := range diff.FunctionsModified {
+		set("function "+fnChange.Function.Name, "changed", fnChange.Function.Definition)
+	}
+	for _, p := range diff.PoliciesAdded {
+		object := "policy " + p.TableName + "." + p.Name
+		changes[object] = createdOrChanged(changes[object], p.Definition)
+	}
+	for _, g := range diff.GrantsAdded {
+		privileges := strings.Join(g.Privileges, ", ")
+		object := "grant on " + g.TableName + " to " + g.Role
+		if _, revoked := changes[object]; revoked {
+			set(object, "changed to "+privileges, privileges)
+		} else {
+			set(object, "granted "+privileges, privileges)
+		}
+	}
+	for _, v := range diff.ViewsAdded {
+		set("view "+v.ViewName, "created", v.Definition)
+	}
+	for _, vChange := range diff.ViewsModified {
+		set("view "+vChange.View.ViewName, "changed", vChange.View.Definition)
+	}
+	for _, seq := range diff.SequencesAdded {
+		set("sequence "+seq.Name, "created", fmt.Sprintf("%+v", *seq))
+	}
+	for _, seqChange := range diff.SequencesModified {
+		set("sequence "+seqChange.Sequence.Name, "changed", fmt.Sprintf("%+v", *seqChange.Sequence))
+	}
+	for _, m := range diff.RowLevelSecurityEnabled {
+		set("row-level security on "+m.TableName, "enabled", "enabled")
+	}
+	for _, m := range diff.RowLevelSecurityDisabled {
+		set("row-level security on "+m.TableName, "disabled", "disabled")
+	}
+	return changes
+}
+
+// createdOrChanged describes an object the diff adds, which it changed when it also dropped it
+func createdOrChanged(previous schemaChange, definition string) schemaChange {
+	if previous.description == "dropped" {
+		return schemaChange{"changed", definition}
+	}
+	return schemaChange{"created", definition}
+}
+
+// fieldDefinition describes a column by its type, nullability and default, e.g. TEXT NOT NULL DEFAULT 'a'
+func fieldDefinition(f *Field) string {
+	definition := GetSQLTypeForField(f)
+	if !f.IsOptional {
+		definition += " NOT NULL"
+	}
+	if attr := findFieldAttribute(f, "default"); attr != nil && len(attr.Args) > 0 {
+		definition += " DEFAULT " + attr.Args[0]
+	}
+	return definition
+}
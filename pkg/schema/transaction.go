@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// goose runs each migration in a transaction unless the file starts with this directive
+const noTransactionDirective = "-- +goose NO TRANSACTION"
+
+// Statements PostgreSQL refuses to run inside a transaction block. ALTER TYPE ... ADD VALUE is
+// allowed in a transaction since PostgreSQL 12, but the new value can't be used before the
+// transaction commits, and older servers reject it outright.
+var noTransactionRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:CREATE (?:UNIQUE\s+)?|DROP |REINDEX (?:\(.*?\)\s+)?(?:INDEX|TABLE|SCHEMA|DATABASE)\s+)` +
+		`(?:INDEX\s+)?CONCURRENTLY\b`),
+	regexp.MustCompile(`^ALTER TABLE\s.*\sDETACH PARTITION\s.*\sCONCURRENTLY\b`),
+	regexp.MustCompile(`^ALTER TYPE\s.*\sADD VALUE\b`),
+	// Anonymous blocks committing between batches
+	regexp.MustCompile(`^DO\b.*\bCOMMIT\b`),
+	regexp.MustCompile(`^(?:VACUUM|CREATE DATABASE|DROP DATABASE|ALTER SYSTEM|CREATE TABLESPACE|DROP TABLESPACE)\b`),
+}
+
+// RequiresNoTransaction reports whether a SQL script contains a statement that can't run inside
+// a transaction block
+func RequiresNoTransaction(sql string) bool {
+	for _, stmt := range SplitSQLStatements(sql) {
+		normalized := normalizeWhitespace(upperOutsideQuotes(stmt))
+		for _, re := range noTransactionRegexes {
+			if re.MatchString(normalized) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FormatMigration returns the content of a goose migration file, adding the NO TRANSACTION
+// directive when the up or down SQL can't run inside a transaction. goose applies the directive
+// to the whole file, so every statement of such a migration commits on its own.
+func FormatMigration(up, down string) string {
+	content := "-- +goose Up\n" + up + "\n\n-- +goose Down\n" + down
+	if RequiresNoTransaction(up) || RequiresNoTransaction(down) {
+		content = noTransactionDirective + "\n\n" + content
+	}
+	return content
+}
+
+// GooseMigration is a goose migration file split into the statements of its sections
+type GooseMigration struct {
+	Up            []string
+	Down          []string
+	UpSQL         string // Up section as written, including comments such as warnings
+	DownSQL       string
+	NoTransaction bool
+}
+
+// ParseGooseMigration splits the content of a goose migration file into its up and down statements
+func ParseGooseMigration(content string) *GooseMigration {
+	up, down := gooseSections(content)
+	return &GooseMigration{
+		Up:            SplitSQLStatements(up),
+		Down:          SplitSQLStatements(down),
+		UpSQL:         up,
+		DownSQL:       down,
+		NoTransaction: strings.Contains(content, noTransactionDirective),
+	}
+}
+
+// gooseSections returns the SQL of the Up and Down sections of a goose migration. A file without
+// annotations is treated as a single up section.
+func gooseSections(content string) (up, down string) {
+	upStart := strings.Index(content, "-- +goose Up")
+	downStart := strings.Index(content, "-- +goose Down")
+
+	switch {
+	case upStart < 0:
+		return content, ""
+	case downStart > upStart:
+		return content[upStart:downStart], content[downStart:]
+	default:
+		return content[upStart:], ""
+	}
+}
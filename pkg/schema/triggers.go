@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// setTriggerValue applies a single `key = value` line of a trigger block
+func setTriggerValue(t *Trigger, key, value string) {
+	switch key {
+	case "model":
+		t.Model = value
+	case "table":
+		t.TableName = value
+	case "timing":
+		t.Timing = strings.ToUpper(value)
+	case "events":
+		t.Events = strings.ToUpper(value)
+	case "forEach":
+		t.ForEach = strings.ToUpper(value)
+	case "when":
+		t.When = value
+	case "function":
+		t.Function = value
+	case "file":
+		t.File = value
+	}
+}
+
+// resolveTrigger fills in the table name and SQL definition of a parsed trigger block.
+// Relative file paths are resolved against the directory of the schema file.
+func resolveTrigger(t *Trigger, s *Schema, schemaPath string) error {
+	if t.Model != "" {
+		for _, m := range s.Models {
+			if m.Name == t.Model {
+				t.TableName = m.TableName
+				break
+			}
+		}
+		if t.TableName == "" {
+			return fmt.Errorf("trigger %s references unknown model %s", t.Name, t.Model)
+		}
+	}
+
+	if t.File != "" {
+		path := t.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(schemaPath), path)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("trigger %s: %w", t.Name, err)
+		}
+		t.Definition = strings.TrimSpace(string(b))
+		if !strings.HasSuffix(t.Definition, ";") {
+			t.Definition += ";"
+		}
+		return nil
+	}
+
+	if t.TableName == "" || t.Events == "" || t.Function == "" {
+		return fmt.Errorf("trigger %s must define model (or table), events and function, or a file", t.Name)
+	}
+	if t.Timing == "" {
+		t.Timing = "BEFORE"
+	}
+	if t.ForEach == "" {
+		t.ForEach = "ROW"
+	}
+	function := t.Function
+	if !strings.HasSuffix(function, ")") {
+		function += "()"
+	}
+
+	def := "CREATE TRIGGER " + t.Name + " " + t.Timing + " " + t.Events + " ON " + quoteIdent(t.TableName) +
+		" FOR EACH " + t.ForEach
+	if t.When != "" {
+		def += " WHEN (" + t.When + ")"
+	}
+	t.Definition = def + " EXECUTE FUNCTION " + function + ";"
+	return nil
+}
+
+// normalizeSQLDefinition makes SQL definitions comparable regardless of case and formatting
+func normalizeSQLDefinition(sql string) string {
+	sql = strings.TrimSuffix(strings.TrimSpace(sql), ";")
+	return normalizeWhitespace(strings.ToUpper(sql))
+}
+
+func triggerKey(t *Trigger) string {
+	return strings.ToLower(t.TableName + "." + t.Name)
+}
+
+func generateDropTriggerSQL(t *Trigger) string {
+	return "DROP TRIGGER IF EXISTS " + t.Name + " ON " + quoteIdent(t.TableName) + ";"
+}
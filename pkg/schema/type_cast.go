@@ -0,0 +1,571 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phathdt/schema-manager/internal/logger"
+)
+
+// TypeCastResult represents the result of a type cast operation
+type TypeCastResult struct {
+	CanCast        bool
+	CastExpression string // Appended to the column (::INTEGER), a template with %s for the column, or a complete expression
+	IsRisky        bool
+	WarningMessage string
+}
+
+// UsingExpression returns the USING expression converting a column's values
+func (r TypeCastResult) UsingExpression(column string) string {
+	switch {
+	case strings.Contains(r.CastExpression, "%s"):
+		return strings.ReplaceAll(r.CastExpression, "%s", column)
+	case strings.HasPrefix(r.CastExpression, "::"), strings.HasPrefix(r.CastExpression, " "):
+		return column + r.CastExpression
+	}
+	return r.CastExpression
+}
+
+// UsingOverride returns the USING expression a field's @using attribute declares for a change to its
+// type, or with down set the one reverting it: @using("round(%s)::integer", down: "%s::numeric").
+// It returns "" when the field has no such expression.
+func UsingOverride(f *Field, down bool) string {
+	for _, attr := range f.Attributes {
+		if attr.Name != "using" {
+			continue
+		}
+		for i, arg := range attr.Args {
+			if key, value, named := strings.Cut(arg, ":"); named && strings.TrimSpace(key) == "down" {
+				if down {
+					return unquoteUsing(value)
+				}
+				continue
+			}
+			if !down && i == 0 {
+				return unquoteUsing(arg)
+			}
+		}
+	}
+	return ""
+}
+
+func unquoteUsing(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if unquoted, err := strconv.Unquote(arg); err == nil {
+		return unquoted
+	}
+	return strings.Trim(arg, "\"")
+}
+
+// CastRule is a conversion between two column types declared in the generator block as
+// "SOURCE -> TARGET USING expression [RISKY]", where %s in the expression stands for the column
+type CastRule struct {
+	Definition string
+	Source     string // Empty when the definition can't be parsed
+	Target     string
+	Expression string
+	Risky      bool
+}
+
+var castRuleRegex = regexp.MustCompile(`(?i)^(.+?)\s*->\s*(.+?)\s+USING\s+(.+?)(\s+RISKY)?$`)
+
+func parseCastRule(definition string) *CastRule {
+	rule := &CastRule{Definition: definition}
+	if matches := castRuleRegex.FindStringSubmatch(strings.TrimSpace(definition)); matches != nil {
+		rule.Source = strings.ToUpper(matches[1])
+		rule.Target = strings.ToUpper(matches[2])
+		rule.Expression = matches[3]
+		rule.Risky = matches[4] != ""
+	}
+	return rule
+}
+
+// CanCastType looks up a conversion in the configured cast rules before the built-in matrix
+func (g GeneratorConfig) CanCastType(sourceType, targetType string) TypeCastResult {
+	sourcePG := strings.ToUpper(GetPostgreSQLType(sourceType))
+	targetPG := strings.ToUpper(GetPostgreSQLType(targetType))
+	for _, rule := range g.CastRules {
+		if rule.Source != sourcePG || rule.Target != targetPG {
+			continue
+		}
+		result := TypeCastResult{CanCast: true, CastExpression: rule.Expression, IsRisky: rule.Risky}
+		if rule.Risky {
+			result.WarningMessage = fmt.Sprintf("Converting %s to %s with the configured cast USING %s may fail",
+				sourcePG, targetPG, rule.Expression)
+		}
+		return result
+	}
+	return CanCastType(sourceType, targetType)
+}
+
+// GetPostgreSQLType maps Prisma types to PostgreSQL types
+func GetPostgreSQLType(prismaType string) string {
+	typeMap := map[string]string{
+		"String":   "TEXT",
+		"Int":      "INTEGER",
+		"BigInt":   "BIGINT",
+		"Float":    "DOUBLE PRECISION",
+		"Decimal":  "NUMERIC",
+		"Boolean":  "BOOLEAN",
+		"DateTime": "TIMESTAMP",
+		"Json":     "JSONB",
+	}
+
+	if pgType, ok := typeMap[prismaType]; ok {
+		return pgType
+	}
+	return prismaType // fallback to original type
+}
+
+// NormalizedTypes returns the types a modified field is compared by before and after the change.
+// Enums compare by their upper-cased name, since migrations don't keep the case of type names.
+func (fc *FieldChange) NormalizedTypes() (current, target string) {
+	current = NormalizeTypeForComparison(fc.CurrentField.Type, fc.CurrentField.Attributes)
+	target = NormalizeTypeForComparison(fc.Field.Type, fc.Field.Attributes)
+	if fc.CurrentEnum != nil {
+		current = strings.ToUpper(fc.CurrentEnum.Name)
+	}
+	if fc.TargetEnum != nil {
+		target = strings.ToUpper(fc.TargetEnum.Name)
+	}
+	return current, target
+}
+
+// TypeChange returns the type change of a modified field and how its values are converted; changed is
+// false when the column keeps its type. from and to are the normalized types, or the SQL types when the
+// column stays the same kind with another length, precision or scale.
+func (fc *FieldChange) TypeChange(generator GeneratorConfig) (from, to string, cast TypeCastResult, changed bool) {
+	currentNormalizedType, targetNormalizedType := fc.NormalizedTypes()
+	currentSQLType := GetSQLTypeForField(fc.CurrentField)
+	targetSQLType := GetSQLTypeForField(fc.Field)
+
+	// Check if we have a type change (normalized types differ) or DECIMAL precision/scale change
+	hasTypeChange := currentNormalizedType != targetNormalizedType
+	hasDecimalChange := currentNormalizedType == "Decimal" && targetNormalizedType == "Decimal" &&
+		currentSQLType != targetSQLType
+	hasTimestampPrecisionChange := !hasTypeChange && timestampBaseType(currentNormalizedType) != "" &&
+		currentSQLType != targetSQLType
+	hasStringTypeChange := !hasTypeChange && currentNormalizedType == "String" && currentSQLType != targetSQLType
+	if !hasTypeChange && !hasDecimalChange && !hasTimestampPrecisionChange && !hasStringTypeChange {
+		return "", "", TypeCastResult{}, false
+	}
+
+	from, to = currentNormalizedType, targetNormalizedType
+	if !hasTypeChange {
+		// Same kind of column, e.g. DECIMAL(10,2) to DECIMAL(8,2)
+		from, to = currentSQLType, targetSQLType
+	}
+	switch {
+	case hasDecimalChange:
+		// Special handling for DECIMAL precision/scale changes
+		cast = handleDecimalPrecisionChange(currentSQLType, targetSQLType)
+	case hasTimestampPrecisionChange:
+		// Fractional seconds are rounded to the new precision
+		cast = TypeCastResult{CanCast: true}
+	case hasStringTypeChange:
+		cast = handleStringTypeChange(currentSQLType, targetSQLType)
+	default:
+		cast = fc.CastType(generator, false)
+	}
+	if using := UsingOverride(fc.Field, false); using != "" {
+		// The schema spells out the conversion of this column
+		cast = TypeCastResult{CanCast: true, CastExpression: using}
+	}
+	return from, to, cast, true
+}
+
+// CastType returns the conversion of a modified field's type change, or with reverse set of the
+// change back. Changes to, from and between enums go through text.
+func (fc *FieldChange) CastType(g GeneratorConfig, reverse bool) TypeCastResult {
+	current, target := fc.NormalizedTypes()
+	fromEnum, toEnum := fc.CurrentEnum, fc.TargetEnum
+	if reverse {
+		current, target = target, current
+		fromEnum, toEnum = toEnum, fromEnum
+	}
+	if fromEnum == nil && toEnum == nil {
+		return g.CanCastType(current, target)
+	}
+
+	switch {
+	case fromEnum != nil && toEnum != nil:
+		var missing []string
+		for _, v := range fromEnum.Values {
+			if !containsString(toEnum.Values, v) {
+				missing = append(missing, v)
+			}
+		}
+		result := TypeCastResult{CanCast: true, CastExpression: "::TEXT::" + toEnum.Name}
+		if len(missing) > 0 {
+			result.IsRisky = true
+			result.WarningMessage = fmt.Sprintf("rows with %s not in enum %s will fail the conversion",
+				strings.Join(missing, ", "), toEnum.Name)
+		}
+		return result
+	case fromEnum != nil && target == "String":
+		return TypeCastResult{CanCast: true, CastExpression: "::TEXT"}
+	case toEnum != nil && current == "String":
+		return TypeCastResult{
+			CanCast:        true,
+			CastExpression: "::" + toEnum.Name,
+			IsRisky:        true,
+			WarningMessage: fmt.Sprintf("text values that aren't labels of enum %s will fail the conversion", toEnum.Name),
+		}
+	}
+	return g.CanCastType(current, target)
+}
+
+// CanCastType determines if a type can be cast from source to target
+func CanCastType(sourceType, targetType string) TypeCastResult {
+	sourcePG := GetPostgreSQLType(sourceType)
+	targetPG := GetPostgreSQLType(targetType)
+
+	// Same type - no casting needed
+	if sourcePG == targetPG {
+		return TypeCastResult{
+			CanCast:        true,
+			CastExpression: "",
+			IsRisky:        false,
+		}
+	}
+
+	// Define casting compatibility matrix
+	castingRules := map[string]map[string]TypeCastResult{
+		"BIGINT": {
+			"INTEGER": {
+				CanCast:        true,
+				CastExpression: "::INTEGER",
+				IsRisky:        true,
+				WarningMessage: "Converting BIGINT to INTEGER may fail if values exceed INTEGER range (-2,147,483,648 to 2,147,483,647)",
+			},
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"DOUBLE PRECISION": {
+				CanCast:        true,
+				CastExpression: "::DOUBLE PRECISION",
+				IsRisky:        false,
+			},
+			"NUMERIC": {
+				CanCast:        true,
+				CastExpression: "::NUMERIC",
+				IsRisky:        false,
+			},
+		},
+		"INTEGER": {
+			"BIGINT": {
+				CanCast:        true,
+				CastExpression: "::BIGINT",
+				IsRisky:        false,
+			},
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"DOUBLE PRECISION": {
+				CanCast:        true,
+				CastExpression: "::DOUBLE PRECISION",
+				IsRisky:        false,
+			},
+			"NUMERIC": {
+				CanCast:        true,
+				CastExpression: "::NUMERIC",
+				IsRisky:        false,
+			},
+			"BOOLEAN": {
+				CanCast:        true,
+				CastExpression: "::BOOLEAN",
+				IsRisky:        false,
+				WarningMessage: "Converting INTEGER to BOOLEAN: 0 = false, any other value = true",
+			},
+		},
+		"TEXT": {
+			"INTEGER": {
+				CanCast:        true,
+				CastExpression: "::INTEGER",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to INTEGER may fail if text contains non-numeric values",
+			},
+			"BIGINT": {
+				CanCast:        true,
+				CastExpression: "::BIGINT",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to BIGINT may fail if text contains non-numeric values",
+			},
+			"DOUBLE PRECISION": {
+				CanCast:        true,
+				CastExpression: "::DOUBLE PRECISION",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to DOUBLE PRECISION may fail if text contains non-numeric values",
+			},
+			"BOOLEAN": {
+				CanCast:        true,
+				CastExpression: "::BOOLEAN",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to BOOLEAN may fail if text is not 't', 'f', 'true', 'false', '1', or '0'",
+			},
+			"TIMESTAMP": {
+				CanCast:        true,
+				CastExpression: "::TIMESTAMP",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to TIMESTAMP may fail if text is not in valid timestamp format",
+			},
+			"TIMESTAMPTZ": {
+				CanCast:        true,
+				CastExpression: "::TIMESTAMPTZ",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to TIMESTAMPTZ may fail if text is not in valid timestamp format; values without an offset use the session time zone",
+			},
+			"JSONB": {
+				CanCast:        true,
+				CastExpression: "::JSONB",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to JSONB may fail if text is not valid JSON",
+			},
+			"NUMERIC": {
+				CanCast:        true,
+				CastExpression: "::NUMERIC",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to NUMERIC may fail if text contains non-numeric values",
+			},
+			"REAL": {
+				CanCast:        true,
+				CastExpression: "::REAL",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to REAL may fail if text contains non-numeric values",
+			},
+			"UUID": {
+				CanCast:        true,
+				CastExpression: "::UUID",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to UUID may fail if text is not a valid UUID",
+			},
+			"DATE": {
+				CanCast:        true,
+				CastExpression: "::DATE",
+				IsRisky:        true,
+				WarningMessage: "Converting TEXT to DATE may fail if text is not in valid date format",
+			},
+		},
+		"DOUBLE PRECISION": {
+			"INTEGER": {
+				CanCast:        true,
+				CastExpression: "::INTEGER",
+				IsRisky:        true,
+				WarningMessage: "Converting DOUBLE PRECISION to INTEGER will truncate decimal places",
+			},
+			"BIGINT": {
+				CanCast:        true,
+				CastExpression: "::BIGINT",
+				IsRisky:        true,
+				WarningMessage: "Converting DOUBLE PRECISION to BIGINT will truncate decimal places",
+			},
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"REAL": {
+				CanCast:        true,
+				CastExpression: "::REAL",
+				IsRisky:        true,
+				WarningMessage: "Converting DOUBLE PRECISION to REAL keeps only about 6 significant digits",
+			},
+			"NUMERIC": {
+				CanCast:        true,
+				CastExpression: "::NUMERIC",
+				IsRisky:        true,
+				WarningMessage: "Converting DOUBLE PRECISION to NUMERIC fails for Infinity values before PostgreSQL 14",
+			},
+		},
+		"REAL": {
+			"DOUBLE PRECISION": {
+				CanCast:        true,
+				CastExpression: "::DOUBLE PRECISION",
+				IsRisky:        false,
+			},
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"INTEGER": {
+				CanCast:        true,
+				CastExpression: "::INTEGER",
+				IsRisky:        true,
+				WarningMessage: "Converting REAL to INTEGER will round decimal places",
+			},
+			"NUMERIC": {
+				CanCast:        true,
+				CastExpression: "::NUMERIC",
+				IsRisky:        true,
+				WarningMessage: "Converting REAL to NUMERIC fails for Infinity values before PostgreSQL 14",
+			},
+		},
+		"UUID": {
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+		},
+		"DATE": {
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"TIMESTAMP": {
+				CanCast:        true,
+				CastExpression: "::TIMESTAMP",
+				IsRisky:        false,
+			},
+			"TIMESTAMPTZ": {
+				CanCast:        true,
+				CastExpression: "%s::TIMESTAMP AT TIME ZONE 'UTC'",
+				IsRisky:        false,
+				WarningMessage: "Converting DATE to TIMESTAMPTZ sets existing values to midnight UTC",
+			},
+		},
+		"BOOLEAN": {
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"INTEGER": {
+				CanCast:        true,
+				CastExpression: "CASE WHEN %s THEN 1 ELSE 0 END",
+				IsRisky:        false,
+				WarningMessage: "Converting BOOLEAN to INTEGER: true = 1, false = 0",
+			},
+		},
+		"TIMESTAMP": {
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"TIMESTAMPTZ": {
+				CanCast:        true,
+				CastExpression: " AT TIME ZONE 'UTC'",
+				IsRisky:        true,
+				WarningMessage: "Converting TIMESTAMP to TIMESTAMPTZ assumes existing values are in UTC",
+			},
+			"DATE": {
+				CanCast:        true,
+				CastExpression: "::DATE",
+				IsRisky:        true,
+				WarningMessage: "Converting TIMESTAMP to DATE drops the time of day",
+			},
+		},
+		"TIMESTAMPTZ": {
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"TIMESTAMP": {
+				CanCast:        true,
+				CastExpression: " AT TIME ZONE 'UTC'",
+				IsRisky:        true,
+				WarningMessage: "Converting TIMESTAMPTZ to TIMESTAMP stores values as UTC and drops the time zone",
+			},
+			"DATE": {
+				CanCast:        true,
+				CastExpression: "(%s AT TIME ZONE 'UTC')::DATE",
+				IsRisky:        true,
+				WarningMessage: "Converting TIMESTAMPTZ to DATE drops the time of day of the UTC timestamp",
+			},
+		},
+		"JSONB": {
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+			"JSON": {
+				CanCast:        true,
+				CastExpression: "::JSON",
+				IsRisky:        false,
+				WarningMessage: "Converting JSONB to JSON - safe but JSONB is generally preferred for performance",
+			},
+		},
+		"JSON": {
+			"JSONB": {
+				CanCast:        true,
+				CastExpression: "::JSONB",
+				IsRisky:        false,
+				WarningMessage: "Converting JSON to JSONB - safe operation, JSONB offers better performance",
+			},
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+		},
+		"NUMERIC": {
+			"INTEGER": {
+				CanCast:        true,
+				CastExpression: "::INTEGER",
+				IsRisky:        true,
+				WarningMessage: "Converting NUMERIC to INTEGER will truncate decimal places and may fail if values exceed INTEGER range",
+			},
+			"BIGINT": {
+				CanCast:        true,
+				CastExpression: "::BIGINT",
+				IsRisky:        true,
+				WarningMessage: "Converting NUMERIC to BIGINT will truncate decimal places and may fail if values exceed BIGINT range",
+			},
+			"REAL": {
+				CanCast:        true,
+				CastExpression: "::REAL",
+				IsRisky:        true,
+				WarningMessage: "Converting NUMERIC to REAL keeps only about 6 significant digits",
+			},
+			"DOUBLE PRECISION": {
+				CanCast:        true,
+				CastExpression: "::DOUBLE PRECISION",
+				IsRisky:        false,
+			},
+			"NUMERIC": {
+				CanCast:        true,
+				CastExpression: "::NUMERIC",
+				IsRisky:        false,
+			},
+			"TEXT": {
+				CanCast:        true,
+				CastExpression: "::TEXT",
+				IsRisky:        false,
+			},
+		},
+	}
+
+	if sourceRules, ok := castingRules[sourcePG]; ok {
+		if result, ok := sourceRules[targetPG]; ok {
+			return result
+		}
+	}
+
+	// No casting rule found
+	return TypeCastResult{
+		CanCast: false,
+		WarningMessage: fmt.Sprintf(
+			"No automatic casting available from %s to %s. Manual SQL migration required.",
+			sourcePG,
+			targetPG,
+		),
+	}
+}
+
+// LogTypeCastWarning logs warnings for risky type casts
+func LogTypeCastWarning(tableName, columnName string, result TypeCastResult) {
+	if result.IsRisky && result.WarningMessage != "" {
+		logger.Warn("Type cast for %s.%s - %s", tableName, columnName, result.WarningMessage)
+	}
+}
@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DateTime column types selectable with dateTimeType in the generator block
+const (
+	DateTimeTimestamptz = "timestamptz"
+	DateTimeTimestamp   = "timestamp"
+)
+
+// Storage of composite types selectable with compositeTypes in the generator block
+const (
+	CompositeTypesJSONB  = "jsonb"
+	CompositeTypesNative = "native"
+)
+
+// Float column types selectable with floatType in the generator block
+const (
+	FloatDoublePrecision = "double precision"
+	FloatReal            = "real"
+)
+
+// applyTypeMapping resolves the configured native type of fields that don't declare one with a @db
+// attribute, so SQL generation and comparison only have to look at the field itself
+func applyTypeMapping(s *Schema) {
+	dateTimeAttr := "db.Timestamptz"
+	if s.Generator.DateTimeType == DateTimeTimestamp {
+		dateTimeAttr = "db.Timestamp"
+	}
+	stringAttr, _ := stringTypeAttribute(s.Generator.StringType)
+	var fields []*Field
+	for _, m := range s.Models {
+		fields = append(fields, m.Fields...)
+	}
+	// The attributes of native composite types are columns too
+	for _, ct := range s.CompositeTypes {
+		fields = append(fields, ct.Fields...)
+	}
+	for _, f := range fields {
+		if hasNativeTypeAttribute(f) {
+			continue
+		}
+		switch {
+		case f.Type == "DateTime":
+			f.Attributes = append(f.Attributes, &FieldAttribute{Name: dateTimeAttr})
+		case f.Type == "String" && stringAttr != nil:
+			f.Attributes = append(f.Attributes, &FieldAttribute{Name: stringAttr.Name, Args: stringAttr.Args})
+		case f.Type == "Float" && s.Generator.FloatType == FloatReal:
+			f.Attributes = append(f.Attributes, &FieldAttribute{Name: "db.Real"})
+		}
+	}
+}
+
+var stringTypeRegex = regexp.MustCompile(`^varchar(?:\((\d+)\))?$`)
+
+// stringTypeAttribute returns the @db attribute of a stringType setting: nil for text, @db.VarChar(n)
+// for varchar(n). ok is false for unsupported settings.
+func stringTypeAttribute(stringType string) (attr *FieldAttribute, ok bool) {
+	if stringType == "" || stringType == "text" {
+		return nil, true
+	}
+	matches := stringTypeRegex.FindStringSubmatch(stringType)
+	if matches == nil {
+		return nil, false
+	}
+	attr = &FieldAttribute{Name: "db.VarChar"}
+	if matches[1] != "" {
+		attr.Args = []string{matches[1]}
+	}
+	return attr, true
+}
+
+// StringSQLType returns the column type of String fields without a @db attribute, e.g. VARCHAR(191)
+func (g GeneratorConfig) StringSQLType() string {
+	if attr, _ := stringTypeAttribute(g.StringType); attr != nil {
+		return GetSQLTypeForField(&Field{Type: "String", Attributes: []*FieldAttribute{attr}})
+	}
+	return "TEXT"
+}
+
+// varcharLength returns n of a VARCHAR(n) column type, or 0 for unbounded string types
+func varcharLength(sqlType string) int {
+	if !strings.HasPrefix(sqlType, "VARCHAR(") {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(sqlType, "VARCHAR("), ")"))
+	return n
+}
+
+// isStringSQLType reports whether a column type from a migration holds a Prisma String
+func isStringSQLType(sqlType string) bool {
+	switch t := strings.ToUpper(sqlType); {
+	case t == "TEXT", t == "CITEXT", t == "VARCHAR", strings.HasPrefix(t, "VARCHAR("):
+		return true
+	}
+	return false
+}
+
+func hasNativeTypeAttribute(f *Field) bool {
+	for _, attr := range f.Attributes {
+		if strings.HasPrefix(attr.Name, "db.") {
+			return true
+		}
+	}
+	return false
+}
+
+// nativeSQLType returns the column type of a @db attribute that selects a different kind of column
+// for its Prisma type, e.g. TIMESTAMPTZ(3) for @db.Timestamptz(3) or UUID for @db.Uuid. String length
+// attributes (@db.VarChar, @db.Text) are handled by the callers.
+func nativeSQLType(dbType string, args []string) (string, bool) {
+	var sqlType string
+	switch dbType {
+	case "Timestamp":
+		sqlType = "TIMESTAMP"
+	case "Timestamptz":
+		sqlType = "TIMESTAMPTZ"
+	case "Date":
+		return "DATE", true
+	case "Real":
+		return "REAL", true
+	case "DoublePrecision":
+		return "DOUBLE PRECISION", true
+	case "Uuid":
+		return "UUID", true
+	case "JsonB":
+		return "JSONB", true
+	default:
+		return "", false
+	}
+	if len(args) > 0 && args[0] != "" {
+		sqlType += "(" + args[0] + ")"
+	}
+	return sqlType, true
+}
+
+// nativeBaseType returns the kind of column of a type from a migration that nativeSQLType can
+// produce, without precision: timestamp(3) gives TIMESTAMP and float8 gives DOUBLE PRECISION. FLOAT
+// without a precision is double precision in PostgreSQL.
+func nativeBaseType(sqlType string) string {
+	if base := timestampBaseType(sqlType); base != "" {
+		return base
+	}
+	switch strings.ToUpper(sqlType) {
+	case "REAL", "FLOAT4":
+		return "REAL"
+	case "DOUBLE PRECISION", "FLOAT8", "FLOAT":
+		return "DOUBLE PRECISION"
+	case "DATE":
+		return "DATE"
+	case "UUID":
+		return "UUID"
+	}
+	return ""
+}
+
+// timestampBaseType returns TIMESTAMP or TIMESTAMPTZ for a timestamp column type from a migration
+// with any precision, or "" for other types
+func timestampBaseType(sqlType string) string {
+	base, _, _ := strings.Cut(strings.ToUpper(sqlType), "(")
+	switch base {
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		return base
+	}
+	return ""
+}
@@ -0,0 +1,551 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationError is a semantic problem in a schema, positioned in the schema file
+type ValidationError struct {
+	File    string
+	Line    int
+	Message string
+	Warning bool   // Suspicious but accepted, e.g. reserved words that are quoted in generated SQL
+	Rule    string // Schema rule that was violated, "" for problems found by ValidateSchema
+}
+
+func (e *ValidationError) Error() string {
+	message := e.Message
+	if e.Warning {
+		message = "warning: " + message
+	}
+	if e.Rule != "" {
+		message += " (rule " + e.Rule + ")"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, message)
+	}
+	return e.File + ": " + message
+}
+
+var scalarTypes = map[string]bool{
+	"String":   true,
+	"Int":      true,
+	"BigInt":   true,
+	"Float":    true,
+	"Decimal":  true,
+	"Boolean":  true,
+	"DateTime": true,
+	"Json":     true,
+	"Bytes":    true,
+}
+
+var (
+	integerLiteralRegex = regexp.MustCompile(`^-?[0-9]+$`)
+	numberLiteralRegex  = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	stringLiteralRegex  = regexp.MustCompile(`^"(?:[^"\\]|\\.)*"$`)
+	fieldListRegex      = regexp.MustCompile(`\[([^\]]*)\]`)
+)
+
+// ValidateSchema checks a parsed schema for problems the parser accepts: duplicate names, unknown
+// types, relations and composite keys referencing missing fields, and defaults of the wrong type.
+// Table and column names that are reserved words and colliding foreign key names are reported as
+// warnings.
+func ValidateSchema(s *Schema, path string) []*ValidationError {
+	var errs []*ValidationError
+	report := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{File: path, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &ValidationError{
+			File: path, Line: line, Message: fmt.Sprintf(format, args...), Warning: true,
+		})
+	}
+
+	if dt := s.Generator.DateTimeType; dt != "" && dt != DateTimeTimestamptz && dt != DateTimeTimestamp {
+		report(0, "generator dateTimeType must be %q or %q, not %q", DateTimeTimestamptz, DateTimeTimestamp, dt)
+	}
+	if ft := s.Generator.FloatType; ft != "" && ft != FloatDoublePrecision && ft != FloatReal {
+		report(0, "generator floatType must be %q or %q, not %q", FloatDoublePrecision, FloatReal, ft)
+	}
+	if rm := s.Generator.RelationMode; rm != "" && rm != RelationModeForeignKeys && rm != RelationModePrisma {
+		report(0, "datasource relationMode must be %q or %q, not %q", RelationModeForeignKeys, RelationModePrisma, rm)
+	}
+	if b := s.Generator.Backup; b != "" && b != BackupPgDump && b != BackupCSV {
+		report(0, "generator backup must be %q or %q, not %q", BackupPgDump, BackupCSV, b)
+	}
+	for _, rule := range s.Generator.CastRules {
+		if rule.Source == "" {
+			report(0, "generator cast rule %q must look like \"SOURCE -> TARGET USING expression\", optionally followed by RISKY",
+				rule.Definition)
+		}
+	}
+	for _, rule := range s.Generator.Rules {
+		if rule.Severity == "" {
+			report(0, "generator rule %q must end in \": error\", \": warning\" or \": off\"", rule.Definition)
+		} else if !rule.validRule() {
+			report(0, "generator rule %q is neither a built-in rule nor a rule expression", rule.Definition)
+		}
+	}
+	if ct := s.Generator.CompositeTypes; ct != "" && ct != CompositeTypesJSONB && ct != CompositeTypesNative {
+		report(0, "generator compositeTypes must be %q or %q, not %q", CompositeTypesJSONB, CompositeTypesNative, ct)
+	}
+	if _, ok := stringTypeAttribute(s.Generator.StringType); !ok {
+		report(0, "generator stringType must be \"text\" or \"varchar(n)\", not %q", s.Generator.StringType)
+	}
+
+	models := map[string]*Model{}
+	enums := map[string]*Enum{}
+	tables := map[string]*Model{}
+	for _, m := range s.Models {
+		if _, ok := models[m.Name]; ok {
+			report(m.Line, "model %s is declared more than once", m.Name)
+		} else if _, ok := enums[m.Name]; ok {
+			report(m.Line, "model %s has the same name as an enum", m.Name)
+		}
+		if other, ok := tables[m.TableName]; ok && other.Name != m.Name {
+			report(m.Line, "model %s maps to table %s, which is already used by model %s", m.Name, m.TableName, other.Name)
+		}
+		if len(m.TableName) > maxIdentifierLength {
+			report(m.Line, "table name %s of model %s is longer than %d characters and would be truncated",
+				m.TableName, m.Name, maxIdentifierLength)
+		}
+		if IsReservedWord(m.TableName) {
+			warn(m.Line, "table name %s of model %s is a reserved SQL word and will be quoted", m.TableName, m.Name)
+		}
+		models[m.Name] = m
+		tables[m.TableName] = m
+	}
+	for _, e := range s.Enums {
+		if _, ok := enums[e.Name]; ok {
+			report(e.Line, "enum %s is declared more than once", e.Name)
+		} else if _, ok := models[e.Name]; ok {
+			report(e.Line, "enum %s has the same name as a model", e.Name)
+		}
+		enums[e.Name] = e
+
+		values := map[string]bool{}
+		for _, v := range enumValueNames(e) {
+			if values[v] {
+				report(e.Line, "enum %s has duplicate value %s", e.Name, v)
+			}
+			values[v] = true
+		}
+	}
+
+	compositeTypes := map[string]*CompositeType{}
+	for _, ct := range s.CompositeTypes {
+		if _, ok := compositeTypes[ct.Name]; ok {
+			report(ct.Line, "type %s is declared more than once", ct.Name)
+		} else if _, ok := models[ct.Name]; ok {
+			report(ct.Line, "type %s has the same name as a model", ct.Name)
+		} else if _, ok := enums[ct.Name]; ok {
+			report(ct.Line, "type %s has the same name as an enum", ct.Name)
+		}
+		compositeTypes[ct.Name] = ct
+	}
+	for _, ct := range s.CompositeTypes {
+		fields := map[string]bool{}
+		for _, f := range ct.Fields {
+			if fields[f.Name] {
+				report(f.Line, "field %s.%s is declared more than once", ct.Name, f.Name)
+			}
+			fields[f.Name] = true
+			if _, ok := models[f.Type]; ok {
+				report(f.Line, "field %s.%s has type %s, but types can't hold relations to models", ct.Name, f.Name, f.Type)
+			} else if _, ok := compositeTypes[f.Type]; ok && f.IsArray {
+				report(f.Line, "field %s.%s is a list of type %s, which schema-manager can't store", ct.Name, f.Name, f.Type)
+			} else if !ok && enums[f.Type] == nil && !scalarTypes[f.Type] && !strings.HasPrefix(f.Type, "Unsupported(") {
+				report(f.Line, "%s", unknownTypeMessage(ct.Name, f, s))
+			}
+		}
+	}
+
+	for _, m := range s.Models {
+		fields := map[string]*Field{}
+		columns := map[string]*Field{}
+		for _, f := range m.Fields {
+			related, isRelation := models[f.Type]
+			if _, ok := fields[f.Name]; ok {
+				report(f.Line, "field %s.%s is declared more than once", m.Name, f.Name)
+			} else if other, ok := columns[f.ColumnName]; ok && !isRelation {
+				report(f.Line, "fields %s.%s and %s.%s map to the same column %s",
+					m.Name, other.Name, m.Name, f.Name, f.ColumnName)
+			}
+			fields[f.Name] = f
+			if !isRelation {
+				columns[f.ColumnName] = f
+				if len(f.ColumnName) > maxIdentifierLength && !f.IsArray {
+					report(f.Line, "column name %s of field %s.%s is longer than %d characters and would be truncated",
+						f.ColumnName, m.Name, f.Name, maxIdentifierLength)
+				}
+				if IsReservedWord(f.ColumnName) && !f.IsArray {
+					warn(f.Line, "column name %s of field %s.%s is a reserved SQL word and will be quoted",
+						f.ColumnName, m.Name, f.Name)
+				}
+				if f.Name != f.ColumnName && f.ColumnName == strings.ToLower(f.Name) && findFieldAttribute(f, "map") == nil && !f.IsArray {
+					warn(f.Line, "field %s.%s has no @map and is stored as column %s; add @map to choose the column name",
+						m.Name, f.Name, f.ColumnName)
+				}
+			}
+
+			enum, isEnum := enums[f.Type]
+			_, isCompositeType := compositeTypes[f.Type]
+			if !isRelation && !isEnum && !isCompositeType && !scalarTypes[f.Type] && !strings.HasPrefix(f.Type, "Unsupported(") {
+				report(f.Line, "%s", unknownTypeMessage(m.Name, f, s))
+				continue
+			}
+			if isCompositeType && f.IsArray {
+				report(f.Line, "field %s.%s is a list of type %s, which schema-manager can't store; use a single %s or Json",
+					m.Name, f.Name, f.Type, f.Type)
+			}
+
+			for _, attr := range f.Attributes {
+				switch attr.Name {
+				case "db.Timestamp", "db.Timestamptz", "db.Date":
+					if f.Type != "DateTime" {
+						report(f.Line, "field %s.%s has @%s but is not a DateTime", m.Name, f.Name, attr.Name)
+					}
+				case "db.Real", "db.DoublePrecision":
+					if f.Type != "Float" {
+						report(f.Line, "field %s.%s has @%s but is not a Float", m.Name, f.Name, attr.Name)
+					}
+				case "db.Uuid":
+					if f.Type != "String" {
+						report(f.Line, "field %s.%s has @%s but is not a String", m.Name, f.Name, attr.Name)
+					}
+				case "using":
+					if UsingOverride(f, false) == "" {
+						report(f.Line, "field %s.%s has @using without a USING expression", m.Name, f.Name)
+					}
+				case "relation":
+					if !isRelation {
+						report(f.Line, "field %s.%s has @relation but %s is not a model", m.Name, f.Name, f.Type)
+						continue
+					}
+					local, references := relationFieldLists(attr)
+					for _, name := range local {
+						if _, ok := findFieldByName(m, name); !ok {
+							report(f.Line, "relation %s.%s references unknown field %s in model %s",
+								m.Name, f.Name, name, m.Name)
+						}
+					}
+					for _, name := range references {
+						if _, ok := findFieldByName(related, name); !ok {
+							report(f.Line, "relation %s.%s references unknown field %s in model %s",
+								m.Name, f.Name, name, related.Name)
+						}
+					}
+					if len(local) != len(references) {
+						report(f.Line, "relation %s.%s has %d fields but %d references",
+							m.Name, f.Name, len(local), len(references))
+					}
+				case "default":
+					if len(attr.Args) > 0 {
+						if msg := checkDefaultValue(f, attr.Args[0], enum); msg != "" {
+							report(f.Line, "field %s.%s: %s", m.Name, f.Name, msg)
+						}
+					}
+					if seq := nextvalSequence(f); seq != "" && findSequence(s.Sequences, seq) == nil {
+						warn(f.Line, "field %s.%s draws from sequence %s, which has no sequence block and must be created by hand",
+							m.Name, f.Name, seq)
+					}
+				}
+			}
+		}
+
+		if m.Partitioning != nil {
+			for _, problem := range validatePartitioning(m) {
+				report(m.Line, "%s", problem)
+			}
+		}
+		for _, attr := range m.Attributes {
+			switch attr.Name {
+			case "id", "unique", "index":
+				for _, name := range modelAttributeFields(attr) {
+					if _, ok := fields[name]; !ok {
+						report(attr.Line, "@@%s on model %s references unknown field %s", attr.Name, m.Name, name)
+					}
+				}
+			}
+		}
+	}
+
+	// Foreign key names come from a template, which may map several relations to the same name
+	fkNames := map[string]string{}
+	for _, m := range s.Models {
+		for _, f := range m.Fields {
+			attr := findFieldAttribute(f, "relation")
+			if attr == nil {
+				continue
+			}
+			local, _ := relationFieldLists(attr)
+			var columns []string
+			for _, name := range local {
+				if lf, ok := findFieldByName(m, name); ok {
+					columns = append(columns, lf.ColumnName)
+				}
+			}
+			if len(columns) == 0 {
+				continue
+			}
+			name := s.Generator.ForeignKeyName(m.TableName, columns, relationTable(f, s.Generator))
+			relation := m.Name + "." + f.Name
+			if other, ok := fkNames[name]; ok {
+				warn(f.Line, "foreign key name %s of relation %s is already used by relation %s", name, relation, other)
+				continue
+			}
+			fkNames[name] = relation
+		}
+	}
+	return errs
+}
+
+// CheckFieldTypes reports the fields whose type is neither a scalar type nor an enum, composite type or
+// model of the schema. A typo like `status Statuss` would otherwise become a column of a type that doesn't exist
+// and fail only when the migration is applied.
+func CheckFieldTypes(s *Schema, path string) []*ValidationError {
+	known := map[string]bool{}
+	for _, m := range s.Models {
+		known[m.Name] = true
+	}
+	for _, e := range s.Enums {
+		known[e.Name] = true
+	}
+	for _, ct := range s.CompositeTypes {
+		known[ct.Name] = true
+	}
+	var errs []*ValidationError
+	check := func(owner string, fields []*Field) {
+		for _, f := range fields {
+			if !known[f.Type] && !scalarTypes[f.Type] && !strings.HasPrefix(f.Type, "Unsupported(") {
+				errs = append(errs, &ValidationError{File: path, Line: f.Line, Message: unknownTypeMessage(owner, f, s)})
+			}
+		}
+	}
+	for _, m := range s.Models {
+		check(m.Name, m.Fields)
+	}
+	for _, ct := range s.CompositeTypes {
+		check(ct.Name, ct.Fields)
+	}
+	return errs
+}
+
+// CheckDefaults reports the @default values that don't fit the type of their field, like a string on
+// an Int or now() on a String, which would otherwise be written into a migration that fails to apply
+func CheckDefaults(s *Schema, path string) []*ValidationError {
+	enums := map[string]*Enum{}
+	for _, e := range s.Enums {
+		enums[e.Name] = e
+	}
+	var errs []*ValidationError
+	for _, m := range s.Models {
+		for _, f := range m.Fields {
+			attr := findFieldAttribute(f, "default")
+			if attr == nil || len(attr.Args) == 0 {
+				continue
+			}
+			if msg := checkDefaultValue(f, attr.Args[0], enums[f.Type]); msg != "" {
+				errs = append(errs, &ValidationError{File: path, Line: f.Line,
+					Message: fmt.Sprintf("field %s.%s: %s", m.Name, f.Name, msg)})
+			}
+		}
+	}
+	return errs
+}
+
+// unknownTypeMessage describes a field of a model or composite type whose type is unknown, suggesting
+// the scalar, enum, composite type or model name closest to it
+func unknownTypeMessage(owner string, f *Field, s *Schema) string {
+	message := fmt.Sprintf("field %s.%s has unknown type %s; it is not a scalar type or an enum, type or model of the schema",
+		owner, f.Name, f.Type)
+	candidates := make([]string, 0, len(scalarTypes)+len(s.Enums)+len(s.CompositeTypes)+len(s.Models))
+	for name := range scalarTypes {
+		candidates = append(candidates, name)
+	}
+	for _, e := range s.Enums {
+		candidates = append(candidates, e.Name)
+	}
+	for _, ct := range s.CompositeTypes {
+		candidates = append(candidates, ct.Name)
+	}
+	for _, other := range s.Models {
+		candidates = append(candidates, other.Name)
+	}
+	if suggestion := closestName(f.Type, candidates); suggestion != "" {
+		message += " - did you mean " + suggestion + "?"
+	}
+	return message
+}
+
+// closestName returns the candidate within an edit distance of 2 of name (ignoring case), preferring the
+// nearest and then the alphabetically first, or "" when none is that close
+func closestName(name string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// enumValueNames returns the value names of an enum, without attributes like @map
+func enumValueNames(e *Enum) []string {
+	var names []string
+	for _, v := range e.Values {
+		if fields := strings.Fields(v); len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
+
+// relationFieldLists returns the fields and references lists of a @relation attribute
+func relationFieldLists(attr *FieldAttribute) ([]string, []string) {
+	var local, references []string
+	for _, arg := range attr.Args {
+		key, value, found := strings.Cut(arg, ":")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "fields":
+			local = parseFieldList(value)
+		case "references":
+			references = parseFieldList(value)
+		}
+	}
+	return local, references
+}
+
+// modelAttributeFields returns the field list of a block attribute such as @@id([a, b])
+func modelAttributeFields(attr *ModelAttribute) []string {
+	args := strings.Join(attr.Args, ",")
+	if key, value, found := strings.Cut(args, ":"); found && strings.TrimSpace(key) == "fields" {
+		args = value
+	}
+	return parseFieldList(args)
+}
+
+// parseFieldList parses the first [a, b(sort: Desc)] list in s into field names
+func parseFieldList(s string) []string {
+	matches := fieldListRegex.FindStringSubmatch(s)
+	if len(matches) < 2 {
+		return nil
+	}
+	var names []string
+	for _, item := range strings.Split(matches[1], ",") {
+		name := strings.TrimSpace(item)
+		if i := strings.Index(name, "("); i >= 0 {
+			name = name[:i]
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func findFieldByName(m *Model, name string) (*Field, bool) {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// defaultFunctionTypes lists the field types each @default function applies to
+var defaultFunctionTypes = map[string][]string{
+	"autoincrement": {"Int", "BigInt"},
+	"sequence":      {"Int", "BigInt"},
+	"now":           {"DateTime"},
+	"uuid":          {"String"},
+	"cuid":          {"String"},
+	"ulid":          {"String"},
+	"nanoid":        {"String"},
+}
+
+// defaultLiterals describes the literal defaults each scalar type takes
+var defaultLiterals = map[string]string{
+	"Int":      "an integer",
+	"BigInt":   "an integer",
+	"Float":    "a number",
+	"Decimal":  "a number",
+	"Boolean":  "true or false",
+	"String":   "a quoted string",
+	"DateTime": "now() or a quoted timestamp",
+	"Json":     "a quoted JSON string",
+	"Bytes":    "a quoted string",
+}
+
+// checkDefaultValue returns a message when a @default value does not fit the field type
+func checkDefaultValue(f *Field, value string, enum *Enum) string {
+	if strings.HasPrefix(value, "dbgenerated(") || f.IsArray {
+		return ""
+	}
+
+	if name, _, found := strings.Cut(value, "("); found && !strings.HasPrefix(value, "\"") {
+		types, known := defaultFunctionTypes[name]
+		if !known {
+			return fmt.Sprintf("unknown default function %s", value)
+		}
+		if !containsString(types, f.Type) {
+			return fmt.Sprintf("%s() only applies to %s fields, not %s", name, strings.Join(types, " and "), f.Type)
+		}
+		return ""
+	}
+
+	if enum != nil {
+		for _, v := range enumValueNames(enum) {
+			if v == value {
+				return ""
+			}
+		}
+		return fmt.Sprintf("default %s is not a value of enum %s", value, enum.Name)
+	}
+
+	var ok bool
+	switch f.Type {
+	case "Int", "BigInt":
+		ok = integerLiteralRegex.MatchString(value)
+	case "Float", "Decimal":
+		ok = numberLiteralRegex.MatchString(value)
+	case "Boolean":
+		ok = value == "true" || value == "false"
+	case "String", "DateTime", "Json", "Bytes":
+		ok = stringLiteralRegex.MatchString(value)
+	default:
+		return ""
+	}
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("default %s does not fit type %s, which takes %s", value, f.Type, defaultLiterals[f.Type])
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ViewsDir is the directory, relative to schema.prisma, holding view queries
+const ViewsDir = "sql/views"
+
+var createViewRegex = regexp.MustCompile(
+	`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?VIEW\s+"?([a-zA-Z0-9_]+)"?\s+AS\s+`,
+)
+
+// resolveView loads the query of a view block from sql/views/<view name>.sql
+func resolveView(v *View, schemaPath string) error {
+	name := v.ViewName + ".sql"
+	b, err := os.ReadFile(filepath.Join(filepath.Dir(schemaPath), ViewsDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("view %s: missing definition %s/%s", v.Name, ViewsDir, name)
+		}
+		return err
+	}
+
+	statements := SplitSQLStatements(string(b))
+	if len(statements) != 1 {
+		return fmt.Errorf("view %s: %s/%s must contain exactly one query", v.Name, ViewsDir, name)
+	}
+	query := statements[0]
+	if loc := createViewRegex.FindStringIndex(query); loc != nil {
+		query = query[loc[1]:]
+	}
+	setViewQuery(v, query)
+	return nil
+}
+
+// setViewQuery sets the query of a view and derives its CREATE OR REPLACE definition and hash
+func setViewQuery(v *View, query string) {
+	v.Query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	v.Definition = "CREATE OR REPLACE VIEW " + v.ViewName + " AS\n" + v.Query + ";"
+	v.Hash = hashSQLDefinition(v.Query)
+}
+
+func generateDropViewSQL(v *View) string {
+	return "DROP VIEW IF EXISTS " + v.ViewName + ";"
+}